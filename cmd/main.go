@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
@@ -13,13 +14,28 @@ import (
 	"github.com/Secure-Website-Builder/Backend/internal/http/handlers"
 	"github.com/Secure-Website-Builder/Backend/internal/http/middleware"
 	"github.com/Secure-Website-Builder/Backend/internal/http/router"
+	"github.com/Secure-Website-Builder/Backend/internal/jwtkeys"
 	"github.com/Secure-Website-Builder/Backend/internal/limiter"
+	"github.com/Secure-Website-Builder/Backend/internal/notify"
+	"github.com/Secure-Website-Builder/Backend/internal/oidc"
+	"github.com/Secure-Website-Builder/Backend/internal/passhash"
+	"github.com/Secure-Website-Builder/Backend/internal/productview"
+	"github.com/Secure-Website-Builder/Backend/internal/pwned"
+	"github.com/Secure-Website-Builder/Backend/internal/revocation"
+	"github.com/Secure-Website-Builder/Backend/internal/services/admin"
+	"github.com/Secure-Website-Builder/Backend/internal/services/analytics"
+	"github.com/Secure-Website-Builder/Backend/internal/services/apikey"
 	"github.com/Secure-Website-Builder/Backend/internal/services/auth"
 	"github.com/Secure-Website-Builder/Backend/internal/services/cart"
 	"github.com/Secure-Website-Builder/Backend/internal/services/category"
 	"github.com/Secure-Website-Builder/Backend/internal/services/media"
+	"github.com/Secure-Website-Builder/Backend/internal/services/privacy"
 	"github.com/Secure-Website-Builder/Backend/internal/services/product"
+	"github.com/Secure-Website-Builder/Backend/internal/services/promotion"
+	"github.com/Secure-Website-Builder/Backend/internal/services/recovery"
+	"github.com/Secure-Website-Builder/Backend/internal/services/staff"
 	"github.com/Secure-Website-Builder/Backend/internal/services/store"
+	"github.com/Secure-Website-Builder/Backend/internal/services/visitor"
 	"github.com/Secure-Website-Builder/Backend/internal/storage"
 )
 
@@ -72,16 +88,92 @@ func main() {
 		log.Fatalf("failed to initialize image storage: %v", err)
 	}
 
+	// JWT signing / verification keys
+	jwtKeys, err := jwtkeys.Load(appConfig.JWT, secrets.JWTSecret)
+	if err != nil {
+		log.Fatalf("failed to load jwt keys: %v", err)
+	}
+
+	// Notifications (emails)
+	notifier, err := notify.New(appConfig.Notifications.Driver, appConfig.Notifications.FilePath)
+	if err != nil {
+		log.Fatalf("failed to initialize notifier: %v", err)
+	}
+
+	// External identity providers for store owner login
+	oidcProviders, err := oidc.NewRegistry(appConfig.OIDC, config.OIDCClientSecret)
+	if err != nil {
+		log.Fatalf("failed to configure oidc providers: %v", err)
+	}
+
+	// Breached password checks
+	breachPolicy, err := pwned.New(appConfig.PasswordBreach)
+	if err != nil {
+		log.Fatalf("failed to configure password breach check: %v", err)
+	}
+
+	// Password hashing
+	passwordHasher, err := passhash.New(appConfig.PasswordHashing)
+	if err != nil {
+		log.Fatalf("failed to configure password hashing: %v", err)
+	}
+
+	// Revoked access tokens, kept in memory for JWTAuth
+	revokedTokens, err := revocation.New(context.Background(), db, appConfig.TokenRevocation.SyncInterval())
+	if err != nil {
+		log.Fatalf("failed to load token revocations: %v", err)
+	}
+
 	// Services
 	mediaService := media.New(storage)
 	categoryService := category.New(db)
 	productService := product.New(db, storage, mediaService)
-	cartService := cart.New(db)
+	cartService := cart.New(db, appConfig.VisitorSessions, appConfig.StockReservations, limiter.NewManager(
+		appConfig.StockReservations.ChangesPerSecond,
+		appConfig.StockReservations.ChangesBurst,
+		appConfig.RateLimit.CleanupInterval(),
+	))
 	storeService := store.New(db, storage)
-	authService := auth.New(db, secrets.JWTSecret)
+	loginLockout := limiter.NewLockout(
+		appConfig.LoginProtection.Policy(),
+		appConfig.LoginProtection.CleanupInterval(),
+	)
+	authService := auth.New(db, jwtKeys, appConfig.Auth, notifier, loginLockout, oidcProviders, breachPolicy, revokedTokens, passwordHasher)
+	adminService := admin.New(db, breachPolicy, revokedTokens, passwordHasher)
+	privacyService := privacy.New(db, storage, notifier, appConfig.Privacy)
+	apiKeyService := apikey.New(db)
+	staffService := staff.New(db, notifier, appConfig.Staff)
+	visitorService := visitor.New(db, appConfig.VisitorSessions)
+	analyticsService := analytics.New(db)
+	promotionService := promotion.New(db)
+
+	// Background processing of data export / erasure requests
+	go privacyService.Run(context.Background())
+
+	// Product views for analytics, written in batches
+	productViews := productview.New(db, appConfig.ProductViews)
+	go productViews.Run(context.Background())
+
+	// Deletes expired stock reservations of carts
+	go cartService.Run(context.Background())
+
+	// Reminders of abandoned carts
+	cartRecovery := recovery.New(db, notifier, appConfig.CartRecovery)
+	go cartRecovery.Run(context.Background())
 
 	// Middleware helpers
-	storeOwnerChecker := middleware.NewStoreOwnerChecker(storeService)
+	storeMemberChecker := middleware.NewStoreMemberChecker(staffService)
+	sessionChecker := middleware.NewSessionChecker(authService, revokedTokens)
+	emailVerificationChecker := middleware.NewEmailVerificationChecker(
+		authService,
+		appConfig.Auth.EmailVerification,
+	)
+	adminAuditor := middleware.NewAdminAuditor(adminService)
+	apiKeyChecker := middleware.NewAPIKeyChecker(apiKeyService, limiter.NewManager(
+		appConfig.APIKeys.RequestsPerSecond,
+		appConfig.APIKeys.Burst,
+		appConfig.APIKeys.CleanupInterval(),
+	))
 	rateLimiterManager := limiter.NewManager(
 		appConfig.RateLimit.RequestsPerSecond,
 		appConfig.RateLimit.Burst,
@@ -93,11 +185,19 @@ func main() {
 
 	// Handlers
 	categoryHandler := handlers.NewCategoryHandler(categoryService)
-	productHandler := handlers.NewProductHandler(productService)
+	productHandler := handlers.NewProductHandler(productService, productViews)
 	categoryProductHandler := handlers.NewCategoryProductHandler(productService)
 	cartHandler := handlers.NewCartHandler(cartService)
 	authHandler := handlers.NewAuthHandler(authService)
 	storeHandler := handlers.NewStoreHandler(storeService)
+	jwksHandler := handlers.NewJWKSHandler(jwtKeys)
+	adminHandler := handlers.NewAdminHandler(adminService)
+	dataRequestHandler := handlers.NewDataRequestHandler(privacyService, authService)
+	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeyService)
+	staffHandler := handlers.NewStaffHandler(staffService)
+	visitorSessionHandler := handlers.NewVisitorSessionHandler(visitorService)
+	analyticsHandler := handlers.NewAnalyticsHandler(analyticsService)
+	promotionHandler := handlers.NewPromotionHandler(promotionService)
 
 	// Router
 	r := router.SetupRouter(
@@ -107,9 +207,21 @@ func main() {
 		cartHandler,
 		authHandler,
 		storeHandler,
+		jwksHandler,
+		adminHandler,
+		dataRequestHandler,
+		apiKeyHandler,
+		staffHandler,
+		visitorSessionHandler,
+		analyticsHandler,
+		promotionHandler,
 		rateLimiter,
-		storeOwnerChecker,
-		secrets.JWTSecret,
+		storeMemberChecker,
+		sessionChecker,
+		emailVerificationChecker,
+		adminAuditor,
+		apiKeyChecker,
+		jwtKeys,
 	)
 
 	port := secrets.AppPort
@@ -0,0 +1,83 @@
+// Command pwned-bloom builds the Bloom filter used by the "bloom" password
+// breach backend from a Pwned Passwords SHA-1 dataset ("HASH:COUNT" lines).
+package main
+
+import (
+	"bufio"
+	"flag"
+	"io"
+	"log"
+	"os"
+
+	"github.com/Secure-Website-Builder/Backend/internal/pwned"
+)
+
+func main() {
+	in := flag.String("in", "", "SHA-1 dataset file")
+	out := flag.String("out", "pwned.bloom", "filter file to write")
+	n := flag.Uint64("n", 0, "number of entries in the dataset (counted when 0)")
+	p := flag.Float64("p", 0.001, "false positive rate")
+	flag.Parse()
+
+	if *in == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	entries := *n
+	if entries == 0 {
+		var err error
+		entries, err = countLines(*in)
+		if err != nil {
+			log.Fatalf("failed to count dataset entries: %v", err)
+		}
+	}
+
+	m, k := pwned.BloomSize(entries, *p)
+	log.Printf("building filter for %d entries: %d bits (%d MiB), %d hashes", entries, m, m/8>>20, k)
+
+	src, err := os.Open(*in)
+	if err != nil {
+		log.Fatalf("failed to open dataset: %v", err)
+	}
+	defer src.Close()
+
+	dst, err := os.Create(*out)
+	if err != nil {
+		log.Fatalf("failed to create filter: %v", err)
+	}
+
+	w := bufio.NewWriter(dst)
+	if err := pwned.BuildBloom(bufio.NewReaderSize(src, 1<<20), w, entries, *p); err != nil {
+		log.Fatalf("failed to build filter: %v", err)
+	}
+	if err := w.Flush(); err != nil {
+		log.Fatalf("failed to write filter: %v", err)
+	}
+	if err := dst.Close(); err != nil {
+		log.Fatalf("failed to write filter: %v", err)
+	}
+}
+
+func countLines(path string) (uint64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	var count uint64
+	r := bufio.NewReaderSize(f, 1<<20)
+	for {
+		line, err := r.ReadSlice('\n')
+		if len(line) > 1 {
+			count++
+		}
+		if err == io.EOF {
+			return count, nil
+		}
+		if err != nil && err != bufio.ErrBufferFull {
+			return 0, err
+		}
+	}
+}
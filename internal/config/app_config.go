@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"os"
 	"time"
+
+	"github.com/Secure-Website-Builder/Backend/internal/limiter"
 )
 
 const CONFIG_FILE_PATH = "./internal/config/config.json"
@@ -16,8 +18,201 @@ type RateLimitConfig struct {
 	CleanupIntervalMinutes int `json:"cleanup_interval_minutes"`
 }
 
+// LoginProtectionConfig throttles failed logins per account; see
+// limiter.LockoutPolicy.
+type LoginProtectionConfig struct {
+	FreeAttempts           int `json:"free_attempts"`
+	BaseDelaySeconds       int `json:"base_delay_seconds"`
+	MaxDelaySeconds        int `json:"max_delay_seconds"`
+	MaxFailures            int `json:"max_failures"`
+	LockoutMinutes         int `json:"lockout_minutes"`
+	WindowMinutes          int `json:"window_minutes"`
+	CleanupIntervalMinutes int `json:"cleanup_interval_minutes"`
+}
+
+// EmailVerificationConfig controls verification links and which actions
+// are refused until the account's email address is verified.
+type EmailVerificationConfig struct {
+	TTLMinutes            int    `json:"ttl_minutes"`
+	URL                   string `json:"url"`
+	RequiredToCreateStore bool   `json:"required_to_create_store"`
+	RequiredToCheckout    bool   `json:"required_to_checkout"`
+}
+
+type AuthConfig struct {
+	MFAIssuer                 string                  `json:"mfa_issuer"`
+	MFAPendingTokenTTLMinutes int                     `json:"mfa_pending_token_ttl_minutes"`
+	PasswordResetTTLMinutes   int                     `json:"password_reset_ttl_minutes"`
+	PasswordResetURL          string                  `json:"password_reset_url"`
+	EmailVerification         EmailVerificationConfig `json:"email_verification"`
+}
+
+type NotificationConfig struct {
+	Driver   string `json:"driver"`
+	FilePath string `json:"file_path"`
+}
+
+// JWTKeyConfig describes one signing/verification key. Keys that are only
+// kept to verify tokens issued before a rotation need just the public key.
+type JWTKeyConfig struct {
+	KeyID          string `json:"kid"`
+	Algorithm      string `json:"algorithm"` // RS256 or EdDSA
+	PrivateKeyFile string `json:"private_key_file"`
+	PublicKeyFile  string `json:"public_key_file"`
+}
+
+// JWTConfig selects how access tokens are signed. An empty SigningKeyID keeps
+// HS256 with JWT_SECRET; AllowLegacyHS256 keeps accepting those tokens while
+// migrating to asymmetric keys.
+type JWTConfig struct {
+	SigningKeyID     string         `json:"signing_key_id"`
+	AllowLegacyHS256 bool           `json:"allow_legacy_hs256"`
+	Keys             []JWTKeyConfig `json:"keys"`
+}
+
+// OIDCProviderConfig describes an external identity provider store owners
+// can sign in with. The client secret is read from the environment variable
+// named by ClientSecretEnv so it never lives in this file.
+type OIDCProviderConfig struct {
+	Name            string   `json:"name"`
+	Issuer          string   `json:"issuer"`
+	ClientID        string   `json:"client_id"`
+	ClientSecretEnv string   `json:"client_secret_env"`
+	RedirectURL     string   `json:"redirect_url"`
+	Scopes          []string `json:"scopes"`
+	// AllowSignup creates a store owner on first login when no account
+	// uses the (verified) email yet.
+	AllowSignup bool `json:"allow_signup"`
+}
+
+type OIDCConfig struct {
+	StateTTLMinutes int                  `json:"state_ttl_minutes"`
+	Providers       []OIDCProviderConfig `json:"providers"`
+}
+
+// PasswordBreachConfig selects where passwords are checked against known
+// breaches. Backend is "hibp" (the range API at HIBPBaseURL, which can point
+// at a mirror), "file" (a local SHA-1 dataset sorted by hash), "bloom" (a
+// filter built from such a dataset) or "none". FailClosed rejects passwords
+// when the check cannot be completed instead of skipping it.
+type PasswordBreachConfig struct {
+	Backend         string `json:"backend"`
+	HIBPBaseURL     string `json:"hibp_base_url"`
+	TimeoutSeconds  int    `json:"timeout_seconds"`
+	CacheTTLMinutes int    `json:"cache_ttl_minutes"`
+	CacheMaxEntries int    `json:"cache_max_entries"`
+	DatasetPath     string `json:"dataset_path"`
+	FailClosed      bool   `json:"fail_closed"`
+}
+
+// PrivacyConfig controls the worker that processes customer data export and
+// erasure requests. A request still processing after StaleAfterMinutes is
+// assumed abandoned and picked up again, up to MaxAttempts times.
+type PrivacyConfig struct {
+	WorkerIntervalSeconds int `json:"worker_interval_seconds"`
+	StaleAfterMinutes     int `json:"stale_after_minutes"`
+	MaxAttempts           int `json:"max_attempts"`
+}
+
+// StaffConfig controls invitations to a store's staff. The invitation link
+// is URL with the token appended as ?token=.
+type StaffConfig struct {
+	InvitationTTLHours int    `json:"invitation_ttl_hours"`
+	InvitationURL      string `json:"invitation_url"`
+}
+
+// VisitorSessionConfig controls the anonymous sessions storefronts use for
+// carts and checkout. A session expires after IdleTimeoutHours without
+// use; each use starts the timeout again.
+type VisitorSessionConfig struct {
+	IdleTimeoutHours int `json:"idle_timeout_hours"`
+}
+
+// ProductViewConfig controls how product views are recorded for
+// analytics. A session viewing the same product again within
+// DedupWindowMinutes counts once; up to DedupMaxEntries recent views are
+// remembered for this. Views are queued in memory (up to QueueSize) and
+// written in batches of up to BatchSize, at least every
+// FlushIntervalSeconds.
+type ProductViewConfig struct {
+	DedupWindowMinutes   int `json:"dedup_window_minutes"`
+	DedupMaxEntries      int `json:"dedup_max_entries"`
+	BatchSize            int `json:"batch_size"`
+	FlushIntervalSeconds int `json:"flush_interval_seconds"`
+	QueueSize            int `json:"queue_size"`
+}
+
+// StockReservationConfig limits what a cart can hold and controls the
+// sweeper of expired reservations. A cart item reserves at most
+// MaxQuantityPerItem units. Each visitor session may add to or change its
+// cart ChangesPerSecond times a second, in bursts of ChangesBurst.
+//
+// The sweeper deletes expired reservations every SweepIntervalSeconds, up
+// to SweepBatchSize rows per statement. Expired reservations stop counting
+// at once; the sweeper only keeps the table small.
+type StockReservationConfig struct {
+	MaxQuantityPerItem   int32 `json:"max_quantity_per_item"`
+	ChangesPerSecond     int   `json:"changes_per_second"`
+	ChangesBurst         int   `json:"changes_burst"`
+	SweepIntervalSeconds int   `json:"sweep_interval_seconds"`
+	SweepBatchSize       int   `json:"sweep_batch_size"`
+}
+
+// CartRecoveryConfig controls the worker that reminds customers of
+// abandoned carts. Every WorkerIntervalSeconds it sends up to BatchSize
+// reminders. The link of a reminder is RecoveryURL with store_id and token
+// query parameters; it restores the cart for LinkTTLHours.
+type CartRecoveryConfig struct {
+	WorkerIntervalSeconds int    `json:"worker_interval_seconds"`
+	BatchSize             int    `json:"batch_size"`
+	LinkTTLHours          int    `json:"link_ttl_hours"`
+	RecoveryURL           string `json:"recovery_url"`
+}
+
+// APIKeyConfig rate limits requests made with store API keys, per key.
+type APIKeyConfig struct {
+	RequestsPerSecond      int `json:"requests_per_second"`
+	Burst                  int `json:"burst"`
+	CleanupIntervalMinutes int `json:"cleanup_interval_minutes"`
+}
+
+// PasswordHashingConfig selects how new password hashes are made.
+// Algorithm is "argon2id" or "bcrypt"; hashes made with weaker settings
+// (or with bcrypt while argon2id is configured) are upgraded on login.
+// Argon2id hashes computed at once use at most Argon2MaxMemoryMiB.
+type PasswordHashingConfig struct {
+	Algorithm          string `json:"algorithm"`
+	Argon2MemoryKiB    uint32 `json:"argon2_memory_kib"`
+	Argon2Iterations   uint32 `json:"argon2_iterations"`
+	Argon2Parallelism  uint8  `json:"argon2_parallelism"`
+	Argon2MaxMemoryMiB uint32 `json:"argon2_max_memory_mib"`
+	BcryptCost         int    `json:"bcrypt_cost"`
+}
+
+// TokenRevocationConfig sets how often each instance reads access token
+// revocations made by other instances. It bounds how long a revoked token
+// keeps working on another instance.
+type TokenRevocationConfig struct {
+	SyncIntervalSeconds int `json:"sync_interval_seconds"`
+}
+
 type AppConfig struct {
-	RateLimit RateLimitConfig `json:"rate_limit"`
+	RateLimit         RateLimitConfig        `json:"rate_limit"`
+	LoginProtection   LoginProtectionConfig  `json:"login_protection"`
+	Auth              AuthConfig             `json:"auth"`
+	JWT               JWTConfig              `json:"jwt"`
+	Notifications     NotificationConfig     `json:"notifications"`
+	OIDC              OIDCConfig             `json:"oidc"`
+	PasswordBreach    PasswordBreachConfig   `json:"password_breach"`
+	PasswordHashing   PasswordHashingConfig  `json:"password_hashing"`
+	Privacy           PrivacyConfig          `json:"privacy"`
+	TokenRevocation   TokenRevocationConfig  `json:"token_revocation"`
+	APIKeys           APIKeyConfig           `json:"api_keys"`
+	Staff             StaffConfig            `json:"staff"`
+	VisitorSessions   VisitorSessionConfig   `json:"visitor_sessions"`
+	ProductViews      ProductViewConfig      `json:"product_views"`
+	StockReservations StockReservationConfig `json:"stock_reservations"`
+	CartRecovery      CartRecoveryConfig     `json:"cart_recovery"`
 }
 
 func LoadAppConfig(path string) (*AppConfig, error) {
@@ -40,9 +235,191 @@ func LoadAppConfig(path string) (*AppConfig, error) {
 		return nil, fmt.Errorf("invalid rate limit config")
 	}
 
+	lp := cfg.LoginProtection
+	if lp.FreeAttempts < 0 ||
+		lp.BaseDelaySeconds <= 0 ||
+		lp.MaxDelaySeconds < lp.BaseDelaySeconds ||
+		lp.MaxFailures <= lp.FreeAttempts ||
+		lp.LockoutMinutes <= 0 ||
+		lp.WindowMinutes <= 0 ||
+		lp.CleanupIntervalMinutes <= 0 {
+		return nil, fmt.Errorf("invalid login protection config")
+	}
+
+	if cfg.Auth.MFAIssuer == "" ||
+		cfg.Auth.MFAPendingTokenTTLMinutes <= 0 ||
+		cfg.Auth.PasswordResetTTLMinutes <= 0 ||
+		cfg.Auth.PasswordResetURL == "" ||
+		cfg.Auth.EmailVerification.TTLMinutes <= 0 ||
+		cfg.Auth.EmailVerification.URL == "" {
+		return nil, fmt.Errorf("invalid auth config")
+	}
+
+	if cfg.OIDC.StateTTLMinutes <= 0 {
+		return nil, fmt.Errorf("invalid oidc config")
+	}
+	names := map[string]bool{}
+	for _, p := range cfg.OIDC.Providers {
+		if p.Name == "" || p.Issuer == "" || p.ClientID == "" || p.RedirectURL == "" || names[p.Name] {
+			return nil, fmt.Errorf("invalid oidc provider %q", p.Name)
+		}
+		names[p.Name] = true
+	}
+
+	pb := cfg.PasswordBreach
+	switch pb.Backend {
+	case "hibp":
+		if pb.HIBPBaseURL == "" || pb.TimeoutSeconds <= 0 || pb.CacheTTLMinutes < 0 || pb.CacheMaxEntries < 0 {
+			return nil, fmt.Errorf("invalid password breach config")
+		}
+	case "file", "bloom":
+		if pb.DatasetPath == "" {
+			return nil, fmt.Errorf("invalid password breach config")
+		}
+	case "none":
+	default:
+		return nil, fmt.Errorf("invalid password breach backend %q", pb.Backend)
+	}
+
+	switch cfg.PasswordHashing.Algorithm {
+	case "argon2id", "bcrypt":
+	default:
+		return nil, fmt.Errorf("invalid password hashing algorithm %q", cfg.PasswordHashing.Algorithm)
+	}
+
+	if cfg.Privacy.WorkerIntervalSeconds <= 0 ||
+		cfg.Privacy.StaleAfterMinutes <= 0 ||
+		cfg.Privacy.MaxAttempts <= 0 {
+		return nil, fmt.Errorf("invalid privacy config")
+	}
+
+	if cfg.TokenRevocation.SyncIntervalSeconds <= 0 {
+		return nil, fmt.Errorf("invalid token revocation config")
+	}
+
+	if cfg.APIKeys.RequestsPerSecond <= 0 ||
+		cfg.APIKeys.Burst <= 0 ||
+		cfg.APIKeys.CleanupIntervalMinutes <= 0 {
+		return nil, fmt.Errorf("invalid api key config")
+	}
+
+	if cfg.Staff.InvitationTTLHours <= 0 || cfg.Staff.InvitationURL == "" {
+		return nil, fmt.Errorf("invalid staff config")
+	}
+
+	if cfg.VisitorSessions.IdleTimeoutHours <= 0 {
+		return nil, fmt.Errorf("invalid visitor session config")
+	}
+
+	if cfg.ProductViews.DedupWindowMinutes <= 0 ||
+		cfg.ProductViews.DedupMaxEntries <= 0 ||
+		cfg.ProductViews.BatchSize <= 0 ||
+		cfg.ProductViews.FlushIntervalSeconds <= 0 ||
+		cfg.ProductViews.QueueSize <= 0 {
+		return nil, fmt.Errorf("invalid product view config")
+	}
+
+	if cfg.StockReservations.MaxQuantityPerItem <= 0 ||
+		cfg.StockReservations.ChangesPerSecond <= 0 ||
+		cfg.StockReservations.ChangesBurst <= 0 ||
+		cfg.StockReservations.SweepIntervalSeconds <= 0 ||
+		cfg.StockReservations.SweepBatchSize <= 0 {
+		return nil, fmt.Errorf("invalid stock reservation config")
+	}
+
+	if cfg.CartRecovery.WorkerIntervalSeconds <= 0 ||
+		cfg.CartRecovery.BatchSize <= 0 ||
+		cfg.CartRecovery.LinkTTLHours <= 0 ||
+		cfg.CartRecovery.RecoveryURL == "" {
+		return nil, fmt.Errorf("invalid cart recovery config")
+	}
+
 	return &cfg, nil
 }
 
 func (r RateLimitConfig) CleanupInterval() time.Duration {
 	return time.Duration(r.CleanupIntervalMinutes) * time.Minute
 }
+
+func (l LoginProtectionConfig) Policy() limiter.LockoutPolicy {
+	return limiter.LockoutPolicy{
+		FreeAttempts:    l.FreeAttempts,
+		BaseDelay:       time.Duration(l.BaseDelaySeconds) * time.Second,
+		MaxDelay:        time.Duration(l.MaxDelaySeconds) * time.Second,
+		MaxFailures:     l.MaxFailures,
+		LockoutDuration: time.Duration(l.LockoutMinutes) * time.Minute,
+		Window:          time.Duration(l.WindowMinutes) * time.Minute,
+	}
+}
+
+func (l LoginProtectionConfig) CleanupInterval() time.Duration {
+	return time.Duration(l.CleanupIntervalMinutes) * time.Minute
+}
+
+func (a AuthConfig) MFAPendingTokenTTL() time.Duration {
+	return time.Duration(a.MFAPendingTokenTTLMinutes) * time.Minute
+}
+
+func (a AuthConfig) PasswordResetTTL() time.Duration {
+	return time.Duration(a.PasswordResetTTLMinutes) * time.Minute
+}
+
+func (e EmailVerificationConfig) TTL() time.Duration {
+	return time.Duration(e.TTLMinutes) * time.Minute
+}
+
+func (o OIDCConfig) StateTTL() time.Duration {
+	return time.Duration(o.StateTTLMinutes) * time.Minute
+}
+
+func (p PasswordBreachConfig) Timeout() time.Duration {
+	return time.Duration(p.TimeoutSeconds) * time.Second
+}
+
+func (p PasswordBreachConfig) CacheTTL() time.Duration {
+	return time.Duration(p.CacheTTLMinutes) * time.Minute
+}
+
+func (p PrivacyConfig) WorkerInterval() time.Duration {
+	return time.Duration(p.WorkerIntervalSeconds) * time.Second
+}
+
+func (p PrivacyConfig) StaleAfter() time.Duration {
+	return time.Duration(p.StaleAfterMinutes) * time.Minute
+}
+
+func (t TokenRevocationConfig) SyncInterval() time.Duration {
+	return time.Duration(t.SyncIntervalSeconds) * time.Second
+}
+
+func (a APIKeyConfig) CleanupInterval() time.Duration {
+	return time.Duration(a.CleanupIntervalMinutes) * time.Minute
+}
+
+func (s StaffConfig) InvitationTTL() time.Duration {
+	return time.Duration(s.InvitationTTLHours) * time.Hour
+}
+
+func (v VisitorSessionConfig) IdleTimeout() time.Duration {
+	return time.Duration(v.IdleTimeoutHours) * time.Hour
+}
+
+func (p ProductViewConfig) DedupWindow() time.Duration {
+	return time.Duration(p.DedupWindowMinutes) * time.Minute
+}
+
+func (p ProductViewConfig) FlushInterval() time.Duration {
+	return time.Duration(p.FlushIntervalSeconds) * time.Second
+}
+
+func (s StockReservationConfig) SweepInterval() time.Duration {
+	return time.Duration(s.SweepIntervalSeconds) * time.Second
+}
+
+func (c CartRecoveryConfig) WorkerInterval() time.Duration {
+	return time.Duration(c.WorkerIntervalSeconds) * time.Second
+}
+
+func (c CartRecoveryConfig) LinkTTL() time.Duration {
+	return time.Duration(c.LinkTTLHours) * time.Hour
+}
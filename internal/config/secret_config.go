@@ -65,3 +65,18 @@ func LoadSecrets() (*Secret, error) {
 		MinIOBucket:   values["MINIO_BUCKET"],
 	}, nil
 }
+
+// OIDCClientSecret returns the client secret of an OIDC provider from the
+// environment variable named in its configuration.
+func OIDCClientSecret(p OIDCProviderConfig) (string, error) {
+	if p.ClientSecretEnv == "" {
+		return "", fmt.Errorf("oidc provider %q: client_secret_env is not set", p.Name)
+	}
+
+	value, ok := os.LookupEnv(p.ClientSecretEnv)
+	if !ok || value == "" {
+		return "", fmt.Errorf("missing env vars: [%s]", p.ClientSecretEnv)
+	}
+
+	return value, nil
+}
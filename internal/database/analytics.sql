@@ -471,4 +471,65 @@ WHERE vs.store_id = $1
   AND vs.first_seen_at >= $2
   AND vs.first_seen_at < $3
 GROUP BY DAY
-ORDER BY DAY;
\ No newline at end of file
+ORDER BY DAY;
+
+-- Per product: the sessions that viewed it, the viewing sessions that
+-- added it to their cart, and their share.
+-- name: GetProductAddToCartRates :many
+WITH views AS
+  (SELECT pv.product_id,
+          COUNT(DISTINCT pv.session_id) AS viewing_sessions
+   FROM product_view pv
+   WHERE pv.store_id = sqlc.arg('store_id')
+     AND pv.viewed_at >= sqlc.arg('from')::TIMESTAMPTZ
+     AND pv.viewed_at < sqlc.arg('to')::TIMESTAMPTZ
+   GROUP BY pv.product_id),
+     adds AS
+  (SELECT ce.product_id,
+          COUNT(DISTINCT ce.session_id) AS adding_sessions
+   FROM cart_event ce
+   JOIN product p ON p.product_id = ce.product_id
+   WHERE p.store_id = sqlc.arg('store_id')
+     AND ce.event_type = 'add'
+     AND ce.created_at >= sqlc.arg('from')::TIMESTAMPTZ
+     AND ce.created_at < sqlc.arg('to')::TIMESTAMPTZ
+     AND EXISTS
+       (SELECT 1
+        FROM product_view pv
+        WHERE pv.product_id = ce.product_id
+          AND pv.session_id = ce.session_id
+          AND pv.viewed_at >= sqlc.arg('from')::TIMESTAMPTZ
+          AND pv.viewed_at < sqlc.arg('to')::TIMESTAMPTZ)
+   GROUP BY ce.product_id)
+SELECT p.product_id,
+       p.name AS product_name,
+       COALESCE(v.viewing_sessions, 0)::BIGINT AS viewing_sessions,
+       COALESCE(a.adding_sessions, 0)::BIGINT AS adding_sessions,
+       COALESCE(ROUND(COALESCE(a.adding_sessions, 0)::numeric / NULLIF(v.viewing_sessions, 0) * 100, 2), 0)::NUMERIC AS add_to_cart_rate_percent
+FROM product p
+LEFT JOIN views v ON v.product_id = p.product_id
+LEFT JOIN adds a ON a.product_id = p.product_id
+WHERE p.store_id = sqlc.arg('store_id')
+  AND (v.product_id IS NOT NULL OR a.product_id IS NOT NULL)
+ORDER BY add_to_cart_rate_percent DESC,
+         viewing_sessions DESC;
+
+-- name: GetCartRecoveryStats :one
+SELECT
+  (SELECT COUNT(*)
+   FROM cart_reminder r
+   WHERE r.store_id = sqlc.arg('store_id')
+     AND r.sent_at >= sqlc.arg('from')
+     AND r.sent_at < sqlc.arg('to'))::BIGINT AS reminders_sent,
+  (SELECT COUNT(DISTINCT r.cart_id)
+   FROM cart_reminder r
+   WHERE r.store_id = sqlc.arg('store_id')
+     AND r.recovered_at >= sqlc.arg('from')
+     AND r.recovered_at < sqlc.arg('to'))::BIGINT AS carts_recovered,
+  COUNT(o.order_id)::BIGINT AS recovered_orders,
+  COALESCE(SUM(o.total_amount), 0)::NUMERIC AS recovered_revenue
+FROM cart_reminder cr
+JOIN customer_order o ON o.order_id = cr.order_id
+WHERE cr.store_id = sqlc.arg('store_id')
+  AND o.created_at >= sqlc.arg('from')
+  AND o.created_at < sqlc.arg('to');
@@ -6,14 +6,25 @@ SELECT
   p.brand,
   p.description,
   p.category_id,
-  p.stock_quantity AS total_stock,
-  pv.stock_quantity AS item_stock,
+  (p.stock_quantity - res.product_reserved)::INT AS total_stock,
+  (pv.stock_quantity - res.item_reserved)::INT AS item_stock,
   pv.price,
   pv.primary_image_url,
-  p.in_stock
+  (p.in_stock AND p.stock_quantity > res.product_reserved) AS in_stock
 FROM product p
 JOIN product_variant pv
   ON pv.variant_id = p.default_variant_id
+-- stock held by carts is not available to others
+LEFT JOIN LATERAL (
+  SELECT
+    COALESCE(SUM(LEAST(r.quantity, ci.quantity)), 0) AS product_reserved,
+    COALESCE(SUM(LEAST(r.quantity, ci.quantity)) FILTER (WHERE r.variant_id = pv.variant_id), 0) AS item_reserved
+  FROM stock_reservation r
+  JOIN cart_item ci ON ci.cart_item_id = r.cart_item_id
+  JOIN product_variant rv ON rv.variant_id = r.variant_id
+  WHERE rv.product_id = p.product_id
+    AND r.expires_at > NOW()
+) res ON TRUE
 
 /*{{DYNAMIC_JOINS}}*/
 
@@ -23,6 +34,6 @@ WHERE p.store_id = $1
   AND ($5::TEXT IS NULL OR p.brand = $5)
   AND ($6::DECIMAL IS NULL OR pv.price >= $6)
   AND ($7::DECIMAL IS NULL OR pv.price <= $7)
-  AND ($8::BOOLEAN IS NULL OR p.in_stock = $8)
+  AND ($8::BOOLEAN IS NULL OR (p.in_stock AND p.stock_quantity > res.product_reserved) = $8)
 ORDER BY p.created_at DESC
 LIMIT $2 OFFSET $3;
@@ -106,24 +106,61 @@ LIMIT 1;
 SELECT
   c.cart_id,
   c.store_id,
-  c.updated_at
+  c.updated_at,
+  c.promotion_code
 FROM cart c
 WHERE c.session_id = $1
   AND c.store_id = $2
 LIMIT 1;
 
+-- Whether an item of the cart no longer matches its variant, checked
+-- without locks so that reading an unchanged cart does not block.
+-- name: CartNeedsRepricing :one
+SELECT EXISTS (
+  SELECT 1
+  FROM cart_item ci
+  JOIN product_variant v ON v.variant_id = ci.variant_id
+  JOIN product p ON p.product_id = v.product_id
+  WHERE ci.cart_id = $1
+    AND (v.deleted_at IS NOT NULL
+      OR p.deleted_at IS NOT NULL
+      OR ci.unit_price <> v.price
+      OR ci.quantity > v.stock_quantity - COALESCE((
+        SELECT SUM(LEAST(r.quantity, rci.quantity))
+        FROM stock_reservation r
+        JOIN cart_item rci ON rci.cart_item_id = r.cart_item_id
+        WHERE r.variant_id = v.variant_id
+          AND rci.cart_id <> ci.cart_id
+          AND r.expires_at > NOW()
+      ), 0))
+)::BOOLEAN AS needs_repricing;
+
 -- name: GetCartItemsForUpdate :many
 SELECT
   ci.cart_item_id,
   ci.variant_id,
+  v.product_id,
+  p.name AS product_name,
+  p.category_id,
   ci.quantity AS cart_quantity,
   ci.unit_price,
-  v.stock_quantity AS available_stock,
-  (v.unit_price * ci.quantity)::NUMERIC AS subtotal
+  v.price AS current_price,
+  (v.stock_quantity - COALESCE((
+    SELECT SUM(LEAST(r.quantity, rci.quantity))
+    FROM stock_reservation r
+    JOIN cart_item rci ON rci.cart_item_id = r.cart_item_id
+    WHERE r.variant_id = v.variant_id
+      AND rci.cart_id <> ci.cart_id
+      AND r.expires_at > NOW()
+  ), 0))::INT AS available_stock,
+  (v.deleted_at IS NOT NULL OR p.deleted_at IS NOT NULL)::BOOLEAN AS discontinued,
+  (v.price * ci.quantity)::NUMERIC AS subtotal
 FROM cart_item ci
 JOIN product_variant v ON v.variant_id = ci.variant_id
+JOIN product p ON p.product_id = v.product_id
 WHERE ci.cart_id = $1
-FOR UPDATE;
+ORDER BY ci.variant_id
+FOR UPDATE OF ci, v;
 
 -- name: GetCartTotal :one
 SELECT
@@ -137,6 +174,7 @@ SELECT
 	ci.variant_id,
 	p.product_id,
 	p.name AS product_name,
+	p.category_id,
 	v.sku,
 	v.primary_image_url,
 	ci.unit_price,
@@ -158,12 +196,26 @@ INSERT INTO customer_order (
   store_id,
   customer_id,
   session_id,
-  total_amount
+  total_amount,
+  guest_email,
+  shipping_address
 ) VALUES (
-  $1, $2, $3, $4
+  $1, $2, $3, $4, $5, $6
 )
 RETURNING *;
 
+-- Links the guest orders placed with the customer's email address in the
+-- customer's store to the account.
+-- name: LinkGuestOrdersToCustomer :execrows
+UPDATE customer_order o
+SET customer_id = c.customer_id,
+    updated_at = NOW()
+FROM customer c
+WHERE c.customer_id = $1
+  AND o.store_id = c.store_id
+  AND o.customer_id IS NULL
+  AND LOWER(o.guest_email) = LOWER(c.email);
+
 -- name: CreateOrderItem :exec
 INSERT INTO order_item (
   order_id,
@@ -175,6 +227,10 @@ INSERT INTO order_item (
   $1, $2, $3, $4, $5
 );
 
+-- name: CreateOrderDiscount :exec
+INSERT INTO order_discount (order_id, promotion_id, code, description, type, amount)
+VALUES ($1, $2, $3, $4, $5, $6);
+
 -- name: UpdateOrderStatus :exec
 UPDATE customer_order
 SET status = $2,
@@ -199,10 +255,31 @@ SET stock_quantity = stock_quantity - @cart_quantity,
     updated_at = NOW()
 WHERE variant_id = $1;
 
--- name: GetSession :one
-SELECT session_id, customer_id
-FROM visitor_session
-WHERE session_id = $1 AND store_id = $2;
+-- name: CreateVisitorSession :one
+INSERT INTO visitor_session (store_id, ip_address, user_agent, is_returning)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- A visitor session stays usable while it has been seen since idle_since;
+-- every use moves last_seen_at forward.
+-- name: TouchVisitorSession :one
+UPDATE visitor_session
+SET last_seen_at = NOW()
+WHERE session_id = sqlc.arg('session_id')
+  AND store_id = sqlc.arg('store_id')
+  AND last_seen_at > sqlc.arg('idle_since')
+RETURNING *;
+
+-- name: VisitorSessionExists :one
+SELECT EXISTS (
+  SELECT 1 FROM visitor_session WHERE session_id = $1 AND store_id = $2
+);
+
+-- name: LinkVisitorSessionToCustomer :exec
+UPDATE visitor_session
+SET customer_id = sqlc.arg('customer_id')
+WHERE session_id = sqlc.arg('session_id')
+  AND store_id = sqlc.arg('store_id');
 
 -- name: GetCartForSession :one
 SELECT *
@@ -229,6 +306,10 @@ SET customer_id = $1,
     updated_at  = NOW()
 WHERE cart_id = $3;
 
+-- name: RecordCartEvent :exec
+INSERT INTO cart_event (session_id, product_id, variant_id, event_type)
+VALUES ($1, $2, $3, $4);
+
 -- name: DeleteCart :exec
 DELETE FROM cart
 WHERE cart_id = $1;
@@ -241,6 +322,7 @@ RETURNING *;
 -- name: GetVariantForCart :one
 SELECT
   variant_id,
+  product_id,
   price,
   stock_quantity
 FROM product_variant
@@ -249,24 +331,164 @@ WHERE variant_id = $1
   AND deleted_at IS NULL
 FOR UPDATE;
 
--- name: UpsertCartItem :exec
+-- name: UpsertCartItem :one
 INSERT INTO cart_item (cart_id, variant_id, quantity, unit_price)
 VALUES ($1, $2, $3, $4)
 ON CONFLICT (cart_id, variant_id)
 DO UPDATE SET
-  quantity = cart_item.quantity + EXCLUDED.quantity;
+  quantity = cart_item.quantity + EXCLUDED.quantity
+RETURNING *;
 
 -- name: TouchCart :exec
 UPDATE cart SET updated_at = NOW() WHERE cart_id = $1;
 
--- name: IsStoreOwner :one
-SELECT EXISTS (
-    SELECT 1
-    FROM store
-    WHERE store_id = $1
-      AND store_owner_id = $2
+-- name: GetCartItemForUpdate :one
+SELECT *
+FROM cart_item
+WHERE cart_item_id = $1 AND cart_id = $2
+FOR UPDATE;
+
+-- name: SetCartItemQuantity :exec
+UPDATE cart_item
+SET quantity = $2
+WHERE cart_item_id = $1;
+
+-- name: SetCartItemUnitPrice :exec
+UPDATE cart_item
+SET unit_price = $2
+WHERE cart_item_id = $1;
+
+-- name: SetCartPromotionCode :exec
+UPDATE cart
+SET promotion_code = $2,
+    updated_at = NOW()
+WHERE cart_id = $1;
+
+-- name: GetReservedStock :one
+SELECT COALESCE(SUM(LEAST(r.quantity, ci.quantity)), 0)::INT AS reserved
+FROM stock_reservation r
+JOIN cart_item ci ON ci.cart_item_id = r.cart_item_id
+WHERE r.variant_id = $1
+  AND ci.cart_id <> $2
+  AND r.expires_at > NOW();
+
+-- name: UpsertStockReservation :exec
+INSERT INTO stock_reservation (cart_item_id, variant_id, quantity, expires_at)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (cart_item_id)
+DO UPDATE SET
+  quantity = EXCLUDED.quantity;
+
+-- name: DeleteExpiredStockReservations :execrows
+DELETE FROM stock_reservation
+WHERE cart_item_id IN (
+  SELECT cart_item_id
+  FROM stock_reservation
+  WHERE expires_at <= NOW()
+  LIMIT $1
 );
 
+-- name: ListCartsDueForReminder :many
+SELECT
+  c.cart_id,
+  c.store_id,
+  s.name AS store_name,
+  cu.name AS customer_name,
+  cu.email,
+  COALESCE(c.updated_at, c.created_at)::TIMESTAMPTZ AS cart_activity_at,
+  r.reminders_sent
+FROM cart c
+JOIN store s ON s.store_id = c.store_id
+JOIN customer cu ON cu.customer_id = c.customer_id
+CROSS JOIN LATERAL (
+  SELECT
+    COUNT(*)::INT AS reminders_sent,
+    MAX(cr.sent_at) AS last_sent_at
+  FROM cart_reminder cr
+  WHERE cr.cart_id = c.cart_id
+    AND cr.cart_activity_at = COALESCE(c.updated_at, c.created_at)
+) r
+WHERE s.cart_reminder_max > 0
+  AND r.reminders_sent < s.cart_reminder_max
+  AND COALESCE(r.last_sent_at, c.updated_at, c.created_at)
+      < NOW() - make_interval(hours => s.cart_reminder_delay_hours)
+  AND cu.email_verified_at IS NOT NULL
+  AND cu.erased_at IS NULL
+  AND EXISTS (SELECT 1 FROM cart_item ci WHERE ci.cart_id = c.cart_id)
+ORDER BY cart_activity_at
+LIMIT $1;
+
+-- name: CreateCartReminder :one
+INSERT INTO cart_reminder (cart_id, store_id, cart_activity_at, reminder_number, token_hash, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (cart_id, cart_activity_at, reminder_number) DO NOTHING
+RETURNING *;
+
+-- name: GetCartReminderByTokenForUpdate :one
+SELECT *
+FROM cart_reminder
+WHERE token_hash = $1
+  AND expires_at > NOW()
+  AND recovered_at IS NULL
+FOR UPDATE;
+
+-- Ends the links of the other reminders of a cart, once a newer one was
+-- sent or one was used.
+-- name: ExpireCartReminders :exec
+UPDATE cart_reminder
+SET expires_at = NOW()
+WHERE cart_id = $1
+  AND cart_reminder_id <> $2
+  AND expires_at > NOW();
+
+-- name: MarkCartReminderRecovered :exec
+UPDATE cart_reminder
+SET recovered_at = COALESCE(recovered_at, NOW())
+WHERE cart_reminder_id = $1;
+
+-- name: MoveCartToSession :one
+UPDATE cart
+SET session_id = $3,
+    updated_at = NOW()
+WHERE cart_id = $1 AND store_id = $2
+RETURNING *;
+
+-- An order counts as recovered if it is placed within 7 days of the
+-- cart being restored through a reminder.
+-- name: AttributeOrderToCartReminder :exec
+UPDATE cart_reminder
+SET order_id = $2
+WHERE cart_reminder_id = (
+  SELECT r.cart_reminder_id
+  FROM cart_reminder r
+  WHERE r.cart_id = $1
+    AND r.order_id IS NULL
+    AND r.recovered_at > NOW() - INTERVAL '7 days'
+  ORDER BY r.recovered_at DESC
+  LIMIT 1
+);
+
+-- name: DeleteCartItem :one
+WITH d AS (
+  DELETE FROM cart_item
+  WHERE cart_item_id = $1
+    AND cart_id = $2
+  RETURNING variant_id
+)
+SELECT d.variant_id, v.product_id
+FROM d
+JOIN product_variant v ON v.variant_id = d.variant_id;
+
+-- name: DeleteCartItems :many
+WITH d AS (
+  DELETE FROM cart_item
+  WHERE cart_id = $1
+  RETURNING variant_id
+)
+SELECT d.variant_id, v.product_id
+FROM d
+JOIN product_variant v ON v.variant_id = d.variant_id;
+
 -- name: CreateStoreOwner :one
 INSERT INTO store_owner (
   name,
@@ -289,6 +511,42 @@ SELECT
 FROM store_owner
 WHERE email = $1;
 
+-- name: GetStoreOwnerByID :one
+SELECT
+  store_owner_id,
+  name,
+  email,
+  password_hash,
+  email_verified_at,
+  created_at
+FROM store_owner
+WHERE store_owner_id = $1;
+
+-- name: GetStoreOwnerProfile :one
+SELECT
+  store_owner_id,
+  name,
+  email,
+  phone,
+  address,
+  email_verified_at,
+  created_at
+FROM store_owner
+WHERE store_owner_id = $1;
+
+-- name: UpdateStoreOwnerProfile :exec
+UPDATE store_owner
+SET name = $2,
+    phone = $3,
+    address = $4
+WHERE store_owner_id = $1;
+
+-- name: UpdateStoreOwnerEmail :exec
+UPDATE store_owner
+SET email = $2,
+    email_verified_at = NULL
+WHERE store_owner_id = $1;
+
 -- name: CreateCustomer :one
 INSERT INTO customer (
   store_id,
@@ -302,6 +560,19 @@ INSERT INTO customer (
 )
 RETURNING customer_id, store_id, name, email, created_at;
 
+-- name: GetCustomerByID :one
+SELECT
+  customer_id,
+  store_id,
+  name,
+  email,
+  password_hash,
+  email_verified_at,
+  erased_at,
+  created_at
+FROM customer
+WHERE customer_id = $1;
+
 -- name: GetCustomerByEmail :one
 SELECT
   customer_id,
@@ -314,6 +585,43 @@ FROM customer
 WHERE email = $1
   AND store_id = $2;
 
+-- Whether a customer of the store has the email address but has not
+-- verified it. Matched like LinkGuestOrdersToCustomer, ignoring case.
+-- name: CustomerEmailUnverified :one
+SELECT EXISTS (
+  SELECT 1
+  FROM customer
+  WHERE store_id = sqlc.arg('store_id')
+    AND LOWER(email) = LOWER(sqlc.arg('email')::TEXT)
+    AND email_verified_at IS NULL
+);
+
+-- name: GetCustomerProfile :one
+SELECT
+  customer_id,
+  store_id,
+  name,
+  email,
+  phone,
+  address,
+  email_verified_at,
+  created_at
+FROM customer
+WHERE customer_id = $1;
+
+-- name: UpdateCustomerProfile :exec
+UPDATE customer
+SET name = $2,
+    phone = $3,
+    address = $4
+WHERE customer_id = $1;
+
+-- name: UpdateCustomerEmail :exec
+UPDATE customer
+SET email = $2,
+    email_verified_at = NULL
+WHERE customer_id = $1;
+
 -- name: ListCategoryAttributes :many
 SELECT a.attribute_id, a.name, ca.is_required
 FROM category_attribute ca
@@ -322,23 +630,132 @@ ON a.attribute_id = ca.attribute_id
 WHERE ca.category_id = $1;
 
 -- name: GetAdminByEmail :one
-SELECT admin_id, email, password_hash
+SELECT admin_id, email, password_hash, disabled_at
 FROM admin
 WHERE email = $1;
 
--- name: CreateRefreshToken :exec
-INSERT INTO refresh_token (token, user_id, user_role, store_id, expires_at)
+-- name: GetAdminByID :one
+SELECT admin_id, email, created_by, disabled_at, created_at
+FROM admin
+WHERE admin_id = $1;
+
+-- name: ListAdmins :many
+SELECT admin_id, email, created_by, disabled_at, created_at
+FROM admin
+ORDER BY admin_id;
+
+-- name: CreateAdmin :one
+INSERT INTO admin (email, password_hash, created_by)
+VALUES ($1, $2, $3)
+RETURNING admin_id, email, created_by, disabled_at, created_at;
+
+-- name: DisableAdmin :execrows
+UPDATE admin
+SET disabled_at = NOW()
+WHERE admin_id = $1 AND disabled_at IS NULL;
+
+-- name: EnableAdmin :execrows
+UPDATE admin
+SET disabled_at = NULL
+WHERE admin_id = $1 AND disabled_at IS NOT NULL;
+
+-- Hash upgrades on login only apply if the password was not changed
+-- meanwhile.
+-- name: UpgradeAdminPasswordHash :exec
+UPDATE admin
+SET password_hash = sqlc.arg('new_hash')
+WHERE admin_id = sqlc.arg('admin_id') AND password_hash = sqlc.arg('old_hash');
+
+-- name: CreateAdminAuditLog :exec
+INSERT INTO admin_audit_log (admin_id, action, details, status_code, ip_address)
 VALUES ($1, $2, $3, $4, $5);
 
--- name: GetRefreshToken :one
+-- name: ListAdminAuditLog :many
+SELECT *
+FROM admin_audit_log
+WHERE (sqlc.narg('admin_id')::BIGINT IS NULL OR admin_id = sqlc.narg('admin_id'))
+  AND (sqlc.narg('before_id')::BIGINT IS NULL OR admin_audit_log_id < sqlc.narg('before_id'))
+ORDER BY admin_audit_log_id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: CreateRefreshToken :exec
+INSERT INTO refresh_token (token_hash, family_id, user_id, user_role, store_id, expires_at, mfa_verified)
+VALUES ($1, $2, $3, $4, $5, $6, $7);
+
+-- name: GetRefreshTokenForUpdate :one
 SELECT *
 FROM refresh_token
-WHERE token = $1 AND revoked = FALSE;
+WHERE token_hash = $1
+FOR UPDATE;
 
 -- name: RevokeRefreshToken :exec
 UPDATE refresh_token
 SET revoked = TRUE
-WHERE token = $1;
+WHERE token_hash = $1;
+
+-- name: RevokeRefreshTokenFamily :exec
+UPDATE refresh_token
+SET revoked = TRUE
+WHERE family_id = $1 AND revoked = FALSE;
+
+-- name: RevokeUserRefreshTokens :exec
+UPDATE refresh_token
+SET revoked = TRUE
+WHERE user_id = $1 AND user_role = $2 AND revoked = FALSE;
+
+-- name: RevokeOtherUserRefreshTokens :exec
+UPDATE refresh_token
+SET revoked = TRUE
+WHERE user_id = $1 AND user_role = $2 AND family_id <> $3 AND revoked = FALSE;
+
+-- name: CreateAuthSession :exec
+INSERT INTO auth_session (session_id, user_id, user_role, store_id, ip_address, user_agent)
+VALUES ($1, $2, $3, $4, $5, $6);
+
+-- name: GetAuthSession :one
+SELECT *
+FROM auth_session
+WHERE session_id = $1;
+
+-- name: TouchAuthSession :exec
+UPDATE auth_session
+SET last_used_at = NOW(),
+    ip_address = $2,
+    user_agent = $3
+WHERE session_id = $1;
+
+-- name: ListActiveUserSessions :many
+SELECT s.*
+FROM auth_session s
+WHERE s.user_id = $1
+  AND s.user_role = $2
+  AND s.revoked_at IS NULL
+  AND EXISTS (
+    SELECT 1
+    FROM refresh_token rt
+    WHERE rt.family_id = s.session_id
+      AND rt.revoked = FALSE
+      AND rt.expires_at > NOW()
+  )
+ORDER BY s.last_used_at DESC;
+
+-- name: RevokeAuthSession :execrows
+UPDATE auth_session
+SET revoked_at = NOW()
+WHERE session_id = $1
+  AND user_id = $2
+  AND user_role = $3
+  AND revoked_at IS NULL;
+
+-- name: RevokeUserAuthSessions :exec
+UPDATE auth_session
+SET revoked_at = NOW()
+WHERE user_id = $1 AND user_role = $2 AND revoked_at IS NULL;
+
+-- name: RevokeOtherUserAuthSessions :exec
+UPDATE auth_session
+SET revoked_at = NOW()
+WHERE user_id = $1 AND user_role = $2 AND session_id <> $3 AND revoked_at IS NULL;
 
 -- name: GetProductByStoreAndName :one
 SELECT *
@@ -448,6 +865,25 @@ SET download_status = $2,
     updated_at = NOW()
 WHERE store_id = $1;
 
+-- name: UpdateStorePasswordPolicy :exec
+UPDATE store
+SET check_breached_passwords = $2,
+    updated_at = NOW()
+WHERE store_id = $1;
+
+-- name: UpdateStoreStockReservation :exec
+UPDATE store
+SET stock_reservation_minutes = $2,
+    updated_at = NOW()
+WHERE store_id = $1;
+
+-- name: UpdateStoreCartReminders :exec
+UPDATE store
+SET cart_reminder_delay_hours = $2,
+    cart_reminder_max = $3,
+    updated_at = NOW()
+WHERE store_id = $1;
+
 -- name: GetStoreByOwnerID :one
 SELECT *
 FROM store
@@ -496,3 +932,619 @@ WHERE src.cart_id = @from_cart_id
     FROM updated u
     WHERE u.cart_item_id = src.cart_item_id
   );
+
+-- name: GetUserMFA :one
+SELECT *
+FROM user_mfa
+WHERE user_id = $1 AND user_role = $2;
+
+-- name: UpsertUserMFA :exec
+INSERT INTO user_mfa (user_id, user_role, totp_secret)
+VALUES ($1, $2, $3)
+ON CONFLICT (user_id, user_role)
+DO UPDATE SET
+  totp_secret    = EXCLUDED.totp_secret,
+  confirmed_at   = NULL,
+  last_used_step = NULL,
+  created_at     = NOW();
+
+-- name: ConfirmUserMFA :exec
+UPDATE user_mfa
+SET confirmed_at = NOW()
+WHERE user_id = $1 AND user_role = $2;
+
+-- name: MarkUserMFAStepUsed :execrows
+UPDATE user_mfa
+SET last_used_step = @step::BIGINT
+WHERE user_id = $1
+  AND user_role = $2
+  AND (last_used_step IS NULL OR last_used_step < @step::BIGINT);
+
+-- name: DeleteUserMFA :exec
+DELETE FROM user_mfa
+WHERE user_id = $1 AND user_role = $2;
+
+-- name: CreateMFARecoveryCode :exec
+INSERT INTO mfa_recovery_code (user_id, user_role, code_hash)
+VALUES ($1, $2, $3);
+
+-- name: UseMFARecoveryCode :execrows
+UPDATE mfa_recovery_code
+SET used_at = NOW()
+WHERE user_id = $1
+  AND user_role = $2
+  AND code_hash = $3
+  AND used_at IS NULL;
+
+-- name: DeleteMFARecoveryCodes :exec
+DELETE FROM mfa_recovery_code
+WHERE user_id = $1 AND user_role = $2;
+
+-- name: UpdateStoreOwnerPassword :exec
+UPDATE store_owner
+SET password_hash = $2
+WHERE store_owner_id = $1;
+
+-- name: UpdateCustomerPassword :exec
+UPDATE customer
+SET password_hash = $2
+WHERE customer_id = $1;
+
+-- name: UpgradeStoreOwnerPasswordHash :exec
+UPDATE store_owner
+SET password_hash = sqlc.arg('new_hash')
+WHERE store_owner_id = sqlc.arg('store_owner_id') AND password_hash = sqlc.arg('old_hash');
+
+-- name: UpgradeCustomerPasswordHash :exec
+UPDATE customer
+SET password_hash = sqlc.arg('new_hash')
+WHERE customer_id = sqlc.arg('customer_id') AND password_hash = sqlc.arg('old_hash');
+
+-- name: CreatePasswordResetToken :exec
+INSERT INTO password_reset_token (token_hash, user_id, user_role, store_id, expires_at)
+VALUES ($1, $2, $3, $4, $5);
+
+-- Reads a usable reset token without locking it, for the checks that are
+-- too slow to run while it is locked.
+-- name: GetPasswordResetToken :one
+SELECT *
+FROM password_reset_token
+WHERE token_hash = $1
+  AND used_at IS NULL
+  AND expires_at > NOW();
+
+-- name: GetPasswordResetTokenForUpdate :one
+SELECT *
+FROM password_reset_token
+WHERE token_hash = $1
+  AND used_at IS NULL
+  AND expires_at > NOW()
+FOR UPDATE;
+
+-- name: InvalidateUserPasswordResetTokens :exec
+UPDATE password_reset_token
+SET used_at = NOW()
+WHERE user_id = $1 AND user_role = $2 AND used_at IS NULL;
+
+-- name: CreateEmailVerificationToken :exec
+INSERT INTO email_verification_token (token_hash, user_id, user_role, store_id, email, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6);
+
+-- name: GetEmailVerificationTokenForUpdate :one
+SELECT *
+FROM email_verification_token
+WHERE token_hash = $1
+  AND used_at IS NULL
+  AND expires_at > NOW()
+FOR UPDATE;
+
+-- name: InvalidateUserEmailVerificationTokens :exec
+UPDATE email_verification_token
+SET used_at = NOW()
+WHERE user_id = $1 AND user_role = $2 AND used_at IS NULL;
+
+-- name: MarkStoreOwnerEmailVerified :execrows
+UPDATE store_owner
+SET email_verified_at = NOW()
+WHERE store_owner_id = $1 AND email = $2;
+
+-- name: MarkCustomerEmailVerified :execrows
+UPDATE customer
+SET email_verified_at = NOW()
+WHERE customer_id = $1 AND email = $2;
+
+-- name: CreateOIDCStoreOwner :one
+INSERT INTO store_owner (name, email, password_hash, email_verified_at)
+VALUES ($1, $2, $3, NOW())
+RETURNING store_owner_id, name, email, created_at;
+
+-- name: GetStoreOwnerIdentity :one
+SELECT *
+FROM store_owner_identity
+WHERE provider = $1 AND subject = $2;
+
+-- name: CreateStoreOwnerIdentity :exec
+INSERT INTO store_owner_identity (store_owner_id, provider, subject, email)
+VALUES ($1, $2, $3, $4);
+
+-- name: CreateOIDCLoginState :exec
+INSERT INTO oidc_login_state (state_hash, provider, code_verifier, nonce, link_store_owner_id, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6);
+
+-- name: ConsumeOIDCLoginState :one
+DELETE FROM oidc_login_state
+WHERE state_hash = $1
+  AND provider = $2
+  AND expires_at > NOW()
+RETURNING *;
+
+-- name: DeleteExpiredOIDCLoginStates :exec
+DELETE FROM oidc_login_state
+WHERE expires_at <= NOW();
+
+-- name: CreateDataRequest :one
+INSERT INTO data_request (store_id, customer_id, request_type, requested_by)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: GetDataRequest :one
+SELECT *
+FROM data_request
+WHERE data_request_id = $1;
+
+-- name: ListCustomerDataRequests :many
+SELECT *
+FROM data_request
+WHERE customer_id = $1
+ORDER BY data_request_id DESC;
+
+-- name: ListStoreDataRequests :many
+SELECT *
+FROM data_request
+WHERE store_id = sqlc.arg('store_id')
+  AND (sqlc.narg('before_id')::BIGINT IS NULL OR data_request_id < sqlc.narg('before_id'))
+ORDER BY data_request_id DESC
+LIMIT sqlc.arg('limit');
+
+-- Takes the oldest pending request, or one whose worker died while
+-- processing it, and marks it as being processed.
+-- name: ClaimDataRequest :one
+UPDATE data_request
+SET status = 'processing',
+    started_at = NOW(),
+    attempts = attempts + 1
+WHERE data_request_id = (
+  SELECT data_request_id
+  FROM data_request
+  WHERE status = 'pending'
+     OR (status = 'processing' AND started_at < sqlc.arg('stale_before')::TIMESTAMPTZ)
+  ORDER BY data_request_id
+  LIMIT 1
+  FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: CompleteDataRequest :exec
+UPDATE data_request
+SET status = 'completed',
+    archive_key = $2,
+    error = NULL,
+    completed_at = NOW()
+WHERE data_request_id = $1;
+
+-- The request is retried until it has been attempted max_attempts times.
+-- name: FailDataRequest :exec
+UPDATE data_request
+SET status = CASE WHEN attempts >= sqlc.arg('max_attempts')::INT THEN 'failed' ELSE 'pending' END,
+    error = sqlc.arg('error')::TEXT,
+    completed_at = CASE WHEN attempts >= sqlc.arg('max_attempts')::INT THEN NOW() END
+WHERE data_request_id = sqlc.arg('data_request_id');
+
+-- name: ClearCustomerDataRequestArchives :exec
+UPDATE data_request
+SET archive_key = NULL
+WHERE customer_id = $1;
+
+-- The sessions of a customer are those linked to the account plus any
+-- session an order of the customer was placed from.
+
+-- name: ListCustomerVisitorSessions :many
+SELECT *
+FROM visitor_session
+WHERE customer_id = sqlc.arg('customer_id')::BIGINT
+   OR session_id IN (SELECT session_id FROM customer_order WHERE customer_id = sqlc.arg('customer_id')::BIGINT)
+ORDER BY first_seen_at;
+
+-- name: ListCustomerProductViews :many
+SELECT pv.*
+FROM product_view pv
+JOIN visitor_session vs ON vs.session_id = pv.session_id
+WHERE vs.customer_id = sqlc.arg('customer_id')::BIGINT
+   OR vs.session_id IN (SELECT session_id FROM customer_order WHERE customer_id = sqlc.arg('customer_id')::BIGINT)
+ORDER BY pv.viewed_at;
+
+-- name: ListCustomerCartEvents :many
+SELECT ce.*
+FROM cart_event ce
+JOIN visitor_session vs ON vs.session_id = ce.session_id
+WHERE vs.customer_id = sqlc.arg('customer_id')::BIGINT
+   OR vs.session_id IN (SELECT session_id FROM customer_order WHERE customer_id = sqlc.arg('customer_id')::BIGINT)
+ORDER BY ce.created_at;
+
+-- name: ListCustomerCartItems :many
+SELECT ci.*
+FROM cart_item ci
+JOIN cart c ON c.cart_id = ci.cart_id
+WHERE c.customer_id = sqlc.arg('customer_id')::BIGINT
+ORDER BY ci.created_at;
+
+-- name: ListCustomerOrders :many
+SELECT *
+FROM customer_order
+WHERE customer_id = sqlc.arg('customer_id')::BIGINT
+ORDER BY created_at;
+
+-- name: ListCustomerOrderItems :many
+SELECT oi.*
+FROM order_item oi
+JOIN customer_order o ON o.order_id = oi.order_id
+WHERE o.customer_id = sqlc.arg('customer_id')::BIGINT
+ORDER BY oi.order_item_id;
+
+-- name: ListCustomerPayments :many
+SELECT p.*
+FROM payment p
+JOIN customer_order o ON o.order_id = p.order_id
+WHERE o.customer_id = sqlc.arg('customer_id')::BIGINT
+ORDER BY p.created_at;
+
+-- name: ListCustomerShipments :many
+SELECT sh.*
+FROM shipment sh
+JOIN customer_order o ON o.order_id = sh.order_id
+WHERE o.customer_id = sqlc.arg('customer_id')::BIGINT
+ORDER BY sh.shipment_id;
+
+-- name: ListUserAuthSessions :many
+SELECT *
+FROM auth_session
+WHERE user_id = $1 AND user_role = $2
+ORDER BY created_at;
+
+-- name: EraseCustomer :exec
+UPDATE customer
+SET name = 'Deleted customer',
+    email = 'erased+' || customer_id || '@invalid',
+    password_hash = '!erased',
+    phone = NULL,
+    address = NULL,
+    email_verified_at = NULL,
+    erased_at = NOW()
+WHERE customer_id = $1;
+
+-- name: AnonymizeCustomerVisitorSessions :exec
+UPDATE visitor_session
+SET ip_address = NULL,
+    user_agent = NULL
+WHERE customer_id = sqlc.arg('customer_id')::BIGINT
+   OR session_id IN (SELECT session_id FROM customer_order WHERE customer_id = sqlc.arg('customer_id')::BIGINT);
+
+-- name: AnonymizeCustomerOrders :exec
+UPDATE customer_order
+SET guest_email = NULL,
+    shipping_address = NULL
+WHERE customer_id = $1;
+
+-- name: DeleteCustomerProductViews :exec
+DELETE FROM product_view
+WHERE session_id IN (
+  SELECT session_id FROM visitor_session WHERE customer_id = sqlc.arg('customer_id')::BIGINT
+  UNION
+  SELECT session_id FROM customer_order WHERE customer_id = sqlc.arg('customer_id')::BIGINT
+);
+
+-- name: DeleteCustomerCartEvents :exec
+DELETE FROM cart_event
+WHERE session_id IN (
+  SELECT session_id FROM visitor_session WHERE customer_id = sqlc.arg('customer_id')::BIGINT
+  UNION
+  SELECT session_id FROM customer_order WHERE customer_id = sqlc.arg('customer_id')::BIGINT
+);
+
+-- name: DeleteCustomerCarts :exec
+DELETE FROM cart
+WHERE customer_id = sqlc.arg('customer_id')::BIGINT;
+
+-- name: DeleteUserRefreshTokens :exec
+DELETE FROM refresh_token
+WHERE user_id = $1 AND user_role = $2;
+
+-- name: DeleteUserAuthSessions :exec
+DELETE FROM auth_session
+WHERE user_id = $1 AND user_role = $2;
+
+-- name: DeleteUserPasswordResetTokens :exec
+DELETE FROM password_reset_token
+WHERE user_id = $1 AND user_role = $2;
+
+-- name: DeleteUserEmailVerificationTokens :exec
+DELETE FROM email_verification_token
+WHERE user_id = $1 AND user_role = $2;
+
+-- name: CreateAccessToken :exec
+INSERT INTO access_token (jti, session_id, expires_at)
+VALUES ($1, $2, $3);
+
+-- Revokes the unexpired access tokens of every revoked session of the user.
+-- Called after sessions are revoked, in the same transaction.
+-- name: RevokeSessionAccessTokens :exec
+INSERT INTO revoked_token (jti, expires_at)
+SELECT a.jti, a.expires_at
+FROM access_token a
+JOIN auth_session s ON s.session_id = a.session_id
+WHERE s.user_id = $1
+  AND s.user_role = $2
+  AND s.revoked_at IS NOT NULL
+  AND a.expires_at > NOW()
+ON CONFLICT (jti) DO NOTHING;
+
+-- Revokes a single token. Nothing is inserted if it was revoked already.
+-- name: RevokeToken :execrows
+INSERT INTO revoked_token (jti, expires_at)
+VALUES ($1, $2)
+ON CONFLICT (jti) DO NOTHING;
+
+-- name: ListRevokedTokensSince :many
+SELECT jti, expires_at, revoked_at
+FROM revoked_token
+WHERE revoked_at > $1
+  AND expires_at > NOW()
+ORDER BY revoked_at;
+
+-- name: DeleteExpiredRevokedTokens :exec
+DELETE FROM revoked_token
+WHERE expires_at < $1;
+
+-- name: DeleteExpiredAccessTokens :exec
+DELETE FROM access_token
+WHERE expires_at < $1;
+
+-- name: CreateStoreAPIKey :one
+INSERT INTO store_api_key (store_id, name, key_prefix, key_hash, scopes, created_by)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: GetStoreAPIKeyByHash :one
+SELECT *
+FROM store_api_key
+WHERE key_hash = $1;
+
+-- name: ListStoreAPIKeys :many
+SELECT *
+FROM store_api_key
+WHERE store_id = $1
+ORDER BY api_key_id DESC;
+
+-- name: RevokeStoreAPIKey :execrows
+UPDATE store_api_key
+SET revoked_at = NOW()
+WHERE api_key_id = $1 AND store_id = $2 AND revoked_at IS NULL;
+
+-- Writes last_used_at at most once a minute per key, not on every request.
+-- name: TouchStoreAPIKey :exec
+UPDATE store_api_key
+SET last_used_at = NOW()
+WHERE api_key_id = $1
+  AND (last_used_at IS NULL OR last_used_at < NOW() - INTERVAL '1 minute');
+
+-- name: ListStoreOrders :many
+SELECT *
+FROM customer_order
+WHERE store_id = sqlc.arg('store_id')
+  AND (sqlc.narg('before_id')::BIGINT IS NULL OR order_id < sqlc.narg('before_id'))
+ORDER BY order_id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: ListStoreOrderItems :many
+SELECT oi.*
+FROM order_item oi
+JOIN customer_order o ON o.order_id = oi.order_id
+WHERE o.store_id = sqlc.arg('store_id')
+  AND oi.order_id = ANY(sqlc.arg('order_ids')::BIGINT[])
+ORDER BY oi.order_item_id;
+
+-- name: ListStoreOrderDiscounts :many
+SELECT od.*
+FROM order_discount od
+JOIN customer_order o ON o.order_id = od.order_id
+WHERE o.store_id = sqlc.arg('store_id')
+  AND od.order_id = ANY(sqlc.arg('order_ids')::BIGINT[])
+ORDER BY od.order_discount_id;
+
+-- The role of a store owner account in a store: "owner" for the owner of
+-- the store, otherwise its staff role. No row if it has no access.
+-- name: GetStoreRole :one
+SELECT 'owner'::VARCHAR AS role
+FROM store
+WHERE store.store_id = $1 AND store.store_owner_id = $2
+UNION ALL
+SELECT m.role
+FROM store_member m
+WHERE m.store_id = $1 AND m.store_owner_id = $2
+LIMIT 1;
+
+-- name: CreateStoreInvitation :one
+INSERT INTO store_invitation (store_id, email, role, token_hash, invited_by, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: DeletePendingStoreInvitations :exec
+DELETE FROM store_invitation
+WHERE store_id = sqlc.arg('store_id')
+  AND LOWER(email) = LOWER(sqlc.arg('email'))
+  AND accepted_at IS NULL;
+
+-- name: ListStoreInvitations :many
+SELECT *
+FROM store_invitation
+WHERE store_id = $1
+  AND accepted_at IS NULL
+ORDER BY store_invitation_id DESC;
+
+-- name: DeleteStoreInvitation :execrows
+DELETE FROM store_invitation
+WHERE store_invitation_id = $1
+  AND store_id = $2
+  AND accepted_at IS NULL;
+
+-- name: GetStoreInvitationForUpdate :one
+SELECT *
+FROM store_invitation
+WHERE token_hash = $1
+  AND accepted_at IS NULL
+  AND expires_at > NOW()
+FOR UPDATE;
+
+-- name: MarkStoreInvitationAccepted :exec
+UPDATE store_invitation
+SET accepted_at = NOW()
+WHERE store_invitation_id = $1;
+
+-- Accepting a new invitation replaces the role of an existing member.
+-- name: AddStoreMember :one
+INSERT INTO store_member (store_id, store_owner_id, role, invited_by)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (store_id, store_owner_id)
+DO UPDATE SET role = EXCLUDED.role
+RETURNING *;
+
+-- name: ListStoreMembers :many
+SELECT
+  m.store_member_id,
+  m.store_owner_id,
+  o.name,
+  o.email,
+  m.role,
+  m.invited_by,
+  m.created_at
+FROM store_member m
+JOIN store_owner o ON o.store_owner_id = m.store_owner_id
+WHERE m.store_id = $1
+ORDER BY m.store_member_id;
+
+-- name: UpdateStoreMemberRole :execrows
+UPDATE store_member
+SET role = $3
+WHERE store_member_id = $1 AND store_id = $2;
+
+-- name: RemoveStoreMember :execrows
+DELETE FROM store_member
+WHERE store_member_id = $1 AND store_id = $2;
+
+-- Product views are written in batches. Views of sessions that do not
+-- exist in the store are skipped rather than failing the batch.
+-- name: InsertProductViews :exec
+INSERT INTO product_view (product_id, store_id, session_id, viewed_at)
+SELECT v.product_id, v.store_id, v.session_id, v.viewed_at
+FROM (
+  SELECT
+    unnest(sqlc.arg('product_ids')::BIGINT[]) AS product_id,
+    unnest(sqlc.arg('store_ids')::BIGINT[]) AS store_id,
+    unnest(sqlc.arg('session_ids')::UUID[]) AS session_id,
+    unnest(sqlc.arg('viewed_at')::TIMESTAMPTZ[]) AS viewed_at
+) AS v
+JOIN visitor_session vs ON vs.session_id = v.session_id AND vs.store_id = v.store_id;
+
+-- name: CreatePromotion :one
+INSERT INTO promotion (
+  store_id, name, code, type, value, buy_quantity, get_quantity,
+  min_subtotal, category_id, variant_ids, first_order_only, usage_limit,
+  usage_limit_per_customer, starts_at, ends_at, is_active
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+RETURNING *;
+
+-- name: UpdatePromotion :one
+UPDATE promotion
+SET name = $3,
+    code = $4,
+    type = $5,
+    value = $6,
+    buy_quantity = $7,
+    get_quantity = $8,
+    min_subtotal = $9,
+    category_id = $10,
+    variant_ids = $11,
+    first_order_only = $12,
+    usage_limit = $13,
+    usage_limit_per_customer = $14,
+    starts_at = $15,
+    ends_at = $16,
+    is_active = $17,
+    updated_at = NOW()
+WHERE promotion_id = $1 AND store_id = $2
+RETURNING *;
+
+-- name: ListStorePromotions :many
+SELECT *
+FROM promotion
+WHERE store_id = $1
+ORDER BY created_at DESC;
+
+-- name: DeletePromotion :execrows
+DELETE FROM promotion
+WHERE promotion_id = $1 AND store_id = $2;
+
+-- name: GetActivePromotionByCode :one
+SELECT *
+FROM promotion
+WHERE store_id = sqlc.arg('store_id')
+  AND is_active
+  AND (starts_at IS NULL OR starts_at <= NOW())
+  AND (ends_at IS NULL OR ends_at > NOW())
+  AND UPPER(code) = UPPER(sqlc.arg('code')::TEXT);
+
+-- The promotions that apply to a cart: the active automatic promotions of
+-- the store plus the promotion of the code entered, if any.
+-- name: ListActivePromotions :many
+SELECT *
+FROM promotion
+WHERE store_id = sqlc.arg('store_id')
+  AND is_active
+  AND (starts_at IS NULL OR starts_at <= NOW())
+  AND (ends_at IS NULL OR ends_at > NOW())
+  AND (code IS NULL OR UPPER(code) = UPPER(sqlc.narg('code')::TEXT))
+ORDER BY promotion_id;
+
+-- Locks the promotions so that their usage limits hold across concurrent
+-- checkouts.
+-- name: LockPromotions :many
+SELECT *
+FROM promotion
+WHERE promotion_id = ANY(sqlc.arg('promotion_ids')::BIGINT[])
+ORDER BY promotion_id
+FOR UPDATE;
+
+-- Cancelled and refunded orders give their use back.
+-- name: GetPromotionUsage :many
+SELECT
+  p.promotion_id,
+  (SELECT COUNT(*)
+   FROM order_discount od
+   JOIN customer_order o ON o.order_id = od.order_id
+   WHERE od.promotion_id = p.promotion_id
+     AND COALESCE(o.status, 'pending') NOT IN ('cancelled', 'refunded'))::INT AS times_used,
+  (SELECT COUNT(*)
+   FROM order_discount od
+   JOIN customer_order o ON o.order_id = od.order_id
+   WHERE od.promotion_id = p.promotion_id
+     AND o.customer_id = sqlc.narg('customer_id')
+     AND COALESCE(o.status, 'pending') NOT IN ('cancelled', 'refunded'))::INT AS customer_times_used
+FROM promotion p
+WHERE p.promotion_id = ANY(sqlc.arg('promotion_ids')::BIGINT[]);
+
+-- name: CustomerHasOrders :one
+SELECT EXISTS (
+  SELECT 1
+  FROM customer_order
+  WHERE store_id = $1 AND customer_id = $2
+);
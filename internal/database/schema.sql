@@ -9,6 +9,7 @@ CREATE TABLE store_owner (
   password_hash   TEXT NOT NULL,
   phone           VARCHAR(50),
   address         JSONB,
+  email_verified_at TIMESTAMP WITH TIME ZONE,
   created_at      TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
 );
 
@@ -20,6 +21,14 @@ CREATE TABLE store (
   download_status VARCHAR(50) CHECK (download_status IN ('pending', 'completed', 'failed')) DEFAULT 'pending' NOT NULL,
   currency        VARCHAR(10) DEFAULT 'EGP',
   timezone        VARCHAR(100) DEFAULT 'UTC',
+  -- also check customer passwords against known breaches
+  check_breached_passwords BOOLEAN NOT NULL DEFAULT FALSE,
+  -- how long adding to a cart holds the stock; 0 disables reservations
+  stock_reservation_minutes INT NOT NULL DEFAULT 0 CHECK (stock_reservation_minutes >= 0),
+  -- abandoned cart reminders: up to cart_reminder_max, each
+  -- cart_reminder_delay_hours after the cart or the previous reminder
+  cart_reminder_delay_hours INT NOT NULL DEFAULT 24 CHECK (cart_reminder_delay_hours > 0),
+  cart_reminder_max INT NOT NULL DEFAULT 0 CHECK (cart_reminder_max >= 0),
   created_at      TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
   updated_at      TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
 );
@@ -119,6 +128,8 @@ CREATE TABLE customer (
   password_hash   TEXT NOT NULL,
   phone           VARCHAR(50),
   address         JSONB,
+  email_verified_at TIMESTAMP WITH TIME ZONE,
+  erased_at       TIMESTAMP WITH TIME ZONE, -- personal data removed on request
   created_at      TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
   UNIQUE (store_id, email)
 );
@@ -145,6 +156,7 @@ CREATE TABLE cart (
   customer_id BIGINT REFERENCES customer(customer_id),
   created_at  TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
   updated_at  TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+  promotion_code VARCHAR(50), -- discount code entered by the shopper
 
   UNIQUE (store_id, session_id),
   UNIQUE (store_id, customer_id)
@@ -161,6 +173,20 @@ CREATE TABLE cart_item (
   UNIQUE (cart_id, variant_id)
 );
 
+-- A cart item holds up to quantity units of the variant until expires_at,
+-- counted from when the item was added. Available stock is the variant's
+-- stock minus the active reservations of other carts.
+CREATE TABLE stock_reservation (
+  cart_item_id BIGINT PRIMARY KEY REFERENCES cart_item(cart_item_id) ON DELETE CASCADE,
+  variant_id   BIGINT NOT NULL REFERENCES product_variant(variant_id),
+  quantity     INT NOT NULL CHECK (quantity > 0),
+  expires_at   TIMESTAMP WITH TIME ZONE NOT NULL,
+  created_at   TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_stock_reservation_variant ON stock_reservation(variant_id, expires_at);
+CREATE INDEX idx_stock_reservation_expires ON stock_reservation(expires_at);
+
 CREATE TABLE customer_order (
   order_id        BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
   store_id        BIGINT NOT NULL REFERENCES store(store_id),
@@ -169,9 +195,15 @@ CREATE TABLE customer_order (
   total_amount    DECIMAL(10,2) NOT NULL,
   status          VARCHAR(50) DEFAULT 'pending' CHECK(status IN ('pending', 'completed', 'shipped', 'cancelled', 'refunded')),
   created_at      TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
-  updated_at      TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+  updated_at      TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+  guest_email     VARCHAR(255), -- contact of orders placed without an account
+  shipping_address JSONB
 );
 
+-- Guest orders are linked to an account verified with their email
+CREATE INDEX idx_customer_order_guest_email ON customer_order(store_id, LOWER(guest_email))
+  WHERE customer_id IS NULL;
+
 CREATE TABLE order_item (
   order_item_id   BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
   order_id        BIGINT NOT NULL REFERENCES customer_order(order_id),
@@ -181,6 +213,53 @@ CREATE TABLE order_item (
   subtotal        DECIMAL(10,2) NOT NULL
 );
 
+-- Promotions of a store. A promotion with a code applies once the shopper
+-- enters the code; one without applies to every cart that qualifies.
+-- value is the percentage off for 'percentage' and the amount off for
+-- 'fixed_amount'. 'buy_x_get_y' gives get_quantity of every buy_quantity +
+-- get_quantity units free, the cheapest first. The conditions apply to
+-- the items of category_id and variant_ids only, when set.
+CREATE TABLE promotion (
+  promotion_id    BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+  store_id        BIGINT NOT NULL REFERENCES store(store_id),
+  name            VARCHAR(255) NOT NULL,
+  code            VARCHAR(50),
+  type            VARCHAR(20) NOT NULL CHECK (type IN ('percentage', 'fixed_amount', 'free_shipping', 'buy_x_get_y')),
+  value           DECIMAL(10,2) NOT NULL DEFAULT 0,
+  buy_quantity    INT,
+  get_quantity    INT,
+  min_subtotal    DECIMAL(10,2),
+  category_id     BIGINT REFERENCES category_definition(category_id),
+  variant_ids     BIGINT[],
+  first_order_only BOOLEAN NOT NULL DEFAULT FALSE,
+  usage_limit     INT,
+  usage_limit_per_customer INT,
+  starts_at       TIMESTAMP WITH TIME ZONE,
+  ends_at         TIMESTAMP WITH TIME ZONE,
+  is_active       BOOLEAN NOT NULL DEFAULT TRUE,
+  created_at      TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  updated_at      TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_promotion_store ON promotion(store_id, created_at DESC);
+CREATE UNIQUE INDEX idx_promotion_code ON promotion(store_id, UPPER(code)) WHERE code IS NOT NULL;
+
+-- Discounts given on an order. description and code are copied from the
+-- promotion, so the order reads the same after the promotion changes.
+CREATE TABLE order_discount (
+  order_discount_id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+  order_id        BIGINT NOT NULL REFERENCES customer_order(order_id),
+  promotion_id    BIGINT REFERENCES promotion(promotion_id) ON DELETE SET NULL,
+  code            VARCHAR(50),
+  description     VARCHAR(255) NOT NULL,
+  type            VARCHAR(20) NOT NULL,
+  amount          DECIMAL(10,2) NOT NULL,
+  created_at      TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_order_discount_order ON order_discount(order_id);
+CREATE INDEX idx_order_discount_promotion ON order_discount(promotion_id);
+
 CREATE TABLE payment (
   payment_id      BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
   order_id        BIGINT NOT NULL REFERENCES customer_order(order_id),
@@ -209,6 +288,8 @@ CREATE TABLE product_view (
   viewed_at       TIMESTAMP WITH TIME ZONE DEFAULT NOW()
 );
 
+CREATE INDEX idx_product_view_store ON product_view(store_id, viewed_at);
+
 CREATE TABLE cart_event (
   cart_event_id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
   session_id    UUID NOT NULL REFERENCES visitor_session(session_id),
@@ -218,20 +299,254 @@ CREATE TABLE cart_event (
   created_at    TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
 );
 
+CREATE INDEX idx_cart_event_product ON cart_event(product_id, created_at);
+
+-- Reminders sent for abandoned carts. Each has a link to restore the cart,
+-- identified by a token of which only a SHA-256 is stored.
+-- cart_activity_at is the cart's updated_at when the reminder was sent, so
+-- changing the cart starts the reminders over. order_id is the order placed
+-- after the cart was recovered through the link.
+CREATE TABLE cart_reminder (
+  cart_reminder_id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+  cart_id          BIGINT REFERENCES cart(cart_id) ON DELETE SET NULL,
+  store_id         BIGINT NOT NULL REFERENCES store(store_id),
+  cart_activity_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  reminder_number  INT NOT NULL,
+  token_hash       TEXT UNIQUE NOT NULL,
+  expires_at       TIMESTAMP WITH TIME ZONE NOT NULL,
+  sent_at          TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  recovered_at     TIMESTAMP WITH TIME ZONE,
+  order_id         BIGINT REFERENCES customer_order(order_id),
+  UNIQUE (cart_id, cart_activity_at, reminder_number)
+);
+
+CREATE INDEX idx_cart_reminder_store ON cart_reminder(store_id, sent_at);
+
+-- Data subject requests (GDPR). Exports are written to object storage as a
+-- JSON archive; erasure anonymizes the customer but keeps orders and
+-- payments for accounting. Requests are processed by a background worker.
+CREATE TABLE data_request (
+  data_request_id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+  store_id        BIGINT NOT NULL REFERENCES store(store_id),
+  customer_id     BIGINT NOT NULL REFERENCES customer(customer_id),
+  request_type    VARCHAR(20) NOT NULL CHECK (request_type IN ('export', 'erasure')),
+  status          VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'processing', 'completed', 'failed')),
+  requested_by    VARCHAR(20) NOT NULL CHECK (requested_by IN ('customer', 'store_owner')),
+  archive_key     TEXT,
+  error           TEXT,
+  attempts        INT NOT NULL DEFAULT 0,
+  created_at      TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  started_at      TIMESTAMP WITH TIME ZONE,
+  completed_at    TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_data_request_store ON data_request(store_id, created_at DESC);
+CREATE INDEX idx_data_request_customer ON data_request(customer_id, created_at DESC);
+-- at most one open request of each type per customer
+CREATE UNIQUE INDEX idx_data_request_open ON data_request(customer_id, request_type)
+  WHERE status IN ('pending', 'processing');
+
+-- One row per login. session_id doubles as the family_id of the refresh
+-- tokens issued for the login and is carried in the "sid" claim of access
+-- tokens, so revoking the row ends both.
+CREATE TABLE auth_session (
+  session_id    UUID PRIMARY KEY,
+  user_id       BIGINT NOT NULL,
+  user_role     VARCHAR(20) NOT NULL CHECK (user_role IN ('store_owner', 'customer', 'admin')),
+  store_id      BIGINT REFERENCES store(store_id),
+  ip_address    INET,
+  user_agent    TEXT,
+  created_at    TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  last_used_at  TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  revoked_at    TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_auth_session_user ON auth_session(user_id, user_role);
+
+-- Only a SHA-256 of each refresh token is stored. Tokens rotated from the same
+-- login share a family_id so that replaying a revoked token can revoke the
+-- whole family.
 CREATE TABLE refresh_token (
   refresh_token_id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
-  token            TEXT UNIQUE NOT NULL,
+  token_hash       TEXT UNIQUE NOT NULL,
+  family_id        UUID NOT NULL REFERENCES auth_session(session_id),
   user_id          BIGINT NOT NULL,
-  user_role        VARCHAR(20) NOT NULL CHECK (user_role IN ('store_owner', 'customer')),
+  user_role        VARCHAR(20) NOT NULL CHECK (user_role IN ('store_owner', 'customer', 'admin')),
   store_id         BIGINT REFERENCES store(store_id),
   expires_at       TIMESTAMP WITH TIME ZONE NOT NULL,
   revoked          BOOLEAN DEFAULT FALSE,
+  mfa_verified     BOOLEAN NOT NULL DEFAULT FALSE,
   created_at       TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
 );
 
+CREATE INDEX idx_refresh_token_family ON refresh_token(family_id);
+
+-- Access tokens carry their id in the "jti" claim. Each issued token is
+-- recorded with its session so that revoking the session can revoke the
+-- tokens already handed out for it.
+CREATE TABLE access_token (
+  jti         UUID PRIMARY KEY,
+  session_id  UUID NOT NULL REFERENCES auth_session(session_id) ON DELETE CASCADE,
+  expires_at  TIMESTAMP WITH TIME ZONE NOT NULL,
+  created_at  TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_access_token_session ON access_token(session_id);
+CREATE INDEX idx_access_token_expires ON access_token(expires_at);
+
+-- Revoked access tokens that have not expired yet. JWTAuth checks an
+-- in-memory copy that every instance keeps in sync (see internal/revocation).
+CREATE TABLE revoked_token (
+  jti         UUID PRIMARY KEY,
+  expires_at  TIMESTAMP WITH TIME ZONE NOT NULL,
+  revoked_at  TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_revoked_token_revoked ON revoked_token(revoked_at);
+CREATE INDEX idx_revoked_token_expires ON revoked_token(expires_at);
+
+-- ===============================
+-- MULTI-FACTOR AUTHENTICATION
+-- ===============================
+
+CREATE TABLE user_mfa (
+  user_mfa_id      BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+  user_id          BIGINT NOT NULL,
+  user_role        VARCHAR(20) NOT NULL CHECK (user_role IN ('store_owner', 'admin')),
+  totp_secret      TEXT NOT NULL,
+  confirmed_at     TIMESTAMP WITH TIME ZONE,
+  last_used_step   BIGINT,
+  created_at       TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  UNIQUE (user_id, user_role)
+);
+
+CREATE TABLE mfa_recovery_code (
+  mfa_recovery_code_id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+  user_id              BIGINT NOT NULL,
+  user_role            VARCHAR(20) NOT NULL CHECK (user_role IN ('store_owner', 'admin')),
+  code_hash            TEXT NOT NULL,
+  used_at              TIMESTAMP WITH TIME ZONE,
+  created_at           TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  UNIQUE (user_id, user_role, code_hash)
+);
+
+CREATE TABLE password_reset_token (
+  password_reset_token_id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+  token_hash              TEXT UNIQUE NOT NULL,
+  user_id                 BIGINT NOT NULL,
+  user_role               VARCHAR(20) NOT NULL CHECK (user_role IN ('store_owner', 'customer')),
+  store_id                BIGINT REFERENCES store(store_id),
+  expires_at              TIMESTAMP WITH TIME ZONE NOT NULL,
+  used_at                 TIMESTAMP WITH TIME ZONE,
+  created_at              TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- email is the address the link was sent to; the account is only marked
+-- verified if it still has that address when the link is used.
+CREATE TABLE email_verification_token (
+  email_verification_token_id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+  token_hash                  TEXT UNIQUE NOT NULL,
+  user_id                     BIGINT NOT NULL,
+  user_role                   VARCHAR(20) NOT NULL CHECK (user_role IN ('store_owner', 'customer')),
+  store_id                    BIGINT REFERENCES store(store_id),
+  email                       VARCHAR(255) NOT NULL,
+  expires_at                  TIMESTAMP WITH TIME ZONE NOT NULL,
+  used_at                     TIMESTAMP WITH TIME ZONE,
+  created_at                  TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- External OpenID Connect identities linked to a store owner. subject is the
+-- "sub" claim, which is only unique per provider.
+CREATE TABLE store_owner_identity (
+  store_owner_identity_id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+  store_owner_id          BIGINT NOT NULL REFERENCES store_owner(store_owner_id) ON DELETE CASCADE,
+  provider                VARCHAR(100) NOT NULL,
+  subject                 VARCHAR(255) NOT NULL,
+  email                   VARCHAR(255),
+  created_at              TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  UNIQUE (provider, subject)
+);
+
+-- Pending OIDC authorization requests, keyed by a SHA-256 of the state
+-- parameter. A row is deleted when the callback consumes it.
+CREATE TABLE oidc_login_state (
+  state_hash          TEXT PRIMARY KEY,
+  provider            VARCHAR(100) NOT NULL,
+  code_verifier       TEXT NOT NULL,
+  nonce               TEXT NOT NULL,
+  link_store_owner_id BIGINT REFERENCES store_owner(store_owner_id) ON DELETE CASCADE,
+  expires_at          TIMESTAMP WITH TIME ZONE NOT NULL,
+  created_at          TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Staff of a store. Staff sign in with a store owner account of their own
+-- (the account type of business users) and get the permissions of their
+-- role in this store. The owner of the store is not listed here.
+CREATE TABLE store_member (
+  store_member_id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+  store_id        BIGINT NOT NULL REFERENCES store(store_id),
+  store_owner_id  BIGINT NOT NULL REFERENCES store_owner(store_owner_id),
+  role            VARCHAR(30) NOT NULL CHECK (role IN ('manager', 'inventory_clerk', 'order_fulfilment')),
+  invited_by      BIGINT REFERENCES store_owner(store_owner_id),
+  created_at      TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  UNIQUE (store_id, store_owner_id)
+);
+
+CREATE INDEX idx_store_member_owner ON store_member(store_owner_id);
+
+-- Invitations to join the staff of a store, keyed by a SHA-256 of the
+-- token mailed to the invitee.
+CREATE TABLE store_invitation (
+  store_invitation_id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+  store_id            BIGINT NOT NULL REFERENCES store(store_id),
+  email               VARCHAR(255) NOT NULL,
+  role                VARCHAR(30) NOT NULL CHECK (role IN ('manager', 'inventory_clerk', 'order_fulfilment')),
+  token_hash          TEXT UNIQUE NOT NULL,
+  invited_by          BIGINT NOT NULL REFERENCES store_owner(store_owner_id),
+  expires_at          TIMESTAMP WITH TIME ZONE NOT NULL,
+  accepted_at         TIMESTAMP WITH TIME ZONE,
+  created_at          TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_store_invitation_store ON store_invitation(store_id, created_at DESC);
+
+-- API keys let integrations of a store (e.g. an ERP syncing inventory)
+-- call the API without an interactive login. A key can only do what its
+-- scopes allow. Only a SHA-256 of the key is stored; key_prefix is its
+-- non-secret start, shown so owners can tell keys apart.
+CREATE TABLE store_api_key (
+  api_key_id   BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+  store_id     BIGINT NOT NULL REFERENCES store(store_id),
+  name         VARCHAR(100) NOT NULL,
+  key_prefix   VARCHAR(20) NOT NULL,
+  key_hash     TEXT UNIQUE NOT NULL,
+  scopes       TEXT[] NOT NULL,
+  created_by   BIGINT NOT NULL REFERENCES store_owner(store_owner_id),
+  last_used_at TIMESTAMP WITH TIME ZONE,
+  revoked_at   TIMESTAMP WITH TIME ZONE,
+  created_at   TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_store_api_key_store ON store_api_key(store_id, created_at DESC);
+
 CREATE TABLE admin (
   admin_id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
   email    VARCHAR(255) UNIQUE NOT NULL,
   password_hash TEXT NOT NULL,
+  created_by  BIGINT REFERENCES admin(admin_id), -- NULL for seeded admins
+  disabled_at TIMESTAMP WITH TIME ZONE,
   created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
-);
\ No newline at end of file
+);
+
+-- Every state-changing request made by an admin.
+CREATE TABLE admin_audit_log (
+  admin_audit_log_id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+  admin_id           BIGINT NOT NULL REFERENCES admin(admin_id),
+  action             VARCHAR(255) NOT NULL,
+  details            JSONB NOT NULL DEFAULT '{}',
+  status_code        INT NOT NULL,
+  ip_address         INET,
+  created_at         TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_admin_audit_log_admin ON admin_audit_log(admin_id, created_at DESC);
\ No newline at end of file
@@ -12,7 +12,66 @@ var (
 	ErrInvalidVariant   = errors.New("invalid variant")
 	ErrInsufficientStock = errors.New("insufficient stock")
 	ErrCartNotFound     = errors.New("cart not found")
+	ErrCartItemNotFound = errors.New("cart item not found")
 	ErrCartEmpty        = errors.New("cart empty")
+	ErrCartChanged      = errors.New("cart changed")
 	ErrOutOfStock       = errors.New("out of stock")
 	ErrInvalidQuantity  = errors.New("invalid quantity")
+	ErrInvalidMFACode   = errors.New("invalid mfa code")
+	ErrInvalidMFAToken  = errors.New("invalid mfa token")
+	ErrMFANotEnrolled   = errors.New("mfa not enrolled")
+	ErrMFAAlreadyEnabled = errors.New("mfa already enabled")
+	ErrMFANotSupported  = errors.New("mfa not supported for role")
+	ErrInvalidResetToken = errors.New("invalid reset token")
+	ErrPasswordPolicy   = errors.New("password policy violation")
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrRefreshTokenExpired = errors.New("refresh token expired")
+	ErrRefreshTokenReused  = errors.New("refresh token reuse detected")
+	ErrSessionRevoked      = errors.New("session revoked")
+	ErrSessionNotFound     = errors.New("session not found")
+	ErrAccountLocked       = errors.New("account temporarily locked")
+	ErrLoginThrottled      = errors.New("too many failed login attempts")
+	ErrInvalidVerificationToken = errors.New("invalid verification token")
+	ErrEmailAlreadyVerified     = errors.New("email already verified")
+	ErrEmailNotVerified         = errors.New("email not verified")
+	ErrAdminNotFound            = errors.New("admin not found")
+	ErrAdminEmailTaken          = errors.New("admin email already in use")
+	ErrCannotDisableSelf        = errors.New("cannot disable own account")
+	ErrUnknownOIDCProvider      = errors.New("unknown oidc provider")
+	ErrInvalidOIDCState         = errors.New("invalid oidc state")
+	ErrOIDCLoginFailed          = errors.New("oidc login failed")
+	ErrOIDCAccountExists        = errors.New("account exists for oidc email")
+	ErrOIDCIdentityLinked       = errors.New("oidc identity already linked")
+	ErrBreachCheckUnavailable   = errors.New("password breach check unavailable")
+	ErrInvalidProfile           = errors.New("invalid profile")
+	ErrProfileNotSupported      = errors.New("profile not supported for role")
+	ErrEmailTaken               = errors.New("email already in use")
+	ErrInvalidCurrentPassword   = errors.New("invalid current password")
+	ErrInvalidDataRequestType   = errors.New("invalid data request type")
+	ErrDataRequestNotFound      = errors.New("data request not found")
+	ErrDataRequestPending       = errors.New("data request already pending")
+	ErrExportNotReady           = errors.New("export not ready")
+	ErrCustomerNotFound         = errors.New("customer not found")
+	ErrCustomerErased           = errors.New("customer erased")
+	ErrInvalidAPIKeyName        = errors.New("invalid api key name")
+	ErrInvalidAPIKeyScope       = errors.New("invalid api key scope")
+	ErrAPIKeyNotFound           = errors.New("api key not found")
+	ErrAPIKeyOwnerOnly          = errors.New("api keys are created by store owners")
+	ErrInvalidStaffRole         = errors.New("invalid staff role")
+	ErrInvalidInvitation        = errors.New("invalid invitation")
+	ErrInvitationNotFound       = errors.New("invitation not found")
+	ErrInvitationEmailMismatch  = errors.New("invitation email mismatch")
+	ErrAlreadyStoreOwner        = errors.New("already owner of store")
+	ErrStaffMemberNotFound      = errors.New("staff member not found")
+	ErrGuestEmailRequired       = errors.New("guest email required")
+	ErrInvalidShippingAddress   = errors.New("invalid shipping address")
+	ErrInvalidDateRange         = errors.New("invalid date range")
+	ErrInvalidReservationTime   = errors.New("invalid stock reservation time")
+	ErrTooManyCartChanges       = errors.New("too many cart changes")
+	ErrInvalidRecoveryLink      = errors.New("invalid cart recovery link")
+	ErrInvalidReminderSettings  = errors.New("invalid cart reminder settings")
+	ErrInvalidPromotion         = errors.New("invalid promotion")
+	ErrPromotionNotFound        = errors.New("promotion not found")
+	ErrPromotionCodeTaken       = errors.New("promotion code taken")
+	ErrInvalidPromotionCode     = errors.New("invalid promotion code")
 )
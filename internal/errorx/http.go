@@ -35,17 +35,187 @@ func Resolve(err error) HTTPError {
 
 	case errors.Is(err, ErrCartNotFound):
 		return HTTPError{http.StatusNotFound, MsgCartNotFound}
+	case errors.Is(err, ErrCartItemNotFound):
+		return HTTPError{http.StatusNotFound, MsgCartItemNotFound}
 
 	case errors.Is(err, ErrCartEmpty):
 		return HTTPError{http.StatusBadRequest, MsgCartEmpty}
+	case errors.Is(err, ErrCartChanged):
+		return HTTPError{http.StatusConflict, MsgCartChanged}
 
 	case errors.Is(err, ErrInvalidQuantity):
 		return HTTPError{http.StatusBadRequest, MsgInvalidQuantity}
 
+	case errors.Is(err, ErrInvalidMFACode):
+		return HTTPError{http.StatusUnauthorized, MsgInvalidMFACode}
+
+	case errors.Is(err, ErrInvalidMFAToken):
+		return HTTPError{http.StatusUnauthorized, MsgInvalidMFAToken}
+
+	case errors.Is(err, ErrMFANotEnrolled):
+		return HTTPError{http.StatusBadRequest, MsgMFANotEnrolled}
+
+	case errors.Is(err, ErrMFAAlreadyEnabled):
+		return HTTPError{http.StatusConflict, MsgMFAAlreadyEnabled}
+
+	case errors.Is(err, ErrMFANotSupported):
+		return HTTPError{http.StatusForbidden, MsgMFANotSupported}
+
+	case errors.Is(err, ErrInvalidResetToken):
+		return HTTPError{http.StatusBadRequest, MsgInvalidResetToken}
+
+	case errors.Is(err, ErrInvalidRefreshToken):
+		return HTTPError{http.StatusUnauthorized, MsgInvalidRefreshToken}
+
+	case errors.Is(err, ErrRefreshTokenExpired):
+		return HTTPError{http.StatusUnauthorized, MsgRefreshTokenExpired}
+
+	case errors.Is(err, ErrRefreshTokenReused):
+		return HTTPError{http.StatusUnauthorized, MsgRefreshTokenReused}
+
+	case errors.Is(err, ErrSessionRevoked):
+		return HTTPError{http.StatusUnauthorized, MsgSessionRevoked}
+
+	case errors.Is(err, ErrSessionNotFound):
+		return HTTPError{http.StatusNotFound, MsgSessionNotFound}
+
+	case errors.Is(err, ErrAccountLocked):
+		return HTTPError{http.StatusTooManyRequests, MsgAccountLocked}
+
+	case errors.Is(err, ErrLoginThrottled):
+		return HTTPError{http.StatusTooManyRequests, MsgLoginThrottled}
+
+	case errors.Is(err, ErrInvalidVerificationToken):
+		return HTTPError{http.StatusBadRequest, MsgInvalidVerificationToken}
+
+	case errors.Is(err, ErrEmailAlreadyVerified):
+		return HTTPError{http.StatusConflict, MsgEmailAlreadyVerified}
+
+	case errors.Is(err, ErrEmailNotVerified):
+		return HTTPError{http.StatusForbidden, MsgEmailNotVerified}
+
+	case errors.Is(err, ErrAdminNotFound):
+		return HTTPError{http.StatusNotFound, MsgAdminNotFound}
+
+	case errors.Is(err, ErrAdminEmailTaken):
+		return HTTPError{http.StatusConflict, MsgAdminEmailTaken}
+
+	case errors.Is(err, ErrCannotDisableSelf):
+		return HTTPError{http.StatusBadRequest, MsgCannotDisableSelf}
+
+	case errors.Is(err, ErrUnknownOIDCProvider):
+		return HTTPError{http.StatusNotFound, MsgUnknownOIDCProvider}
+
+	case errors.Is(err, ErrInvalidOIDCState):
+		return HTTPError{http.StatusBadRequest, MsgInvalidOIDCState}
+
+	case errors.Is(err, ErrOIDCLoginFailed):
+		return HTTPError{http.StatusUnauthorized, MsgOIDCLoginFailed}
+
+	case errors.Is(err, ErrOIDCAccountExists):
+		return HTTPError{http.StatusConflict, MsgOIDCAccountExists}
+
+	case errors.Is(err, ErrOIDCIdentityLinked):
+		return HTTPError{http.StatusConflict, MsgOIDCIdentityLinked}
+
+	case errors.Is(err, ErrBreachCheckUnavailable):
+		return HTTPError{http.StatusServiceUnavailable, MsgBreachCheckUnavailable}
+
+	case errors.Is(err, ErrProfileNotSupported):
+		return HTTPError{http.StatusForbidden, MsgProfileNotSupported}
+
+	case errors.Is(err, ErrEmailTaken):
+		return HTTPError{http.StatusConflict, MsgEmailTaken}
+
+	case errors.Is(err, ErrInvalidCurrentPassword):
+		return HTTPError{http.StatusForbidden, MsgInvalidCurrentPassword}
+
+	case errors.Is(err, ErrInvalidDataRequestType):
+		return HTTPError{http.StatusBadRequest, MsgInvalidDataRequestType}
+
+	case errors.Is(err, ErrDataRequestNotFound):
+		return HTTPError{http.StatusNotFound, MsgDataRequestNotFound}
+
+	case errors.Is(err, ErrDataRequestPending):
+		return HTTPError{http.StatusConflict, MsgDataRequestPending}
+
+	case errors.Is(err, ErrExportNotReady):
+		return HTTPError{http.StatusConflict, MsgExportNotReady}
+
+	case errors.Is(err, ErrCustomerNotFound):
+		return HTTPError{http.StatusNotFound, MsgCustomerNotFound}
+
+	case errors.Is(err, ErrCustomerErased):
+		return HTTPError{http.StatusGone, MsgCustomerErased}
+
+	case errors.Is(err, ErrInvalidAPIKeyName):
+		return HTTPError{http.StatusBadRequest, MsgInvalidAPIKeyName}
+
+	case errors.Is(err, ErrInvalidAPIKeyScope):
+		return HTTPError{http.StatusBadRequest, MsgInvalidAPIKeyScope}
+
+	case errors.Is(err, ErrAPIKeyNotFound):
+		return HTTPError{http.StatusNotFound, MsgAPIKeyNotFound}
+
+	case errors.Is(err, ErrAPIKeyOwnerOnly):
+		return HTTPError{http.StatusForbidden, MsgAPIKeyOwnerOnly}
+
+	case errors.Is(err, ErrInvalidStaffRole):
+		return HTTPError{http.StatusBadRequest, MsgInvalidStaffRole}
+
+	case errors.Is(err, ErrInvalidInvitation):
+		return HTTPError{http.StatusBadRequest, MsgInvalidInvitation}
+
+	case errors.Is(err, ErrInvitationNotFound):
+		return HTTPError{http.StatusNotFound, MsgInvitationNotFound}
+
+	case errors.Is(err, ErrInvitationEmailMismatch):
+		return HTTPError{http.StatusForbidden, MsgInvitationEmailMismatch}
+
+	case errors.Is(err, ErrAlreadyStoreOwner):
+		return HTTPError{http.StatusConflict, MsgAlreadyStoreOwner}
+
+	case errors.Is(err, ErrStaffMemberNotFound):
+		return HTTPError{http.StatusNotFound, MsgStaffMemberNotFound}
+
+	case errors.Is(err, ErrGuestEmailRequired):
+		return HTTPError{http.StatusBadRequest, MsgGuestEmailRequired}
+
+	case errors.Is(err, ErrInvalidShippingAddress):
+		return HTTPError{http.StatusBadRequest, MsgInvalidShippingAddress}
+
+	case errors.Is(err, ErrInvalidDateRange):
+		return HTTPError{http.StatusBadRequest, MsgInvalidDateRange}
+
+	case errors.Is(err, ErrInvalidReservationTime):
+		return HTTPError{http.StatusBadRequest, MsgInvalidReservationTime}
+
+	case errors.Is(err, ErrTooManyCartChanges):
+		return HTTPError{http.StatusTooManyRequests, MsgTooManyCartChanges}
+
+	case errors.Is(err, ErrInvalidRecoveryLink):
+		return HTTPError{http.StatusBadRequest, MsgInvalidRecoveryLink}
+
+	case errors.Is(err, ErrInvalidReminderSettings):
+		return HTTPError{http.StatusBadRequest, MsgInvalidReminderSettings}
+
+	case errors.Is(err, ErrPromotionNotFound):
+		return HTTPError{http.StatusNotFound, MsgPromotionNotFound}
+
+	case errors.Is(err, ErrPromotionCodeTaken):
+		return HTTPError{http.StatusConflict, MsgPromotionCodeTaken}
+
+	case errors.Is(err, ErrInvalidPromotionCode):
+		return HTTPError{http.StatusBadRequest, MsgInvalidPromotionCode}
+
+	// Policy errors carry the specific rule that failed.
+	case errors.Is(err, ErrPasswordPolicy), errors.Is(err, ErrInvalidProfile), errors.Is(err, ErrInvalidPromotion):
+		return HTTPError{http.StatusBadRequest, err.Error()}
+
 	case errors.Is(err, sql.ErrNoRows):
 		return HTTPError{http.StatusNotFound, MsgResourceNotFound}
 
 	default:
-		return HTTPError{http.StatusInternalServerError, MsgCheckoutFailed}
+		return HTTPError{http.StatusInternalServerError, MsgInternalError}
 	}
 }
@@ -9,10 +9,67 @@ const (
 	MsgInvalidVariant     = "invalid variant"
 	MsgInsufficientStock  = "insufficient stock"
 	MsgCartNotFound       = "cart not found"
+	MsgCartItemNotFound   = "cart item not found"
 	MsgCartEmpty          = "cart is empty"
+	MsgCartChanged        = "cart changed, review it before checking out"
 	MsgOutOfStock         = "item out of stock"
 	MsgResourceNotFound   = "resource not found"
 	MsgCheckoutFailed     = "checkout failed"
 	MsgAddItemFailed      = "failed to add item to cart"
 	MsgInvalidQuantity    = "quantity must be greater than zero"
+	MsgInvalidMFACode     = "invalid mfa code"
+	MsgInvalidMFAToken    = "invalid or expired mfa token"
+	MsgMFANotEnrolled     = "mfa is not enabled for this account"
+	MsgMFAAlreadyEnabled  = "mfa is already enabled for this account"
+	MsgMFANotSupported    = "mfa is not supported for this account type"
+	MsgInvalidResetToken  = "invalid or expired reset token"
+	MsgInternalError      = "internal server error"
+	MsgInvalidRefreshToken = "invalid refresh token"
+	MsgRefreshTokenExpired = "refresh token expired"
+	MsgRefreshTokenReused  = "refresh token reuse detected, please log in again"
+	MsgSessionRevoked      = "session has been revoked, please log in again"
+	MsgSessionNotFound     = "session not found"
+	MsgAccountLocked       = "account temporarily locked after too many failed attempts, try again later"
+	MsgLoginThrottled      = "too many failed attempts, wait before trying again"
+	MsgInvalidVerificationToken = "invalid or expired verification token"
+	MsgEmailAlreadyVerified     = "email is already verified"
+	MsgEmailNotVerified         = "verify your email address first"
+	MsgAdminNotFound            = "admin not found"
+	MsgAdminEmailTaken          = "an admin with this email already exists"
+	MsgCannotDisableSelf        = "admins cannot disable their own account"
+	MsgUnknownOIDCProvider      = "unknown identity provider"
+	MsgInvalidOIDCState         = "invalid or expired login request, please start again"
+	MsgOIDCLoginFailed          = "sign-in with the identity provider failed"
+	MsgOIDCAccountExists        = "an account with this email already exists, log in and link the provider first"
+	MsgOIDCIdentityLinked       = "this identity or provider is already linked to an account"
+	MsgBreachCheckUnavailable   = "password could not be checked right now, try again later"
+	MsgProfileNotSupported      = "profiles are only available to customers and store owners"
+	MsgEmailTaken               = "an account with this email already exists"
+	MsgInvalidCurrentPassword   = "current password is incorrect"
+	MsgInvalidDataRequestType   = "type must be export or erasure"
+	MsgDataRequestNotFound      = "data request not found"
+	MsgDataRequestPending       = "a request of this type is already being processed"
+	MsgExportNotReady           = "the export is not ready for download"
+	MsgCustomerNotFound         = "customer not found"
+	MsgCustomerErased           = "the personal data of this customer has been erased"
+	MsgInvalidAPIKeyName        = "name is required and must be at most 100 characters"
+	MsgInvalidAPIKeyScope       = "scopes must be one or more of products:read, products:write, orders:read"
+	MsgAPIKeyNotFound           = "api key not found"
+	MsgAPIKeyOwnerOnly          = "api keys can only be created by the store owner or staff"
+	MsgInvalidStaffRole         = "role must be manager, inventory_clerk or order_fulfilment"
+	MsgInvalidInvitation        = "invalid or expired invitation"
+	MsgInvitationNotFound       = "invitation not found"
+	MsgInvitationEmailMismatch  = "this invitation was sent to a different email address"
+	MsgAlreadyStoreOwner        = "you already own this store"
+	MsgStaffMemberNotFound      = "staff member not found"
+	MsgGuestEmailRequired       = "an email address is required to check out without an account"
+	MsgInvalidShippingAddress   = "a shipping address with street, city and country is required"
+	MsgInvalidDateRange         = "from and to must be dates (YYYY-MM-DD) with from before to, at most 366 days apart"
+	MsgInvalidReservationTime   = "reservation_minutes must be between 0 and 1440"
+	MsgTooManyCartChanges       = "too many cart changes, try again shortly"
+	MsgInvalidRecoveryLink      = "cart recovery link is invalid or expired"
+	MsgInvalidReminderSettings  = "delay_hours must be between 1 and 720 and max_reminders between 0 and 5"
+	MsgPromotionNotFound        = "promotion not found"
+	MsgPromotionCodeTaken       = "another promotion of the store has this code"
+	MsgInvalidPromotionCode     = "promotion code is invalid, expired or used up"
 )
@@ -0,0 +1,19 @@
+package errorx
+
+import "time"
+
+// RetryAfterError wraps a sentinel error with how long the client should
+// wait before trying again. ErrorMiddleware exposes it as a Retry-After
+// header.
+type RetryAfterError struct {
+	Err        error
+	RetryAfter time.Duration
+}
+
+func (e *RetryAfterError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RetryAfterError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Secure-Website-Builder/Backend/internal/errorx"
+	"github.com/Secure-Website-Builder/Backend/internal/http/middleware"
+	"github.com/Secure-Website-Builder/Backend/internal/services/admin"
+	"github.com/gin-gonic/gin"
+)
+
+type AdminHandler struct {
+	service *admin.Service
+}
+
+func NewAdminHandler(service *admin.Service) *AdminHandler {
+	return &AdminHandler{service: service}
+}
+
+type CreateAdminRequest struct {
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required"`
+}
+
+// ListAdmins handles GET /admin/admins
+func (h *AdminHandler) ListAdmins(c *gin.Context) {
+	admins, err := h.service.ListAdmins(c.Request.Context())
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"admins": admins})
+}
+
+// GetAdmin handles GET /admin/admins/:admin_id
+func (h *AdminHandler) GetAdmin(c *gin.Context) {
+	adminID, err := strconv.ParseInt(c.Param("admin_id"), 10, 64)
+	if err != nil {
+		c.Error(errorx.ErrAdminNotFound)
+		return
+	}
+
+	a, err := h.service.GetAdmin(c.Request.Context(), adminID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, a)
+}
+
+// CreateAdmin handles POST /admin/admins
+func (h *AdminHandler) CreateAdmin(c *gin.Context) {
+	var req CreateAdminRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errorx.ErrInvalidRequestBody)
+		return
+	}
+	middleware.AddAuditDetail(c, "email", req.Email)
+
+	a, err := h.service.CreateAdmin(
+		c.Request.Context(),
+		c.GetInt64("user_id"),
+		req.Email,
+		req.Password,
+	)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, a)
+}
+
+// DisableAdmin handles POST /admin/admins/:admin_id/disable
+func (h *AdminHandler) DisableAdmin(c *gin.Context) {
+	adminID, err := strconv.ParseInt(c.Param("admin_id"), 10, 64)
+	if err != nil {
+		c.Error(errorx.ErrAdminNotFound)
+		return
+	}
+
+	if err := h.service.DisableAdmin(
+		c.Request.Context(),
+		c.GetInt64("user_id"),
+		adminID,
+	); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// EnableAdmin handles POST /admin/admins/:admin_id/enable
+func (h *AdminHandler) EnableAdmin(c *gin.Context) {
+	adminID, err := strconv.ParseInt(c.Param("admin_id"), 10, 64)
+	if err != nil {
+		c.Error(errorx.ErrAdminNotFound)
+		return
+	}
+
+	if err := h.service.EnableAdmin(c.Request.Context(), adminID); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListAuditLog handles GET /admin/audit-log?admin_id=&before_id=&limit=
+func (h *AdminHandler) ListAuditLog(c *gin.Context) {
+	var (
+		adminID, beforeID *int64
+		limit             int32
+	)
+
+	if raw := c.Query("admin_id"); raw != "" {
+		id, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			c.Error(errorx.ErrInvalidRequestBody)
+			return
+		}
+		adminID = &id
+	}
+
+	if raw := c.Query("before_id"); raw != "" {
+		id, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			c.Error(errorx.ErrInvalidRequestBody)
+			return
+		}
+		beforeID = &id
+	}
+
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.ParseInt(raw, 10, 32)
+		if err != nil {
+			c.Error(errorx.ErrInvalidRequestBody)
+			return
+		}
+		limit = int32(n)
+	}
+
+	entries, err := h.service.ListAuditLog(c.Request.Context(), adminID, beforeID, limit)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"entries": entries})
+}
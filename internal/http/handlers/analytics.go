@@ -1,9 +1,18 @@
 package handlers
 
 import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Secure-Website-Builder/Backend/internal/errorx"
 	"github.com/Secure-Website-Builder/Backend/internal/services/analytics"
+	"github.com/gin-gonic/gin"
 )
 
+// defaultReportDays is the period of a report without from and to.
+const defaultReportDays = 30
+
 type AnalyticsHandler struct {
 	service *analytics.Service
 }
@@ -11,3 +20,86 @@ type AnalyticsHandler struct {
 func NewAnalyticsHandler(s *analytics.Service) *AnalyticsHandler {
 	return &AnalyticsHandler{service: s}
 }
+
+// ProductAddToCartRates handles GET /dashboard/stores/:store_id/analytics/add-to-cart
+//
+// from and to are dates (YYYY-MM-DD, UTC); to is inclusive. Without them
+// the report covers the last 30 days.
+func (h *AnalyticsHandler) ProductAddToCartRates(c *gin.Context) {
+	storeID, err := strconv.ParseInt(c.Param("store_id"), 10, 64)
+	if err != nil {
+		c.Error(errorx.ErrInvalidStoreID)
+		return
+	}
+
+	from, to, err := reportPeriod(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	rates, err := h.service.ProductAddToCartRates(c.Request.Context(), storeID, from, to)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"from":     from,
+		"to":       to,
+		"products": rates,
+	})
+}
+
+// CartRecovery handles GET /dashboard/stores/:store_id/analytics/cart-recovery
+//
+// The period is selected as for ProductAddToCartRates.
+func (h *AnalyticsHandler) CartRecovery(c *gin.Context) {
+	storeID, err := strconv.ParseInt(c.Param("store_id"), 10, 64)
+	if err != nil {
+		c.Error(errorx.ErrInvalidStoreID)
+		return
+	}
+
+	from, to, err := reportPeriod(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	recovery, err := h.service.CartRecovery(c.Request.Context(), storeID, from, to)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"from":     from,
+		"to":       to,
+		"recovery": recovery,
+	})
+}
+
+// reportPeriod returns the half-open period [from, to) selected by the
+// from and to query parameters.
+func reportPeriod(c *gin.Context) (time.Time, time.Time, error) {
+	to := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, 1)
+	if raw := c.Query("to"); raw != "" {
+		day, err := time.Parse(time.DateOnly, raw)
+		if err != nil {
+			return time.Time{}, time.Time{}, errorx.ErrInvalidDateRange
+		}
+		to = day.AddDate(0, 0, 1)
+	}
+
+	from := to.AddDate(0, 0, -defaultReportDays)
+	if raw := c.Query("from"); raw != "" {
+		day, err := time.Parse(time.DateOnly, raw)
+		if err != nil {
+			return time.Time{}, time.Time{}, errorx.ErrInvalidDateRange
+		}
+		from = day
+	}
+
+	return from, to, nil
+}
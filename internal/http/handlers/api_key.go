@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Secure-Website-Builder/Backend/internal/errorx"
+	"github.com/Secure-Website-Builder/Backend/internal/services/apikey"
+	"github.com/gin-gonic/gin"
+)
+
+type APIKeyHandler struct {
+	service *apikey.Service
+}
+
+func NewAPIKeyHandler(service *apikey.Service) *APIKeyHandler {
+	return &APIKeyHandler{service: service}
+}
+
+type CreateAPIKeyRequest struct {
+	Name   string   `json:"name" binding:"required"`
+	Scopes []string `json:"scopes" binding:"required"`
+}
+
+// CreateAPIKey handles POST /dashboard/stores/:store_id/api-keys
+//
+// The response is the only time the key is shown.
+func (h *APIKeyHandler) CreateAPIKey(c *gin.Context) {
+	storeID, err := strconv.ParseInt(c.Param("store_id"), 10, 64)
+	if err != nil {
+		c.Error(errorx.ErrInvalidStoreID)
+		return
+	}
+
+	// A key records the store owner account that created it, so admins,
+	// who pass the role check, cannot create one.
+	if c.GetString("role") != "store_owner" {
+		c.Error(errorx.ErrAPIKeyOwnerOnly)
+		return
+	}
+
+	var req CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errorx.ErrInvalidRequestBody)
+		return
+	}
+
+	key, err := h.service.Create(
+		c.Request.Context(),
+		storeID,
+		c.GetInt64("user_id"),
+		req.Name,
+		req.Scopes,
+	)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusCreated, key)
+}
+
+// ListAPIKeys handles GET /dashboard/stores/:store_id/api-keys
+func (h *APIKeyHandler) ListAPIKeys(c *gin.Context) {
+	storeID, err := strconv.ParseInt(c.Param("store_id"), 10, 64)
+	if err != nil {
+		c.Error(errorx.ErrInvalidStoreID)
+		return
+	}
+
+	keys, err := h.service.List(c.Request.Context(), storeID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"api_keys": keys})
+}
+
+// RevokeAPIKey handles DELETE /dashboard/stores/:store_id/api-keys/:api_key_id
+func (h *APIKeyHandler) RevokeAPIKey(c *gin.Context) {
+	storeID, err := strconv.ParseInt(c.Param("store_id"), 10, 64)
+	if err != nil {
+		c.Error(errorx.ErrInvalidStoreID)
+		return
+	}
+
+	keyID, err := strconv.ParseInt(c.Param("api_key_id"), 10, 64)
+	if err != nil {
+		c.Error(errorx.ErrAPIKeyNotFound)
+		return
+	}
+
+	if err := h.service.Revoke(c.Request.Context(), storeID, keyID); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
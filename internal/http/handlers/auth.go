@@ -1,8 +1,12 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
 
+	"github.com/Secure-Website-Builder/Backend/internal/errorx"
+	"github.com/Secure-Website-Builder/Backend/internal/http/middleware"
 	"github.com/Secure-Website-Builder/Backend/internal/services/auth"
 	"github.com/Secure-Website-Builder/Backend/internal/types"
 	"github.com/gin-gonic/gin"
@@ -35,7 +39,61 @@ type LoginRequest struct {
 }
 
 type AuthResponse struct {
-	Token string `json:"token"`
+	Token                 string `json:"token,omitempty"`
+	MFAToken              string `json:"mfa_token,omitempty"`
+	MFARequired           bool   `json:"mfa_required,omitempty"`
+	MFAEnrollmentRequired bool   `json:"mfa_enrollment_required,omitempty"`
+}
+
+// writeAuthResult sets the refresh cookie (when one was issued) and renders
+// the access token or the pending MFA challenge.
+func writeAuthResult(c *gin.Context, result *auth.AuthResult) {
+	if result.MFAToken != "" {
+		c.JSON(http.StatusOK, AuthResponse{
+			MFAToken:    result.MFAToken,
+			MFARequired: true,
+		})
+		return
+	}
+
+	setRefreshCookie(c, result.RefreshToken)
+
+	c.JSON(http.StatusOK, AuthResponse{
+		Token:                 result.AccessToken,
+		MFAEnrollmentRequired: result.MFAEnrollmentRequired,
+	})
+}
+
+// clientInfo captures the device details recorded on the auth session.
+func clientInfo(c *gin.Context) auth.ClientInfo {
+	return auth.ClientInfo{
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}
+}
+
+// writeLoginError reports throttling and lockouts (with Retry-After) through
+// the error middleware and everything else as a failed login.
+func writeLoginError(c *gin.Context, err error) {
+	var retry *errorx.RetryAfterError
+	if errors.As(err, &retry) {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+}
+
+func setRefreshCookie(c *gin.Context, refreshToken string) {
+	c.SetCookie(
+		"refresh_token",
+		refreshToken,
+		7*24*60*60,
+		"/",
+		"",
+		true,
+		true,
+	)
 }
 
 func (h *AuthHandler) Register(c *gin.Context) {
@@ -55,7 +113,7 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		addr = req.Address
 	}
 
-	accessToken, refreshToken, err := h.service.Register(
+	result, err := h.service.Register(
 		c.Request.Context(),
 		req.Name,
 		req.Email,
@@ -64,21 +122,14 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		req.StoreID,
 		phone,
 		addr,
+		clientInfo(c),
 	)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	c.SetCookie(
-		"refresh_token",
-		refreshToken,
-		7*24*60*60,
-		"/",
-		"",
-		true,
-		true,
-	)
-	c.JSON(http.StatusOK, AuthResponse{Token: accessToken})
+
+	writeAuthResult(c, result)
 }
 
 func (h *AuthHandler) Login(c *gin.Context) {
@@ -96,30 +147,21 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		}
 	}
 
-	accessToken, refreshToken, err := h.service.Login(
+	result, err := h.service.Login(
 		c.Request.Context(),
 		req.Email,
 		req.Password,
 		req.Role,
 		req.StoreID,
 		sessionID,
+		clientInfo(c),
 	)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		writeLoginError(c, err)
 		return
 	}
 
-	c.SetCookie(
-		"refresh_token",
-		refreshToken,
-		7*24*60*60,
-		"/",
-		"",
-		true,
-		true,
-	)
-
-	c.JSON(http.StatusOK, AuthResponse{Token: accessToken})
+	writeAuthResult(c, result)
 }
 
 type AdminLoginRequest struct {
@@ -134,17 +176,18 @@ func (h *AuthHandler) AdminLogin(c *gin.Context) {
 		return
 	}
 
-	token, err := h.service.AdminLogin(
+	result, err := h.service.AdminLogin(
 		c.Request.Context(),
 		req.Email,
 		req.Password,
+		clientInfo(c),
 	)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		writeLoginError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"token": token})
+	writeAuthResult(c, result)
 }
 
 func (h *AuthHandler) RefreshToken(c *gin.Context) {
@@ -157,21 +200,14 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 	access, newRefresh, err := h.service.Refresh(
 		c.Request.Context(),
 		refreshToken,
+		clientInfo(c),
 	)
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.SetCookie(
-		"refresh_token",
-		newRefresh,
-		7*24*60*60,
-		"/",
-		"",
-		true,
-		true,
-	)
+	setRefreshCookie(c, newRefresh)
 
 	c.JSON(http.StatusOK, gin.H{
 		"access_token": access,
@@ -199,3 +235,129 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 
 	c.Status(http.StatusNoContent)
 }
+
+type ForgotPasswordRequest struct {
+	Email   string `json:"email" binding:"required,email"`
+	Role    string `json:"role" binding:"required,oneof=store_owner customer"`
+	StoreID *int64 `json:"store_id"` // required only for customers
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// ForgotPassword handles POST /auth/password/forgot. The response is the same
+// whether or not the account exists.
+func (h *AuthHandler) ForgotPassword(c *gin.Context) {
+	var req ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errorx.ErrInvalidRequestBody)
+		return
+	}
+
+	if err := h.service.RequestPasswordReset(
+		c.Request.Context(),
+		req.Email,
+		req.Role,
+		req.StoreID,
+	); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusAccepted)
+}
+
+// ResetPassword handles POST /auth/password/reset
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+	var req ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errorx.ErrInvalidRequestBody)
+		return
+	}
+
+	if err := h.service.ResetPassword(
+		c.Request.Context(),
+		req.Token,
+		req.Password,
+	); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+type UnlockAccountRequest struct {
+	Email   string `json:"email" binding:"required,email"`
+	Role    string `json:"role" binding:"required,oneof=store_owner customer admin"`
+	StoreID *int64 `json:"store_id"` // required only for customers
+}
+
+// UnlockAccount handles POST /admin/accounts/unlock, lifting a login lockout
+// before it expires.
+func (h *AuthHandler) UnlockAccount(c *gin.Context) {
+	var req UnlockAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errorx.ErrInvalidRequestBody)
+		return
+	}
+	middleware.AddAuditDetail(c, "email", req.Email)
+	middleware.AddAuditDetail(c, "role", req.Role)
+	if req.StoreID != nil {
+		middleware.AddAuditDetail(c, "target_store_id", strconv.FormatInt(*req.StoreID, 10))
+	}
+
+	if req.Role == "customer" && req.StoreID == nil {
+		c.Error(errorx.ErrInvalidStoreID)
+		return
+	}
+
+	if err := h.service.UnlockAccount(
+		c.Request.Context(),
+		req.Email,
+		req.Role,
+		req.StoreID,
+	); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// VerifyEmail handles POST /auth/email/verify
+func (h *AuthHandler) VerifyEmail(c *gin.Context) {
+	var req VerifyEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errorx.ErrInvalidRequestBody)
+		return
+	}
+
+	if err := h.service.VerifyEmail(c.Request.Context(), req.Token); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ResendEmailVerification handles POST /auth/email/verify/resend
+func (h *AuthHandler) ResendEmailVerification(c *gin.Context) {
+	err := h.service.ResendEmailVerification(
+		c.Request.Context(),
+		c.GetInt64("user_id"),
+		c.GetString("role"),
+	)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusAccepted)
+}
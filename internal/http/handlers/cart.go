@@ -9,6 +9,7 @@ import (
 
 	"github.com/Secure-Website-Builder/Backend/internal/services/cart"
 	"github.com/Secure-Website-Builder/Backend/internal/errorx"
+	"github.com/Secure-Website-Builder/Backend/internal/types"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
@@ -21,6 +22,15 @@ func NewCartHandler(s *cart.Service) *CartHandler {
 	return &CartHandler{Service: s}
 }
 
+// cartCustomerID returns the logged-in customer, or nil for guests.
+func cartCustomerID(c *gin.Context) *int64 {
+	if c.GetString("role") != "customer" {
+		return nil
+	}
+	id := c.GetInt64("user_id")
+	return &id
+}
+
 func (h *CartHandler) GetCart(c *gin.Context) {
 	ctx := c.Request.Context()
 
@@ -42,7 +52,11 @@ func (h *CartHandler) GetCart(c *gin.Context) {
 		return
 	}
 
-	cartDTO, err := h.Service.GetCart(ctx, storeID, sessionID)
+	cartDTO, err := h.Service.GetCart(ctx, storeID, sessionID, cartCustomerID(c))
+	if errors.Is(err, errorx.ErrInvalidSession) {
+		c.Error(err)
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load cart"})
 		return
@@ -87,6 +101,7 @@ func (h *CartHandler) AddItem(c *gin.Context) {
 		ctx,
 		storeID,
 		sessionID,
+		cartCustomerID(c),
 		req.VariantID,
 		req.Quantity,
 	)
@@ -115,14 +130,23 @@ func (h *CartHandler) handleAddItemError(c *gin.Context, err error) {
 	case strings.Contains(err.Error(), "insufficient stock"):
 		c.JSON(http.StatusConflict, gin.H{"error": "insufficient stock"})
 
+	case errors.Is(err, errorx.ErrTooManyCartChanges):
+		c.Error(err)
+
 	default:
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to add item to cart"})
 	}
 }
 
 
+// CheckoutRequest is the checkout body. Guests must also send email and
+// shipping_address; customers may leave out shipping_address to use the
+// address of their account.
 type CheckoutRequest struct {
-	PaymentMethod string `json:"payment_method" binding:"required"`
+	PaymentMethod   string         `json:"payment_method" binding:"required"`
+	Email           string         `json:"email"`
+	ShippingAddress *types.Address `json:"shipping_address"`
+	Total           string         `json:"total" binding:"required"`
 }
 
 func (h *CartHandler) Checkout(c *gin.Context) {
@@ -152,7 +176,20 @@ func (h *CartHandler) Checkout(c *gin.Context) {
 		return
 	}
 
-	err = h.Service.Checkout(ctx, storeID, sessionID, req.PaymentMethod)
+	changed, err := h.Service.Checkout(ctx, storeID, sessionID, cartCustomerID(c), cart.CheckoutInput{
+		PaymentMethod:   req.PaymentMethod,
+		Email:           req.Email,
+		ShippingAddress: req.ShippingAddress,
+		Total:           req.Total,
+	})
+	if errors.Is(err, errorx.ErrCartChanged) && changed != nil {
+		// The shopper needs the repriced cart to review it.
+		c.JSON(http.StatusConflict, gin.H{
+			"error": errorx.MsgCartChanged,
+			"cart":  changed,
+		})
+		return
+	}
 	if err != nil {
 		c.Error(err)
 		return
@@ -160,3 +197,152 @@ func (h *CartHandler) Checkout(c *gin.Context) {
 
 	c.Status(http.StatusCreated)
 }
+
+// cartScope reads the store and visitor session of a cart request. It
+// reports the error on c and returns false if either is invalid.
+func cartScope(c *gin.Context) (int64, uuid.UUID, bool) {
+	storeID, err := strconv.ParseInt(c.Param("store_id"), 10, 64)
+	if err != nil {
+		c.Error(errorx.ErrInvalidStoreID)
+		return 0, uuid.Nil, false
+	}
+
+	rawSessionID := c.GetHeader("X-Session-ID")
+	if rawSessionID == "" {
+		c.Error(errorx.ErrMissingSessionID)
+		return 0, uuid.Nil, false
+	}
+
+	sessionID, err := uuid.Parse(rawSessionID)
+	if err != nil {
+		c.Error(errorx.ErrInvalidSessionID)
+		return 0, uuid.Nil, false
+	}
+
+	return storeID, sessionID, true
+}
+
+type UpdateCartItemRequest struct {
+	Quantity int32 `json:"quantity" binding:"required,min=1"`
+}
+
+// UpdateItem handles PATCH /stores/:store_id/cart/items/:item_id
+func (h *CartHandler) UpdateItem(c *gin.Context) {
+	storeID, sessionID, ok := cartScope(c)
+	if !ok {
+		return
+	}
+
+	itemID, err := strconv.ParseInt(c.Param("item_id"), 10, 64)
+	if err != nil {
+		c.Error(errorx.ErrCartItemNotFound)
+		return
+	}
+
+	var req UpdateCartItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errorx.ErrInvalidRequestBody)
+		return
+	}
+
+	cartDTO, err := h.Service.UpdateItemQuantity(
+		c.Request.Context(),
+		storeID,
+		sessionID,
+		cartCustomerID(c),
+		itemID,
+		req.Quantity,
+	)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, cartDTO)
+}
+
+// RemoveItem handles DELETE /stores/:store_id/cart/items/:item_id
+func (h *CartHandler) RemoveItem(c *gin.Context) {
+	storeID, sessionID, ok := cartScope(c)
+	if !ok {
+		return
+	}
+
+	itemID, err := strconv.ParseInt(c.Param("item_id"), 10, 64)
+	if err != nil {
+		c.Error(errorx.ErrCartItemNotFound)
+		return
+	}
+
+	cartDTO, err := h.Service.RemoveItem(c.Request.Context(), storeID, sessionID, cartCustomerID(c), itemID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, cartDTO)
+}
+
+// ClearCart handles DELETE /stores/:store_id/cart
+func (h *CartHandler) ClearCart(c *gin.Context) {
+	storeID, sessionID, ok := cartScope(c)
+	if !ok {
+		return
+	}
+
+	cartDTO, err := h.Service.ClearCart(c.Request.Context(), storeID, sessionID, cartCustomerID(c))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, cartDTO)
+}
+
+type ApplyPromotionCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// ApplyPromotionCode handles PUT /stores/:store_id/cart/promotion-code
+func (h *CartHandler) ApplyPromotionCode(c *gin.Context) {
+	storeID, sessionID, ok := cartScope(c)
+	if !ok {
+		return
+	}
+
+	var req ApplyPromotionCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errorx.ErrInvalidRequestBody)
+		return
+	}
+
+	cartDTO, err := h.Service.ApplyPromotionCode(
+		c.Request.Context(),
+		storeID,
+		sessionID,
+		cartCustomerID(c),
+		req.Code,
+	)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, cartDTO)
+}
+
+// RemovePromotionCode handles DELETE /stores/:store_id/cart/promotion-code
+func (h *CartHandler) RemovePromotionCode(c *gin.Context) {
+	storeID, sessionID, ok := cartScope(c)
+	if !ok {
+		return
+	}
+
+	cartDTO, err := h.Service.RemovePromotionCode(c.Request.Context(), storeID, sessionID, cartCustomerID(c))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, cartDTO)
+}
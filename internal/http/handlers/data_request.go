@@ -0,0 +1,240 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/Secure-Website-Builder/Backend/internal/errorx"
+	"github.com/Secure-Website-Builder/Backend/internal/http/middleware"
+	"github.com/Secure-Website-Builder/Backend/internal/services/auth"
+	"github.com/Secure-Website-Builder/Backend/internal/services/privacy"
+	"github.com/gin-gonic/gin"
+)
+
+type DataRequestHandler struct {
+	service *privacy.Service
+	auth    *auth.Service
+}
+
+func NewDataRequestHandler(service *privacy.Service, authService *auth.Service) *DataRequestHandler {
+	return &DataRequestHandler{service: service, auth: authService}
+}
+
+type CreateOwnDataRequestRequest struct {
+	Type string `json:"type" binding:"required"`
+	// CurrentPassword is required to request an erasure.
+	CurrentPassword string `json:"current_password"`
+}
+
+type CreateStoreDataRequestRequest struct {
+	CustomerID int64  `json:"customer_id" binding:"required"`
+	Type       string `json:"type" binding:"required"`
+}
+
+// requireCustomer refuses the admin bypass of RequireRole: the /me data
+// requests act on the caller's own customer account.
+func requireCustomer(c *gin.Context) bool {
+	if c.GetString("role") != "customer" {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		return false
+	}
+	return true
+}
+
+// CreateOwnRequest handles POST /me/data-requests
+func (h *DataRequestHandler) CreateOwnRequest(c *gin.Context) {
+	if !requireCustomer(c) {
+		return
+	}
+
+	var req CreateOwnDataRequestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errorx.ErrInvalidRequestBody)
+		return
+	}
+
+	ctx := c.Request.Context()
+	customerID := c.GetInt64("user_id")
+
+	if req.Type == privacy.RequestErasure {
+		if err := h.auth.ConfirmPassword(ctx, customerID, "customer", req.CurrentPassword); err != nil {
+			c.Error(err)
+			return
+		}
+	}
+
+	dataRequest, err := h.service.CreateOwnRequest(ctx, customerID, req.Type)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, dataRequest)
+}
+
+// ListOwnRequests handles GET /me/data-requests
+func (h *DataRequestHandler) ListOwnRequests(c *gin.Context) {
+	if !requireCustomer(c) {
+		return
+	}
+
+	requests, err := h.service.ListCustomerRequests(c.Request.Context(), c.GetInt64("user_id"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"requests": requests})
+}
+
+// DownloadOwnExport handles GET /me/data-requests/:request_id/download
+func (h *DataRequestHandler) DownloadOwnExport(c *gin.Context) {
+	if !requireCustomer(c) {
+		return
+	}
+
+	requestID, err := strconv.ParseInt(c.Param("request_id"), 10, 64)
+	if err != nil {
+		c.Error(errorx.ErrDataRequestNotFound)
+		return
+	}
+
+	archive, name, err := h.service.OpenCustomerArchive(c.Request.Context(), c.GetInt64("user_id"), requestID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	defer archive.Close()
+
+	sendArchive(c, archive, name)
+}
+
+// CreateStoreRequest handles POST /dashboard/stores/:store_id/data-requests
+func (h *DataRequestHandler) CreateStoreRequest(c *gin.Context) {
+	storeID, err := strconv.ParseInt(c.Param("store_id"), 10, 64)
+	if err != nil {
+		c.Error(errorx.ErrInvalidStoreID)
+		return
+	}
+
+	var req CreateStoreDataRequestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errorx.ErrInvalidRequestBody)
+		return
+	}
+	middleware.AddAuditDetail(c, "customer_id", strconv.FormatInt(req.CustomerID, 10))
+	middleware.AddAuditDetail(c, "type", req.Type)
+
+	dataRequest, err := h.service.CreateRequest(
+		c.Request.Context(),
+		storeID,
+		req.CustomerID,
+		req.Type,
+	)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, dataRequest)
+}
+
+// ListStoreRequests handles GET /dashboard/stores/:store_id/data-requests?before_id=&limit=
+func (h *DataRequestHandler) ListStoreRequests(c *gin.Context) {
+	storeID, err := strconv.ParseInt(c.Param("store_id"), 10, 64)
+	if err != nil {
+		c.Error(errorx.ErrInvalidStoreID)
+		return
+	}
+
+	var (
+		beforeID *int64
+		limit    int32
+	)
+
+	if raw := c.Query("before_id"); raw != "" {
+		id, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			c.Error(errorx.ErrInvalidRequestBody)
+			return
+		}
+		beforeID = &id
+	}
+
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.ParseInt(raw, 10, 32)
+		if err != nil {
+			c.Error(errorx.ErrInvalidRequestBody)
+			return
+		}
+		limit = int32(n)
+	}
+
+	requests, err := h.service.ListStoreRequests(c.Request.Context(), storeID, beforeID, limit)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"requests": requests})
+}
+
+// GetStoreRequest handles GET /dashboard/stores/:store_id/data-requests/:request_id
+func (h *DataRequestHandler) GetStoreRequest(c *gin.Context) {
+	storeID, requestID, ok := storeRequestParams(c)
+	if !ok {
+		return
+	}
+
+	dataRequest, err := h.service.GetStoreRequest(c.Request.Context(), storeID, requestID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dataRequest)
+}
+
+// DownloadStoreExport handles GET /dashboard/stores/:store_id/data-requests/:request_id/download
+func (h *DataRequestHandler) DownloadStoreExport(c *gin.Context) {
+	storeID, requestID, ok := storeRequestParams(c)
+	if !ok {
+		return
+	}
+
+	archive, name, err := h.service.OpenStoreArchive(c.Request.Context(), storeID, requestID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	defer archive.Close()
+
+	sendArchive(c, archive, name)
+}
+
+func storeRequestParams(c *gin.Context) (storeID, requestID int64, ok bool) {
+	storeID, err := strconv.ParseInt(c.Param("store_id"), 10, 64)
+	if err != nil {
+		c.Error(errorx.ErrInvalidStoreID)
+		return 0, 0, false
+	}
+
+	requestID, err = strconv.ParseInt(c.Param("request_id"), 10, 64)
+	if err != nil {
+		c.Error(errorx.ErrDataRequestNotFound)
+		return 0, 0, false
+	}
+
+	return storeID, requestID, true
+}
+
+// sendArchive streams an export as an attachment. Exports hold personal
+// data, so they must not be cached along the way.
+func sendArchive(c *gin.Context, archive io.Reader, name string) {
+	c.DataFromReader(http.StatusOK, -1, "application/json", archive, map[string]string{
+		"Content-Disposition": fmt.Sprintf("attachment; filename=%q", name),
+		"Cache-Control":       "no-store",
+	})
+}
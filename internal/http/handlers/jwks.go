@@ -0,0 +1,22 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/Secure-Website-Builder/Backend/internal/jwtkeys"
+	"github.com/gin-gonic/gin"
+)
+
+type JWKSHandler struct {
+	keys *jwtkeys.KeySet
+}
+
+func NewJWKSHandler(keys *jwtkeys.KeySet) *JWKSHandler {
+	return &JWKSHandler{keys: keys}
+}
+
+// GetJWKS handles GET /.well-known/jwks.json
+func (h *JWKSHandler) GetJWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.keys.JWKS())
+}
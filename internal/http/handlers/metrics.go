@@ -0,0 +1,24 @@
+package handlers
+
+import (
+	"encoding/json"
+	"expvar"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Metrics serves the named expvar counters as one JSON object. Only these
+// are served: expvar also publishes the command line and memory stats,
+// which are not for the API.
+func Metrics(names ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		out := make(map[string]json.RawMessage, len(names))
+		for _, name := range names {
+			if v := expvar.Get(name); v != nil {
+				out[name] = json.RawMessage(v.String())
+			}
+		}
+		c.JSON(http.StatusOK, out)
+	}
+}
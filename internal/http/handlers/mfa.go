@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/Secure-Website-Builder/Backend/internal/errorx"
+	"github.com/gin-gonic/gin"
+)
+
+type MFACodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type MFAVerifyRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+// EnrollMFA handles POST /auth/mfa/enroll
+func (h *AuthHandler) EnrollMFA(c *gin.Context) {
+	enrollment, err := h.service.EnrollMFA(
+		c.Request.Context(),
+		c.GetInt64("user_id"),
+		c.GetString("role"),
+	)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, enrollment)
+}
+
+// ConfirmMFA handles POST /auth/mfa/enroll/confirm
+func (h *AuthHandler) ConfirmMFA(c *gin.Context) {
+	var req MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errorx.ErrInvalidRequestBody)
+		return
+	}
+
+	err := h.service.ConfirmMFA(
+		c.Request.Context(),
+		c.GetInt64("user_id"),
+		c.GetString("role"),
+		req.Code,
+	)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// VerifyMFA handles POST /auth/mfa/verify, the second step of a login.
+func (h *AuthHandler) VerifyMFA(c *gin.Context) {
+	var req MFAVerifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errorx.ErrInvalidRequestBody)
+		return
+	}
+
+	result, err := h.service.VerifyMFA(c.Request.Context(), req.MFAToken, req.Code, clientInfo(c))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	writeAuthResult(c, result)
+}
+
+// DisableMFA handles POST /auth/mfa/disable
+func (h *AuthHandler) DisableMFA(c *gin.Context) {
+	var req MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errorx.ErrInvalidRequestBody)
+		return
+	}
+
+	err := h.service.DisableMFA(
+		c.Request.Context(),
+		c.GetInt64("user_id"),
+		c.GetString("role"),
+		req.Code,
+	)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/Secure-Website-Builder/Backend/internal/errorx"
+	"github.com/gin-gonic/gin"
+)
+
+type OIDCCallbackRequest struct {
+	Code  string `json:"code" binding:"required"`
+	State string `json:"state" binding:"required"`
+}
+
+// oidcBindingCookie holds the browser binding of a started OIDC login. The
+// callback is only accepted from the browser that has it.
+const oidcBindingCookie = "oidc_binding"
+
+// StartOIDCLogin handles GET /auth/oidc/:provider/authorize. The client
+// sends the user to the returned URL.
+func (h *AuthHandler) StartOIDCLogin(c *gin.Context) {
+	authURL, binding, err := h.service.StartOIDCLogin(c.Request.Context(), c.Param("provider"), nil)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	setOIDCBindingCookie(c, binding, 0)
+
+	c.JSON(http.StatusOK, gin.H{"authorization_url": authURL})
+}
+
+// OIDCCallback handles POST /auth/oidc/:provider/callback with the code and
+// state the provider redirected back with.
+func (h *AuthHandler) OIDCCallback(c *gin.Context) {
+	var req OIDCCallbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errorx.ErrInvalidRequestBody)
+		return
+	}
+
+	// A missing cookie leaves binding empty, which never matches
+	binding, _ := c.Cookie(oidcBindingCookie)
+	setOIDCBindingCookie(c, "", -1)
+
+	result, err := h.service.CompleteOIDCLogin(
+		c.Request.Context(),
+		c.Param("provider"),
+		req.Code,
+		req.State,
+		binding,
+		clientInfo(c),
+	)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	writeAuthResult(c, result)
+}
+
+func setOIDCBindingCookie(c *gin.Context, binding string, maxAge int) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(
+		oidcBindingCookie,
+		binding,
+		maxAge,
+		"/auth/oidc",
+		"",
+		true,
+		true,
+	)
+}
+
+// requireStoreOwner refuses the admin bypass of RequireRole: identities can
+// only be linked to the caller's own store owner account.
+func requireStoreOwner(c *gin.Context) bool {
+	if c.GetString("role") != "store_owner" {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		return false
+	}
+	return true
+}
+
+// StartOIDCLink handles POST /auth/oidc/:provider/link
+func (h *AuthHandler) StartOIDCLink(c *gin.Context) {
+	if !requireStoreOwner(c) {
+		return
+	}
+	ownerID := c.GetInt64("user_id")
+
+	authURL, _, err := h.service.StartOIDCLogin(c.Request.Context(), c.Param("provider"), &ownerID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"authorization_url": authURL})
+}
+
+// OIDCLinkCallback handles POST /auth/oidc/:provider/link/callback
+func (h *AuthHandler) OIDCLinkCallback(c *gin.Context) {
+	if !requireStoreOwner(c) {
+		return
+	}
+
+	var req OIDCCallbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errorx.ErrInvalidRequestBody)
+		return
+	}
+
+	err := h.service.CompleteOIDCLink(
+		c.Request.Context(),
+		c.Param("provider"),
+		req.Code,
+		req.State,
+		c.GetInt64("user_id"),
+	)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
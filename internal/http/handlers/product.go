@@ -7,16 +7,19 @@ import (
 
 	"github.com/Secure-Website-Builder/Backend/internal/database"
 	"github.com/Secure-Website-Builder/Backend/internal/models"
+	"github.com/Secure-Website-Builder/Backend/internal/productview"
 	"github.com/Secure-Website-Builder/Backend/internal/services/product"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
 type ProductHandler struct {
 	Service *product.Service
+	Views   *productview.Recorder
 }
 
-func NewProductHandler(s *product.Service) *ProductHandler {
-	return &ProductHandler{Service: s}
+func NewProductHandler(s *product.Service, views *productview.Recorder) *ProductHandler {
+	return &ProductHandler{Service: s, Views: views}
 }
 
 func (h *ProductHandler) GetProduct(c *gin.Context) {
@@ -32,9 +35,27 @@ func (h *ProductHandler) GetProduct(c *gin.Context) {
 		return
 	}
 
+	h.recordView(c, storeID, productID)
+
 	c.JSON(http.StatusOK, product)
 }
 
+// recordView counts a product view for analytics. Only shoppers with a
+// visitor session count; the store's staff, API keys and admins do not.
+func (h *ProductHandler) recordView(c *gin.Context, storeID, productID int64) {
+	switch c.GetString("role") {
+	case "store_owner", "api_key", "admin":
+		return
+	}
+
+	sessionID, err := uuid.Parse(c.GetHeader("X-Session-ID"))
+	if err != nil {
+		return
+	}
+
+	h.Views.Record(storeID, productID, sessionID, c.Request.UserAgent())
+}
+
 // ListProducts handles GET /stores/:store_id/products
 func (h *ProductHandler) ListProducts(c *gin.Context) {
 	ctx := c.Request.Context()
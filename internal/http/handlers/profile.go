@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/Secure-Website-Builder/Backend/internal/errorx"
+	"github.com/Secure-Website-Builder/Backend/internal/services/auth"
+	"github.com/Secure-Website-Builder/Backend/internal/types"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type UpdateProfileRequest struct {
+	Name    *string        `json:"name"`
+	Phone   *string        `json:"phone"`
+	Address *types.Address `json:"address"`
+}
+
+type ChangeEmailRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	Email           string `json:"email" binding:"required,email"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"`
+}
+
+// GetProfile handles GET /me
+func (h *AuthHandler) GetProfile(c *gin.Context) {
+	profile, err := h.service.GetProfile(
+		c.Request.Context(),
+		c.GetInt64("user_id"),
+		c.GetString("role"),
+	)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, profile)
+}
+
+// UpdateProfile handles PATCH /me
+func (h *AuthHandler) UpdateProfile(c *gin.Context) {
+	var req UpdateProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errorx.ErrInvalidRequestBody)
+		return
+	}
+
+	profile, err := h.service.UpdateProfile(
+		c.Request.Context(),
+		c.GetInt64("user_id"),
+		c.GetString("role"),
+		auth.ProfileUpdate{
+			Name:    req.Name,
+			Phone:   req.Phone,
+			Address: req.Address,
+		},
+	)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, profile)
+}
+
+// ChangeEmail handles POST /me/email
+func (h *AuthHandler) ChangeEmail(c *gin.Context) {
+	var req ChangeEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errorx.ErrInvalidRequestBody)
+		return
+	}
+
+	err := h.service.ChangeEmail(
+		c.Request.Context(),
+		c.GetInt64("user_id"),
+		c.GetString("role"),
+		req.CurrentPassword,
+		req.Email,
+	)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ChangePassword handles POST /me/password. Other sessions of the account
+// are ended; the caller stays logged in.
+func (h *AuthHandler) ChangePassword(c *gin.Context) {
+	var req ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errorx.ErrInvalidRequestBody)
+		return
+	}
+
+	sessionID, _ := c.Get("session_id")
+	current, _ := sessionID.(uuid.UUID)
+
+	err := h.service.ChangePassword(
+		c.Request.Context(),
+		c.GetInt64("user_id"),
+		c.GetString("role"),
+		current,
+		req.CurrentPassword,
+		req.NewPassword,
+	)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
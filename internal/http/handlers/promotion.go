@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Secure-Website-Builder/Backend/internal/errorx"
+	"github.com/Secure-Website-Builder/Backend/internal/services/promotion"
+	"github.com/gin-gonic/gin"
+)
+
+type PromotionHandler struct {
+	service *promotion.Service
+}
+
+func NewPromotionHandler(service *promotion.Service) *PromotionHandler {
+	return &PromotionHandler{service: service}
+}
+
+// PromotionRequest is a promotion as sent by the store. Leaving out code
+// makes the promotion apply automatically.
+type PromotionRequest struct {
+	Name                  string     `json:"name" binding:"required"`
+	Code                  *string    `json:"code"`
+	Type                  string     `json:"type" binding:"required"`
+	Value                 float64    `json:"value"`
+	BuyQuantity           *int32     `json:"buy_quantity"`
+	GetQuantity           *int32     `json:"get_quantity"`
+	MinSubtotal           *float64   `json:"min_subtotal"`
+	CategoryID            *int64     `json:"category_id"`
+	VariantIDs            []int64    `json:"variant_ids"`
+	FirstOrderOnly        bool       `json:"first_order_only"`
+	UsageLimit            *int32     `json:"usage_limit"`
+	UsageLimitPerCustomer *int32     `json:"usage_limit_per_customer"`
+	StartsAt              *time.Time `json:"starts_at"`
+	EndsAt                *time.Time `json:"ends_at"`
+	IsActive              *bool      `json:"is_active"`
+}
+
+func (r PromotionRequest) input() promotion.Input {
+	return promotion.Input{
+		Name:                  r.Name,
+		Code:                  r.Code,
+		Type:                  r.Type,
+		Value:                 r.Value,
+		BuyQuantity:           r.BuyQuantity,
+		GetQuantity:           r.GetQuantity,
+		MinSubtotal:           r.MinSubtotal,
+		CategoryID:            r.CategoryID,
+		VariantIDs:            r.VariantIDs,
+		FirstOrderOnly:        r.FirstOrderOnly,
+		UsageLimit:            r.UsageLimit,
+		UsageLimitPerCustomer: r.UsageLimitPerCustomer,
+		StartsAt:              r.StartsAt,
+		EndsAt:                r.EndsAt,
+		IsActive:              r.IsActive,
+	}
+}
+
+// CreatePromotion handles POST /dashboard/stores/:store_id/promotions
+func (h *PromotionHandler) CreatePromotion(c *gin.Context) {
+	storeID, err := strconv.ParseInt(c.Param("store_id"), 10, 64)
+	if err != nil {
+		c.Error(errorx.ErrInvalidStoreID)
+		return
+	}
+
+	var req PromotionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errorx.ErrInvalidRequestBody)
+		return
+	}
+
+	p, err := h.service.Create(c.Request.Context(), storeID, req.input())
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, p)
+}
+
+// ListPromotions handles GET /dashboard/stores/:store_id/promotions
+func (h *PromotionHandler) ListPromotions(c *gin.Context) {
+	storeID, err := strconv.ParseInt(c.Param("store_id"), 10, 64)
+	if err != nil {
+		c.Error(errorx.ErrInvalidStoreID)
+		return
+	}
+
+	promotions, err := h.service.List(c.Request.Context(), storeID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"promotions": promotions})
+}
+
+// UpdatePromotion handles PUT /dashboard/stores/:store_id/promotions/:promotion_id
+func (h *PromotionHandler) UpdatePromotion(c *gin.Context) {
+	storeID, err := strconv.ParseInt(c.Param("store_id"), 10, 64)
+	if err != nil {
+		c.Error(errorx.ErrInvalidStoreID)
+		return
+	}
+
+	promotionID, err := strconv.ParseInt(c.Param("promotion_id"), 10, 64)
+	if err != nil {
+		c.Error(errorx.ErrPromotionNotFound)
+		return
+	}
+
+	var req PromotionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errorx.ErrInvalidRequestBody)
+		return
+	}
+
+	p, err := h.service.Update(c.Request.Context(), storeID, promotionID, req.input())
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, p)
+}
+
+// DeletePromotion handles DELETE /dashboard/stores/:store_id/promotions/:promotion_id
+func (h *PromotionHandler) DeletePromotion(c *gin.Context) {
+	storeID, err := strconv.ParseInt(c.Param("store_id"), 10, 64)
+	if err != nil {
+		c.Error(errorx.ErrInvalidStoreID)
+		return
+	}
+
+	promotionID, err := strconv.ParseInt(c.Param("promotion_id"), 10, 64)
+	if err != nil {
+		c.Error(errorx.ErrPromotionNotFound)
+		return
+	}
+
+	if err := h.service.Delete(c.Request.Context(), storeID, promotionID); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
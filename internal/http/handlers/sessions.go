@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/Secure-Website-Builder/Backend/internal/errorx"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ListSessions handles GET /auth/sessions
+func (h *AuthHandler) ListSessions(c *gin.Context) {
+	currentSessionID, _ := c.Get("session_id")
+	current, _ := currentSessionID.(uuid.UUID)
+
+	sessions, err := h.service.ListSessions(
+		c.Request.Context(),
+		c.GetInt64("user_id"),
+		c.GetString("role"),
+		current,
+	)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"sessions": sessions})
+}
+
+// RevokeSession handles DELETE /auth/sessions/:session_id
+func (h *AuthHandler) RevokeSession(c *gin.Context) {
+	sessionID, err := uuid.Parse(c.Param("session_id"))
+	if err != nil {
+		c.Error(errorx.ErrSessionNotFound)
+		return
+	}
+
+	err = h.service.RevokeSession(
+		c.Request.Context(),
+		c.GetInt64("user_id"),
+		c.GetString("role"),
+		sessionID,
+	)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// RevokeAllSessions handles DELETE /auth/sessions. The caller's own session
+// is revoked as well.
+func (h *AuthHandler) RevokeAllSessions(c *gin.Context) {
+	err := h.service.RevokeAllSessions(
+		c.Request.Context(),
+		c.GetInt64("user_id"),
+		c.GetString("role"),
+	)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
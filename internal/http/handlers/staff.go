@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Secure-Website-Builder/Backend/internal/errorx"
+	"github.com/Secure-Website-Builder/Backend/internal/http/middleware"
+	"github.com/Secure-Website-Builder/Backend/internal/services/staff"
+	"github.com/gin-gonic/gin"
+)
+
+type StaffHandler struct {
+	service *staff.Service
+}
+
+func NewStaffHandler(service *staff.Service) *StaffHandler {
+	return &StaffHandler{service: service}
+}
+
+type InviteStaffRequest struct {
+	Email string `json:"email" binding:"required,email"`
+	Role  string `json:"role" binding:"required"`
+}
+
+type UpdateStaffRoleRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+type AcceptInvitationRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// ListMembers handles GET /dashboard/stores/:store_id/staff
+func (h *StaffHandler) ListMembers(c *gin.Context) {
+	storeID, err := strconv.ParseInt(c.Param("store_id"), 10, 64)
+	if err != nil {
+		c.Error(errorx.ErrInvalidStoreID)
+		return
+	}
+
+	members, err := h.service.ListMembers(c.Request.Context(), storeID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"members": members})
+}
+
+// UpdateMemberRole handles PATCH /dashboard/stores/:store_id/staff/:member_id
+func (h *StaffHandler) UpdateMemberRole(c *gin.Context) {
+	storeID, memberID, ok := staffMemberParams(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateStaffRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errorx.ErrInvalidRequestBody)
+		return
+	}
+	middleware.AddAuditDetail(c, "role", req.Role)
+
+	if err := h.service.UpdateMemberRole(c.Request.Context(), storeID, memberID, req.Role); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// RemoveMember handles DELETE /dashboard/stores/:store_id/staff/:member_id
+func (h *StaffHandler) RemoveMember(c *gin.Context) {
+	storeID, memberID, ok := staffMemberParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.RemoveMember(c.Request.Context(), storeID, memberID); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// Invite handles POST /dashboard/stores/:store_id/staff/invitations
+func (h *StaffHandler) Invite(c *gin.Context) {
+	storeID, err := strconv.ParseInt(c.Param("store_id"), 10, 64)
+	if err != nil {
+		c.Error(errorx.ErrInvalidStoreID)
+		return
+	}
+
+	var req InviteStaffRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errorx.ErrInvalidRequestBody)
+		return
+	}
+	middleware.AddAuditDetail(c, "email", req.Email)
+	middleware.AddAuditDetail(c, "role", req.Role)
+
+	invitation, err := h.service.Invite(
+		c.Request.Context(),
+		storeID,
+		c.GetInt64("user_id"),
+		req.Email,
+		req.Role,
+	)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, invitation)
+}
+
+// ListInvitations handles GET /dashboard/stores/:store_id/staff/invitations
+func (h *StaffHandler) ListInvitations(c *gin.Context) {
+	storeID, err := strconv.ParseInt(c.Param("store_id"), 10, 64)
+	if err != nil {
+		c.Error(errorx.ErrInvalidStoreID)
+		return
+	}
+
+	invitations, err := h.service.ListInvitations(c.Request.Context(), storeID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"invitations": invitations})
+}
+
+// RevokeInvitation handles DELETE /dashboard/stores/:store_id/staff/invitations/:invitation_id
+func (h *StaffHandler) RevokeInvitation(c *gin.Context) {
+	storeID, err := strconv.ParseInt(c.Param("store_id"), 10, 64)
+	if err != nil {
+		c.Error(errorx.ErrInvalidStoreID)
+		return
+	}
+
+	invitationID, err := strconv.ParseInt(c.Param("invitation_id"), 10, 64)
+	if err != nil {
+		c.Error(errorx.ErrInvitationNotFound)
+		return
+	}
+
+	if err := h.service.RevokeInvitation(c.Request.Context(), storeID, invitationID); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// AcceptInvitation handles POST /staff/invitations/accept
+func (h *StaffHandler) AcceptInvitation(c *gin.Context) {
+	if !requireStoreOwner(c) {
+		return
+	}
+
+	var req AcceptInvitationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errorx.ErrInvalidRequestBody)
+		return
+	}
+
+	member, err := h.service.AcceptInvitation(c.Request.Context(), c.GetInt64("user_id"), req.Token)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, member)
+}
+
+func staffMemberParams(c *gin.Context) (storeID, memberID int64, ok bool) {
+	storeID, err := strconv.ParseInt(c.Param("store_id"), 10, 64)
+	if err != nil {
+		c.Error(errorx.ErrInvalidStoreID)
+		return 0, 0, false
+	}
+
+	memberID, err = strconv.ParseInt(c.Param("member_id"), 10, 64)
+	if err != nil {
+		c.Error(errorx.ErrStaffMemberNotFound)
+		return 0, 0, false
+	}
+
+	return storeID, memberID, true
+}
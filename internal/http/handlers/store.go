@@ -5,6 +5,8 @@ import (
 	"net/http"
 	"strconv"
 
+	"github.com/Secure-Website-Builder/Backend/internal/errorx"
+	"github.com/Secure-Website-Builder/Backend/internal/models"
 	"github.com/Secure-Website-Builder/Backend/internal/services/store"
 	"github.com/gin-gonic/gin"
 )
@@ -80,3 +82,194 @@ func (h *StoreHandler) GetStore(c *gin.Context) {
 
 	c.JSON(http.StatusOK, store)
 }
+
+// GetPasswordPolicy handles GET /dashboard/stores/:store_id/password-policy
+func (h *StoreHandler) GetPasswordPolicy(c *gin.Context) {
+
+	storeID, err := strconv.ParseInt(c.Param("store_id"), 10, 64)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+			"error": "invalid store_id",
+		})
+		return
+	}
+
+	policy, err := h.Service.GetPasswordPolicy(c.Request.Context(), storeID)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{
+			"error": "store not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+type UpdatePasswordPolicyRequest struct {
+	CheckBreachedPasswords *bool `json:"check_breached_passwords" binding:"required"`
+}
+
+// UpdatePasswordPolicy handles PUT /dashboard/stores/:store_id/password-policy
+func (h *StoreHandler) UpdatePasswordPolicy(c *gin.Context) {
+
+	storeID, err := strconv.ParseInt(c.Param("store_id"), 10, 64)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+			"error": "invalid store_id",
+		})
+		return
+	}
+
+	var req UpdatePasswordPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	policy := models.StorePasswordPolicyDTO{
+		CheckBreachedPasswords: *req.CheckBreachedPasswords,
+	}
+
+	if err := h.Service.UpdatePasswordPolicy(c.Request.Context(), storeID, policy); err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+// GetStockReservation handles GET /dashboard/stores/:store_id/stock-reservation
+func (h *StoreHandler) GetStockReservation(c *gin.Context) {
+	storeID, err := strconv.ParseInt(c.Param("store_id"), 10, 64)
+	if err != nil {
+		c.Error(errorx.ErrInvalidStoreID)
+		return
+	}
+
+	setting, err := h.Service.GetStockReservation(c.Request.Context(), storeID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, setting)
+}
+
+type UpdateStockReservationRequest struct {
+	ReservationMinutes *int32 `json:"reservation_minutes" binding:"required"`
+}
+
+// UpdateStockReservation handles PUT /dashboard/stores/:store_id/stock-reservation
+func (h *StoreHandler) UpdateStockReservation(c *gin.Context) {
+	storeID, err := strconv.ParseInt(c.Param("store_id"), 10, 64)
+	if err != nil {
+		c.Error(errorx.ErrInvalidStoreID)
+		return
+	}
+
+	var req UpdateStockReservationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errorx.ErrInvalidRequestBody)
+		return
+	}
+
+	setting := models.StoreStockReservationDTO{
+		ReservationMinutes: *req.ReservationMinutes,
+	}
+
+	if err := h.Service.UpdateStockReservation(c.Request.Context(), storeID, setting); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, setting)
+}
+
+// GetCartReminders handles GET /dashboard/stores/:store_id/cart-reminders
+func (h *StoreHandler) GetCartReminders(c *gin.Context) {
+	storeID, err := strconv.ParseInt(c.Param("store_id"), 10, 64)
+	if err != nil {
+		c.Error(errorx.ErrInvalidStoreID)
+		return
+	}
+
+	setting, err := h.Service.GetCartReminders(c.Request.Context(), storeID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, setting)
+}
+
+type UpdateCartRemindersRequest struct {
+	DelayHours   *int32 `json:"delay_hours" binding:"required"`
+	MaxReminders *int32 `json:"max_reminders" binding:"required"`
+}
+
+// UpdateCartReminders handles PUT /dashboard/stores/:store_id/cart-reminders
+func (h *StoreHandler) UpdateCartReminders(c *gin.Context) {
+	storeID, err := strconv.ParseInt(c.Param("store_id"), 10, 64)
+	if err != nil {
+		c.Error(errorx.ErrInvalidStoreID)
+		return
+	}
+
+	var req UpdateCartRemindersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errorx.ErrInvalidRequestBody)
+		return
+	}
+
+	setting := models.StoreCartRemindersDTO{
+		DelayHours:   *req.DelayHours,
+		MaxReminders: *req.MaxReminders,
+	}
+
+	if err := h.Service.UpdateCartReminders(c.Request.Context(), storeID, setting); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, setting)
+}
+
+// ListOrders handles GET /dashboard/stores/:store_id/orders?before_id=&limit=
+func (h *StoreHandler) ListOrders(c *gin.Context) {
+	storeID, err := strconv.ParseInt(c.Param("store_id"), 10, 64)
+	if err != nil {
+		c.Error(errorx.ErrInvalidStoreID)
+		return
+	}
+
+	var (
+		beforeID *int64
+		limit    int32
+	)
+
+	if raw := c.Query("before_id"); raw != "" {
+		id, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			c.Error(errorx.ErrInvalidRequestBody)
+			return
+		}
+		beforeID = &id
+	}
+
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.ParseInt(raw, 10, 32)
+		if err != nil {
+			c.Error(errorx.ErrInvalidRequestBody)
+			return
+		}
+		limit = int32(n)
+	}
+
+	orders, err := h.Service.ListOrders(c.Request.Context(), storeID, beforeID, limit)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"orders": orders})
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Secure-Website-Builder/Backend/internal/errorx"
+	"github.com/Secure-Website-Builder/Backend/internal/services/visitor"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type VisitorSessionHandler struct {
+	service *visitor.Service
+}
+
+func NewVisitorSessionHandler(service *visitor.Service) *VisitorSessionHandler {
+	return &VisitorSessionHandler{service: service}
+}
+
+// StartSession handles POST /stores/:store_id/sessions
+//
+// A client that already has a session sends it in X-Session-ID. It gets
+// the same session back (200) while it is valid, and a new one (201) once
+// it has expired or if it belongs to another store.
+func (h *VisitorSessionHandler) StartSession(c *gin.Context) {
+	storeID, err := strconv.ParseInt(c.Param("store_id"), 10, 64)
+	if err != nil {
+		c.Error(errorx.ErrInvalidStoreID)
+		return
+	}
+
+	var presented *uuid.UUID
+	if raw := c.GetHeader("X-Session-ID"); raw != "" {
+		if id, err := uuid.Parse(raw); err == nil {
+			presented = &id
+		}
+	}
+
+	session, created, err := h.service.Start(
+		c.Request.Context(),
+		storeID,
+		presented,
+		c.ClientIP(),
+		c.Request.UserAgent(),
+	)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	c.JSON(status, session)
+}
+
+type RecoverCartRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// RecoverCart handles POST /stores/:store_id/sessions/recover
+//
+// The token comes from the link of an abandoned cart reminder. The cart is
+// moved to a new session, which is returned (201).
+func (h *VisitorSessionHandler) RecoverCart(c *gin.Context) {
+	storeID, err := strconv.ParseInt(c.Param("store_id"), 10, 64)
+	if err != nil {
+		c.Error(errorx.ErrInvalidStoreID)
+		return
+	}
+
+	var req RecoverCartRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errorx.ErrInvalidRequestBody)
+		return
+	}
+
+	session, err := h.service.RecoverCart(
+		c.Request.Context(),
+		storeID,
+		req.Token,
+		c.ClientIP(),
+		c.Request.UserAgent(),
+	)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, session)
+}
@@ -4,11 +4,13 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/Secure-Website-Builder/Backend/internal/jwtkeys"
 	"github.com/Secure-Website-Builder/Backend/internal/utils"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
-func JWTAuth(secret string) gin.HandlerFunc {
+func JWTAuth(keys *jwtkeys.KeySet, sessions *SessionChecker) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" || !strings.HasPrefix(authHeader, "Bearer ") {
@@ -17,14 +19,57 @@ func JWTAuth(secret string) gin.HandlerFunc {
 		}
 
 		tokenStr := strings.TrimPrefix(authHeader, "Bearer ")
-		token, claims, err := utils.ParseJWT(tokenStr, secret)
+		token, claims, err := utils.ParseJWT(tokenStr, keys)
 		if err != nil || !token.Valid {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
 			return
 		}
 
+		// mfa_pending tokens must only be exchanged at /auth/mfa/verify
+		if claims["typ"] != utils.TokenTypeAccess {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+			return
+		}
+
+		role, _ := claims["role"].(string)
+
+		// Every access token belongs to an auth session that can be revoked
+		// before the token expires.
+		rawSID, _ := claims["sid"].(string)
+		sessionID, err := uuid.Parse(rawSID)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+			return
+		}
+
+		// Revoking a session revokes the tokens issued for it, so tokens
+		// with an id only need the in-memory revocation list. Older tokens
+		// without one fall back to looking up the session.
+		rawJTI, _ := claims["jti"].(string)
+		if tokenID, err := uuid.Parse(rawJTI); err == nil {
+			if sessions.IsRevoked(tokenID) {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "token revoked"})
+				return
+			}
+		} else {
+			active, err := sessions.IsActive(c.Request.Context(), sessionID)
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+				return
+			}
+			if !active {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "session revoked"})
+				return
+			}
+		}
+
+		c.Set("session_id", sessionID)
+
+		mfaVerified, _ := claims["mfa"].(bool)
+
 		c.Set("user_id", int64(claims["user_id"].(float64)))
-		c.Set("role", claims["role"].(string))
+		c.Set("role", role)
+		c.Set("mfa_verified", mfaVerified)
 
 		if storeID, ok := claims["store_id"]; ok {
 			id := int64(storeID.(float64))
@@ -35,12 +80,30 @@ func JWTAuth(secret string) gin.HandlerFunc {
 	}
 }
 
+// JWTOrGuestAuth uses jwtAuth when the request carries a bearer token and
+// otherwise lets it through with the role "guest", for routes that also
+// serve shoppers without an account.
+func JWTOrGuestAuth(jwtAuth gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") != "" {
+			jwtAuth(c)
+			return
+		}
+		c.Set("role", "guest")
+		c.Next()
+	}
+}
+
 // RequireRole middleware
+//
+// Admins may use any route, but only with a token that passed MFA unless the
+// route lists "admin" explicitly.
 func RequireRole(roles ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		role := c.GetString("role")
+		adminBypass := role == "admin" && c.GetBool("mfa_verified")
 		for _, r := range roles {
-			if r == role || role == "admin" { // admin bypass
+			if r == role || adminBypass {
 				c.Next()
 				return
 			}
@@ -48,3 +111,15 @@ func RequireRole(roles ...string) gin.HandlerFunc {
 		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "forbidden"})
 	}
 }
+
+// RequireMFA rejects tokens that were issued without a second factor for
+// roles where MFA is mandatory (see utils.MFARequired).
+func RequireMFA() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !utils.MFARequired(c.GetString("role")) || c.GetBool("mfa_verified") {
+			c.Next()
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "multi-factor authentication required"})
+	}
+}
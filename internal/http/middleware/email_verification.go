@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/Secure-Website-Builder/Backend/internal/config"
+	"github.com/Secure-Website-Builder/Backend/internal/services/auth"
+	"github.com/gin-gonic/gin"
+)
+
+// EmailVerificationChecker refuses selected actions to accounts whose
+// email address is not verified yet, as configured by Policy.
+type EmailVerificationChecker struct {
+	Service *auth.Service
+	Policy  config.EmailVerificationConfig
+}
+
+func NewEmailVerificationChecker(
+	service *auth.Service,
+	policy config.EmailVerificationConfig,
+) *EmailVerificationChecker {
+	return &EmailVerificationChecker{Service: service, Policy: policy}
+}
+
+func (e *EmailVerificationChecker) require(enabled bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := c.GetString("role")
+		// Guests have no account to verify
+		if !enabled || role == "admin" || role == "guest" {
+			c.Next()
+			return
+		}
+
+		verified, err := e.Service.IsEmailVerified(c.Request.Context(), c.GetInt64("user_id"), role)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+			return
+		}
+		if !verified {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "verify your email address first"})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequireVerifiedEmailToCreateStore guards store creation.
+func RequireVerifiedEmailToCreateStore(checker *EmailVerificationChecker) gin.HandlerFunc {
+	return checker.require(checker.Policy.RequiredToCreateStore)
+}
+
+// RequireVerifiedEmailToCheckout guards checkout.
+func RequireVerifiedEmailToCheckout(checker *EmailVerificationChecker) gin.HandlerFunc {
+	return checker.require(checker.Policy.RequiredToCheckout)
+}
@@ -1,6 +1,10 @@
 package middleware
 
 import (
+	"errors"
+	"math"
+	"strconv"
+
 	"github.com/Secure-Website-Builder/Backend/internal/errorx"
 	"github.com/gin-gonic/gin"
 )
@@ -14,6 +18,12 @@ func ErrorMiddleware() gin.HandlerFunc {
 			return
 		}
 
+		var retry *errorx.RetryAfterError
+		if errors.As(err.Err, &retry) {
+			seconds := int(math.Ceil(retry.RetryAfter.Seconds()))
+			c.Header("Retry-After", strconv.Itoa(seconds))
+		}
+
 		httpErr := errorx.Resolve(err.Err)
 		c.JSON(httpErr.Status, gin.H{"error": httpErr.Message})
 	}
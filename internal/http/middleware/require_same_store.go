@@ -29,14 +29,15 @@ func RequireSameStore() gin.HandlerFunc {
 
 		// Store ID from token
 		storeIDFromToken, exists := c.Get("store_id")
-		if !exists {
+		tokenStoreID, ok := storeIDFromToken.(*int64)
+		if !exists || !ok || tokenStoreID == nil {
 			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
 				"error": "store context missing in token",
 			})
 			return
 		}
 
-		if storeIDFromURL != storeIDFromToken.(int64) {
+		if storeIDFromURL != *tokenStoreID {
 			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
 				"error": "accessing another store is forbidden",
 			})
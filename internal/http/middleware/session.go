@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"context"
+
+	"github.com/Secure-Website-Builder/Backend/internal/revocation"
+	"github.com/Secure-Website-Builder/Backend/internal/services/auth"
+	"github.com/google/uuid"
+)
+
+// SessionChecker lets JWTAuth reject access tokens that were revoked, or
+// whose auth session was (logout, "log out other devices", password reset,
+// token theft, admin suspension).
+type SessionChecker struct {
+	Service *auth.Service
+	Revoked *revocation.List
+}
+
+func NewSessionChecker(service *auth.Service, revoked *revocation.List) *SessionChecker {
+	return &SessionChecker{Service: service, Revoked: revoked}
+}
+
+func (s *SessionChecker) IsActive(ctx context.Context, sessionID uuid.UUID) (bool, error) {
+	return s.Service.IsSessionActive(ctx, sessionID)
+}
+
+// IsRevoked is answered from memory, so it is cheap enough for every
+// request.
+func (s *SessionChecker) IsRevoked(tokenID uuid.UUID) bool {
+	return s.Revoked.IsRevoked(tokenID)
+}
@@ -3,6 +3,8 @@ package router
 import (
 	"github.com/Secure-Website-Builder/Backend/internal/http/handlers"
 	"github.com/Secure-Website-Builder/Backend/internal/http/middleware"
+	"github.com/Secure-Website-Builder/Backend/internal/jwtkeys"
+	"github.com/Secure-Website-Builder/Backend/internal/permissions"
 	"github.com/gin-gonic/gin"
 )
 
@@ -13,30 +15,123 @@ func SetupRouter(
 	cartHandler *handlers.CartHandler,
 	authHandler *handlers.AuthHandler,
 	storeHandler *handlers.StoreHandler,
+	jwksHandler *handlers.JWKSHandler,
+	adminHandler *handlers.AdminHandler,
+	dataRequestHandler *handlers.DataRequestHandler,
+	apiKeyHandler *handlers.APIKeyHandler,
+	staffHandler *handlers.StaffHandler,
+	visitorSessionHandler *handlers.VisitorSessionHandler,
+	analyticsHandler *handlers.AnalyticsHandler,
+	promotionHandler *handlers.PromotionHandler,
 	rateLimiter *middleware.RateLimiter,
-	storeOwnerChecker *middleware.StoreOwnerChecker,
-	jwtSecret string,
+	storeMemberChecker *middleware.StoreMemberChecker,
+	sessionChecker *middleware.SessionChecker,
+	emailVerificationChecker *middleware.EmailVerificationChecker,
+	adminAuditor *middleware.AdminAuditor,
+	apiKeyChecker *middleware.APIKeyChecker,
+	jwtKeys *jwtkeys.KeySet,
 ) *gin.Engine {
 
 	r := gin.Default()
 	r.Use(middleware.ErrorMiddleware())
 	r.Use(rateLimiter.Middleware())
 
+	// Public verification keys for services that validate our tokens
+	r.GET("/.well-known/jwks.json", jwksHandler.GetJWKS)
+
 	// Auth routes (public)
 	r.POST("/auth/register", authHandler.Register)
 	r.POST("/auth/login", authHandler.Login)
 	r.POST("/auth/logout", authHandler.Logout)
 	r.POST("/auth/refresh", authHandler.RefreshToken)
+	r.POST("/auth/mfa/verify", authHandler.VerifyMFA)
+	r.POST("/auth/password/forgot", authHandler.ForgotPassword)
+	r.POST("/auth/password/reset", authHandler.ResetPassword)
+	r.POST("/auth/email/verify", authHandler.VerifyEmail)
+	r.GET("/auth/oidc/:provider/authorize", authHandler.StartOIDCLogin)
+	r.POST("/auth/oidc/:provider/callback", authHandler.OIDCCallback)
 	r.POST("/admin/auth/login", authHandler.AdminLogin)
+	r.POST("/admin/auth/refresh", authHandler.RefreshToken)
+	r.POST("/admin/auth/logout", authHandler.Logout)
+
+	// Storefront visitor sessions (public), needed before using the cart
+	r.POST("/stores/:store_id/sessions", visitorSessionHandler.StartSession)
+	r.POST("/stores/:store_id/sessions/recover", visitorSessionHandler.RecoverCart)
 
+	// Store API keys are accepted wherever a route allows the "api_key" role.
+	// Admins may use any route behind it, so their writes are audited on all
+	// of them, not only under /admin.
 	auth := r.Group("/")
-	auth.Use(middleware.JWTAuth(jwtSecret))
+	auth.Use(
+		middleware.JWTOrAPIKeyAuth(
+			middleware.JWTAuth(jwtKeys, sessionChecker),
+			middleware.APIKeyAuth(apiKeyChecker),
+		),
+		middleware.AuditAdminActions(adminAuditor),
+	)
+
+	// Active sessions of the logged-in user
+	sessions := auth.Group("/auth/sessions")
+	sessions.Use(middleware.RequireRole("customer", "store_owner", "admin"))
+	{
+		sessions.GET("", authHandler.ListSessions)
+		sessions.DELETE("", authHandler.RevokeAllSessions)
+		sessions.DELETE("/:session_id", authHandler.RevokeSession)
+	}
+
+	auth.POST(
+		"/auth/email/verify/resend",
+		middleware.RequireRole("customer", "store_owner"),
+		authHandler.ResendEmailVerification,
+	)
+
+	// Own profile (customers and store owners)
+	me := auth.Group("/me")
+	me.Use(middleware.RequireRole("customer", "store_owner"))
+	{
+		me.GET("", authHandler.GetProfile)
+		me.PATCH("", authHandler.UpdateProfile)
+		me.POST("/email", middleware.RequireMFA(), authHandler.ChangeEmail)
+		me.POST("/password", middleware.RequireMFA(), authHandler.ChangePassword)
+
+		// Personal data export and erasure (customers)
+		me.POST("/data-requests", dataRequestHandler.CreateOwnRequest)
+		me.GET("/data-requests", dataRequestHandler.ListOwnRequests)
+		me.GET("/data-requests/:request_id/download", dataRequestHandler.DownloadOwnExport)
+	}
+
+	// Link an external identity to the logged-in store owner
+	oidcLink := auth.Group("/auth/oidc/:provider/link")
+	oidcLink.Use(middleware.RequireRole("store_owner"))
+	{
+		oidcLink.POST("", authHandler.StartOIDCLink)
+		oidcLink.POST("/callback", authHandler.OIDCLinkCallback)
+	}
+
+	// MFA enrollment (store owners and admins)
+	mfa := auth.Group("/auth/mfa")
+	mfa.Use(middleware.RequireRole("store_owner", "admin"))
+	{
+		mfa.POST("/enroll", authHandler.EnrollMFA)
+		mfa.POST("/enroll/confirm", authHandler.ConfirmMFA)
+		mfa.POST("/disable", authHandler.DisableMFA)
+	}
+
+	// Join the staff of a store (store owner accounts)
+	auth.POST(
+		"/staff/invitations/accept",
+		middleware.RequireRole("store_owner"),
+		staffHandler.AcceptInvitation,
+	)
 
 	// Create store (store owner only)
 	stores := auth.Group("/stores")
 	stores.Use(middleware.RequireRole("store_owner"))
 	{
-		stores.POST("", storeHandler.CreateStore)
+		stores.POST("",
+			middleware.RequireVerifiedEmailToCreateStore(emailVerificationChecker),
+			storeHandler.CreateStore,
+		)
 	}
 
 	// Public / customer-facing store routes
@@ -44,9 +139,10 @@ func SetupRouter(
 	{
 		// Shared middlewares for customer/store_owner/admin
 		storeRoutes.Use(
-			middleware.RequireRole("customer", "store_owner", "admin"),
+			middleware.RequireRole("customer", "store_owner", "api_key"),
 			middleware.RequireSameStore(),
-			middleware.RequireStoreOwner(storeOwnerChecker),
+			middleware.RequireStoreMember(storeMemberChecker),
+			middleware.RequirePermission(permissions.ProductsRead),
 		)
 
 		storeRoutes.GET("", storeHandler.GetStore)
@@ -57,33 +153,100 @@ func SetupRouter(
 		storeRoutes.GET("/products/:product_id", productHandler.GetProduct)
 	}
 
-	// Cart endpoints
-	cartGroup := auth.Group("/stores/:store_id/cart")
+	// Cart endpoints, for customers and guests. Both need a visitor
+	// session in X-Session-ID.
+	cartGroup := r.Group("/stores/:store_id/cart")
 	cartGroup.Use(
-		middleware.RequireRole("customer", "admin"),
+		middleware.JWTOrGuestAuth(middleware.JWTAuth(jwtKeys, sessionChecker)),
+		middleware.AuditAdminActions(adminAuditor),
+		middleware.RequireRole("customer", "guest"),
 		middleware.RequireSameStore(),
 	)
 	cartGroup.GET("", cartHandler.GetCart)
 	cartGroup.POST("/items", cartHandler.AddItem)
-	cartGroup.POST("/checkout", cartHandler.Checkout)
+	cartGroup.PATCH("/items/:item_id", cartHandler.UpdateItem)
+	cartGroup.DELETE("/items/:item_id", cartHandler.RemoveItem)
+	cartGroup.DELETE("", cartHandler.ClearCart)
+	cartGroup.PUT("/promotion-code", cartHandler.ApplyPromotionCode)
+	cartGroup.DELETE("/promotion-code", cartHandler.RemovePromotionCode)
+	cartGroup.POST("/checkout",
+		middleware.RequireVerifiedEmailToCheckout(emailVerificationChecker),
+		cartHandler.Checkout,
+	)
 
-	// Store owner dashboard routes
+	// Store dashboard routes, for the owner, staff and API keys of the
+	// store. Each route needs a permission of the caller's store role or a
+	// scope of the API key.
 	dashboard := auth.Group("/dashboard/stores/:store_id")
 	dashboard.Use(
-		middleware.RequireRole("store_owner"),
-		middleware.RequireStoreOwner(storeOwnerChecker),
+		middleware.RequireRole("store_owner", "api_key"),
+		middleware.RequireMFA(),
+		middleware.RequireStoreMember(storeMemberChecker),
 	)
 	{
-		dashboard.POST("/products", productHandler.CreateProduct)
-		dashboard.POST("/products/:product_id/variants", productHandler.AddVariant)
-		dashboard.POST("/products/:product_id/variants/:variant_id/images", productHandler.UploadVariantImage)
+		productsWrite := middleware.RequirePermission(permissions.ProductsWrite)
+		dashboard.POST("/products", productsWrite, productHandler.CreateProduct)
+		dashboard.POST("/products/:product_id/variants", productsWrite, productHandler.AddVariant)
+		dashboard.POST("/products/:product_id/variants/:variant_id/images", productsWrite, productHandler.UploadVariantImage)
+
+		dashboard.GET("/orders", middleware.RequirePermission(permissions.OrdersRead), storeHandler.ListOrders)
+
+		analyticsRead := middleware.RequirePermission(permissions.AnalyticsRead)
+		dashboard.GET("/analytics/add-to-cart", analyticsRead, analyticsHandler.ProductAddToCartRates)
+		dashboard.GET("/analytics/cart-recovery", analyticsRead, analyticsHandler.CartRecovery)
+
+		settings := middleware.RequirePermission(permissions.StoreSettings)
+		dashboard.GET("/password-policy", settings, storeHandler.GetPasswordPolicy)
+		dashboard.PUT("/password-policy", settings, storeHandler.UpdatePasswordPolicy)
+		dashboard.GET("/stock-reservation", settings, storeHandler.GetStockReservation)
+		dashboard.PUT("/stock-reservation", settings, storeHandler.UpdateStockReservation)
+		dashboard.GET("/cart-reminders", settings, storeHandler.GetCartReminders)
+		dashboard.PUT("/cart-reminders", settings, storeHandler.UpdateCartReminders)
+
+		promotions := middleware.RequirePermission(permissions.PromotionsManage)
+		dashboard.POST("/promotions", promotions, promotionHandler.CreatePromotion)
+		dashboard.GET("/promotions", promotions, promotionHandler.ListPromotions)
+		dashboard.PUT("/promotions/:promotion_id", promotions, promotionHandler.UpdatePromotion)
+		dashboard.DELETE("/promotions/:promotion_id", promotions, promotionHandler.DeletePromotion)
+
+		customerData := middleware.RequirePermission(permissions.CustomerData)
+		dashboard.POST("/data-requests", customerData, dataRequestHandler.CreateStoreRequest)
+		dashboard.GET("/data-requests", customerData, dataRequestHandler.ListStoreRequests)
+		dashboard.GET("/data-requests/:request_id", customerData, dataRequestHandler.GetStoreRequest)
+		dashboard.GET("/data-requests/:request_id/download", customerData, dataRequestHandler.DownloadStoreExport)
+
+		apiKeys := middleware.RequirePermission(permissions.APIKeysManage)
+		dashboard.POST("/api-keys", apiKeys, apiKeyHandler.CreateAPIKey)
+		dashboard.GET("/api-keys", apiKeys, apiKeyHandler.ListAPIKeys)
+		dashboard.DELETE("/api-keys/:api_key_id", apiKeys, apiKeyHandler.RevokeAPIKey)
+
+		staff := middleware.RequirePermission(permissions.StaffManage)
+		dashboard.GET("/staff", staff, staffHandler.ListMembers)
+		dashboard.PATCH("/staff/:member_id", staff, staffHandler.UpdateMemberRole)
+		dashboard.DELETE("/staff/:member_id", staff, staffHandler.RemoveMember)
+		dashboard.POST("/staff/invitations", staff, staffHandler.Invite)
+		dashboard.GET("/staff/invitations", staff, staffHandler.ListInvitations)
+		dashboard.DELETE("/staff/invitations/:invitation_id", staff, staffHandler.RevokeInvitation)
 	}
 
 	// Admin-only routes
-	admin := r.Group("/admin")
-	admin.Use(middleware.RequireRole("admin"))
+	admin := auth.Group("/admin")
+	admin.Use(
+		middleware.RequireRole("admin"),
+		middleware.RequireMFA(),
+	)
 	{
-		// admin endpoints here
+		admin.GET("/admins", adminHandler.ListAdmins)
+		admin.POST("/admins", adminHandler.CreateAdmin)
+		admin.GET("/admins/:admin_id", adminHandler.GetAdmin)
+		admin.POST("/admins/:admin_id/disable", adminHandler.DisableAdmin)
+		admin.POST("/admins/:admin_id/enable", adminHandler.EnableAdmin)
+		admin.GET("/audit-log", adminHandler.ListAuditLog)
+
+		admin.POST("/accounts/unlock", authHandler.UnlockAccount)
+
+		// Runtime counters
+		admin.GET("/metrics", handlers.Metrics("password_breach_checks", "product_views"))
 	}
 
 	return r
@@ -0,0 +1,64 @@
+package jwtkeys
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+	"sort"
+)
+
+// JWK is the public part of a key in RFC 7517 format.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+
+	// OKP (Ed25519)
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns every asymmetric verification key. The HMAC secret is never
+// published, so HS256 tokens can only be verified by this service.
+func (ks *KeySet) JWKS() JWKS {
+	out := JWKS{Keys: make([]JWK, 0, len(ks.keys))}
+
+	for _, key := range ks.keys {
+		jwk := JWK{
+			Kid: key.ID,
+			Use: "sig",
+			Alg: key.Algorithm,
+		}
+
+		switch pub := key.public.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		default:
+			continue
+		}
+
+		out.Keys = append(out.Keys, jwk)
+	}
+
+	sort.Slice(out.Keys, func(i, j int) bool {
+		return out.Keys[i].Kid < out.Keys[j].Kid
+	})
+
+	return out
+}
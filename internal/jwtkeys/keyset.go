@@ -0,0 +1,231 @@
+package jwtkeys
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/Secure-Website-Builder/Backend/internal/config"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+)
+
+// Key is a single verification key, optionally able to sign.
+type Key struct {
+	ID        string
+	Algorithm string
+
+	private crypto.PrivateKey // nil for verification-only keys
+	public  crypto.PublicKey
+}
+
+// KeySet signs tokens with the active key and verifies tokens against every
+// configured key, selected by the "kid" header.
+//
+// Rotation works by adding the new key, switching signing_key_id to it and
+// keeping the old key (public part only is enough) until every token it
+// signed has expired.
+type KeySet struct {
+	signing *Key
+	keys    map[string]*Key
+
+	// hmacSecret verifies legacy HS256 tokens that carry no kid; it is also
+	// used for signing when no asymmetric signing key is configured.
+	hmacSecret []byte
+	allowHMAC  bool
+}
+
+// NewHMAC returns a key set that signs and verifies with a shared secret
+// only, matching the behaviour before asymmetric keys were supported.
+func NewHMAC(secret string) *KeySet {
+	return &KeySet{
+		keys:       map[string]*Key{},
+		hmacSecret: []byte(secret),
+		allowHMAC:  true,
+	}
+}
+
+// Load builds the key set described by cfg. With no signing_key_id the set
+// falls back to HS256 with hmacSecret.
+func Load(cfg config.JWTConfig, hmacSecret string) (*KeySet, error) {
+	ks := NewHMAC(hmacSecret)
+	ks.allowHMAC = cfg.SigningKeyID == "" || cfg.AllowLegacyHS256
+
+	for _, kc := range cfg.Keys {
+		key, err := loadKey(kc)
+		if err != nil {
+			return nil, fmt.Errorf("jwt key %q: %w", kc.KeyID, err)
+		}
+		if _, exists := ks.keys[key.ID]; exists {
+			return nil, fmt.Errorf("duplicate jwt key id %q", key.ID)
+		}
+		ks.keys[key.ID] = key
+	}
+
+	if cfg.SigningKeyID != "" {
+		key, ok := ks.keys[cfg.SigningKeyID]
+		if !ok {
+			return nil, fmt.Errorf("signing key %q is not configured", cfg.SigningKeyID)
+		}
+		if key.private == nil {
+			return nil, fmt.Errorf("signing key %q has no private key", cfg.SigningKeyID)
+		}
+		ks.signing = key
+	}
+
+	return ks, nil
+}
+
+// Sign serializes claims with the active signing key.
+func (ks *KeySet) Sign(claims jwt.Claims) (string, error) {
+	if ks.signing == nil {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		return token.SignedString(ks.hmacSecret)
+	}
+
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(ks.signing.Algorithm), claims)
+	token.Header["kid"] = ks.signing.ID
+	return token.SignedString(ks.signing.private)
+}
+
+// Parse verifies tokenStr and decodes it into claims. The algorithm in the
+// token header must match the algorithm of the key selected by kid, which
+// prevents algorithm-confusion attacks (e.g. an HS256 token "signed" with a
+// public RSA key).
+func (ks *KeySet) Parse(tokenStr string, claims jwt.Claims) (*jwt.Token, error) {
+	return jwt.ParseWithClaims(
+		tokenStr,
+		claims,
+		ks.keyFunc,
+		jwt.WithValidMethods([]string{AlgHS256, AlgRS256, AlgEdDSA}),
+	)
+}
+
+func (ks *KeySet) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	if kid == "" {
+		if !ks.allowHMAC {
+			return nil, errors.New("missing kid")
+		}
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return ks.hmacSecret, nil
+	}
+
+	key, ok := ks.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown kid %q", kid)
+	}
+	if token.Method.Alg() != key.Algorithm {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+
+	return key.public, nil
+}
+
+func loadKey(kc config.JWTKeyConfig) (*Key, error) {
+	if kc.KeyID == "" {
+		return nil, errors.New("kid is required")
+	}
+
+	key := &Key{ID: kc.KeyID, Algorithm: kc.Algorithm}
+
+	switch {
+	case kc.PrivateKeyFile != "":
+		priv, err := readPrivateKey(kc.PrivateKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		key.private = priv
+
+		switch k := priv.(type) {
+		case *rsa.PrivateKey:
+			key.public = &k.PublicKey
+		case ed25519.PrivateKey:
+			key.public = k.Public()
+		}
+
+	case kc.PublicKeyFile != "":
+		pub, err := readPublicKey(kc.PublicKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		key.public = pub
+
+	default:
+		return nil, errors.New("private_key_file or public_key_file is required")
+	}
+
+	switch key.public.(type) {
+	case *rsa.PublicKey:
+		if key.Algorithm != AlgRS256 {
+			return nil, fmt.Errorf("RSA key used with algorithm %q", key.Algorithm)
+		}
+	case ed25519.PublicKey:
+		if key.Algorithm != AlgEdDSA {
+			return nil, fmt.Errorf("Ed25519 key used with algorithm %q", key.Algorithm)
+		}
+	default:
+		return nil, errors.New("unsupported key type")
+	}
+
+	return key, nil
+}
+
+func readPrivateKey(path string) (crypto.PrivateKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		return x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+}
+
+func readPublicKey(path string) (crypto.PublicKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch block.Type {
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	case "PUBLIC KEY":
+		return x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+}
+
+func readPEM(path string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM data found")
+	}
+
+	return block, nil
+}
@@ -0,0 +1,151 @@
+package limiter
+
+import (
+	"sync"
+	"time"
+)
+
+// LockoutPolicy controls how failed attempts against a single account are
+// throttled.
+type LockoutPolicy struct {
+	// FreeAttempts is the number of failures allowed before delays start.
+	FreeAttempts int
+	// BaseDelay is the wait imposed after the first failure past
+	// FreeAttempts; it doubles with every further failure up to MaxDelay.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// MaxFailures locks the account for LockoutDuration once reached.
+	MaxFailures     int
+	LockoutDuration time.Duration
+	// Window is how long a failure is remembered after the last one.
+	Window time.Duration
+}
+
+type failureRecord struct {
+	failures    int
+	lastFailure time.Time
+	nextAttempt time.Time
+	lockedUntil time.Time
+}
+
+// Lockout counts failed attempts per key (typically an account) rather than
+// per client, so spreading a credential-stuffing attack over many IPs does
+// not reset the counter.
+//
+// Instead of sleeping, the progressive delay is enforced by rejecting any
+// attempt made before it has elapsed.
+type Lockout struct {
+	records map[string]*failureRecord
+	mu      sync.Mutex
+
+	policy LockoutPolicy
+}
+
+func NewLockout(policy LockoutPolicy, cleanup time.Duration) *Lockout {
+	l := &Lockout{
+		records: make(map[string]*failureRecord),
+		policy:  policy,
+	}
+
+	go l.cleanupLoop(cleanup)
+	return l
+}
+
+// Attempt reports whether an attempt for key may proceed. An attempt that
+// may is counted as failed right away, under the same lock as the check,
+// so concurrent attempts cannot all pass before any of them is counted; a
+// successful one is cleared with Reset. When the attempt may not proceed,
+// retryAfter is how long the caller has to wait and locked tells whether
+// the key is locked out rather than just delayed.
+func (l *Lockout) Attempt(key string) (retryAfter time.Duration, locked bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+
+	rec := l.current(key, now)
+	if rec == nil {
+		rec = &failureRecord{}
+		l.records[key] = rec
+	}
+
+	if now.Before(rec.lockedUntil) {
+		return rec.lockedUntil.Sub(now), true
+	}
+	if now.Before(rec.nextAttempt) {
+		return rec.nextAttempt.Sub(now), false
+	}
+
+	l.fail(rec, now)
+	return 0, false
+}
+
+// fail counts a failed attempt. Callers must hold l.mu.
+func (l *Lockout) fail(rec *failureRecord, now time.Time) {
+	rec.failures++
+	rec.lastFailure = now
+
+	if rec.failures >= l.policy.MaxFailures {
+		rec.lockedUntil = now.Add(l.policy.LockoutDuration)
+		rec.failures = 0
+		return
+	}
+
+	if over := rec.failures - l.policy.FreeAttempts; over > 0 {
+		rec.nextAttempt = now.Add(l.delay(over))
+	}
+}
+
+// Reset forgets every failure recorded for key. It is called after a
+// successful attempt and to unlock an account manually.
+func (l *Lockout) Reset(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.records, key)
+}
+
+func (l *Lockout) delay(over int) time.Duration {
+	d := l.policy.BaseDelay
+	for i := 1; i < over && d < l.policy.MaxDelay; i++ {
+		d *= 2
+	}
+	if d > l.policy.MaxDelay {
+		d = l.policy.MaxDelay
+	}
+	return d
+}
+
+// current returns the record for key, dropping it once it has expired.
+// Callers must hold l.mu.
+func (l *Lockout) current(key string, now time.Time) *failureRecord {
+	rec, exists := l.records[key]
+	if !exists {
+		return nil
+	}
+
+	if l.expired(rec, now) {
+		delete(l.records, key)
+		return nil
+	}
+
+	return rec
+}
+
+func (l *Lockout) expired(rec *failureRecord, now time.Time) bool {
+	return now.After(rec.lockedUntil) && now.Sub(rec.lastFailure) > l.policy.Window
+}
+
+func (l *Lockout) cleanupLoop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	for range ticker.C {
+		now := time.Now()
+		l.mu.Lock()
+		for key, rec := range l.records {
+			if l.expired(rec, now) {
+				delete(l.records, key)
+			}
+		}
+		l.mu.Unlock()
+	}
+}
@@ -2,7 +2,11 @@ package models
 
 import (
 	"database/sql"
+	"encoding/json"
 	"time"
+
+	"github.com/Secure-Website-Builder/Backend/internal/types"
+	"github.com/google/uuid"
 )
 
 type ProductFullDetailsDTO struct {
@@ -63,12 +67,56 @@ type CartItemDTO struct {
 	Subtotal   string  `json:"subtotal"`
 }
 
+// CartDTO is a cart with its promotions applied. Total is the subtotal
+// less the discounts.
 type CartDTO struct {
-	CartID    int64         `json:"cart_id"`
-	StoreID   int64         `json:"store_id"`
-	Items     []CartItemDTO `json:"items"`
-	Total     string       `json:"total"`
-	UpdatedAt sql.NullTime  `json:"updated_at"`
+	CartID        int64           `json:"cart_id"`
+	StoreID       int64           `json:"store_id"`
+	Items         []CartItemDTO   `json:"items"`
+	Subtotal      string          `json:"subtotal"`
+	Discounts     []DiscountDTO   `json:"discounts"`
+	DiscountTotal string          `json:"discount_total"`
+	Total         string          `json:"total"`
+	PromotionCode *string         `json:"promotion_code,omitempty"`
+	FreeShipping  bool            `json:"free_shipping"`
+	UpdatedAt     sql.NullTime    `json:"updated_at"`
+	Changes       []CartChangeDTO `json:"changes,omitempty"`
+}
+
+// DiscountDTO is a promotion applied to a cart or an order. The promotion
+// id of an order discount is unset once the promotion is deleted.
+type DiscountDTO struct {
+	PromotionID *int64  `json:"promotion_id,omitempty"`
+	Code        *string `json:"code,omitempty"`
+	Description string  `json:"description"`
+	Type        string  `json:"type"`
+	Amount      string  `json:"amount"`
+}
+
+// CartChangeDTO tells the shopper how an item was changed to match the
+// catalog: its price changed, its quantity was reduced to the stock, or it
+// was removed because it was deleted or sold out.
+type CartChangeDTO struct {
+	Type        string `json:"type"`
+	CartItemID  int64  `json:"cart_item_id"`
+	VariantID   int64  `json:"variant_id"`
+	ProductID   int64  `json:"product_id"`
+	Product     string `json:"product_name"`
+	OldPrice    string `json:"old_price,omitempty"`
+	NewPrice    string `json:"new_price,omitempty"`
+	OldQuantity int32  `json:"old_quantity,omitempty"`
+	NewQuantity int32  `json:"new_quantity,omitempty"`
+}
+
+// VisitorSessionDTO is a storefront visitor session. Clients send the
+// session id in the X-Session-ID header.
+type VisitorSessionDTO struct {
+	SessionID   uuid.UUID `json:"session_id"`
+	StoreID     int64     `json:"store_id"`
+	IsReturning bool      `json:"is_returning"`
+	FirstSeenAt time.Time `json:"first_seen_at"`
+	LastSeenAt  time.Time `json:"last_seen_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
 }
 
 type VariantAttributeInput struct {
@@ -103,3 +151,186 @@ type StoreDTO struct {
 	CreatedAt    time.Time `json:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at"`
 }
+
+// StorePasswordPolicyDTO is the customer password policy of a store.
+type StorePasswordPolicyDTO struct {
+	CheckBreachedPasswords bool `json:"check_breached_passwords"`
+}
+
+// StoreStockReservationDTO is how long adding to a cart holds stock in a
+// store. Zero means stock is not reserved.
+type StoreStockReservationDTO struct {
+	ReservationMinutes int32 `json:"reservation_minutes"`
+}
+
+// StoreCartRemindersDTO is when a store reminds customers of abandoned
+// carts: up to MaxReminders, each DelayHours after the cart was last
+// changed or the previous reminder. Zero MaxReminders sends none.
+type StoreCartRemindersDTO struct {
+	DelayHours   int32 `json:"delay_hours"`
+	MaxReminders int32 `json:"max_reminders"`
+}
+
+type MFAEnrollmentDTO struct {
+	Secret        string   `json:"secret"`
+	OTPAuthURI    string   `json:"otpauth_uri"`
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type SessionDTO struct {
+	SessionID  uuid.UUID `json:"session_id"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	IPAddress  *string   `json:"ip_address,omitempty"`
+	UserAgent  *string   `json:"user_agent,omitempty"`
+	Current    bool      `json:"current"`
+}
+
+// ProfileDTO is the profile of the logged-in customer or store owner.
+type ProfileDTO struct {
+	UserID        int64          `json:"user_id"`
+	Role          string         `json:"role"`
+	StoreID       *int64         `json:"store_id,omitempty"`
+	Name          string         `json:"name"`
+	Email         string         `json:"email"`
+	EmailVerified bool           `json:"email_verified"`
+	Phone         *string        `json:"phone,omitempty"`
+	Address       *types.Address `json:"address,omitempty"`
+	CreatedAt     time.Time      `json:"created_at"`
+}
+
+type AdminDTO struct {
+	AdminID    int64      `json:"admin_id"`
+	Email      string     `json:"email"`
+	CreatedBy  *int64     `json:"created_by,omitempty"`
+	Disabled   bool       `json:"disabled"`
+	DisabledAt *time.Time `json:"disabled_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type AdminAuditLogDTO struct {
+	AuditLogID int64           `json:"audit_log_id"`
+	AdminID    int64           `json:"admin_id"`
+	Action     string          `json:"action"`
+	Details    json.RawMessage `json:"details"`
+	StatusCode int32           `json:"status_code"`
+	IPAddress  *string         `json:"ip_address,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+// APIKeyDTO describes a store API key. Key holds the secret and is only set
+// in the response that creates the key.
+type APIKeyDTO struct {
+	APIKeyID   int64      `json:"api_key_id"`
+	Name       string     `json:"name"`
+	Key        string     `json:"key,omitempty"`
+	KeyPrefix  string     `json:"key_prefix"`
+	Scopes     []string   `json:"scopes"`
+	CreatedBy  int64      `json:"created_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// StoreMemberDTO is someone with access to a store's dashboard. The owner
+// is listed with the role "owner" and no member id.
+type StoreMemberDTO struct {
+	MemberID  int64     `json:"member_id,omitempty"`
+	UserID    int64     `json:"user_id"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email"`
+	Role      string    `json:"role"`
+	InvitedBy *int64    `json:"invited_by,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// StoreInvitationDTO is a pending invitation to join a store's staff.
+type StoreInvitationDTO struct {
+	InvitationID int64     `json:"invitation_id"`
+	Email        string    `json:"email"`
+	Role         string    `json:"role"`
+	InvitedBy    int64     `json:"invited_by"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// StoreOrderDTO is an order as listed in the store dashboard.
+type StoreOrderDTO struct {
+	OrderID         int64               `json:"order_id"`
+	CustomerID      *int64              `json:"customer_id,omitempty"`
+	GuestEmail      *string             `json:"guest_email,omitempty"`
+	ShippingAddress *types.Address      `json:"shipping_address,omitempty"`
+	TotalAmount     string              `json:"total_amount"`
+	Status          *string             `json:"status,omitempty"`
+	CreatedAt       time.Time           `json:"created_at"`
+	Items           []StoreOrderItemDTO `json:"items"`
+	Discounts       []DiscountDTO       `json:"discounts"`
+}
+
+type StoreOrderItemDTO struct {
+	VariantID int64  `json:"variant_id"`
+	Quantity  int32  `json:"quantity"`
+	UnitPrice string `json:"unit_price"`
+	Subtotal  string `json:"subtotal"`
+}
+
+// ProductAddToCartRateDTO is how often sessions that viewed a product
+// added it to their cart.
+type ProductAddToCartRateDTO struct {
+	ProductID            int64  `json:"product_id"`
+	ProductName          string `json:"product_name"`
+	ViewingSessions      int64  `json:"viewing_sessions"`
+	AddingSessions       int64  `json:"adding_sessions"`
+	AddToCartRatePercent string `json:"add_to_cart_rate_percent"`
+}
+
+// CartRecoveryDTO sums up abandoned cart reminders over a period: the
+// reminders sent, the carts restored through them and the orders placed
+// from restored carts.
+type CartRecoveryDTO struct {
+	RemindersSent    int64  `json:"reminders_sent"`
+	CartsRecovered   int64  `json:"carts_recovered"`
+	RecoveredOrders  int64  `json:"recovered_orders"`
+	RecoveredRevenue string `json:"recovered_revenue"`
+}
+
+// DataRequestDTO is a customer data export or erasure request.
+type DataRequestDTO struct {
+	DataRequestID     int64      `json:"data_request_id"`
+	StoreID           int64      `json:"store_id"`
+	CustomerID        int64      `json:"customer_id"`
+	Type              string     `json:"type"`
+	Status            string     `json:"status"`
+	RequestedBy       string     `json:"requested_by"`
+	Error             *string    `json:"error,omitempty"`
+	Attempts          int32      `json:"attempts"`
+	DownloadAvailable bool       `json:"download_available"`
+	CreatedAt         time.Time  `json:"created_at"`
+	StartedAt         *time.Time `json:"started_at,omitempty"`
+	CompletedAt       *time.Time `json:"completed_at,omitempty"`
+}
+
+// PromotionDTO is a promotion as managed in the store dashboard. Promotions
+// without a code apply automatically. TimesUsed counts the orders that got
+// the promotion.
+type PromotionDTO struct {
+	PromotionID           int64      `json:"promotion_id"`
+	Name                  string     `json:"name"`
+	Code                  *string    `json:"code,omitempty"`
+	Type                  string     `json:"type"`
+	Value                 string     `json:"value"`
+	BuyQuantity           *int32     `json:"buy_quantity,omitempty"`
+	GetQuantity           *int32     `json:"get_quantity,omitempty"`
+	MinSubtotal           *string    `json:"min_subtotal,omitempty"`
+	CategoryID            *int64     `json:"category_id,omitempty"`
+	VariantIDs            []int64    `json:"variant_ids,omitempty"`
+	FirstOrderOnly        bool       `json:"first_order_only"`
+	UsageLimit            *int32     `json:"usage_limit,omitempty"`
+	UsageLimitPerCustomer *int32     `json:"usage_limit_per_customer,omitempty"`
+	TimesUsed             int32      `json:"times_used"`
+	StartsAt              *time.Time `json:"starts_at,omitempty"`
+	EndsAt                *time.Time `json:"ends_at,omitempty"`
+	IsActive              bool       `json:"is_active"`
+	CreatedAt             time.Time  `json:"created_at"`
+	UpdatedAt             time.Time  `json:"updated_at"`
+}
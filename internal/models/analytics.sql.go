@@ -28,6 +28,52 @@ func (q *Queries) GetAverageDeliveryDays(ctx context.Context, storeID int64) (st
 	return avg_delivery_days, err
 }
 
+const getCartRecoveryStats = `-- name: GetCartRecoveryStats :one
+SELECT
+  (SELECT COUNT(*)
+   FROM cart_reminder r
+   WHERE r.store_id = $1
+     AND r.sent_at >= $2
+     AND r.sent_at < $3)::BIGINT AS reminders_sent,
+  (SELECT COUNT(DISTINCT r.cart_id)
+   FROM cart_reminder r
+   WHERE r.store_id = $1
+     AND r.recovered_at >= $2
+     AND r.recovered_at < $3)::BIGINT AS carts_recovered,
+  COUNT(o.order_id)::BIGINT AS recovered_orders,
+  COALESCE(SUM(o.total_amount), 0)::NUMERIC AS recovered_revenue
+FROM cart_reminder cr
+JOIN customer_order o ON o.order_id = cr.order_id
+WHERE cr.store_id = $1
+  AND o.created_at >= $2
+  AND o.created_at < $3
+`
+
+type GetCartRecoveryStatsParams struct {
+	StoreID int64
+	From    time.Time
+	To      time.Time
+}
+
+type GetCartRecoveryStatsRow struct {
+	RemindersSent    int64
+	CartsRecovered   int64
+	RecoveredOrders  int64
+	RecoveredRevenue string
+}
+
+func (q *Queries) GetCartRecoveryStats(ctx context.Context, arg GetCartRecoveryStatsParams) (GetCartRecoveryStatsRow, error) {
+	row := q.db.QueryRowContext(ctx, getCartRecoveryStats, arg.StoreID, arg.From, arg.To)
+	var i GetCartRecoveryStatsRow
+	err := row.Scan(
+		&i.RemindersSent,
+		&i.CartsRecovered,
+		&i.RecoveredOrders,
+		&i.RecoveredRevenue,
+	)
+	return i, err
+}
+
 const getCompletedOrders = `-- name: GetCompletedOrders :one
 
 SELECT COUNT(*) AS completed_orders
@@ -572,6 +618,89 @@ func (q *Queries) GetPopularButNotSellingProducts(ctx context.Context, arg GetPo
 	return items, nil
 }
 
+const getProductAddToCartRates = `-- name: GetProductAddToCartRates :many
+WITH views AS
+  (SELECT pv.product_id,
+          COUNT(DISTINCT pv.session_id) AS viewing_sessions
+   FROM product_view pv
+   WHERE pv.store_id = $1
+     AND pv.viewed_at >= $2::TIMESTAMPTZ
+     AND pv.viewed_at < $3::TIMESTAMPTZ
+   GROUP BY pv.product_id),
+     adds AS
+  (SELECT ce.product_id,
+          COUNT(DISTINCT ce.session_id) AS adding_sessions
+   FROM cart_event ce
+   JOIN product p ON p.product_id = ce.product_id
+   WHERE p.store_id = $1
+     AND ce.event_type = 'add'
+     AND ce.created_at >= $2::TIMESTAMPTZ
+     AND ce.created_at < $3::TIMESTAMPTZ
+     AND EXISTS
+       (SELECT 1
+        FROM product_view pv
+        WHERE pv.product_id = ce.product_id
+          AND pv.session_id = ce.session_id
+          AND pv.viewed_at >= $2::TIMESTAMPTZ
+          AND pv.viewed_at < $3::TIMESTAMPTZ)
+   GROUP BY ce.product_id)
+SELECT p.product_id,
+       p.name AS product_name,
+       COALESCE(v.viewing_sessions, 0)::BIGINT AS viewing_sessions,
+       COALESCE(a.adding_sessions, 0)::BIGINT AS adding_sessions,
+       COALESCE(ROUND(COALESCE(a.adding_sessions, 0)::numeric / NULLIF(v.viewing_sessions, 0) * 100, 2), 0)::NUMERIC AS add_to_cart_rate_percent
+FROM product p
+LEFT JOIN views v ON v.product_id = p.product_id
+LEFT JOIN adds a ON a.product_id = p.product_id
+WHERE p.store_id = $1
+  AND (v.product_id IS NOT NULL OR a.product_id IS NOT NULL)
+ORDER BY add_to_cart_rate_percent DESC,
+         viewing_sessions DESC
+`
+
+type GetProductAddToCartRatesParams struct {
+	StoreID int64
+	From    time.Time
+	To      time.Time
+}
+
+type GetProductAddToCartRatesRow struct {
+	ProductID            int64
+	ProductName          string
+	ViewingSessions      int64
+	AddingSessions       int64
+	AddToCartRatePercent string
+}
+
+func (q *Queries) GetProductAddToCartRates(ctx context.Context, arg GetProductAddToCartRatesParams) ([]GetProductAddToCartRatesRow, error) {
+	rows, err := q.db.QueryContext(ctx, getProductAddToCartRates, arg.StoreID, arg.From, arg.To)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetProductAddToCartRatesRow
+	for rows.Next() {
+		var i GetProductAddToCartRatesRow
+		if err := rows.Scan(
+			&i.ProductID,
+			&i.ProductName,
+			&i.ViewingSessions,
+			&i.AddingSessions,
+			&i.AddToCartRatePercent,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getProductsNeedingAttention = `-- name: GetProductsNeedingAttention :many
 WITH product_sales AS
   (SELECT pv.product_id,
@@ -6,6 +6,7 @@ package models
 
 import (
 	"database/sql"
+	"encoding/json"
 	"time"
 
 	"github.com/Secure-Website-Builder/Backend/internal/types"
@@ -13,25 +14,57 @@ import (
 	"github.com/sqlc-dev/pqtype"
 )
 
+type AccessToken struct {
+	Jti       uuid.UUID
+	SessionID uuid.UUID
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
+
 type Admin struct {
 	AdminID      int64
 	Email        string
 	PasswordHash string
+	CreatedBy    sql.NullInt64
+	DisabledAt   sql.NullTime
 	CreatedAt    time.Time
 }
 
+type AdminAuditLog struct {
+	AdminAuditLogID int64
+	AdminID         int64
+	Action          string
+	Details         json.RawMessage
+	StatusCode      int32
+	IpAddress       pqtype.Inet
+	CreatedAt       time.Time
+}
+
 type AttributeDefinition struct {
 	AttributeID int64
 	Name        string
 }
 
-type Cart struct {
-	CartID     int64
-	StoreID    int64
+type AuthSession struct {
 	SessionID  uuid.UUID
-	CustomerID sql.NullInt64
+	UserID     int64
+	UserRole   string
+	StoreID    sql.NullInt64
+	IpAddress  pqtype.Inet
+	UserAgent  sql.NullString
 	CreatedAt  time.Time
-	UpdatedAt  sql.NullTime
+	LastUsedAt time.Time
+	RevokedAt  sql.NullTime
+}
+
+type Cart struct {
+	CartID        int64
+	StoreID       int64
+	SessionID     uuid.UUID
+	CustomerID    sql.NullInt64
+	CreatedAt     time.Time
+	UpdatedAt     sql.NullTime
+	PromotionCode sql.NullString
 }
 
 type CartEvent struct {
@@ -52,6 +85,19 @@ type CartItem struct {
 	CreatedAt  time.Time
 }
 
+type CartReminder struct {
+	CartReminderID int64
+	CartID         sql.NullInt64
+	StoreID        int64
+	CartActivityAt time.Time
+	ReminderNumber int32
+	TokenHash      string
+	ExpiresAt      time.Time
+	SentAt         time.Time
+	RecoveredAt    sql.NullTime
+	OrderID        sql.NullInt64
+}
+
 type CategoryAttribute struct {
 	CategoryID  int64
 	AttributeID int64
@@ -65,25 +111,86 @@ type CategoryDefinition struct {
 }
 
 type Customer struct {
-	CustomerID   int64
-	StoreID      int64
-	Name         string
-	Email        string
-	PasswordHash string
-	Phone        sql.NullString
-	Address      types.NullableAddress
-	CreatedAt    time.Time
+	CustomerID      int64
+	StoreID         int64
+	Name            string
+	Email           string
+	PasswordHash    string
+	Phone           sql.NullString
+	Address         types.NullableAddress
+	EmailVerifiedAt sql.NullTime
+	ErasedAt        sql.NullTime
+	CreatedAt       time.Time
 }
 
 type CustomerOrder struct {
-	OrderID     int64
-	StoreID     int64
-	CustomerID  sql.NullInt64
-	SessionID   uuid.UUID
-	TotalAmount string
-	Status      sql.NullString
-	CreatedAt   time.Time
-	UpdatedAt   sql.NullTime
+	OrderID         int64
+	StoreID         int64
+	CustomerID      sql.NullInt64
+	SessionID       uuid.UUID
+	TotalAmount     string
+	Status          sql.NullString
+	CreatedAt       time.Time
+	UpdatedAt       sql.NullTime
+	GuestEmail      sql.NullString
+	ShippingAddress types.NullableAddress
+}
+
+type DataRequest struct {
+	DataRequestID int64
+	StoreID       int64
+	CustomerID    int64
+	RequestType   string
+	Status        string
+	RequestedBy   string
+	ArchiveKey    sql.NullString
+	Error         sql.NullString
+	Attempts      int32
+	CreatedAt     time.Time
+	StartedAt     sql.NullTime
+	CompletedAt   sql.NullTime
+}
+
+type EmailVerificationToken struct {
+	EmailVerificationTokenID int64
+	TokenHash                string
+	UserID                   int64
+	UserRole                 string
+	StoreID                  sql.NullInt64
+	Email                    string
+	ExpiresAt                time.Time
+	UsedAt                   sql.NullTime
+	CreatedAt                time.Time
+}
+
+type MfaRecoveryCode struct {
+	MfaRecoveryCodeID int64
+	UserID            int64
+	UserRole          string
+	CodeHash          string
+	UsedAt            sql.NullTime
+	CreatedAt         time.Time
+}
+
+type OidcLoginState struct {
+	StateHash        string
+	Provider         string
+	CodeVerifier     string
+	Nonce            string
+	LinkStoreOwnerID sql.NullInt64
+	ExpiresAt        time.Time
+	CreatedAt        time.Time
+}
+
+type OrderDiscount struct {
+	OrderDiscountID int64
+	OrderID         int64
+	PromotionID     sql.NullInt64
+	Code            sql.NullString
+	Description     string
+	Type            string
+	Amount          string
+	CreatedAt       time.Time
 }
 
 type OrderItem struct {
@@ -95,6 +202,17 @@ type OrderItem struct {
 	Subtotal    string
 }
 
+type PasswordResetToken struct {
+	PasswordResetTokenID int64
+	TokenHash            string
+	UserID               int64
+	UserRole             string
+	StoreID              sql.NullInt64
+	ExpiresAt            time.Time
+	UsedAt               sql.NullTime
+	CreatedAt            time.Time
+}
+
 type Payment struct {
 	PaymentID      int64
 	OrderID        int64
@@ -150,17 +268,47 @@ type ProductView struct {
 	ViewedAt      sql.NullTime
 }
 
+type Promotion struct {
+	PromotionID           int64
+	StoreID               int64
+	Name                  string
+	Code                  sql.NullString
+	Type                  string
+	Value                 string
+	BuyQuantity           sql.NullInt32
+	GetQuantity           sql.NullInt32
+	MinSubtotal           sql.NullString
+	CategoryID            sql.NullInt64
+	VariantIds            []int64
+	FirstOrderOnly        bool
+	UsageLimit            sql.NullInt32
+	UsageLimitPerCustomer sql.NullInt32
+	StartsAt              sql.NullTime
+	EndsAt                sql.NullTime
+	IsActive              bool
+	CreatedAt             time.Time
+	UpdatedAt             time.Time
+}
+
 type RefreshToken struct {
 	RefreshTokenID int64
-	Token          string
+	TokenHash      string
+	FamilyID       uuid.UUID
 	UserID         int64
 	UserRole       string
 	StoreID        sql.NullInt64
 	ExpiresAt      time.Time
 	Revoked        sql.NullBool
+	MfaVerified    bool
 	CreatedAt      time.Time
 }
 
+type RevokedToken struct {
+	Jti       uuid.UUID
+	ExpiresAt time.Time
+	RevokedAt time.Time
+}
+
 type Shipment struct {
 	ShipmentID     int64
 	OrderID        int64
@@ -171,16 +319,41 @@ type Shipment struct {
 	Status         sql.NullString
 }
 
+type StockReservation struct {
+	CartItemID int64
+	VariantID  int64
+	Quantity   int32
+	ExpiresAt  time.Time
+	CreatedAt  time.Time
+}
+
 type Store struct {
-	StoreID        int64
-	StoreOwnerID   int64
-	Name           string
-	Domain         sql.NullString
-	DownloadStatus string
-	Currency       sql.NullString
-	Timezone       sql.NullString
-	CreatedAt      time.Time
-	UpdatedAt      time.Time
+	StoreID                 int64
+	StoreOwnerID            int64
+	Name                    string
+	Domain                  sql.NullString
+	DownloadStatus          string
+	Currency                sql.NullString
+	Timezone                sql.NullString
+	CheckBreachedPasswords  bool
+	StockReservationMinutes int32
+	CartReminderDelayHours  int32
+	CartReminderMax         int32
+	CreatedAt               time.Time
+	UpdatedAt               time.Time
+}
+
+type StoreApiKey struct {
+	ApiKeyID   int64
+	StoreID    int64
+	Name       string
+	KeyPrefix  string
+	KeyHash    string
+	Scopes     []string
+	CreatedBy  int64
+	LastUsedAt sql.NullTime
+	RevokedAt  sql.NullTime
+	CreatedAt  time.Time
 }
 
 type StoreCategory struct {
@@ -188,13 +361,54 @@ type StoreCategory struct {
 	CategoryID int64
 }
 
+type StoreInvitation struct {
+	StoreInvitationID int64
+	StoreID           int64
+	Email             string
+	Role              string
+	TokenHash         string
+	InvitedBy         int64
+	ExpiresAt         time.Time
+	AcceptedAt        sql.NullTime
+	CreatedAt         time.Time
+}
+
+type StoreMember struct {
+	StoreMemberID int64
+	StoreID       int64
+	StoreOwnerID  int64
+	Role          string
+	InvitedBy     sql.NullInt64
+	CreatedAt     time.Time
+}
+
 type StoreOwner struct {
-	StoreOwnerID int64
-	Name         string
-	Email        string
-	PasswordHash string
-	Phone        sql.NullString
-	Address      types.NullableAddress
+	StoreOwnerID    int64
+	Name            string
+	Email           string
+	PasswordHash    string
+	Phone           sql.NullString
+	Address         types.NullableAddress
+	EmailVerifiedAt sql.NullTime
+	CreatedAt       time.Time
+}
+
+type StoreOwnerIdentity struct {
+	StoreOwnerIdentityID int64
+	StoreOwnerID         int64
+	Provider             string
+	Subject              string
+	Email                sql.NullString
+	CreatedAt            time.Time
+}
+
+type UserMfa struct {
+	UserMfaID    int64
+	UserID       int64
+	UserRole     string
+	TotpSecret   string
+	ConfirmedAt  sql.NullTime
+	LastUsedStep sql.NullInt64
 	CreatedAt    time.Time
 }
 
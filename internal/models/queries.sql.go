@@ -8,12 +8,69 @@ package models
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"time"
 
 	"github.com/Secure-Website-Builder/Backend/internal/types"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/sqlc-dev/pqtype"
 )
 
+const addStoreMember = `-- name: AddStoreMember :one
+INSERT INTO store_member (store_id, store_owner_id, role, invited_by)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (store_id, store_owner_id)
+DO UPDATE SET role = EXCLUDED.role
+RETURNING store_member_id, store_id, store_owner_id, role, invited_by, created_at
+`
+
+type AddStoreMemberParams struct {
+	StoreID      int64
+	StoreOwnerID int64
+	Role         string
+	InvitedBy    sql.NullInt64
+}
+
+func (q *Queries) AddStoreMember(ctx context.Context, arg AddStoreMemberParams) (StoreMember, error) {
+	row := q.db.QueryRowContext(ctx, addStoreMember, arg.StoreID, arg.StoreOwnerID, arg.Role, arg.InvitedBy)
+	var i StoreMember
+	err := row.Scan(
+		&i.StoreMemberID,
+		&i.StoreID,
+		&i.StoreOwnerID,
+		&i.Role,
+		&i.InvitedBy,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const anonymizeCustomerOrders = `-- name: AnonymizeCustomerOrders :exec
+UPDATE customer_order
+SET guest_email = NULL,
+    shipping_address = NULL
+WHERE customer_id = $1
+`
+
+func (q *Queries) AnonymizeCustomerOrders(ctx context.Context, customerID sql.NullInt64) error {
+	_, err := q.db.ExecContext(ctx, anonymizeCustomerOrders, customerID)
+	return err
+}
+
+const anonymizeCustomerVisitorSessions = `-- name: AnonymizeCustomerVisitorSessions :exec
+UPDATE visitor_session
+SET ip_address = NULL,
+    user_agent = NULL
+WHERE customer_id = $1::BIGINT
+   OR session_id IN (SELECT session_id FROM customer_order WHERE customer_id = $1::BIGINT)
+`
+
+func (q *Queries) AnonymizeCustomerVisitorSessions(ctx context.Context, customerID int64) error {
+	_, err := q.db.ExecContext(ctx, anonymizeCustomerVisitorSessions, customerID)
+	return err
+}
+
 const attachCartToCustomer = `-- name: AttachCartToCustomer :exec
 UPDATE cart
 SET customer_id = $1,
@@ -33,6 +90,60 @@ func (q *Queries) AttachCartToCustomer(ctx context.Context, arg AttachCartToCust
 	return err
 }
 
+const attributeOrderToCartReminder = `-- name: AttributeOrderToCartReminder :exec
+UPDATE cart_reminder
+SET order_id = $2
+WHERE cart_reminder_id = (
+  SELECT r.cart_reminder_id
+  FROM cart_reminder r
+  WHERE r.cart_id = $1
+    AND r.order_id IS NULL
+    AND r.recovered_at > NOW() - INTERVAL '7 days'
+  ORDER BY r.recovered_at DESC
+  LIMIT 1
+)
+`
+
+type AttributeOrderToCartReminderParams struct {
+	CartID  sql.NullInt64
+	OrderID sql.NullInt64
+}
+
+func (q *Queries) AttributeOrderToCartReminder(ctx context.Context, arg AttributeOrderToCartReminderParams) error {
+	_, err := q.db.ExecContext(ctx, attributeOrderToCartReminder, arg.CartID, arg.OrderID)
+	return err
+}
+
+const cartNeedsRepricing = `-- name: CartNeedsRepricing :one
+SELECT EXISTS (
+  SELECT 1
+  FROM cart_item ci
+  JOIN product_variant v ON v.variant_id = ci.variant_id
+  JOIN product p ON p.product_id = v.product_id
+  WHERE ci.cart_id = $1
+    AND (v.deleted_at IS NOT NULL
+      OR p.deleted_at IS NOT NULL
+      OR ci.unit_price <> v.price
+      OR ci.quantity > v.stock_quantity - COALESCE((
+        SELECT SUM(LEAST(r.quantity, rci.quantity))
+        FROM stock_reservation r
+        JOIN cart_item rci ON rci.cart_item_id = r.cart_item_id
+        WHERE r.variant_id = v.variant_id
+          AND rci.cart_id <> ci.cart_id
+          AND r.expires_at > NOW()
+      ), 0))
+)::BOOLEAN AS needs_repricing
+`
+
+// Whether an item of the cart no longer matches its variant, checked
+// without locks so that reading an unchanged cart does not block.
+func (q *Queries) CartNeedsRepricing(ctx context.Context, cartID int64) (bool, error) {
+	row := q.db.QueryRowContext(ctx, cartNeedsRepricing, cartID)
+	var needs_repricing bool
+	err := row.Scan(&needs_repricing)
+	return needs_repricing, err
+}
+
 const categoryHasAttribute = `-- name: CategoryHasAttribute :one
 SELECT 1
 FROM category_attribute
@@ -52,6 +163,43 @@ func (q *Queries) CategoryHasAttribute(ctx context.Context, arg CategoryHasAttri
 	return column_1, err
 }
 
+const claimDataRequest = `-- name: ClaimDataRequest :one
+UPDATE data_request
+SET status = 'processing',
+    started_at = NOW(),
+    attempts = attempts + 1
+WHERE data_request_id = (
+  SELECT data_request_id
+  FROM data_request
+  WHERE status = 'pending'
+     OR (status = 'processing' AND started_at < $1::TIMESTAMPTZ)
+  ORDER BY data_request_id
+  LIMIT 1
+  FOR UPDATE SKIP LOCKED
+)
+RETURNING data_request_id, store_id, customer_id, request_type, status, requested_by, archive_key, error, attempts, created_at, started_at, completed_at
+`
+
+func (q *Queries) ClaimDataRequest(ctx context.Context, staleBefore time.Time) (DataRequest, error) {
+	row := q.db.QueryRowContext(ctx, claimDataRequest, staleBefore)
+	var i DataRequest
+	err := row.Scan(
+		&i.DataRequestID,
+		&i.StoreID,
+		&i.CustomerID,
+		&i.RequestType,
+		&i.Status,
+		&i.RequestedBy,
+		&i.ArchiveKey,
+		&i.Error,
+		&i.Attempts,
+		&i.CreatedAt,
+		&i.StartedAt,
+		&i.CompletedAt,
+	)
+	return i, err
+}
+
 const clearCartItems = `-- name: ClearCartItems :exec
 DELETE FROM cart_item
 WHERE cart_id = $1
@@ -62,10 +210,183 @@ func (q *Queries) ClearCartItems(ctx context.Context, cartID int64) error {
 	return err
 }
 
+const clearCustomerDataRequestArchives = `-- name: ClearCustomerDataRequestArchives :exec
+UPDATE data_request
+SET archive_key = NULL
+WHERE customer_id = $1
+`
+
+func (q *Queries) ClearCustomerDataRequestArchives(ctx context.Context, customerID int64) error {
+	_, err := q.db.ExecContext(ctx, clearCustomerDataRequestArchives, customerID)
+	return err
+}
+
+const completeDataRequest = `-- name: CompleteDataRequest :exec
+UPDATE data_request
+SET status = 'completed',
+    archive_key = $2,
+    error = NULL,
+    completed_at = NOW()
+WHERE data_request_id = $1
+`
+
+type CompleteDataRequestParams struct {
+	DataRequestID int64
+	ArchiveKey    sql.NullString
+}
+
+func (q *Queries) CompleteDataRequest(ctx context.Context, arg CompleteDataRequestParams) error {
+	_, err := q.db.ExecContext(ctx, completeDataRequest, arg.DataRequestID, arg.ArchiveKey)
+	return err
+}
+
+const confirmUserMFA = `-- name: ConfirmUserMFA :exec
+UPDATE user_mfa
+SET confirmed_at = NOW()
+WHERE user_id = $1 AND user_role = $2
+`
+
+type ConfirmUserMFAParams struct {
+	UserID   int64
+	UserRole string
+}
+
+func (q *Queries) ConfirmUserMFA(ctx context.Context, arg ConfirmUserMFAParams) error {
+	_, err := q.db.ExecContext(ctx, confirmUserMFA, arg.UserID, arg.UserRole)
+	return err
+}
+
+const consumeOIDCLoginState = `-- name: ConsumeOIDCLoginState :one
+DELETE FROM oidc_login_state
+WHERE state_hash = $1
+  AND provider = $2
+  AND expires_at > NOW()
+RETURNING state_hash, provider, code_verifier, nonce, link_store_owner_id, expires_at, created_at
+`
+
+type ConsumeOIDCLoginStateParams struct {
+	StateHash string
+	Provider  string
+}
+
+func (q *Queries) ConsumeOIDCLoginState(ctx context.Context, arg ConsumeOIDCLoginStateParams) (OidcLoginState, error) {
+	row := q.db.QueryRowContext(ctx, consumeOIDCLoginState, arg.StateHash, arg.Provider)
+	var i OidcLoginState
+	err := row.Scan(
+		&i.StateHash,
+		&i.Provider,
+		&i.CodeVerifier,
+		&i.Nonce,
+		&i.LinkStoreOwnerID,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createAccessToken = `-- name: CreateAccessToken :exec
+INSERT INTO access_token (jti, session_id, expires_at)
+VALUES ($1, $2, $3)
+`
+
+type CreateAccessTokenParams struct {
+	Jti       uuid.UUID
+	SessionID uuid.UUID
+	ExpiresAt time.Time
+}
+
+func (q *Queries) CreateAccessToken(ctx context.Context, arg CreateAccessTokenParams) error {
+	_, err := q.db.ExecContext(ctx, createAccessToken, arg.Jti, arg.SessionID, arg.ExpiresAt)
+	return err
+}
+
+const createAdmin = `-- name: CreateAdmin :one
+INSERT INTO admin (email, password_hash, created_by)
+VALUES ($1, $2, $3)
+RETURNING admin_id, email, created_by, disabled_at, created_at
+`
+
+type CreateAdminParams struct {
+	Email        string
+	PasswordHash string
+	CreatedBy    sql.NullInt64
+}
+
+type CreateAdminRow struct {
+	AdminID    int64
+	Email      string
+	CreatedBy  sql.NullInt64
+	DisabledAt sql.NullTime
+	CreatedAt  time.Time
+}
+
+func (q *Queries) CreateAdmin(ctx context.Context, arg CreateAdminParams) (CreateAdminRow, error) {
+	row := q.db.QueryRowContext(ctx, createAdmin, arg.Email, arg.PasswordHash, arg.CreatedBy)
+	var i CreateAdminRow
+	err := row.Scan(
+		&i.AdminID,
+		&i.Email,
+		&i.CreatedBy,
+		&i.DisabledAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createAdminAuditLog = `-- name: CreateAdminAuditLog :exec
+INSERT INTO admin_audit_log (admin_id, action, details, status_code, ip_address)
+VALUES ($1, $2, $3, $4, $5)
+`
+
+type CreateAdminAuditLogParams struct {
+	AdminID    int64
+	Action     string
+	Details    json.RawMessage
+	StatusCode int32
+	IpAddress  pqtype.Inet
+}
+
+func (q *Queries) CreateAdminAuditLog(ctx context.Context, arg CreateAdminAuditLogParams) error {
+	_, err := q.db.ExecContext(ctx, createAdminAuditLog,
+		arg.AdminID,
+		arg.Action,
+		arg.Details,
+		arg.StatusCode,
+		arg.IpAddress,
+	)
+	return err
+}
+
+const createAuthSession = `-- name: CreateAuthSession :exec
+INSERT INTO auth_session (session_id, user_id, user_role, store_id, ip_address, user_agent)
+VALUES ($1, $2, $3, $4, $5, $6)
+`
+
+type CreateAuthSessionParams struct {
+	SessionID uuid.UUID
+	UserID    int64
+	UserRole  string
+	StoreID   sql.NullInt64
+	IpAddress pqtype.Inet
+	UserAgent sql.NullString
+}
+
+func (q *Queries) CreateAuthSession(ctx context.Context, arg CreateAuthSessionParams) error {
+	_, err := q.db.ExecContext(ctx, createAuthSession,
+		arg.SessionID,
+		arg.UserID,
+		arg.UserRole,
+		arg.StoreID,
+		arg.IpAddress,
+		arg.UserAgent,
+	)
+	return err
+}
+
 const createCart = `-- name: CreateCart :one
 INSERT INTO cart (store_id, session_id, customer_id)
 VALUES ($1, $2, $3)
-RETURNING cart_id, store_id, session_id, customer_id, created_at, updated_at
+RETURNING cart_id, store_id, session_id, customer_id, created_at, updated_at, promotion_code
 `
 
 type CreateCartParams struct {
@@ -84,6 +405,41 @@ func (q *Queries) CreateCart(ctx context.Context, arg CreateCartParams) (Cart, e
 		&i.CustomerID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.PromotionCode,
+	)
+	return i, err
+}
+
+const createCartReminder = `-- name: CreateCartReminder :one
+INSERT INTO cart_reminder (cart_id, store_id, cart_activity_at, reminder_number, token_hash, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (cart_id, cart_activity_at, reminder_number) DO NOTHING
+RETURNING cart_reminder_id, cart_id, store_id, cart_activity_at, reminder_number, token_hash, expires_at, sent_at, recovered_at, order_id
+`
+
+type CreateCartReminderParams struct {
+	CartID         sql.NullInt64
+	StoreID        int64
+	CartActivityAt time.Time
+	ReminderNumber int32
+	TokenHash      string
+	ExpiresAt      time.Time
+}
+
+func (q *Queries) CreateCartReminder(ctx context.Context, arg CreateCartReminderParams) (CartReminder, error) {
+	row := q.db.QueryRowContext(ctx, createCartReminder, arg.CartID, arg.StoreID, arg.CartActivityAt, arg.ReminderNumber, arg.TokenHash, arg.ExpiresAt)
+	var i CartReminder
+	err := row.Scan(
+		&i.CartReminderID,
+		&i.CartID,
+		&i.StoreID,
+		&i.CartActivityAt,
+		&i.ReminderNumber,
+		&i.TokenHash,
+		&i.ExpiresAt,
+		&i.SentAt,
+		&i.RecoveredAt,
+		&i.OrderID,
 	)
 	return i, err
 }
@@ -139,23 +495,159 @@ func (q *Queries) CreateCustomer(ctx context.Context, arg CreateCustomerParams)
 	return i, err
 }
 
+const createDataRequest = `-- name: CreateDataRequest :one
+INSERT INTO data_request (store_id, customer_id, request_type, requested_by)
+VALUES ($1, $2, $3, $4)
+RETURNING data_request_id, store_id, customer_id, request_type, status, requested_by, archive_key, error, attempts, created_at, started_at, completed_at
+`
+
+type CreateDataRequestParams struct {
+	StoreID     int64
+	CustomerID  int64
+	RequestType string
+	RequestedBy string
+}
+
+func (q *Queries) CreateDataRequest(ctx context.Context, arg CreateDataRequestParams) (DataRequest, error) {
+	row := q.db.QueryRowContext(ctx, createDataRequest, arg.StoreID, arg.CustomerID, arg.RequestType, arg.RequestedBy)
+	var i DataRequest
+	err := row.Scan(
+		&i.DataRequestID,
+		&i.StoreID,
+		&i.CustomerID,
+		&i.RequestType,
+		&i.Status,
+		&i.RequestedBy,
+		&i.ArchiveKey,
+		&i.Error,
+		&i.Attempts,
+		&i.CreatedAt,
+		&i.StartedAt,
+		&i.CompletedAt,
+	)
+	return i, err
+}
+
+const createEmailVerificationToken = `-- name: CreateEmailVerificationToken :exec
+INSERT INTO email_verification_token (token_hash, user_id, user_role, store_id, email, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+`
+
+type CreateEmailVerificationTokenParams struct {
+	TokenHash string
+	UserID    int64
+	UserRole  string
+	StoreID   sql.NullInt64
+	Email     string
+	ExpiresAt time.Time
+}
+
+func (q *Queries) CreateEmailVerificationToken(ctx context.Context, arg CreateEmailVerificationTokenParams) error {
+	_, err := q.db.ExecContext(ctx, createEmailVerificationToken,
+		arg.TokenHash,
+		arg.UserID,
+		arg.UserRole,
+		arg.StoreID,
+		arg.Email,
+		arg.ExpiresAt,
+	)
+	return err
+}
+
+const createMFARecoveryCode = `-- name: CreateMFARecoveryCode :exec
+INSERT INTO mfa_recovery_code (user_id, user_role, code_hash)
+VALUES ($1, $2, $3)
+`
+
+type CreateMFARecoveryCodeParams struct {
+	UserID   int64
+	UserRole string
+	CodeHash string
+}
+
+func (q *Queries) CreateMFARecoveryCode(ctx context.Context, arg CreateMFARecoveryCodeParams) error {
+	_, err := q.db.ExecContext(ctx, createMFARecoveryCode, arg.UserID, arg.UserRole, arg.CodeHash)
+	return err
+}
+
+const createOIDCLoginState = `-- name: CreateOIDCLoginState :exec
+INSERT INTO oidc_login_state (state_hash, provider, code_verifier, nonce, link_store_owner_id, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+`
+
+type CreateOIDCLoginStateParams struct {
+	StateHash        string
+	Provider         string
+	CodeVerifier     string
+	Nonce            string
+	LinkStoreOwnerID sql.NullInt64
+	ExpiresAt        time.Time
+}
+
+func (q *Queries) CreateOIDCLoginState(ctx context.Context, arg CreateOIDCLoginStateParams) error {
+	_, err := q.db.ExecContext(ctx, createOIDCLoginState,
+		arg.StateHash,
+		arg.Provider,
+		arg.CodeVerifier,
+		arg.Nonce,
+		arg.LinkStoreOwnerID,
+		arg.ExpiresAt,
+	)
+	return err
+}
+
+const createOIDCStoreOwner = `-- name: CreateOIDCStoreOwner :one
+INSERT INTO store_owner (name, email, password_hash, email_verified_at)
+VALUES ($1, $2, $3, NOW())
+RETURNING store_owner_id, name, email, created_at
+`
+
+type CreateOIDCStoreOwnerParams struct {
+	Name         string
+	Email        string
+	PasswordHash string
+}
+
+type CreateOIDCStoreOwnerRow struct {
+	StoreOwnerID int64
+	Name         string
+	Email        string
+	CreatedAt    time.Time
+}
+
+func (q *Queries) CreateOIDCStoreOwner(ctx context.Context, arg CreateOIDCStoreOwnerParams) (CreateOIDCStoreOwnerRow, error) {
+	row := q.db.QueryRowContext(ctx, createOIDCStoreOwner, arg.Name, arg.Email, arg.PasswordHash)
+	var i CreateOIDCStoreOwnerRow
+	err := row.Scan(
+		&i.StoreOwnerID,
+		&i.Name,
+		&i.Email,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
 const createOrder = `-- name: CreateOrder :one
 INSERT INTO customer_order (
   store_id,
   customer_id,
   session_id,
-  total_amount
+  total_amount,
+  guest_email,
+  shipping_address
 ) VALUES (
-  $1, $2, $3, $4
+  $1, $2, $3, $4, $5, $6
 )
-RETURNING order_id, store_id, customer_id, session_id, total_amount, status, created_at, updated_at
+RETURNING order_id, store_id, customer_id, session_id, total_amount, status, created_at, updated_at, guest_email, shipping_address
 `
 
 type CreateOrderParams struct {
-	StoreID     int64
-	CustomerID  sql.NullInt64
-	SessionID   uuid.UUID
-	TotalAmount string
+	StoreID         int64
+	CustomerID      sql.NullInt64
+	SessionID       uuid.UUID
+	TotalAmount     string
+	GuestEmail      sql.NullString
+	ShippingAddress types.NullableAddress
 }
 
 func (q *Queries) CreateOrder(ctx context.Context, arg CreateOrderParams) (CustomerOrder, error) {
@@ -164,6 +656,8 @@ func (q *Queries) CreateOrder(ctx context.Context, arg CreateOrderParams) (Custo
 		arg.CustomerID,
 		arg.SessionID,
 		arg.TotalAmount,
+		arg.GuestEmail,
+		arg.ShippingAddress,
 	)
 	var i CustomerOrder
 	err := row.Scan(
@@ -175,10 +669,31 @@ func (q *Queries) CreateOrder(ctx context.Context, arg CreateOrderParams) (Custo
 		&i.Status,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.GuestEmail,
+		&i.ShippingAddress,
 	)
 	return i, err
 }
 
+const createOrderDiscount = `-- name: CreateOrderDiscount :exec
+INSERT INTO order_discount (order_id, promotion_id, code, description, type, amount)
+VALUES ($1, $2, $3, $4, $5, $6)
+`
+
+type CreateOrderDiscountParams struct {
+	OrderID     int64
+	PromotionID sql.NullInt64
+	Code        sql.NullString
+	Description string
+	Type        string
+	Amount      string
+}
+
+func (q *Queries) CreateOrderDiscount(ctx context.Context, arg CreateOrderDiscountParams) error {
+	_, err := q.db.ExecContext(ctx, createOrderDiscount, arg.OrderID, arg.PromotionID, arg.Code, arg.Description, arg.Type, arg.Amount)
+	return err
+}
+
 const createOrderItem = `-- name: CreateOrderItem :exec
 INSERT INTO order_item (
   order_id,
@@ -210,6 +725,30 @@ func (q *Queries) CreateOrderItem(ctx context.Context, arg CreateOrderItemParams
 	return err
 }
 
+const createPasswordResetToken = `-- name: CreatePasswordResetToken :exec
+INSERT INTO password_reset_token (token_hash, user_id, user_role, store_id, expires_at)
+VALUES ($1, $2, $3, $4, $5)
+`
+
+type CreatePasswordResetTokenParams struct {
+	TokenHash string
+	UserID    int64
+	UserRole  string
+	StoreID   sql.NullInt64
+	ExpiresAt time.Time
+}
+
+func (q *Queries) CreatePasswordResetToken(ctx context.Context, arg CreatePasswordResetTokenParams) error {
+	_, err := q.db.ExecContext(ctx, createPasswordResetToken,
+		arg.TokenHash,
+		arg.UserID,
+		arg.UserRole,
+		arg.StoreID,
+		arg.ExpiresAt,
+	)
+	return err
+}
+
 const createPayment = `-- name: CreatePayment :exec
 INSERT INTO payment (
   order_id,
@@ -285,27 +824,87 @@ func (q *Queries) CreateProduct(ctx context.Context, arg CreateProductParams) (P
 	)
 	return i, err
 }
-// #nosec G101
-const createRefreshToken = `-- name: CreateRefreshToken :exec
-INSERT INTO refresh_token (token, user_id, user_role, store_id, expires_at)
-VALUES ($1, $2, $3, $4, $5)
+
+const createPromotion = `-- name: CreatePromotion :one
+INSERT INTO promotion (
+  store_id, name, code, type, value, buy_quantity, get_quantity,
+  min_subtotal, category_id, variant_ids, first_order_only, usage_limit,
+  usage_limit_per_customer, starts_at, ends_at, is_active
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+RETURNING promotion_id, store_id, name, code, type, value, buy_quantity, get_quantity, min_subtotal, category_id, variant_ids, first_order_only, usage_limit, usage_limit_per_customer, starts_at, ends_at, is_active, created_at, updated_at
 `
 
-type CreateRefreshTokenParams struct {
-	Token     string
-	UserID    int64
-	UserRole  string
-	StoreID   sql.NullInt64
-	ExpiresAt time.Time
+type CreatePromotionParams struct {
+	StoreID               int64
+	Name                  string
+	Code                  sql.NullString
+	Type                  string
+	Value                 string
+	BuyQuantity           sql.NullInt32
+	GetQuantity           sql.NullInt32
+	MinSubtotal           sql.NullString
+	CategoryID            sql.NullInt64
+	VariantIds            []int64
+	FirstOrderOnly        bool
+	UsageLimit            sql.NullInt32
+	UsageLimitPerCustomer sql.NullInt32
+	StartsAt              sql.NullTime
+	EndsAt                sql.NullTime
+	IsActive              bool
+}
+
+func (q *Queries) CreatePromotion(ctx context.Context, arg CreatePromotionParams) (Promotion, error) {
+	row := q.db.QueryRowContext(ctx, createPromotion, arg.StoreID, arg.Name, arg.Code, arg.Type, arg.Value, arg.BuyQuantity, arg.GetQuantity, arg.MinSubtotal, arg.CategoryID, pq.Array(arg.VariantIds), arg.FirstOrderOnly, arg.UsageLimit, arg.UsageLimitPerCustomer, arg.StartsAt, arg.EndsAt, arg.IsActive)
+	var i Promotion
+	err := row.Scan(
+		&i.PromotionID,
+		&i.StoreID,
+		&i.Name,
+		&i.Code,
+		&i.Type,
+		&i.Value,
+		&i.BuyQuantity,
+		&i.GetQuantity,
+		&i.MinSubtotal,
+		&i.CategoryID,
+		pq.Array(&i.VariantIds),
+		&i.FirstOrderOnly,
+		&i.UsageLimit,
+		&i.UsageLimitPerCustomer,
+		&i.StartsAt,
+		&i.EndsAt,
+		&i.IsActive,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+// #nosec G101
+const createRefreshToken = `-- name: CreateRefreshToken :exec
+INSERT INTO refresh_token (token_hash, family_id, user_id, user_role, store_id, expires_at, mfa_verified)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+`
+
+type CreateRefreshTokenParams struct {
+	TokenHash   string
+	FamilyID    uuid.UUID
+	UserID      int64
+	UserRole    string
+	StoreID     sql.NullInt64
+	ExpiresAt   time.Time
+	MfaVerified bool
 }
 
 func (q *Queries) CreateRefreshToken(ctx context.Context, arg CreateRefreshTokenParams) error {
 	_, err := q.db.ExecContext(ctx, createRefreshToken,
-		arg.Token,
+		arg.TokenHash,
+		arg.FamilyID,
 		arg.UserID,
 		arg.UserRole,
 		arg.StoreID,
 		arg.ExpiresAt,
+		arg.MfaVerified,
 	)
 	return err
 }
@@ -318,7 +917,7 @@ INSERT INTO store (
     currency,
     timezone
 ) VALUES ($1, $2, $3, $4, $5)
-RETURNING store_id, store_owner_id, name, domain, download_status, currency, timezone, created_at, updated_at
+RETURNING store_id, store_owner_id, name, domain, download_status, currency, timezone, check_breached_passwords, stock_reservation_minutes, cart_reminder_delay_hours, cart_reminder_max, created_at, updated_at
 `
 
 type CreateStoreParams struct {
@@ -346,12 +945,81 @@ func (q *Queries) CreateStore(ctx context.Context, arg CreateStoreParams) (Store
 		&i.DownloadStatus,
 		&i.Currency,
 		&i.Timezone,
+		&i.CheckBreachedPasswords,
+		&i.StockReservationMinutes,
+		&i.CartReminderDelayHours,
+		&i.CartReminderMax,
 		&i.CreatedAt,
 		&i.UpdatedAt,
 	)
 	return i, err
 }
 
+const createStoreAPIKey = `-- name: CreateStoreAPIKey :one
+INSERT INTO store_api_key (store_id, name, key_prefix, key_hash, scopes, created_by)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING api_key_id, store_id, name, key_prefix, key_hash, scopes, created_by, last_used_at, revoked_at, created_at
+`
+
+type CreateStoreAPIKeyParams struct {
+	StoreID   int64
+	Name      string
+	KeyPrefix string
+	KeyHash   string
+	Scopes    []string
+	CreatedBy int64
+}
+
+func (q *Queries) CreateStoreAPIKey(ctx context.Context, arg CreateStoreAPIKeyParams) (StoreApiKey, error) {
+	row := q.db.QueryRowContext(ctx, createStoreAPIKey, arg.StoreID, arg.Name, arg.KeyPrefix, arg.KeyHash, pq.Array(arg.Scopes), arg.CreatedBy)
+	var i StoreApiKey
+	err := row.Scan(
+		&i.ApiKeyID,
+		&i.StoreID,
+		&i.Name,
+		&i.KeyPrefix,
+		&i.KeyHash,
+		pq.Array(&i.Scopes),
+		&i.CreatedBy,
+		&i.LastUsedAt,
+		&i.RevokedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createStoreInvitation = `-- name: CreateStoreInvitation :one
+INSERT INTO store_invitation (store_id, email, role, token_hash, invited_by, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING store_invitation_id, store_id, email, role, token_hash, invited_by, expires_at, accepted_at, created_at
+`
+
+type CreateStoreInvitationParams struct {
+	StoreID   int64
+	Email     string
+	Role      string
+	TokenHash string
+	InvitedBy int64
+	ExpiresAt time.Time
+}
+
+func (q *Queries) CreateStoreInvitation(ctx context.Context, arg CreateStoreInvitationParams) (StoreInvitation, error) {
+	row := q.db.QueryRowContext(ctx, createStoreInvitation, arg.StoreID, arg.Email, arg.Role, arg.TokenHash, arg.InvitedBy, arg.ExpiresAt)
+	var i StoreInvitation
+	err := row.Scan(
+		&i.StoreInvitationID,
+		&i.StoreID,
+		&i.Email,
+		&i.Role,
+		&i.TokenHash,
+		&i.InvitedBy,
+		&i.ExpiresAt,
+		&i.AcceptedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
 const createStoreOwner = `-- name: CreateStoreOwner :one
 INSERT INTO store_owner (
   name,
@@ -398,6 +1066,28 @@ func (q *Queries) CreateStoreOwner(ctx context.Context, arg CreateStoreOwnerPara
 	return i, err
 }
 
+const createStoreOwnerIdentity = `-- name: CreateStoreOwnerIdentity :exec
+INSERT INTO store_owner_identity (store_owner_id, provider, subject, email)
+VALUES ($1, $2, $3, $4)
+`
+
+type CreateStoreOwnerIdentityParams struct {
+	StoreOwnerID int64
+	Provider     string
+	Subject      string
+	Email        sql.NullString
+}
+
+func (q *Queries) CreateStoreOwnerIdentity(ctx context.Context, arg CreateStoreOwnerIdentityParams) error {
+	_, err := q.db.ExecContext(ctx, createStoreOwnerIdentity,
+		arg.StoreOwnerID,
+		arg.Provider,
+		arg.Subject,
+		arg.Email,
+	)
+	return err
+}
+
 const createVariant = `-- name: CreateVariant :one
 INSERT INTO product_variant (
   product_id, store_id, attribute_hash,
@@ -442,6 +1132,79 @@ func (q *Queries) CreateVariant(ctx context.Context, arg CreateVariantParams) (P
 	return i, err
 }
 
+const createVisitorSession = `-- name: CreateVisitorSession :one
+INSERT INTO visitor_session (store_id, ip_address, user_agent, is_returning)
+VALUES ($1, $2, $3, $4)
+RETURNING session_id, store_id, customer_id, ip_address, user_agent, first_seen_at, last_seen_at, is_returning
+`
+
+type CreateVisitorSessionParams struct {
+	StoreID     int64
+	IpAddress   pqtype.Inet
+	UserAgent   sql.NullString
+	IsReturning sql.NullBool
+}
+
+func (q *Queries) CreateVisitorSession(ctx context.Context, arg CreateVisitorSessionParams) (VisitorSession, error) {
+	row := q.db.QueryRowContext(ctx, createVisitorSession, arg.StoreID, arg.IpAddress, arg.UserAgent, arg.IsReturning)
+	var i VisitorSession
+	err := row.Scan(
+		&i.SessionID,
+		&i.StoreID,
+		&i.CustomerID,
+		&i.IpAddress,
+		&i.UserAgent,
+		&i.FirstSeenAt,
+		&i.LastSeenAt,
+		&i.IsReturning,
+	)
+	return i, err
+}
+
+const customerEmailUnverified = `-- name: CustomerEmailUnverified :one
+SELECT EXISTS (
+  SELECT 1
+  FROM customer
+  WHERE store_id = $1
+    AND LOWER(email) = LOWER($2::TEXT)
+    AND email_verified_at IS NULL
+)
+`
+
+type CustomerEmailUnverifiedParams struct {
+	StoreID int64
+	Email   string
+}
+
+// Whether a customer of the store has the email address but has not
+// verified it. Matched like LinkGuestOrdersToCustomer, ignoring case.
+func (q *Queries) CustomerEmailUnverified(ctx context.Context, arg CustomerEmailUnverifiedParams) (bool, error) {
+	row := q.db.QueryRowContext(ctx, customerEmailUnverified, arg.StoreID, arg.Email)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const customerHasOrders = `-- name: CustomerHasOrders :one
+SELECT EXISTS (
+  SELECT 1
+  FROM customer_order
+  WHERE store_id = $1 AND customer_id = $2
+)
+`
+
+type CustomerHasOrdersParams struct {
+	StoreID    int64
+	CustomerID sql.NullInt64
+}
+
+func (q *Queries) CustomerHasOrders(ctx context.Context, arg CustomerHasOrdersParams) (bool, error) {
+	row := q.db.QueryRowContext(ctx, customerHasOrders, arg.StoreID, arg.CustomerID)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
 const decreaseVariantStock = `-- name: DecreaseVariantStock :exec
 UPDATE product_variant
 SET stock_quantity = stock_quantity - $2,
@@ -469,508 +1232,2692 @@ func (q *Queries) DeleteCart(ctx context.Context, cartID int64) error {
 	return err
 }
 
-const deleteStore = `-- name: DeleteStore :exec
-DELETE FROM store
-WHERE store_id = $1
+const deleteCartItem = `-- name: DeleteCartItem :one
+WITH d AS (
+  DELETE FROM cart_item
+  WHERE cart_item_id = $1
+    AND cart_id = $2
+  RETURNING variant_id
+)
+SELECT d.variant_id, v.product_id
+FROM d
+JOIN product_variant v ON v.variant_id = d.variant_id
 `
 
-func (q *Queries) DeleteStore(ctx context.Context, storeID int64) error {
-	_, err := q.db.ExecContext(ctx, deleteStore, storeID)
-	return err
+type DeleteCartItemParams struct {
+	CartItemID int64
+	CartID     int64
 }
 
-const getAdminByEmail = `-- name: GetAdminByEmail :one
-SELECT admin_id, email, password_hash
-FROM admin
-WHERE email = $1
-`
-
-type GetAdminByEmailRow struct {
-	AdminID      int64
-	Email        string
-	PasswordHash string
+type DeleteCartItemRow struct {
+	VariantID int64
+	ProductID int64
 }
 
-func (q *Queries) GetAdminByEmail(ctx context.Context, email string) (GetAdminByEmailRow, error) {
-	row := q.db.QueryRowContext(ctx, getAdminByEmail, email)
-	var i GetAdminByEmailRow
-	err := row.Scan(&i.AdminID, &i.Email, &i.PasswordHash)
+func (q *Queries) DeleteCartItem(ctx context.Context, arg DeleteCartItemParams) (DeleteCartItemRow, error) {
+	row := q.db.QueryRowContext(ctx, deleteCartItem, arg.CartItemID, arg.CartID)
+	var i DeleteCartItemRow
+	err := row.Scan(&i.VariantID, &i.ProductID)
 	return i, err
 }
 
-const getCartByCustomerForUpdate = `-- name: GetCartByCustomerForUpdate :one
-SELECT cart_id, store_id, session_id, customer_id, created_at, updated_at
-FROM cart
-WHERE store_id = $1 AND customer_id = $2
-FOR UPDATE
+const deleteCartItems = `-- name: DeleteCartItems :many
+WITH d AS (
+  DELETE FROM cart_item
+  WHERE cart_id = $1
+  RETURNING variant_id
+)
+SELECT d.variant_id, v.product_id
+FROM d
+JOIN product_variant v ON v.variant_id = d.variant_id
 `
 
-type GetCartByCustomerForUpdateParams struct {
-	StoreID    int64
-	CustomerID sql.NullInt64
+type DeleteCartItemsRow struct {
+	VariantID int64
+	ProductID int64
 }
 
-func (q *Queries) GetCartByCustomerForUpdate(ctx context.Context, arg GetCartByCustomerForUpdateParams) (Cart, error) {
-	row := q.db.QueryRowContext(ctx, getCartByCustomerForUpdate, arg.StoreID, arg.CustomerID)
-	var i Cart
-	err := row.Scan(
-		&i.CartID,
-		&i.StoreID,
-		&i.SessionID,
-		&i.CustomerID,
-		&i.CreatedAt,
-		&i.UpdatedAt,
-	)
-	return i, err
+func (q *Queries) DeleteCartItems(ctx context.Context, cartID int64) ([]DeleteCartItemsRow, error) {
+	rows, err := q.db.QueryContext(ctx, deleteCartItems, cartID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []DeleteCartItemsRow
+	for rows.Next() {
+		var i DeleteCartItemsRow
+		if err := rows.Scan(&i.VariantID, &i.ProductID); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
-const getCartBySession = `-- name: GetCartBySession :one
-SELECT
-  c.cart_id,
-  c.store_id,
-  c.updated_at
-FROM cart c
-WHERE c.session_id = $1
-  AND c.store_id = $2
-LIMIT 1
+const deleteCustomerCartEvents = `-- name: DeleteCustomerCartEvents :exec
+DELETE FROM cart_event
+WHERE session_id IN (
+  SELECT session_id FROM visitor_session WHERE customer_id = $1::BIGINT
+  UNION
+  SELECT session_id FROM customer_order WHERE customer_id = $1::BIGINT
+)
 `
 
-type GetCartBySessionParams struct {
-	SessionID uuid.UUID
-	StoreID   int64
+func (q *Queries) DeleteCustomerCartEvents(ctx context.Context, customerID int64) error {
+	_, err := q.db.ExecContext(ctx, deleteCustomerCartEvents, customerID)
+	return err
 }
 
-type GetCartBySessionRow struct {
-	CartID    int64
-	StoreID   int64
-	UpdatedAt sql.NullTime
+const deleteCustomerCarts = `-- name: DeleteCustomerCarts :exec
+DELETE FROM cart
+WHERE customer_id = $1::BIGINT
+`
+
+func (q *Queries) DeleteCustomerCarts(ctx context.Context, customerID int64) error {
+	_, err := q.db.ExecContext(ctx, deleteCustomerCarts, customerID)
+	return err
 }
 
-func (q *Queries) GetCartBySession(ctx context.Context, arg GetCartBySessionParams) (GetCartBySessionRow, error) {
-	row := q.db.QueryRowContext(ctx, getCartBySession, arg.SessionID, arg.StoreID)
-	var i GetCartBySessionRow
-	err := row.Scan(&i.CartID, &i.StoreID, &i.UpdatedAt)
-	return i, err
+const deleteCustomerProductViews = `-- name: DeleteCustomerProductViews :exec
+DELETE FROM product_view
+WHERE session_id IN (
+  SELECT session_id FROM visitor_session WHERE customer_id = $1::BIGINT
+  UNION
+  SELECT session_id FROM customer_order WHERE customer_id = $1::BIGINT
+)
+`
+
+func (q *Queries) DeleteCustomerProductViews(ctx context.Context, customerID int64) error {
+	_, err := q.db.ExecContext(ctx, deleteCustomerProductViews, customerID)
+	return err
 }
 
-const getCartBySessionForUpdate = `-- name: GetCartBySessionForUpdate :one
-SELECT cart_id, store_id, session_id, customer_id, created_at, updated_at
-FROM cart
-WHERE store_id = $1 AND session_id = $2
-FOR UPDATE
+const deleteExpiredAccessTokens = `-- name: DeleteExpiredAccessTokens :exec
+DELETE FROM access_token
+WHERE expires_at < $1
 `
 
-type GetCartBySessionForUpdateParams struct {
-	StoreID   int64
-	SessionID uuid.UUID
+func (q *Queries) DeleteExpiredAccessTokens(ctx context.Context, expiresAt time.Time) error {
+	_, err := q.db.ExecContext(ctx, deleteExpiredAccessTokens, expiresAt)
+	return err
 }
 
-func (q *Queries) GetCartBySessionForUpdate(ctx context.Context, arg GetCartBySessionForUpdateParams) (Cart, error) {
-	row := q.db.QueryRowContext(ctx, getCartBySessionForUpdate, arg.StoreID, arg.SessionID)
-	var i Cart
-	err := row.Scan(
-		&i.CartID,
-		&i.StoreID,
-		&i.SessionID,
-		&i.CustomerID,
-		&i.CreatedAt,
-		&i.UpdatedAt,
-	)
-	return i, err
+const deleteExpiredOIDCLoginStates = `-- name: DeleteExpiredOIDCLoginStates :exec
+DELETE FROM oidc_login_state
+WHERE expires_at <= NOW()
+`
+
+func (q *Queries) DeleteExpiredOIDCLoginStates(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteExpiredOIDCLoginStates)
+	return err
 }
 
-const getCartForSession = `-- name: GetCartForSession :one
-SELECT cart_id, store_id, session_id, customer_id, created_at, updated_at
-FROM cart
-WHERE store_id = $1 AND session_id = $2
-FOR UPDATE
+const deleteExpiredRevokedTokens = `-- name: DeleteExpiredRevokedTokens :exec
+DELETE FROM revoked_token
+WHERE expires_at < $1
 `
 
-type GetCartForSessionParams struct {
-	StoreID   int64
-	SessionID uuid.UUID
+func (q *Queries) DeleteExpiredRevokedTokens(ctx context.Context, expiresAt time.Time) error {
+	_, err := q.db.ExecContext(ctx, deleteExpiredRevokedTokens, expiresAt)
+	return err
 }
 
-func (q *Queries) GetCartForSession(ctx context.Context, arg GetCartForSessionParams) (Cart, error) {
-	row := q.db.QueryRowContext(ctx, getCartForSession, arg.StoreID, arg.SessionID)
-	var i Cart
-	err := row.Scan(
-		&i.CartID,
-		&i.StoreID,
-		&i.SessionID,
-		&i.CustomerID,
-		&i.CreatedAt,
-		&i.UpdatedAt,
-	)
-	return i, err
+const deleteExpiredStockReservations = `-- name: DeleteExpiredStockReservations :execrows
+DELETE FROM stock_reservation
+WHERE cart_item_id IN (
+  SELECT cart_item_id
+  FROM stock_reservation
+  WHERE expires_at <= NOW()
+  LIMIT $1
+)
+`
+
+func (q *Queries) DeleteExpiredStockReservations(ctx context.Context, limit int32) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteExpiredStockReservations, limit)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
 
-const getCartItems = `-- name: GetCartItems :many
-SELECT
-	ci.cart_item_id,
-	ci.variant_id,
-	p.product_id,
-	p.name AS product_name,
-	v.sku,
-	v.primary_image_url,
-	ci.unit_price,
-	ci.quantity,
-	(ci.unit_price * ci.quantity)::NUMERIC AS subtotal
-FROM cart_item ci
-JOIN product_variant v ON v.variant_id = ci.variant_id
-JOIN product p ON p.product_id = v.product_id
-WHERE ci.cart_id = $1
-ORDER BY ci.created_at
+const deleteMFARecoveryCodes = `-- name: DeleteMFARecoveryCodes :exec
+DELETE FROM mfa_recovery_code
+WHERE user_id = $1 AND user_role = $2
 `
 
-type GetCartItemsRow struct {
-	CartItemID      int64
-	VariantID       int64
-	ProductID       int64
-	ProductName     string
-	Sku             string
-	PrimaryImageUrl sql.NullString
-	UnitPrice       string
-	Quantity        int32
-	Subtotal        string
+type DeleteMFARecoveryCodesParams struct {
+	UserID   int64
+	UserRole string
 }
 
-func (q *Queries) GetCartItems(ctx context.Context, cartID int64) ([]GetCartItemsRow, error) {
-	rows, err := q.db.QueryContext(ctx, getCartItems, cartID)
+func (q *Queries) DeleteMFARecoveryCodes(ctx context.Context, arg DeleteMFARecoveryCodesParams) error {
+	_, err := q.db.ExecContext(ctx, deleteMFARecoveryCodes, arg.UserID, arg.UserRole)
+	return err
+}
+
+const deletePendingStoreInvitations = `-- name: DeletePendingStoreInvitations :exec
+DELETE FROM store_invitation
+WHERE store_id = $1
+  AND LOWER(email) = LOWER($2)
+  AND accepted_at IS NULL
+`
+
+type DeletePendingStoreInvitationsParams struct {
+	StoreID int64
+	Email   string
+}
+
+func (q *Queries) DeletePendingStoreInvitations(ctx context.Context, arg DeletePendingStoreInvitationsParams) error {
+	_, err := q.db.ExecContext(ctx, deletePendingStoreInvitations, arg.StoreID, arg.Email)
+	return err
+}
+
+const deletePromotion = `-- name: DeletePromotion :execrows
+DELETE FROM promotion
+WHERE promotion_id = $1 AND store_id = $2
+`
+
+type DeletePromotionParams struct {
+	PromotionID int64
+	StoreID     int64
+}
+
+func (q *Queries) DeletePromotion(ctx context.Context, arg DeletePromotionParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deletePromotion, arg.PromotionID, arg.StoreID)
 	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []GetCartItemsRow
-	for rows.Next() {
-		var i GetCartItemsRow
-		if err := rows.Scan(
-			&i.CartItemID,
-			&i.VariantID,
-			&i.ProductID,
-			&i.ProductName,
-			&i.Sku,
-			&i.PrimaryImageUrl,
-			&i.UnitPrice,
-			&i.Quantity,
-			&i.Subtotal,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Close(); err != nil {
-		return nil, err
+		return 0, err
 	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
+	return result.RowsAffected()
 }
 
-const getCartItemsForUpdate = `-- name: GetCartItemsForUpdate :many
-SELECT
-  ci.cart_item_id,
-  ci.variant_id,
-  ci.quantity AS cart_quantity,
-  ci.unit_price,
-  v.stock_quantity AS available_stock,
-  (v.unit_price * ci.quantity)::NUMERIC AS subtotal
-FROM cart_item ci
-JOIN product_variant v ON v.variant_id = ci.variant_id
-WHERE ci.cart_id = $1
-FOR UPDATE
+const deleteStore = `-- name: DeleteStore :exec
+DELETE FROM store
+WHERE store_id = $1
 `
 
-type GetCartItemsForUpdateRow struct {
-	CartItemID     int64
-	VariantID      int64
-	CartQuantity   int32
-	UnitPrice      string
-	AvailableStock int32
-	Subtotal       string
+func (q *Queries) DeleteStore(ctx context.Context, storeID int64) error {
+	_, err := q.db.ExecContext(ctx, deleteStore, storeID)
+	return err
 }
 
-func (q *Queries) GetCartItemsForUpdate(ctx context.Context, cartID int64) ([]GetCartItemsForUpdateRow, error) {
-	rows, err := q.db.QueryContext(ctx, getCartItemsForUpdate, cartID)
+const deleteStoreInvitation = `-- name: DeleteStoreInvitation :execrows
+DELETE FROM store_invitation
+WHERE store_invitation_id = $1
+  AND store_id = $2
+  AND accepted_at IS NULL
+`
+
+type DeleteStoreInvitationParams struct {
+	StoreInvitationID int64
+	StoreID           int64
+}
+
+func (q *Queries) DeleteStoreInvitation(ctx context.Context, arg DeleteStoreInvitationParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteStoreInvitation, arg.StoreInvitationID, arg.StoreID)
 	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []GetCartItemsForUpdateRow
-	for rows.Next() {
-		var i GetCartItemsForUpdateRow
-		if err := rows.Scan(
-			&i.CartItemID,
-			&i.VariantID,
-			&i.CartQuantity,
-			&i.UnitPrice,
-			&i.AvailableStock,
-			&i.Subtotal,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
+		return 0, err
 	}
-	if err := rows.Close(); err != nil {
-		return nil, err
+	return result.RowsAffected()
+}
+
+const deleteUserAuthSessions = `-- name: DeleteUserAuthSessions :exec
+DELETE FROM auth_session
+WHERE user_id = $1 AND user_role = $2
+`
+
+type DeleteUserAuthSessionsParams struct {
+	UserID   int64
+	UserRole string
+}
+
+func (q *Queries) DeleteUserAuthSessions(ctx context.Context, arg DeleteUserAuthSessionsParams) error {
+	_, err := q.db.ExecContext(ctx, deleteUserAuthSessions, arg.UserID, arg.UserRole)
+	return err
+}
+
+const deleteUserEmailVerificationTokens = `-- name: DeleteUserEmailVerificationTokens :exec
+DELETE FROM email_verification_token
+WHERE user_id = $1 AND user_role = $2
+`
+
+type DeleteUserEmailVerificationTokensParams struct {
+	UserID   int64
+	UserRole string
+}
+
+func (q *Queries) DeleteUserEmailVerificationTokens(ctx context.Context, arg DeleteUserEmailVerificationTokensParams) error {
+	_, err := q.db.ExecContext(ctx, deleteUserEmailVerificationTokens, arg.UserID, arg.UserRole)
+	return err
+}
+
+const deleteUserMFA = `-- name: DeleteUserMFA :exec
+DELETE FROM user_mfa
+WHERE user_id = $1 AND user_role = $2
+`
+
+type DeleteUserMFAParams struct {
+	UserID   int64
+	UserRole string
+}
+
+func (q *Queries) DeleteUserMFA(ctx context.Context, arg DeleteUserMFAParams) error {
+	_, err := q.db.ExecContext(ctx, deleteUserMFA, arg.UserID, arg.UserRole)
+	return err
+}
+
+const deleteUserPasswordResetTokens = `-- name: DeleteUserPasswordResetTokens :exec
+DELETE FROM password_reset_token
+WHERE user_id = $1 AND user_role = $2
+`
+
+type DeleteUserPasswordResetTokensParams struct {
+	UserID   int64
+	UserRole string
+}
+
+func (q *Queries) DeleteUserPasswordResetTokens(ctx context.Context, arg DeleteUserPasswordResetTokensParams) error {
+	_, err := q.db.ExecContext(ctx, deleteUserPasswordResetTokens, arg.UserID, arg.UserRole)
+	return err
+}
+
+const deleteUserRefreshTokens = `-- name: DeleteUserRefreshTokens :exec
+DELETE FROM refresh_token
+WHERE user_id = $1 AND user_role = $2
+`
+
+type DeleteUserRefreshTokensParams struct {
+	UserID   int64
+	UserRole string
+}
+
+func (q *Queries) DeleteUserRefreshTokens(ctx context.Context, arg DeleteUserRefreshTokensParams) error {
+	_, err := q.db.ExecContext(ctx, deleteUserRefreshTokens, arg.UserID, arg.UserRole)
+	return err
+}
+
+const disableAdmin = `-- name: DisableAdmin :execrows
+UPDATE admin
+SET disabled_at = NOW()
+WHERE admin_id = $1 AND disabled_at IS NULL
+`
+
+func (q *Queries) DisableAdmin(ctx context.Context, adminID int64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, disableAdmin, adminID)
+	if err != nil {
+		return 0, err
 	}
-	if err := rows.Err(); err != nil {
-		return nil, err
+	return result.RowsAffected()
+}
+
+const enableAdmin = `-- name: EnableAdmin :execrows
+UPDATE admin
+SET disabled_at = NULL
+WHERE admin_id = $1 AND disabled_at IS NOT NULL
+`
+
+func (q *Queries) EnableAdmin(ctx context.Context, adminID int64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, enableAdmin, adminID)
+	if err != nil {
+		return 0, err
 	}
-	return items, nil
+	return result.RowsAffected()
 }
 
-const getCartTotal = `-- name: GetCartTotal :one
-SELECT
-  COALESCE(SUM(ci.unit_price * ci.quantity), 0)::NUMERIC AS total
-FROM cart_item ci
-WHERE ci.cart_id = $1
+const eraseCustomer = `-- name: EraseCustomer :exec
+UPDATE customer
+SET name = 'Deleted customer',
+    email = 'erased+' || customer_id || '@invalid',
+    password_hash = '!erased',
+    phone = NULL,
+    address = NULL,
+    email_verified_at = NULL,
+    erased_at = NOW()
+WHERE customer_id = $1
 `
 
-func (q *Queries) GetCartTotal(ctx context.Context, cartID int64) (string, error) {
-	row := q.db.QueryRowContext(ctx, getCartTotal, cartID)
-	var total string
-	err := row.Scan(&total)
-	return total, err
+func (q *Queries) EraseCustomer(ctx context.Context, customerID int64) error {
+	_, err := q.db.ExecContext(ctx, eraseCustomer, customerID)
+	return err
 }
 
-const getCustomerByEmail = `-- name: GetCustomerByEmail :one
-SELECT
-  customer_id,
-  store_id,
-  name,
-  email,
-  password_hash,
-  created_at
-FROM customer
-WHERE email = $1
-  AND store_id = $2
+const expireCartReminders = `-- name: ExpireCartReminders :exec
+UPDATE cart_reminder
+SET expires_at = NOW()
+WHERE cart_id = $1
+  AND cart_reminder_id <> $2
+  AND expires_at > NOW()
 `
 
-type GetCustomerByEmailParams struct {
-	Email   string
+type ExpireCartRemindersParams struct {
+	CartID         sql.NullInt64
+	CartReminderID int64
+}
+
+// Ends the links of the other reminders of a cart, once a newer one was
+// sent or one was used.
+func (q *Queries) ExpireCartReminders(ctx context.Context, arg ExpireCartRemindersParams) error {
+	_, err := q.db.ExecContext(ctx, expireCartReminders, arg.CartID, arg.CartReminderID)
+	return err
+}
+
+const failDataRequest = `-- name: FailDataRequest :exec
+UPDATE data_request
+SET status = CASE WHEN attempts >= $1::INT THEN 'failed' ELSE 'pending' END,
+    error = $2::TEXT,
+    completed_at = CASE WHEN attempts >= $1::INT THEN NOW() END
+WHERE data_request_id = $3
+`
+
+type FailDataRequestParams struct {
+	MaxAttempts   int32
+	Error         string
+	DataRequestID int64
+}
+
+func (q *Queries) FailDataRequest(ctx context.Context, arg FailDataRequestParams) error {
+	_, err := q.db.ExecContext(ctx, failDataRequest, arg.MaxAttempts, arg.Error, arg.DataRequestID)
+	return err
+}
+
+const getActivePromotionByCode = `-- name: GetActivePromotionByCode :one
+SELECT promotion_id, store_id, name, code, type, value, buy_quantity, get_quantity, min_subtotal, category_id, variant_ids, first_order_only, usage_limit, usage_limit_per_customer, starts_at, ends_at, is_active, created_at, updated_at
+FROM promotion
+WHERE store_id = $1
+  AND is_active
+  AND (starts_at IS NULL OR starts_at <= NOW())
+  AND (ends_at IS NULL OR ends_at > NOW())
+  AND UPPER(code) = UPPER($2::TEXT)
+`
+
+type GetActivePromotionByCodeParams struct {
 	StoreID int64
+	Code    string
 }
 
-type GetCustomerByEmailRow struct {
-	CustomerID   int64
-	StoreID      int64
-	Name         string
+func (q *Queries) GetActivePromotionByCode(ctx context.Context, arg GetActivePromotionByCodeParams) (Promotion, error) {
+	row := q.db.QueryRowContext(ctx, getActivePromotionByCode, arg.StoreID, arg.Code)
+	var i Promotion
+	err := row.Scan(
+		&i.PromotionID,
+		&i.StoreID,
+		&i.Name,
+		&i.Code,
+		&i.Type,
+		&i.Value,
+		&i.BuyQuantity,
+		&i.GetQuantity,
+		&i.MinSubtotal,
+		&i.CategoryID,
+		pq.Array(&i.VariantIds),
+		&i.FirstOrderOnly,
+		&i.UsageLimit,
+		&i.UsageLimitPerCustomer,
+		&i.StartsAt,
+		&i.EndsAt,
+		&i.IsActive,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getAdminByEmail = `-- name: GetAdminByEmail :one
+SELECT admin_id, email, password_hash, disabled_at
+FROM admin
+WHERE email = $1
+`
+
+type GetAdminByEmailRow struct {
+	AdminID      int64
 	Email        string
 	PasswordHash string
-	CreatedAt    time.Time
+	DisabledAt   sql.NullTime
 }
 
-func (q *Queries) GetCustomerByEmail(ctx context.Context, arg GetCustomerByEmailParams) (GetCustomerByEmailRow, error) {
-	row := q.db.QueryRowContext(ctx, getCustomerByEmail, arg.Email, arg.StoreID)
-	var i GetCustomerByEmailRow
+func (q *Queries) GetAdminByEmail(ctx context.Context, email string) (GetAdminByEmailRow, error) {
+	row := q.db.QueryRowContext(ctx, getAdminByEmail, email)
+	var i GetAdminByEmailRow
 	err := row.Scan(
-		&i.CustomerID,
-		&i.StoreID,
-		&i.Name,
+		&i.AdminID,
 		&i.Email,
 		&i.PasswordHash,
-		&i.CreatedAt,
+		&i.DisabledAt,
 	)
 	return i, err
 }
 
-const getProductBase = `-- name: GetProductBase :one
-SELECT
-  p.product_id,
-  p.store_id,
-  p.name,
-  p.slug,
-  p.description,
-  p.brand,
-  p.stock_quantity as total_stock,
-  p.category_id,
-  c.name as category_name,
-  p.default_variant_id,
-  v.price,
-  p.in_stock,
-  v.primary_image_url AS primary_image
-FROM product p
-JOIN product_variant v 
-  ON v.variant_id = p.default_variant_id
-JOIN category_definition c
-  ON p.category_id = c.category_id
-WHERE p.store_id = $1 AND p.product_id = $2 AND p.deleted_at IS NULL
+const getAdminByID = `-- name: GetAdminByID :one
+SELECT admin_id, email, created_by, disabled_at, created_at
+FROM admin
+WHERE admin_id = $1
 `
 
-type GetProductBaseParams struct {
-	StoreID   int64
-	ProductID int64
+type GetAdminByIDRow struct {
+	AdminID    int64
+	Email      string
+	CreatedBy  sql.NullInt64
+	DisabledAt sql.NullTime
+	CreatedAt  time.Time
 }
 
-type GetProductBaseRow struct {
-	ProductID        int64
-	StoreID          int64
-	Name             string
-	Slug             sql.NullString
-	Description      sql.NullString
-	Brand            sql.NullString
-	TotalStock       int32
-	CategoryID       int64
-	CategoryName     string
-	DefaultVariantID sql.NullInt64
-	Price            string
-	InStock          bool
-	PrimaryImage     sql.NullString
+func (q *Queries) GetAdminByID(ctx context.Context, adminID int64) (GetAdminByIDRow, error) {
+	row := q.db.QueryRowContext(ctx, getAdminByID, adminID)
+	var i GetAdminByIDRow
+	err := row.Scan(
+		&i.AdminID,
+		&i.Email,
+		&i.CreatedBy,
+		&i.DisabledAt,
+		&i.CreatedAt,
+	)
+	return i, err
 }
 
-func (q *Queries) GetProductBase(ctx context.Context, arg GetProductBaseParams) (GetProductBaseRow, error) {
-	row := q.db.QueryRowContext(ctx, getProductBase, arg.StoreID, arg.ProductID)
-	var i GetProductBaseRow
+const getAuthSession = `-- name: GetAuthSession :one
+SELECT session_id, user_id, user_role, store_id, ip_address, user_agent, created_at, last_used_at, revoked_at
+FROM auth_session
+WHERE session_id = $1
+`
+
+func (q *Queries) GetAuthSession(ctx context.Context, sessionID uuid.UUID) (AuthSession, error) {
+	row := q.db.QueryRowContext(ctx, getAuthSession, sessionID)
+	var i AuthSession
 	err := row.Scan(
-		&i.ProductID,
+		&i.SessionID,
+		&i.UserID,
+		&i.UserRole,
 		&i.StoreID,
-		&i.Name,
-		&i.Slug,
-		&i.Description,
-		&i.Brand,
-		&i.TotalStock,
-		&i.CategoryID,
-		&i.CategoryName,
-		&i.DefaultVariantID,
-		&i.Price,
-		&i.InStock,
-		&i.PrimaryImage,
+		&i.IpAddress,
+		&i.UserAgent,
+		&i.CreatedAt,
+		&i.LastUsedAt,
+		&i.RevokedAt,
 	)
 	return i, err
 }
 
-const getProductByIdentity = `-- name: GetProductByIdentity :one
-SELECT product_id, store_id, category_id, name, slug, description, brand, stock_quantity, created_at, updated_at, in_stock, deleted_at, default_variant_id
-FROM product
-WHERE store_id = $1
-  AND name = $2
-  AND category_id = $3
-  AND brand = $4
-  AND deleted_at IS NULL
-LIMIT 1
+const getCartByCustomerForUpdate = `-- name: GetCartByCustomerForUpdate :one
+SELECT cart_id, store_id, session_id, customer_id, created_at, updated_at, promotion_code
+FROM cart
+WHERE store_id = $1 AND customer_id = $2
+FOR UPDATE
 `
 
-type GetProductByIdentityParams struct {
+type GetCartByCustomerForUpdateParams struct {
 	StoreID    int64
-	Name       string
-	CategoryID int64
-	Brand      sql.NullString
+	CustomerID sql.NullInt64
 }
 
-func (q *Queries) GetProductByIdentity(ctx context.Context, arg GetProductByIdentityParams) (Product, error) {
-	row := q.db.QueryRowContext(ctx, getProductByIdentity,
-		arg.StoreID,
-		arg.Name,
-		arg.CategoryID,
-		arg.Brand,
-	)
-	var i Product
+func (q *Queries) GetCartByCustomerForUpdate(ctx context.Context, arg GetCartByCustomerForUpdateParams) (Cart, error) {
+	row := q.db.QueryRowContext(ctx, getCartByCustomerForUpdate, arg.StoreID, arg.CustomerID)
+	var i Cart
 	err := row.Scan(
-		&i.ProductID,
+		&i.CartID,
 		&i.StoreID,
-		&i.CategoryID,
-		&i.Name,
-		&i.Slug,
-		&i.Description,
-		&i.Brand,
-		&i.StockQuantity,
+		&i.SessionID,
+		&i.CustomerID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
-		&i.InStock,
-		&i.DeletedAt,
-		&i.DefaultVariantID,
+		&i.PromotionCode,
 	)
 	return i, err
 }
 
-const getProductByStoreAndName = `-- name: GetProductByStoreAndName :one
-SELECT product_id, store_id, category_id, name, slug, description, brand, stock_quantity, created_at, updated_at, in_stock, deleted_at, default_variant_id
-FROM product
-WHERE store_id = $1 AND name = $2 AND deleted_at IS NULL
+const getCartBySession = `-- name: GetCartBySession :one
+SELECT
+  c.cart_id,
+  c.store_id,
+  c.updated_at,
+  c.promotion_code
+FROM cart c
+WHERE c.session_id = $1
+  AND c.store_id = $2
 LIMIT 1
 `
 
-type GetProductByStoreAndNameParams struct {
-	StoreID int64
-	Name    string
+type GetCartBySessionParams struct {
+	SessionID uuid.UUID
+	StoreID   int64
 }
 
-func (q *Queries) GetProductByStoreAndName(ctx context.Context, arg GetProductByStoreAndNameParams) (Product, error) {
-	row := q.db.QueryRowContext(ctx, getProductByStoreAndName, arg.StoreID, arg.Name)
-	var i Product
+type GetCartBySessionRow struct {
+	CartID        int64
+	StoreID       int64
+	UpdatedAt     sql.NullTime
+	PromotionCode sql.NullString
+}
+
+func (q *Queries) GetCartBySession(ctx context.Context, arg GetCartBySessionParams) (GetCartBySessionRow, error) {
+	row := q.db.QueryRowContext(ctx, getCartBySession, arg.SessionID, arg.StoreID)
+	var i GetCartBySessionRow
 	err := row.Scan(
-		&i.ProductID,
+		&i.CartID,
 		&i.StoreID,
-		&i.CategoryID,
-		&i.Name,
-		&i.Slug,
-		&i.Description,
-		&i.Brand,
-		&i.StockQuantity,
+		&i.UpdatedAt,
+		&i.PromotionCode,
+	)
+	return i, err
+}
+
+const getCartBySessionForUpdate = `-- name: GetCartBySessionForUpdate :one
+SELECT cart_id, store_id, session_id, customer_id, created_at, updated_at, promotion_code
+FROM cart
+WHERE store_id = $1 AND session_id = $2
+FOR UPDATE
+`
+
+type GetCartBySessionForUpdateParams struct {
+	StoreID   int64
+	SessionID uuid.UUID
+}
+
+func (q *Queries) GetCartBySessionForUpdate(ctx context.Context, arg GetCartBySessionForUpdateParams) (Cart, error) {
+	row := q.db.QueryRowContext(ctx, getCartBySessionForUpdate, arg.StoreID, arg.SessionID)
+	var i Cart
+	err := row.Scan(
+		&i.CartID,
+		&i.StoreID,
+		&i.SessionID,
+		&i.CustomerID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
-		&i.InStock,
-		&i.DeletedAt,
-		&i.DefaultVariantID,
+		&i.PromotionCode,
 	)
 	return i, err
 }
 
-const getProductForUpdate = `-- name: GetProductForUpdate :one
-SELECT product_id, store_id, category_id, stock_quantity
-FROM product
-WHERE product_id = $1
+const getCartForSession = `-- name: GetCartForSession :one
+SELECT cart_id, store_id, session_id, customer_id, created_at, updated_at, promotion_code
+FROM cart
+WHERE store_id = $1 AND session_id = $2
 FOR UPDATE
 `
 
-type GetProductForUpdateRow struct {
-	ProductID     int64
-	StoreID       int64
-	CategoryID    int64
-	StockQuantity int32
+type GetCartForSessionParams struct {
+	StoreID   int64
+	SessionID uuid.UUID
 }
 
-func (q *Queries) GetProductForUpdate(ctx context.Context, productID int64) (GetProductForUpdateRow, error) {
-	row := q.db.QueryRowContext(ctx, getProductForUpdate, productID)
-	var i GetProductForUpdateRow
+func (q *Queries) GetCartForSession(ctx context.Context, arg GetCartForSessionParams) (Cart, error) {
+	row := q.db.QueryRowContext(ctx, getCartForSession, arg.StoreID, arg.SessionID)
+	var i Cart
 	err := row.Scan(
-		&i.ProductID,
+		&i.CartID,
 		&i.StoreID,
-		&i.CategoryID,
-		&i.StockQuantity,
+		&i.SessionID,
+		&i.CustomerID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.PromotionCode,
 	)
 	return i, err
 }
 
-const getProductVariantAttributes = `-- name: GetProductVariantAttributes :many
+const getCartItemForUpdate = `-- name: GetCartItemForUpdate :one
+SELECT cart_item_id, cart_id, variant_id, quantity, unit_price, created_at
+FROM cart_item
+WHERE cart_item_id = $1 AND cart_id = $2
+FOR UPDATE
+`
+
+type GetCartItemForUpdateParams struct {
+	CartItemID int64
+	CartID     int64
+}
+
+func (q *Queries) GetCartItemForUpdate(ctx context.Context, arg GetCartItemForUpdateParams) (CartItem, error) {
+	row := q.db.QueryRowContext(ctx, getCartItemForUpdate, arg.CartItemID, arg.CartID)
+	var i CartItem
+	err := row.Scan(
+		&i.CartItemID,
+		&i.CartID,
+		&i.VariantID,
+		&i.Quantity,
+		&i.UnitPrice,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getCartItems = `-- name: GetCartItems :many
 SELECT
-  ad.attribute_id,
-  ad.name as attribute_name,
-  vav.value as attribute_value
-FROM variant_attribute_value vav
-JOIN attribute_definition ad 
-  ON vav.attribute_id = ad.attribute_id
-WHERE vav.variant_id = $1
+	ci.cart_item_id,
+	ci.variant_id,
+	p.product_id,
+	p.name AS product_name,
+	p.category_id,
+	v.sku,
+	v.primary_image_url,
+	ci.unit_price,
+	ci.quantity,
+	(ci.unit_price * ci.quantity)::NUMERIC AS subtotal
+FROM cart_item ci
+JOIN product_variant v ON v.variant_id = ci.variant_id
+JOIN product p ON p.product_id = v.product_id
+WHERE ci.cart_id = $1
+ORDER BY ci.created_at
 `
 
-type GetProductVariantAttributesRow struct {
-	AttributeID    int64
-	AttributeName  string
-	AttributeValue string
+type GetCartItemsRow struct {
+	CartItemID      int64
+	VariantID       int64
+	ProductID       int64
+	ProductName     string
+	CategoryID      int64
+	Sku             string
+	PrimaryImageUrl sql.NullString
+	UnitPrice       string
+	Quantity        int32
+	Subtotal        string
+}
+
+func (q *Queries) GetCartItems(ctx context.Context, cartID int64) ([]GetCartItemsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getCartItems, cartID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetCartItemsRow
+	for rows.Next() {
+		var i GetCartItemsRow
+		if err := rows.Scan(
+			&i.CartItemID,
+			&i.VariantID,
+			&i.ProductID,
+			&i.ProductName,
+			&i.CategoryID,
+			&i.Sku,
+			&i.PrimaryImageUrl,
+			&i.UnitPrice,
+			&i.Quantity,
+			&i.Subtotal,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
-func (q *Queries) GetProductVariantAttributes(ctx context.Context, variantID int64) ([]GetProductVariantAttributesRow, error) {
-	rows, err := q.db.QueryContext(ctx, getProductVariantAttributes, variantID)
+const getCartItemsForUpdate = `-- name: GetCartItemsForUpdate :many
+SELECT
+  ci.cart_item_id,
+  ci.variant_id,
+  v.product_id,
+  p.name AS product_name,
+  p.category_id,
+  ci.quantity AS cart_quantity,
+  ci.unit_price,
+  v.price AS current_price,
+  (v.stock_quantity - COALESCE((
+    SELECT SUM(LEAST(r.quantity, rci.quantity))
+    FROM stock_reservation r
+    JOIN cart_item rci ON rci.cart_item_id = r.cart_item_id
+    WHERE r.variant_id = v.variant_id
+      AND rci.cart_id <> ci.cart_id
+      AND r.expires_at > NOW()
+  ), 0))::INT AS available_stock,
+  (v.deleted_at IS NOT NULL OR p.deleted_at IS NOT NULL)::BOOLEAN AS discontinued,
+  (v.price * ci.quantity)::NUMERIC AS subtotal
+FROM cart_item ci
+JOIN product_variant v ON v.variant_id = ci.variant_id
+JOIN product p ON p.product_id = v.product_id
+WHERE ci.cart_id = $1
+ORDER BY ci.variant_id
+FOR UPDATE OF ci, v
+`
+
+type GetCartItemsForUpdateRow struct {
+	CartItemID     int64
+	VariantID      int64
+	ProductID      int64
+	ProductName    string
+	CategoryID     int64
+	CartQuantity   int32
+	UnitPrice      string
+	CurrentPrice   string
+	AvailableStock int32
+	Discontinued   bool
+	Subtotal       string
+}
+
+func (q *Queries) GetCartItemsForUpdate(ctx context.Context, cartID int64) ([]GetCartItemsForUpdateRow, error) {
+	rows, err := q.db.QueryContext(ctx, getCartItemsForUpdate, cartID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetCartItemsForUpdateRow
+	for rows.Next() {
+		var i GetCartItemsForUpdateRow
+		if err := rows.Scan(
+			&i.CartItemID,
+			&i.VariantID,
+			&i.ProductID,
+			&i.ProductName,
+			&i.CategoryID,
+			&i.CartQuantity,
+			&i.UnitPrice,
+			&i.CurrentPrice,
+			&i.AvailableStock,
+			&i.Discontinued,
+			&i.Subtotal,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getCartReminderByTokenForUpdate = `-- name: GetCartReminderByTokenForUpdate :one
+SELECT cart_reminder_id, cart_id, store_id, cart_activity_at, reminder_number, token_hash, expires_at, sent_at, recovered_at, order_id
+FROM cart_reminder
+WHERE token_hash = $1
+  AND expires_at > NOW()
+  AND recovered_at IS NULL
+FOR UPDATE
+`
+
+func (q *Queries) GetCartReminderByTokenForUpdate(ctx context.Context, tokenHash string) (CartReminder, error) {
+	row := q.db.QueryRowContext(ctx, getCartReminderByTokenForUpdate, tokenHash)
+	var i CartReminder
+	err := row.Scan(
+		&i.CartReminderID,
+		&i.CartID,
+		&i.StoreID,
+		&i.CartActivityAt,
+		&i.ReminderNumber,
+		&i.TokenHash,
+		&i.ExpiresAt,
+		&i.SentAt,
+		&i.RecoveredAt,
+		&i.OrderID,
+	)
+	return i, err
+}
+
+const getCartTotal = `-- name: GetCartTotal :one
+SELECT
+  COALESCE(SUM(ci.unit_price * ci.quantity), 0)::NUMERIC AS total
+FROM cart_item ci
+WHERE ci.cart_id = $1
+`
+
+func (q *Queries) GetCartTotal(ctx context.Context, cartID int64) (string, error) {
+	row := q.db.QueryRowContext(ctx, getCartTotal, cartID)
+	var total string
+	err := row.Scan(&total)
+	return total, err
+}
+
+const getCustomerByEmail = `-- name: GetCustomerByEmail :one
+SELECT
+  customer_id,
+  store_id,
+  name,
+  email,
+  password_hash,
+  created_at
+FROM customer
+WHERE email = $1
+  AND store_id = $2
+`
+
+type GetCustomerByEmailParams struct {
+	Email   string
+	StoreID int64
+}
+
+type GetCustomerByEmailRow struct {
+	CustomerID   int64
+	StoreID      int64
+	Name         string
+	Email        string
+	PasswordHash string
+	CreatedAt    time.Time
+}
+
+func (q *Queries) GetCustomerByEmail(ctx context.Context, arg GetCustomerByEmailParams) (GetCustomerByEmailRow, error) {
+	row := q.db.QueryRowContext(ctx, getCustomerByEmail, arg.Email, arg.StoreID)
+	var i GetCustomerByEmailRow
+	err := row.Scan(
+		&i.CustomerID,
+		&i.StoreID,
+		&i.Name,
+		&i.Email,
+		&i.PasswordHash,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getCustomerByID = `-- name: GetCustomerByID :one
+SELECT
+  customer_id,
+  store_id,
+  name,
+  email,
+  password_hash,
+  email_verified_at,
+  erased_at,
+  created_at
+FROM customer
+WHERE customer_id = $1
+`
+
+type GetCustomerByIDRow struct {
+	CustomerID      int64
+	StoreID         int64
+	Name            string
+	Email           string
+	PasswordHash    string
+	EmailVerifiedAt sql.NullTime
+	ErasedAt        sql.NullTime
+	CreatedAt       time.Time
+}
+
+func (q *Queries) GetCustomerByID(ctx context.Context, customerID int64) (GetCustomerByIDRow, error) {
+	row := q.db.QueryRowContext(ctx, getCustomerByID, customerID)
+	var i GetCustomerByIDRow
+	err := row.Scan(
+		&i.CustomerID,
+		&i.StoreID,
+		&i.Name,
+		&i.Email,
+		&i.PasswordHash,
+		&i.EmailVerifiedAt,
+		&i.ErasedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getCustomerProfile = `-- name: GetCustomerProfile :one
+SELECT
+  customer_id,
+  store_id,
+  name,
+  email,
+  phone,
+  address,
+  email_verified_at,
+  created_at
+FROM customer
+WHERE customer_id = $1
+`
+
+type GetCustomerProfileRow struct {
+	CustomerID      int64
+	StoreID         int64
+	Name            string
+	Email           string
+	Phone           sql.NullString
+	Address         types.NullableAddress
+	EmailVerifiedAt sql.NullTime
+	CreatedAt       time.Time
+}
+
+func (q *Queries) GetCustomerProfile(ctx context.Context, customerID int64) (GetCustomerProfileRow, error) {
+	row := q.db.QueryRowContext(ctx, getCustomerProfile, customerID)
+	var i GetCustomerProfileRow
+	err := row.Scan(
+		&i.CustomerID,
+		&i.StoreID,
+		&i.Name,
+		&i.Email,
+		&i.Phone,
+		&i.Address,
+		&i.EmailVerifiedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getDataRequest = `-- name: GetDataRequest :one
+SELECT data_request_id, store_id, customer_id, request_type, status, requested_by, archive_key, error, attempts, created_at, started_at, completed_at
+FROM data_request
+WHERE data_request_id = $1
+`
+
+func (q *Queries) GetDataRequest(ctx context.Context, dataRequestID int64) (DataRequest, error) {
+	row := q.db.QueryRowContext(ctx, getDataRequest, dataRequestID)
+	var i DataRequest
+	err := row.Scan(
+		&i.DataRequestID,
+		&i.StoreID,
+		&i.CustomerID,
+		&i.RequestType,
+		&i.Status,
+		&i.RequestedBy,
+		&i.ArchiveKey,
+		&i.Error,
+		&i.Attempts,
+		&i.CreatedAt,
+		&i.StartedAt,
+		&i.CompletedAt,
+	)
+	return i, err
+}
+
+const getEmailVerificationTokenForUpdate = `-- name: GetEmailVerificationTokenForUpdate :one
+SELECT email_verification_token_id, token_hash, user_id, user_role, store_id, email, expires_at, used_at, created_at
+FROM email_verification_token
+WHERE token_hash = $1
+  AND used_at IS NULL
+  AND expires_at > NOW()
+FOR UPDATE
+`
+
+func (q *Queries) GetEmailVerificationTokenForUpdate(ctx context.Context, tokenHash string) (EmailVerificationToken, error) {
+	row := q.db.QueryRowContext(ctx, getEmailVerificationTokenForUpdate, tokenHash)
+	var i EmailVerificationToken
+	err := row.Scan(
+		&i.EmailVerificationTokenID,
+		&i.TokenHash,
+		&i.UserID,
+		&i.UserRole,
+		&i.StoreID,
+		&i.Email,
+		&i.ExpiresAt,
+		&i.UsedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getPasswordResetToken = `-- name: GetPasswordResetToken :one
+SELECT password_reset_token_id, token_hash, user_id, user_role, store_id, expires_at, used_at, created_at
+FROM password_reset_token
+WHERE token_hash = $1
+  AND used_at IS NULL
+  AND expires_at > NOW()
+`
+
+// Reads a usable reset token without locking it, for the checks that are
+// too slow to run while it is locked.
+func (q *Queries) GetPasswordResetToken(ctx context.Context, tokenHash string) (PasswordResetToken, error) {
+	row := q.db.QueryRowContext(ctx, getPasswordResetToken, tokenHash)
+	var i PasswordResetToken
+	err := row.Scan(
+		&i.PasswordResetTokenID,
+		&i.TokenHash,
+		&i.UserID,
+		&i.UserRole,
+		&i.StoreID,
+		&i.ExpiresAt,
+		&i.UsedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getPasswordResetTokenForUpdate = `-- name: GetPasswordResetTokenForUpdate :one
+SELECT password_reset_token_id, token_hash, user_id, user_role, store_id, expires_at, used_at, created_at
+FROM password_reset_token
+WHERE token_hash = $1
+  AND used_at IS NULL
+  AND expires_at > NOW()
+FOR UPDATE
+`
+
+func (q *Queries) GetPasswordResetTokenForUpdate(ctx context.Context, tokenHash string) (PasswordResetToken, error) {
+	row := q.db.QueryRowContext(ctx, getPasswordResetTokenForUpdate, tokenHash)
+	var i PasswordResetToken
+	err := row.Scan(
+		&i.PasswordResetTokenID,
+		&i.TokenHash,
+		&i.UserID,
+		&i.UserRole,
+		&i.StoreID,
+		&i.ExpiresAt,
+		&i.UsedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getProductBase = `-- name: GetProductBase :one
+SELECT
+  p.product_id,
+  p.store_id,
+  p.name,
+  p.slug,
+  p.description,
+  p.brand,
+  p.stock_quantity as total_stock,
+  p.category_id,
+  c.name as category_name,
+  p.default_variant_id,
+  v.price,
+  p.in_stock,
+  v.primary_image_url AS primary_image
+FROM product p
+JOIN product_variant v 
+  ON v.variant_id = p.default_variant_id
+JOIN category_definition c
+  ON p.category_id = c.category_id
+WHERE p.store_id = $1 AND p.product_id = $2 AND p.deleted_at IS NULL
+`
+
+type GetProductBaseParams struct {
+	StoreID   int64
+	ProductID int64
+}
+
+type GetProductBaseRow struct {
+	ProductID        int64
+	StoreID          int64
+	Name             string
+	Slug             sql.NullString
+	Description      sql.NullString
+	Brand            sql.NullString
+	TotalStock       int32
+	CategoryID       int64
+	CategoryName     string
+	DefaultVariantID sql.NullInt64
+	Price            string
+	InStock          bool
+	PrimaryImage     sql.NullString
+}
+
+func (q *Queries) GetProductBase(ctx context.Context, arg GetProductBaseParams) (GetProductBaseRow, error) {
+	row := q.db.QueryRowContext(ctx, getProductBase, arg.StoreID, arg.ProductID)
+	var i GetProductBaseRow
+	err := row.Scan(
+		&i.ProductID,
+		&i.StoreID,
+		&i.Name,
+		&i.Slug,
+		&i.Description,
+		&i.Brand,
+		&i.TotalStock,
+		&i.CategoryID,
+		&i.CategoryName,
+		&i.DefaultVariantID,
+		&i.Price,
+		&i.InStock,
+		&i.PrimaryImage,
+	)
+	return i, err
+}
+
+const getProductByIdentity = `-- name: GetProductByIdentity :one
+SELECT product_id, store_id, category_id, name, slug, description, brand, stock_quantity, created_at, updated_at, in_stock, deleted_at, default_variant_id
+FROM product
+WHERE store_id = $1
+  AND name = $2
+  AND category_id = $3
+  AND brand = $4
+  AND deleted_at IS NULL
+LIMIT 1
+`
+
+type GetProductByIdentityParams struct {
+	StoreID    int64
+	Name       string
+	CategoryID int64
+	Brand      sql.NullString
+}
+
+func (q *Queries) GetProductByIdentity(ctx context.Context, arg GetProductByIdentityParams) (Product, error) {
+	row := q.db.QueryRowContext(ctx, getProductByIdentity,
+		arg.StoreID,
+		arg.Name,
+		arg.CategoryID,
+		arg.Brand,
+	)
+	var i Product
+	err := row.Scan(
+		&i.ProductID,
+		&i.StoreID,
+		&i.CategoryID,
+		&i.Name,
+		&i.Slug,
+		&i.Description,
+		&i.Brand,
+		&i.StockQuantity,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.InStock,
+		&i.DeletedAt,
+		&i.DefaultVariantID,
+	)
+	return i, err
+}
+
+const getProductByStoreAndName = `-- name: GetProductByStoreAndName :one
+SELECT product_id, store_id, category_id, name, slug, description, brand, stock_quantity, created_at, updated_at, in_stock, deleted_at, default_variant_id
+FROM product
+WHERE store_id = $1 AND name = $2 AND deleted_at IS NULL
+LIMIT 1
+`
+
+type GetProductByStoreAndNameParams struct {
+	StoreID int64
+	Name    string
+}
+
+func (q *Queries) GetProductByStoreAndName(ctx context.Context, arg GetProductByStoreAndNameParams) (Product, error) {
+	row := q.db.QueryRowContext(ctx, getProductByStoreAndName, arg.StoreID, arg.Name)
+	var i Product
+	err := row.Scan(
+		&i.ProductID,
+		&i.StoreID,
+		&i.CategoryID,
+		&i.Name,
+		&i.Slug,
+		&i.Description,
+		&i.Brand,
+		&i.StockQuantity,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.InStock,
+		&i.DeletedAt,
+		&i.DefaultVariantID,
+	)
+	return i, err
+}
+
+const getProductForUpdate = `-- name: GetProductForUpdate :one
+SELECT product_id, store_id, category_id, stock_quantity
+FROM product
+WHERE product_id = $1
+FOR UPDATE
+`
+
+type GetProductForUpdateRow struct {
+	ProductID     int64
+	StoreID       int64
+	CategoryID    int64
+	StockQuantity int32
+}
+
+func (q *Queries) GetProductForUpdate(ctx context.Context, productID int64) (GetProductForUpdateRow, error) {
+	row := q.db.QueryRowContext(ctx, getProductForUpdate, productID)
+	var i GetProductForUpdateRow
+	err := row.Scan(
+		&i.ProductID,
+		&i.StoreID,
+		&i.CategoryID,
+		&i.StockQuantity,
+	)
+	return i, err
+}
+
+const getProductVariantAttributes = `-- name: GetProductVariantAttributes :many
+SELECT
+  ad.attribute_id,
+  ad.name as attribute_name,
+  vav.value as attribute_value
+FROM variant_attribute_value vav
+JOIN attribute_definition ad 
+  ON vav.attribute_id = ad.attribute_id
+WHERE vav.variant_id = $1
+`
+
+type GetProductVariantAttributesRow struct {
+	AttributeID    int64
+	AttributeName  string
+	AttributeValue string
+}
+
+func (q *Queries) GetProductVariantAttributes(ctx context.Context, variantID int64) ([]GetProductVariantAttributesRow, error) {
+	rows, err := q.db.QueryContext(ctx, getProductVariantAttributes, variantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetProductVariantAttributesRow
+	for rows.Next() {
+		var i GetProductVariantAttributesRow
+		if err := rows.Scan(&i.AttributeID, &i.AttributeName, &i.AttributeValue); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getProductVariants = `-- name: GetProductVariants :many
+SELECT
+  variant_id,
+  product_id,
+  sku,
+  price,
+  stock_quantity,
+  primary_image_url
+FROM product_variant
+WHERE product_id = $1 AND deleted_at IS NULL
+`
+
+type GetProductVariantsRow struct {
+	VariantID       int64
+	ProductID       int64
+	Sku             string
+	Price           string
+	StockQuantity   int32
+	PrimaryImageUrl sql.NullString
+}
+
+func (q *Queries) GetProductVariants(ctx context.Context, productID int64) ([]GetProductVariantsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getProductVariants, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetProductVariantsRow
+	for rows.Next() {
+		var i GetProductVariantsRow
+		if err := rows.Scan(
+			&i.VariantID,
+			&i.ProductID,
+			&i.Sku,
+			&i.Price,
+			&i.StockQuantity,
+			&i.PrimaryImageUrl,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPromotionUsage = `-- name: GetPromotionUsage :many
+SELECT
+  p.promotion_id,
+  (SELECT COUNT(*)
+   FROM order_discount od
+   JOIN customer_order o ON o.order_id = od.order_id
+   WHERE od.promotion_id = p.promotion_id
+     AND COALESCE(o.status, 'pending') NOT IN ('cancelled', 'refunded'))::INT AS times_used,
+  (SELECT COUNT(*)
+   FROM order_discount od
+   JOIN customer_order o ON o.order_id = od.order_id
+   WHERE od.promotion_id = p.promotion_id
+     AND o.customer_id = $1
+     AND COALESCE(o.status, 'pending') NOT IN ('cancelled', 'refunded'))::INT AS customer_times_used
+FROM promotion p
+WHERE p.promotion_id = ANY($2::BIGINT[])
+`
+
+type GetPromotionUsageParams struct {
+	CustomerID   sql.NullInt64
+	PromotionIds []int64
+}
+
+type GetPromotionUsageRow struct {
+	PromotionID       int64
+	TimesUsed         int32
+	CustomerTimesUsed int32
+}
+
+// Cancelled and refunded orders give their use back.
+func (q *Queries) GetPromotionUsage(ctx context.Context, arg GetPromotionUsageParams) ([]GetPromotionUsageRow, error) {
+	rows, err := q.db.QueryContext(ctx, getPromotionUsage, arg.CustomerID, pq.Array(arg.PromotionIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetPromotionUsageRow
+	for rows.Next() {
+		var i GetPromotionUsageRow
+		if err := rows.Scan(&i.PromotionID, &i.TimesUsed, &i.CustomerTimesUsed); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// #nosec G101
+const getRefreshTokenForUpdate = `-- name: GetRefreshTokenForUpdate :one
+SELECT refresh_token_id, token_hash, family_id, user_id, user_role, store_id, expires_at, revoked, mfa_verified, created_at
+FROM refresh_token
+WHERE token_hash = $1
+FOR UPDATE
+`
+
+func (q *Queries) GetRefreshTokenForUpdate(ctx context.Context, tokenHash string) (RefreshToken, error) {
+	row := q.db.QueryRowContext(ctx, getRefreshTokenForUpdate, tokenHash)
+	var i RefreshToken
+	err := row.Scan(
+		&i.RefreshTokenID,
+		&i.TokenHash,
+		&i.FamilyID,
+		&i.UserID,
+		&i.UserRole,
+		&i.StoreID,
+		&i.ExpiresAt,
+		&i.Revoked,
+		&i.MfaVerified,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getReservedStock = `-- name: GetReservedStock :one
+SELECT COALESCE(SUM(LEAST(r.quantity, ci.quantity)), 0)::INT AS reserved
+FROM stock_reservation r
+JOIN cart_item ci ON ci.cart_item_id = r.cart_item_id
+WHERE r.variant_id = $1
+  AND ci.cart_id <> $2
+  AND r.expires_at > NOW()
+`
+
+type GetReservedStockParams struct {
+	VariantID int64
+	CartID    int64
+}
+
+func (q *Queries) GetReservedStock(ctx context.Context, arg GetReservedStockParams) (int32, error) {
+	row := q.db.QueryRowContext(ctx, getReservedStock, arg.VariantID, arg.CartID)
+	var reserved int32
+	err := row.Scan(&reserved)
+	return reserved, err
+}
+
+const getStore = `-- name: GetStore :one
+SELECT store_id, store_owner_id, name, domain, download_status, currency, timezone, check_breached_passwords, stock_reservation_minutes, cart_reminder_delay_hours, cart_reminder_max, created_at, updated_at
+FROM store
+WHERE store_id = $1
+`
+
+func (q *Queries) GetStore(ctx context.Context, storeID int64) (Store, error) {
+	row := q.db.QueryRowContext(ctx, getStore, storeID)
+	var i Store
+	err := row.Scan(
+		&i.StoreID,
+		&i.StoreOwnerID,
+		&i.Name,
+		&i.Domain,
+		&i.DownloadStatus,
+		&i.Currency,
+		&i.Timezone,
+		&i.CheckBreachedPasswords,
+		&i.StockReservationMinutes,
+		&i.CartReminderDelayHours,
+		&i.CartReminderMax,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getStoreAPIKeyByHash = `-- name: GetStoreAPIKeyByHash :one
+SELECT api_key_id, store_id, name, key_prefix, key_hash, scopes, created_by, last_used_at, revoked_at, created_at
+FROM store_api_key
+WHERE key_hash = $1
+`
+
+func (q *Queries) GetStoreAPIKeyByHash(ctx context.Context, keyHash string) (StoreApiKey, error) {
+	row := q.db.QueryRowContext(ctx, getStoreAPIKeyByHash, keyHash)
+	var i StoreApiKey
+	err := row.Scan(
+		&i.ApiKeyID,
+		&i.StoreID,
+		&i.Name,
+		&i.KeyPrefix,
+		&i.KeyHash,
+		pq.Array(&i.Scopes),
+		&i.CreatedBy,
+		&i.LastUsedAt,
+		&i.RevokedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getStoreByOwnerID = `-- name: GetStoreByOwnerID :one
+SELECT store_id, store_owner_id, name, domain, download_status, currency, timezone, check_breached_passwords, stock_reservation_minutes, cart_reminder_delay_hours, cart_reminder_max, created_at, updated_at
+FROM store
+WHERE store_owner_id = $1
+`
+
+func (q *Queries) GetStoreByOwnerID(ctx context.Context, storeOwnerID int64) (Store, error) {
+	row := q.db.QueryRowContext(ctx, getStoreByOwnerID, storeOwnerID)
+	var i Store
+	err := row.Scan(
+		&i.StoreID,
+		&i.StoreOwnerID,
+		&i.Name,
+		&i.Domain,
+		&i.DownloadStatus,
+		&i.Currency,
+		&i.Timezone,
+		&i.CheckBreachedPasswords,
+		&i.StockReservationMinutes,
+		&i.CartReminderDelayHours,
+		&i.CartReminderMax,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getStoreInvitationForUpdate = `-- name: GetStoreInvitationForUpdate :one
+SELECT store_invitation_id, store_id, email, role, token_hash, invited_by, expires_at, accepted_at, created_at
+FROM store_invitation
+WHERE token_hash = $1
+  AND accepted_at IS NULL
+  AND expires_at > NOW()
+FOR UPDATE
+`
+
+func (q *Queries) GetStoreInvitationForUpdate(ctx context.Context, tokenHash string) (StoreInvitation, error) {
+	row := q.db.QueryRowContext(ctx, getStoreInvitationForUpdate, tokenHash)
+	var i StoreInvitation
+	err := row.Scan(
+		&i.StoreInvitationID,
+		&i.StoreID,
+		&i.Email,
+		&i.Role,
+		&i.TokenHash,
+		&i.InvitedBy,
+		&i.ExpiresAt,
+		&i.AcceptedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getStoreOwnerByEmail = `-- name: GetStoreOwnerByEmail :one
+SELECT
+  store_owner_id,
+  name,
+  email,
+  password_hash,
+  created_at
+FROM store_owner
+WHERE email = $1
+`
+
+type GetStoreOwnerByEmailRow struct {
+	StoreOwnerID int64
+	Name         string
+	Email        string
+	PasswordHash string
+	CreatedAt    time.Time
+}
+
+func (q *Queries) GetStoreOwnerByEmail(ctx context.Context, email string) (GetStoreOwnerByEmailRow, error) {
+	row := q.db.QueryRowContext(ctx, getStoreOwnerByEmail, email)
+	var i GetStoreOwnerByEmailRow
+	err := row.Scan(
+		&i.StoreOwnerID,
+		&i.Name,
+		&i.Email,
+		&i.PasswordHash,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getStoreOwnerByID = `-- name: GetStoreOwnerByID :one
+SELECT
+  store_owner_id,
+  name,
+  email,
+  password_hash,
+  email_verified_at,
+  created_at
+FROM store_owner
+WHERE store_owner_id = $1
+`
+
+type GetStoreOwnerByIDRow struct {
+	StoreOwnerID    int64
+	Name            string
+	Email           string
+	PasswordHash    string
+	EmailVerifiedAt sql.NullTime
+	CreatedAt       time.Time
+}
+
+func (q *Queries) GetStoreOwnerByID(ctx context.Context, storeOwnerID int64) (GetStoreOwnerByIDRow, error) {
+	row := q.db.QueryRowContext(ctx, getStoreOwnerByID, storeOwnerID)
+	var i GetStoreOwnerByIDRow
+	err := row.Scan(
+		&i.StoreOwnerID,
+		&i.Name,
+		&i.Email,
+		&i.PasswordHash,
+		&i.EmailVerifiedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getStoreOwnerIdentity = `-- name: GetStoreOwnerIdentity :one
+SELECT store_owner_identity_id, store_owner_id, provider, subject, email, created_at
+FROM store_owner_identity
+WHERE provider = $1 AND subject = $2
+`
+
+type GetStoreOwnerIdentityParams struct {
+	Provider string
+	Subject  string
+}
+
+func (q *Queries) GetStoreOwnerIdentity(ctx context.Context, arg GetStoreOwnerIdentityParams) (StoreOwnerIdentity, error) {
+	row := q.db.QueryRowContext(ctx, getStoreOwnerIdentity, arg.Provider, arg.Subject)
+	var i StoreOwnerIdentity
+	err := row.Scan(
+		&i.StoreOwnerIdentityID,
+		&i.StoreOwnerID,
+		&i.Provider,
+		&i.Subject,
+		&i.Email,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getStoreOwnerProfile = `-- name: GetStoreOwnerProfile :one
+SELECT
+  store_owner_id,
+  name,
+  email,
+  phone,
+  address,
+  email_verified_at,
+  created_at
+FROM store_owner
+WHERE store_owner_id = $1
+`
+
+type GetStoreOwnerProfileRow struct {
+	StoreOwnerID    int64
+	Name            string
+	Email           string
+	Phone           sql.NullString
+	Address         types.NullableAddress
+	EmailVerifiedAt sql.NullTime
+	CreatedAt       time.Time
+}
+
+func (q *Queries) GetStoreOwnerProfile(ctx context.Context, storeOwnerID int64) (GetStoreOwnerProfileRow, error) {
+	row := q.db.QueryRowContext(ctx, getStoreOwnerProfile, storeOwnerID)
+	var i GetStoreOwnerProfileRow
+	err := row.Scan(
+		&i.StoreOwnerID,
+		&i.Name,
+		&i.Email,
+		&i.Phone,
+		&i.Address,
+		&i.EmailVerifiedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getStoreRole = `-- name: GetStoreRole :one
+SELECT 'owner'::VARCHAR AS role
+FROM store
+WHERE store.store_id = $1 AND store.store_owner_id = $2
+UNION ALL
+SELECT m.role
+FROM store_member m
+WHERE m.store_id = $1 AND m.store_owner_id = $2
+LIMIT 1
+`
+
+type GetStoreRoleParams struct {
+	StoreID      int64
+	StoreOwnerID int64
+}
+
+func (q *Queries) GetStoreRole(ctx context.Context, arg GetStoreRoleParams) (string, error) {
+	row := q.db.QueryRowContext(ctx, getStoreRole, arg.StoreID, arg.StoreOwnerID)
+	var role string
+	err := row.Scan(&role)
+	return role, err
+}
+
+const getTopProductsByCategory = `-- name: GetTopProductsByCategory :many
+SELECT 
+  p.product_id,
+  p.store_id,
+  p.name,
+  p.slug,
+  p.description,
+  p.brand,
+  p.category_id,
+  p.default_variant_id,
+  p.stock_quantity as product_total_stock,
+  v.stock_quantity as item_stock,
+  v.price,
+  p.in_stock,
+  v.primary_image_url AS primary_image
+FROM product p
+JOIN product_variant v 
+  ON v.variant_id = p.default_variant_id
+WHERE 
+  p.store_id = $1 
+  AND p.category_id = $2
+  AND p.in_stock = TRUE
+  AND p.deleted_at IS NULL
+ORDER BY 
+  v.stock_quantity DESC
+LIMIT $3
+`
+
+type GetTopProductsByCategoryParams struct {
+	StoreID    int64
+	CategoryID int64
+	Limit      int32
+}
+
+type GetTopProductsByCategoryRow struct {
+	ProductID         int64
+	StoreID           int64
+	Name              string
+	Slug              sql.NullString
+	Description       sql.NullString
+	Brand             sql.NullString
+	CategoryID        int64
+	DefaultVariantID  sql.NullInt64
+	ProductTotalStock int32
+	ItemStock         int32
+	Price             string
+	InStock           bool
+	PrimaryImage      sql.NullString
+}
+
+func (q *Queries) GetTopProductsByCategory(ctx context.Context, arg GetTopProductsByCategoryParams) ([]GetTopProductsByCategoryRow, error) {
+	rows, err := q.db.QueryContext(ctx, getTopProductsByCategory, arg.StoreID, arg.CategoryID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetTopProductsByCategoryRow
+	for rows.Next() {
+		var i GetTopProductsByCategoryRow
+		if err := rows.Scan(
+			&i.ProductID,
+			&i.StoreID,
+			&i.Name,
+			&i.Slug,
+			&i.Description,
+			&i.Brand,
+			&i.CategoryID,
+			&i.DefaultVariantID,
+			&i.ProductTotalStock,
+			&i.ItemStock,
+			&i.Price,
+			&i.InStock,
+			&i.PrimaryImage,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUserMFA = `-- name: GetUserMFA :one
+SELECT user_mfa_id, user_id, user_role, totp_secret, confirmed_at, last_used_step, created_at
+FROM user_mfa
+WHERE user_id = $1 AND user_role = $2
+`
+
+type GetUserMFAParams struct {
+	UserID   int64
+	UserRole string
+}
+
+func (q *Queries) GetUserMFA(ctx context.Context, arg GetUserMFAParams) (UserMfa, error) {
+	row := q.db.QueryRowContext(ctx, getUserMFA, arg.UserID, arg.UserRole)
+	var i UserMfa
+	err := row.Scan(
+		&i.UserMfaID,
+		&i.UserID,
+		&i.UserRole,
+		&i.TotpSecret,
+		&i.ConfirmedAt,
+		&i.LastUsedStep,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getVariant = `-- name: GetVariant :one
+SELECT variant_id, product_id, store_id, attribute_hash, sku, price, stock_quantity, primary_image_url, created_at, updated_at, deleted_at
+FROM product_variant
+WHERE variant_id = $1
+  AND deleted_at IS NULL
+`
+
+func (q *Queries) GetVariant(ctx context.Context, variantID int64) (ProductVariant, error) {
+	row := q.db.QueryRowContext(ctx, getVariant, variantID)
+	var i ProductVariant
+	err := row.Scan(
+		&i.VariantID,
+		&i.ProductID,
+		&i.StoreID,
+		&i.AttributeHash,
+		&i.Sku,
+		&i.Price,
+		&i.StockQuantity,
+		&i.PrimaryImageUrl,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const getVariantByAttributeHash = `-- name: GetVariantByAttributeHash :one
+SELECT variant_id, product_id, store_id, attribute_hash, sku, price, stock_quantity, primary_image_url, created_at, updated_at, deleted_at
+FROM product_variant
+WHERE product_id = $1
+  AND attribute_hash = $2
+  AND deleted_at IS NULL
+LIMIT 1
+`
+
+type GetVariantByAttributeHashParams struct {
+	ProductID     int64
+	AttributeHash string
+}
+
+func (q *Queries) GetVariantByAttributeHash(ctx context.Context, arg GetVariantByAttributeHashParams) (ProductVariant, error) {
+	row := q.db.QueryRowContext(ctx, getVariantByAttributeHash, arg.ProductID, arg.AttributeHash)
+	var i ProductVariant
+	err := row.Scan(
+		&i.VariantID,
+		&i.ProductID,
+		&i.StoreID,
+		&i.AttributeHash,
+		&i.Sku,
+		&i.Price,
+		&i.StockQuantity,
+		&i.PrimaryImageUrl,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const getVariantForCart = `-- name: GetVariantForCart :one
+SELECT
+  variant_id,
+  product_id,
+  price,
+  stock_quantity
+FROM product_variant
+WHERE variant_id = $1
+  AND store_id = $2
+  AND deleted_at IS NULL
+FOR UPDATE
+`
+
+type GetVariantForCartParams struct {
+	VariantID int64
+	StoreID   int64
+}
+
+type GetVariantForCartRow struct {
+	VariantID     int64
+	ProductID     int64
+	Price         string
+	StockQuantity int32
+}
+
+func (q *Queries) GetVariantForCart(ctx context.Context, arg GetVariantForCartParams) (GetVariantForCartRow, error) {
+	row := q.db.QueryRowContext(ctx, getVariantForCart, arg.VariantID, arg.StoreID)
+	var i GetVariantForCartRow
+	err := row.Scan(
+		&i.VariantID,
+		&i.ProductID,
+		&i.Price,
+		&i.StockQuantity,
+	)
+	return i, err
+}
+
+const getVariantForUpdate = `-- name: GetVariantForUpdate :one
+SELECT variant_id, product_id, store_id, attribute_hash, sku, price, stock_quantity, primary_image_url, created_at, updated_at, deleted_at
+FROM product_variant
+WHERE variant_id = $1
+  AND deleted_at IS NULL
+FOR UPDATE
+`
+
+func (q *Queries) GetVariantForUpdate(ctx context.Context, variantID int64) (ProductVariant, error) {
+	row := q.db.QueryRowContext(ctx, getVariantForUpdate, variantID)
+	var i ProductVariant
+	err := row.Scan(
+		&i.VariantID,
+		&i.ProductID,
+		&i.StoreID,
+		&i.AttributeHash,
+		&i.Sku,
+		&i.Price,
+		&i.StockQuantity,
+		&i.PrimaryImageUrl,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const increaseVariantStock = `-- name: IncreaseVariantStock :exec
+UPDATE product_variant
+SET stock_quantity = stock_quantity + $2,
+    updated_at = NOW()
+WHERE variant_id = $1
+`
+
+type IncreaseVariantStockParams struct {
+	VariantID     int64
+	StockQuantity int32
+}
+
+func (q *Queries) IncreaseVariantStock(ctx context.Context, arg IncreaseVariantStockParams) error {
+	_, err := q.db.ExecContext(ctx, increaseVariantStock, arg.VariantID, arg.StockQuantity)
+	return err
+}
+
+const insertProductViews = `-- name: InsertProductViews :exec
+INSERT INTO product_view (product_id, store_id, session_id, viewed_at)
+SELECT v.product_id, v.store_id, v.session_id, v.viewed_at
+FROM (
+  SELECT
+    unnest($1::BIGINT[]) AS product_id,
+    unnest($2::BIGINT[]) AS store_id,
+    unnest($3::UUID[]) AS session_id,
+    unnest($4::TIMESTAMPTZ[]) AS viewed_at
+) AS v
+JOIN visitor_session vs ON vs.session_id = v.session_id AND vs.store_id = v.store_id
+`
+
+type InsertProductViewsParams struct {
+	ProductIds []int64
+	StoreIds   []int64
+	SessionIds []uuid.UUID
+	ViewedAt   []time.Time
+}
+
+func (q *Queries) InsertProductViews(ctx context.Context, arg InsertProductViewsParams) error {
+	_, err := q.db.ExecContext(ctx, insertProductViews,
+		pq.Array(arg.ProductIds),
+		pq.Array(arg.StoreIds),
+		pq.Array(arg.SessionIds),
+		pq.Array(arg.ViewedAt),
+	)
+	return err
+}
+
+const insertVariantAttribute = `-- name: InsertVariantAttribute :exec
+INSERT INTO variant_attribute_value (
+  variant_id, attribute_id, value
+)
+VALUES ($1, $2, $3)
+`
+
+type InsertVariantAttributeParams struct {
+	VariantID   int64
+	AttributeID int64
+	Value       string
+}
+
+func (q *Queries) InsertVariantAttribute(ctx context.Context, arg InsertVariantAttributeParams) error {
+	_, err := q.db.ExecContext(ctx, insertVariantAttribute, arg.VariantID, arg.AttributeID, arg.Value)
+	return err
+}
+
+const insertVariantImage = `-- name: InsertVariantImage :one
+INSERT INTO product_variant_image (product_variant_id, image_url)
+VALUES ($1, $2)
+RETURNING image_id, product_variant_id, image_url, created_at
+`
+
+type InsertVariantImageParams struct {
+	ProductVariantID int64
+	ImageUrl         string
+}
+
+func (q *Queries) InsertVariantImage(ctx context.Context, arg InsertVariantImageParams) (ProductVariantImage, error) {
+	row := q.db.QueryRowContext(ctx, insertVariantImage, arg.ProductVariantID, arg.ImageUrl)
+	var i ProductVariantImage
+	err := row.Scan(
+		&i.ImageID,
+		&i.ProductVariantID,
+		&i.ImageUrl,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const invalidateUserEmailVerificationTokens = `-- name: InvalidateUserEmailVerificationTokens :exec
+UPDATE email_verification_token
+SET used_at = NOW()
+WHERE user_id = $1 AND user_role = $2 AND used_at IS NULL
+`
+
+type InvalidateUserEmailVerificationTokensParams struct {
+	UserID   int64
+	UserRole string
+}
+
+func (q *Queries) InvalidateUserEmailVerificationTokens(ctx context.Context, arg InvalidateUserEmailVerificationTokensParams) error {
+	_, err := q.db.ExecContext(ctx, invalidateUserEmailVerificationTokens, arg.UserID, arg.UserRole)
+	return err
+}
+
+const invalidateUserPasswordResetTokens = `-- name: InvalidateUserPasswordResetTokens :exec
+UPDATE password_reset_token
+SET used_at = NOW()
+WHERE user_id = $1 AND user_role = $2 AND used_at IS NULL
+`
+
+type InvalidateUserPasswordResetTokensParams struct {
+	UserID   int64
+	UserRole string
+}
+
+func (q *Queries) InvalidateUserPasswordResetTokens(ctx context.Context, arg InvalidateUserPasswordResetTokensParams) error {
+	_, err := q.db.ExecContext(ctx, invalidateUserPasswordResetTokens, arg.UserID, arg.UserRole)
+	return err
+}
+
+const linkGuestOrdersToCustomer = `-- name: LinkGuestOrdersToCustomer :execrows
+UPDATE customer_order o
+SET customer_id = c.customer_id,
+    updated_at = NOW()
+FROM customer c
+WHERE c.customer_id = $1
+  AND o.store_id = c.store_id
+  AND o.customer_id IS NULL
+  AND LOWER(o.guest_email) = LOWER(c.email)
+`
+
+func (q *Queries) LinkGuestOrdersToCustomer(ctx context.Context, customerID int64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, linkGuestOrdersToCustomer, customerID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const linkVisitorSessionToCustomer = `-- name: LinkVisitorSessionToCustomer :exec
+UPDATE visitor_session
+SET customer_id = $1
+WHERE session_id = $2
+  AND store_id = $3
+`
+
+type LinkVisitorSessionToCustomerParams struct {
+	CustomerID sql.NullInt64
+	SessionID  uuid.UUID
+	StoreID    int64
+}
+
+func (q *Queries) LinkVisitorSessionToCustomer(ctx context.Context, arg LinkVisitorSessionToCustomerParams) error {
+	_, err := q.db.ExecContext(ctx, linkVisitorSessionToCustomer, arg.CustomerID, arg.SessionID, arg.StoreID)
+	return err
+}
+
+const listActivePromotions = `-- name: ListActivePromotions :many
+SELECT promotion_id, store_id, name, code, type, value, buy_quantity, get_quantity, min_subtotal, category_id, variant_ids, first_order_only, usage_limit, usage_limit_per_customer, starts_at, ends_at, is_active, created_at, updated_at
+FROM promotion
+WHERE store_id = $1
+  AND is_active
+  AND (starts_at IS NULL OR starts_at <= NOW())
+  AND (ends_at IS NULL OR ends_at > NOW())
+  AND (code IS NULL OR UPPER(code) = UPPER($2::TEXT))
+ORDER BY promotion_id
+`
+
+type ListActivePromotionsParams struct {
+	StoreID int64
+	Code    sql.NullString
+}
+
+func (q *Queries) ListActivePromotions(ctx context.Context, arg ListActivePromotionsParams) ([]Promotion, error) {
+	rows, err := q.db.QueryContext(ctx, listActivePromotions, arg.StoreID, arg.Code)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Promotion
+	for rows.Next() {
+		var i Promotion
+		if err := rows.Scan(
+			&i.PromotionID,
+			&i.StoreID,
+			&i.Name,
+			&i.Code,
+			&i.Type,
+			&i.Value,
+			&i.BuyQuantity,
+			&i.GetQuantity,
+			&i.MinSubtotal,
+			&i.CategoryID,
+			pq.Array(&i.VariantIds),
+			&i.FirstOrderOnly,
+			&i.UsageLimit,
+			&i.UsageLimitPerCustomer,
+			&i.StartsAt,
+			&i.EndsAt,
+			&i.IsActive,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listActiveUserSessions = `-- name: ListActiveUserSessions :many
+SELECT s.session_id, s.user_id, s.user_role, s.store_id, s.ip_address, s.user_agent, s.created_at, s.last_used_at, s.revoked_at
+FROM auth_session s
+WHERE s.user_id = $1
+  AND s.user_role = $2
+  AND s.revoked_at IS NULL
+  AND EXISTS (
+    SELECT 1
+    FROM refresh_token rt
+    WHERE rt.family_id = s.session_id
+      AND rt.revoked = FALSE
+      AND rt.expires_at > NOW()
+  )
+ORDER BY s.last_used_at DESC
+`
+
+type ListActiveUserSessionsParams struct {
+	UserID   int64
+	UserRole string
+}
+
+func (q *Queries) ListActiveUserSessions(ctx context.Context, arg ListActiveUserSessionsParams) ([]AuthSession, error) {
+	rows, err := q.db.QueryContext(ctx, listActiveUserSessions, arg.UserID, arg.UserRole)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AuthSession
+	for rows.Next() {
+		var i AuthSession
+		if err := rows.Scan(
+			&i.SessionID,
+			&i.UserID,
+			&i.UserRole,
+			&i.StoreID,
+			&i.IpAddress,
+			&i.UserAgent,
+			&i.CreatedAt,
+			&i.LastUsedAt,
+			&i.RevokedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAdminAuditLog = `-- name: ListAdminAuditLog :many
+SELECT admin_audit_log_id, admin_id, action, details, status_code, ip_address, created_at
+FROM admin_audit_log
+WHERE ($1::BIGINT IS NULL OR admin_id = $1)
+  AND ($2::BIGINT IS NULL OR admin_audit_log_id < $2)
+ORDER BY admin_audit_log_id DESC
+LIMIT $3
+`
+
+type ListAdminAuditLogParams struct {
+	AdminID  sql.NullInt64
+	BeforeID sql.NullInt64
+	Limit    int32
+}
+
+func (q *Queries) ListAdminAuditLog(ctx context.Context, arg ListAdminAuditLogParams) ([]AdminAuditLog, error) {
+	rows, err := q.db.QueryContext(ctx, listAdminAuditLog, arg.AdminID, arg.BeforeID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AdminAuditLog
+	for rows.Next() {
+		var i AdminAuditLog
+		if err := rows.Scan(
+			&i.AdminAuditLogID,
+			&i.AdminID,
+			&i.Action,
+			&i.Details,
+			&i.StatusCode,
+			&i.IpAddress,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAdmins = `-- name: ListAdmins :many
+SELECT admin_id, email, created_by, disabled_at, created_at
+FROM admin
+ORDER BY admin_id
+`
+
+type ListAdminsRow struct {
+	AdminID    int64
+	Email      string
+	CreatedBy  sql.NullInt64
+	DisabledAt sql.NullTime
+	CreatedAt  time.Time
+}
+
+func (q *Queries) ListAdmins(ctx context.Context) ([]ListAdminsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listAdmins)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListAdminsRow
+	for rows.Next() {
+		var i ListAdminsRow
+		if err := rows.Scan(
+			&i.AdminID,
+			&i.Email,
+			&i.CreatedBy,
+			&i.DisabledAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCartsDueForReminder = `-- name: ListCartsDueForReminder :many
+SELECT
+  c.cart_id,
+  c.store_id,
+  s.name AS store_name,
+  cu.name AS customer_name,
+  cu.email,
+  COALESCE(c.updated_at, c.created_at)::TIMESTAMPTZ AS cart_activity_at,
+  r.reminders_sent
+FROM cart c
+JOIN store s ON s.store_id = c.store_id
+JOIN customer cu ON cu.customer_id = c.customer_id
+CROSS JOIN LATERAL (
+  SELECT
+    COUNT(*)::INT AS reminders_sent,
+    MAX(cr.sent_at) AS last_sent_at
+  FROM cart_reminder cr
+  WHERE cr.cart_id = c.cart_id
+    AND cr.cart_activity_at = COALESCE(c.updated_at, c.created_at)
+) r
+WHERE s.cart_reminder_max > 0
+  AND r.reminders_sent < s.cart_reminder_max
+  AND COALESCE(r.last_sent_at, c.updated_at, c.created_at)
+      < NOW() - make_interval(hours => s.cart_reminder_delay_hours)
+  AND cu.email_verified_at IS NOT NULL
+  AND cu.erased_at IS NULL
+  AND EXISTS (SELECT 1 FROM cart_item ci WHERE ci.cart_id = c.cart_id)
+ORDER BY cart_activity_at
+LIMIT $1
+`
+
+type ListCartsDueForReminderRow struct {
+	CartID         int64
+	StoreID        int64
+	StoreName      string
+	CustomerName   string
+	Email          string
+	CartActivityAt time.Time
+	RemindersSent  int32
+}
+
+func (q *Queries) ListCartsDueForReminder(ctx context.Context, limit int32) ([]ListCartsDueForReminderRow, error) {
+	rows, err := q.db.QueryContext(ctx, listCartsDueForReminder, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListCartsDueForReminderRow
+	for rows.Next() {
+		var i ListCartsDueForReminderRow
+		if err := rows.Scan(
+			&i.CartID,
+			&i.StoreID,
+			&i.StoreName,
+			&i.CustomerName,
+			&i.Email,
+			&i.CartActivityAt,
+			&i.RemindersSent,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCategoriesByStore = `-- name: ListCategoriesByStore :many
+SELECT c.category_id, c.name, pc.name as parent_name
+FROM store_category s
+JOIN category_definition c
+  ON s.category_id = c.category_id
+JOIN category_definition pc
+  ON c.parent_id = pc.category_id
+WHERE s.store_id = $1
+ORDER BY c.name
+`
+
+type ListCategoriesByStoreRow struct {
+	CategoryID int64
+	Name       string
+	ParentName string
+}
+
+func (q *Queries) ListCategoriesByStore(ctx context.Context, storeID int64) ([]ListCategoriesByStoreRow, error) {
+	rows, err := q.db.QueryContext(ctx, listCategoriesByStore, storeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListCategoriesByStoreRow
+	for rows.Next() {
+		var i ListCategoriesByStoreRow
+		if err := rows.Scan(&i.CategoryID, &i.Name, &i.ParentName); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCategoryAttributes = `-- name: ListCategoryAttributes :many
+SELECT a.attribute_id, a.name, ca.is_required
+FROM category_attribute ca
+JOIN attribute_definition a 
+ON a.attribute_id = ca.attribute_id
+WHERE ca.category_id = $1
+`
+
+type ListCategoryAttributesRow struct {
+	AttributeID int64
+	Name        string
+	IsRequired  bool
+}
+
+func (q *Queries) ListCategoryAttributes(ctx context.Context, categoryID int64) ([]ListCategoryAttributesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listCategoryAttributes, categoryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListCategoryAttributesRow
+	for rows.Next() {
+		var i ListCategoryAttributesRow
+		if err := rows.Scan(&i.AttributeID, &i.Name, &i.IsRequired); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCustomerCartEvents = `-- name: ListCustomerCartEvents :many
+SELECT ce.cart_event_id, ce.session_id, ce.product_id, ce.variant_id, ce.event_type, ce.created_at
+FROM cart_event ce
+JOIN visitor_session vs ON vs.session_id = ce.session_id
+WHERE vs.customer_id = $1::BIGINT
+   OR vs.session_id IN (SELECT session_id FROM customer_order WHERE customer_id = $1::BIGINT)
+ORDER BY ce.created_at
+`
+
+func (q *Queries) ListCustomerCartEvents(ctx context.Context, customerID int64) ([]CartEvent, error) {
+	rows, err := q.db.QueryContext(ctx, listCustomerCartEvents, customerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CartEvent
+	for rows.Next() {
+		var i CartEvent
+		if err := rows.Scan(
+			&i.CartEventID,
+			&i.SessionID,
+			&i.ProductID,
+			&i.VariantID,
+			&i.EventType,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCustomerCartItems = `-- name: ListCustomerCartItems :many
+SELECT ci.cart_item_id, ci.cart_id, ci.variant_id, ci.quantity, ci.unit_price, ci.created_at
+FROM cart_item ci
+JOIN cart c ON c.cart_id = ci.cart_id
+WHERE c.customer_id = $1::BIGINT
+ORDER BY ci.created_at
+`
+
+func (q *Queries) ListCustomerCartItems(ctx context.Context, customerID int64) ([]CartItem, error) {
+	rows, err := q.db.QueryContext(ctx, listCustomerCartItems, customerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CartItem
+	for rows.Next() {
+		var i CartItem
+		if err := rows.Scan(
+			&i.CartItemID,
+			&i.CartID,
+			&i.VariantID,
+			&i.Quantity,
+			&i.UnitPrice,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCustomerDataRequests = `-- name: ListCustomerDataRequests :many
+SELECT data_request_id, store_id, customer_id, request_type, status, requested_by, archive_key, error, attempts, created_at, started_at, completed_at
+FROM data_request
+WHERE customer_id = $1
+ORDER BY data_request_id DESC
+`
+
+func (q *Queries) ListCustomerDataRequests(ctx context.Context, customerID int64) ([]DataRequest, error) {
+	rows, err := q.db.QueryContext(ctx, listCustomerDataRequests, customerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []DataRequest
+	for rows.Next() {
+		var i DataRequest
+		if err := rows.Scan(
+			&i.DataRequestID,
+			&i.StoreID,
+			&i.CustomerID,
+			&i.RequestType,
+			&i.Status,
+			&i.RequestedBy,
+			&i.ArchiveKey,
+			&i.Error,
+			&i.Attempts,
+			&i.CreatedAt,
+			&i.StartedAt,
+			&i.CompletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCustomerOrderItems = `-- name: ListCustomerOrderItems :many
+SELECT oi.order_item_id, oi.order_id, oi.variant_id, oi.quantity, oi.unit_price, oi.subtotal
+FROM order_item oi
+JOIN customer_order o ON o.order_id = oi.order_id
+WHERE o.customer_id = $1::BIGINT
+ORDER BY oi.order_item_id
+`
+
+func (q *Queries) ListCustomerOrderItems(ctx context.Context, customerID int64) ([]OrderItem, error) {
+	rows, err := q.db.QueryContext(ctx, listCustomerOrderItems, customerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []OrderItem
+	for rows.Next() {
+		var i OrderItem
+		if err := rows.Scan(
+			&i.OrderItemID,
+			&i.OrderID,
+			&i.VariantID,
+			&i.Quantity,
+			&i.UnitPrice,
+			&i.Subtotal,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCustomerOrders = `-- name: ListCustomerOrders :many
+SELECT order_id, store_id, customer_id, session_id, total_amount, status, created_at, updated_at, guest_email, shipping_address
+FROM customer_order
+WHERE customer_id = $1::BIGINT
+ORDER BY created_at
+`
+
+func (q *Queries) ListCustomerOrders(ctx context.Context, customerID int64) ([]CustomerOrder, error) {
+	rows, err := q.db.QueryContext(ctx, listCustomerOrders, customerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CustomerOrder
+	for rows.Next() {
+		var i CustomerOrder
+		if err := rows.Scan(
+			&i.OrderID,
+			&i.StoreID,
+			&i.CustomerID,
+			&i.SessionID,
+			&i.TotalAmount,
+			&i.Status,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.GuestEmail,
+			&i.ShippingAddress,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCustomerPayments = `-- name: ListCustomerPayments :many
+SELECT p.payment_id, p.order_id, p.method, p.amount, p.status, p.transaction_ref, p.created_at
+FROM payment p
+JOIN customer_order o ON o.order_id = p.order_id
+WHERE o.customer_id = $1::BIGINT
+ORDER BY p.created_at
+`
+
+func (q *Queries) ListCustomerPayments(ctx context.Context, customerID int64) ([]Payment, error) {
+	rows, err := q.db.QueryContext(ctx, listCustomerPayments, customerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Payment
+	for rows.Next() {
+		var i Payment
+		if err := rows.Scan(
+			&i.PaymentID,
+			&i.OrderID,
+			&i.Method,
+			&i.Amount,
+			&i.Status,
+			&i.TransactionRef,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCustomerProductViews = `-- name: ListCustomerProductViews :many
+SELECT pv.product_view_id, pv.product_id, pv.store_id, pv.session_id, pv.viewed_at
+FROM product_view pv
+JOIN visitor_session vs ON vs.session_id = pv.session_id
+WHERE vs.customer_id = $1::BIGINT
+   OR vs.session_id IN (SELECT session_id FROM customer_order WHERE customer_id = $1::BIGINT)
+ORDER BY pv.viewed_at
+`
+
+func (q *Queries) ListCustomerProductViews(ctx context.Context, customerID int64) ([]ProductView, error) {
+	rows, err := q.db.QueryContext(ctx, listCustomerProductViews, customerID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []GetProductVariantAttributesRow
+	var items []ProductView
 	for rows.Next() {
-		var i GetProductVariantAttributesRow
-		if err := rows.Scan(&i.AttributeID, &i.AttributeName, &i.AttributeValue); err != nil {
+		var i ProductView
+		if err := rows.Scan(
+			&i.ProductViewID,
+			&i.ProductID,
+			&i.StoreID,
+			&i.SessionID,
+			&i.ViewedAt,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -984,43 +3931,31 @@ func (q *Queries) GetProductVariantAttributes(ctx context.Context, variantID int
 	return items, nil
 }
 
-const getProductVariants = `-- name: GetProductVariants :many
-SELECT
-  variant_id,
-  product_id,
-  sku,
-  price,
-  stock_quantity,
-  primary_image_url
-FROM product_variant
-WHERE product_id = $1 AND deleted_at IS NULL
+const listCustomerShipments = `-- name: ListCustomerShipments :many
+SELECT sh.shipment_id, sh.order_id, sh.tracking_number, sh.carrier, sh.shipped_at, sh.delivered_at, sh.status
+FROM shipment sh
+JOIN customer_order o ON o.order_id = sh.order_id
+WHERE o.customer_id = $1::BIGINT
+ORDER BY sh.shipment_id
 `
 
-type GetProductVariantsRow struct {
-	VariantID       int64
-	ProductID       int64
-	Sku             string
-	Price           string
-	StockQuantity   int32
-	PrimaryImageUrl sql.NullString
-}
-
-func (q *Queries) GetProductVariants(ctx context.Context, productID int64) ([]GetProductVariantsRow, error) {
-	rows, err := q.db.QueryContext(ctx, getProductVariants, productID)
+func (q *Queries) ListCustomerShipments(ctx context.Context, customerID int64) ([]Shipment, error) {
+	rows, err := q.db.QueryContext(ctx, listCustomerShipments, customerID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []GetProductVariantsRow
+	var items []Shipment
 	for rows.Next() {
-		var i GetProductVariantsRow
+		var i Shipment
 		if err := rows.Scan(
-			&i.VariantID,
-			&i.ProductID,
-			&i.Sku,
-			&i.Price,
-			&i.StockQuantity,
-			&i.PrimaryImageUrl,
+			&i.ShipmentID,
+			&i.OrderID,
+			&i.TrackingNumber,
+			&i.Carrier,
+			&i.ShippedAt,
+			&i.DeliveredAt,
+			&i.Status,
 		); err != nil {
 			return nil, err
 		}
@@ -1035,203 +3970,196 @@ func (q *Queries) GetProductVariants(ctx context.Context, productID int64) ([]Ge
 	return items, nil
 }
 
-// #nosec G101
-const getRefreshToken = `-- name: GetRefreshToken :one
-SELECT refresh_token_id, token, user_id, user_role, store_id, expires_at, revoked, created_at
-FROM refresh_token
-WHERE token = $1 AND revoked = FALSE
-`
-
-func (q *Queries) GetRefreshToken(ctx context.Context, token string) (RefreshToken, error) {
-	row := q.db.QueryRowContext(ctx, getRefreshToken, token)
-	var i RefreshToken
-	err := row.Scan(
-		&i.RefreshTokenID,
-		&i.Token,
-		&i.UserID,
-		&i.UserRole,
-		&i.StoreID,
-		&i.ExpiresAt,
-		&i.Revoked,
-		&i.CreatedAt,
-	)
-	return i, err
-}
-
-const getSession = `-- name: GetSession :one
-SELECT session_id, customer_id
+const listCustomerVisitorSessions = `-- name: ListCustomerVisitorSessions :many
+SELECT session_id, store_id, customer_id, ip_address, user_agent, first_seen_at, last_seen_at, is_returning
 FROM visitor_session
-WHERE session_id = $1 AND store_id = $2
+WHERE customer_id = $1::BIGINT
+   OR session_id IN (SELECT session_id FROM customer_order WHERE customer_id = $1::BIGINT)
+ORDER BY first_seen_at
 `
 
-type GetSessionParams struct {
-	SessionID uuid.UUID
-	StoreID   int64
-}
-
-type GetSessionRow struct {
-	SessionID  uuid.UUID
-	CustomerID sql.NullInt64
-}
-
-func (q *Queries) GetSession(ctx context.Context, arg GetSessionParams) (GetSessionRow, error) {
-	row := q.db.QueryRowContext(ctx, getSession, arg.SessionID, arg.StoreID)
-	var i GetSessionRow
-	err := row.Scan(&i.SessionID, &i.CustomerID)
-	return i, err
+func (q *Queries) ListCustomerVisitorSessions(ctx context.Context, customerID int64) ([]VisitorSession, error) {
+	rows, err := q.db.QueryContext(ctx, listCustomerVisitorSessions, customerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []VisitorSession
+	for rows.Next() {
+		var i VisitorSession
+		if err := rows.Scan(
+			&i.SessionID,
+			&i.StoreID,
+			&i.CustomerID,
+			&i.IpAddress,
+			&i.UserAgent,
+			&i.FirstSeenAt,
+			&i.LastSeenAt,
+			&i.IsReturning,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
-const getStore = `-- name: GetStore :one
-SELECT store_id, store_owner_id, name, domain, download_status, currency, timezone, created_at, updated_at
-FROM store
-WHERE store_id = $1
+const listRevokedTokensSince = `-- name: ListRevokedTokensSince :many
+SELECT jti, expires_at, revoked_at
+FROM revoked_token
+WHERE revoked_at > $1
+  AND expires_at > NOW()
+ORDER BY revoked_at
 `
 
-func (q *Queries) GetStore(ctx context.Context, storeID int64) (Store, error) {
-	row := q.db.QueryRowContext(ctx, getStore, storeID)
-	var i Store
-	err := row.Scan(
-		&i.StoreID,
-		&i.StoreOwnerID,
-		&i.Name,
-		&i.Domain,
-		&i.DownloadStatus,
-		&i.Currency,
-		&i.Timezone,
-		&i.CreatedAt,
-		&i.UpdatedAt,
-	)
-	return i, err
+func (q *Queries) ListRevokedTokensSince(ctx context.Context, revokedAt time.Time) ([]RevokedToken, error) {
+	rows, err := q.db.QueryContext(ctx, listRevokedTokensSince, revokedAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []RevokedToken
+	for rows.Next() {
+		var i RevokedToken
+		if err := rows.Scan(&i.Jti, &i.ExpiresAt, &i.RevokedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
-const getStoreByOwnerID = `-- name: GetStoreByOwnerID :one
-SELECT store_id, store_owner_id, name, domain, download_status, currency, timezone, created_at, updated_at
-FROM store
-WHERE store_owner_id = $1
+const listStoreAPIKeys = `-- name: ListStoreAPIKeys :many
+SELECT api_key_id, store_id, name, key_prefix, key_hash, scopes, created_by, last_used_at, revoked_at, created_at
+FROM store_api_key
+WHERE store_id = $1
+ORDER BY api_key_id DESC
 `
 
-func (q *Queries) GetStoreByOwnerID(ctx context.Context, storeOwnerID int64) (Store, error) {
-	row := q.db.QueryRowContext(ctx, getStoreByOwnerID, storeOwnerID)
-	var i Store
-	err := row.Scan(
-		&i.StoreID,
-		&i.StoreOwnerID,
-		&i.Name,
-		&i.Domain,
-		&i.DownloadStatus,
-		&i.Currency,
-		&i.Timezone,
-		&i.CreatedAt,
-		&i.UpdatedAt,
-	)
-	return i, err
+func (q *Queries) ListStoreAPIKeys(ctx context.Context, storeID int64) ([]StoreApiKey, error) {
+	rows, err := q.db.QueryContext(ctx, listStoreAPIKeys, storeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []StoreApiKey
+	for rows.Next() {
+		var i StoreApiKey
+		if err := rows.Scan(
+			&i.ApiKeyID,
+			&i.StoreID,
+			&i.Name,
+			&i.KeyPrefix,
+			&i.KeyHash,
+			pq.Array(&i.Scopes),
+			&i.CreatedBy,
+			&i.LastUsedAt,
+			&i.RevokedAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
-const getStoreOwnerByEmail = `-- name: GetStoreOwnerByEmail :one
-SELECT
-  store_owner_id,
-  name,
-  email,
-  password_hash,
-  created_at
-FROM store_owner
-WHERE email = $1
+const listStoreDataRequests = `-- name: ListStoreDataRequests :many
+SELECT data_request_id, store_id, customer_id, request_type, status, requested_by, archive_key, error, attempts, created_at, started_at, completed_at
+FROM data_request
+WHERE store_id = $1
+  AND ($2::BIGINT IS NULL OR data_request_id < $2)
+ORDER BY data_request_id DESC
+LIMIT $3
 `
 
-type GetStoreOwnerByEmailRow struct {
-	StoreOwnerID int64
-	Name         string
-	Email        string
-	PasswordHash string
-	CreatedAt    time.Time
+type ListStoreDataRequestsParams struct {
+	StoreID  int64
+	BeforeID sql.NullInt64
+	Limit    int32
 }
 
-func (q *Queries) GetStoreOwnerByEmail(ctx context.Context, email string) (GetStoreOwnerByEmailRow, error) {
-	row := q.db.QueryRowContext(ctx, getStoreOwnerByEmail, email)
-	var i GetStoreOwnerByEmailRow
-	err := row.Scan(
-		&i.StoreOwnerID,
-		&i.Name,
-		&i.Email,
-		&i.PasswordHash,
-		&i.CreatedAt,
-	)
-	return i, err
+func (q *Queries) ListStoreDataRequests(ctx context.Context, arg ListStoreDataRequestsParams) ([]DataRequest, error) {
+	rows, err := q.db.QueryContext(ctx, listStoreDataRequests, arg.StoreID, arg.BeforeID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []DataRequest
+	for rows.Next() {
+		var i DataRequest
+		if err := rows.Scan(
+			&i.DataRequestID,
+			&i.StoreID,
+			&i.CustomerID,
+			&i.RequestType,
+			&i.Status,
+			&i.RequestedBy,
+			&i.ArchiveKey,
+			&i.Error,
+			&i.Attempts,
+			&i.CreatedAt,
+			&i.StartedAt,
+			&i.CompletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
-const getTopProductsByCategory = `-- name: GetTopProductsByCategory :many
-SELECT 
-  p.product_id,
-  p.store_id,
-  p.name,
-  p.slug,
-  p.description,
-  p.brand,
-  p.category_id,
-  p.default_variant_id,
-  p.stock_quantity as product_total_stock,
-  v.stock_quantity as item_stock,
-  v.price,
-  p.in_stock,
-  v.primary_image_url AS primary_image
-FROM product p
-JOIN product_variant v 
-  ON v.variant_id = p.default_variant_id
-WHERE 
-  p.store_id = $1 
-  AND p.category_id = $2
-  AND p.in_stock = TRUE
-  AND p.deleted_at IS NULL
-ORDER BY 
-  v.stock_quantity DESC
-LIMIT $3
+const listStoreInvitations = `-- name: ListStoreInvitations :many
+SELECT store_invitation_id, store_id, email, role, token_hash, invited_by, expires_at, accepted_at, created_at
+FROM store_invitation
+WHERE store_id = $1
+  AND accepted_at IS NULL
+ORDER BY store_invitation_id DESC
 `
 
-type GetTopProductsByCategoryParams struct {
-	StoreID    int64
-	CategoryID int64
-	Limit      int32
-}
-
-type GetTopProductsByCategoryRow struct {
-	ProductID         int64
-	StoreID           int64
-	Name              string
-	Slug              sql.NullString
-	Description       sql.NullString
-	Brand             sql.NullString
-	CategoryID        int64
-	DefaultVariantID  sql.NullInt64
-	ProductTotalStock int32
-	ItemStock         int32
-	Price             string
-	InStock           bool
-	PrimaryImage      sql.NullString
-}
-
-func (q *Queries) GetTopProductsByCategory(ctx context.Context, arg GetTopProductsByCategoryParams) ([]GetTopProductsByCategoryRow, error) {
-	rows, err := q.db.QueryContext(ctx, getTopProductsByCategory, arg.StoreID, arg.CategoryID, arg.Limit)
+func (q *Queries) ListStoreInvitations(ctx context.Context, storeID int64) ([]StoreInvitation, error) {
+	rows, err := q.db.QueryContext(ctx, listStoreInvitations, storeID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []GetTopProductsByCategoryRow
+	var items []StoreInvitation
 	for rows.Next() {
-		var i GetTopProductsByCategoryRow
+		var i StoreInvitation
 		if err := rows.Scan(
-			&i.ProductID,
+			&i.StoreInvitationID,
 			&i.StoreID,
-			&i.Name,
-			&i.Slug,
-			&i.Description,
-			&i.Brand,
-			&i.CategoryID,
-			&i.DefaultVariantID,
-			&i.ProductTotalStock,
-			&i.ItemStock,
-			&i.Price,
-			&i.InStock,
-			&i.PrimaryImage,
+			&i.Email,
+			&i.Role,
+			&i.TokenHash,
+			&i.InvitedBy,
+			&i.ExpiresAt,
+			&i.AcceptedAt,
+			&i.CreatedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -1246,228 +4174,283 @@ func (q *Queries) GetTopProductsByCategory(ctx context.Context, arg GetTopProduc
 	return items, nil
 }
 
-const getVariant = `-- name: GetVariant :one
-SELECT variant_id, product_id, store_id, attribute_hash, sku, price, stock_quantity, primary_image_url, created_at, updated_at, deleted_at
-FROM product_variant
-WHERE variant_id = $1
-  AND deleted_at IS NULL
-`
-
-func (q *Queries) GetVariant(ctx context.Context, variantID int64) (ProductVariant, error) {
-	row := q.db.QueryRowContext(ctx, getVariant, variantID)
-	var i ProductVariant
-	err := row.Scan(
-		&i.VariantID,
-		&i.ProductID,
-		&i.StoreID,
-		&i.AttributeHash,
-		&i.Sku,
-		&i.Price,
-		&i.StockQuantity,
-		&i.PrimaryImageUrl,
-		&i.CreatedAt,
-		&i.UpdatedAt,
-		&i.DeletedAt,
-	)
-	return i, err
-}
-
-const getVariantByAttributeHash = `-- name: GetVariantByAttributeHash :one
-SELECT variant_id, product_id, store_id, attribute_hash, sku, price, stock_quantity, primary_image_url, created_at, updated_at, deleted_at
-FROM product_variant
-WHERE product_id = $1
-  AND attribute_hash = $2
-  AND deleted_at IS NULL
-LIMIT 1
-`
-
-type GetVariantByAttributeHashParams struct {
-	ProductID     int64
-	AttributeHash string
-}
-
-func (q *Queries) GetVariantByAttributeHash(ctx context.Context, arg GetVariantByAttributeHashParams) (ProductVariant, error) {
-	row := q.db.QueryRowContext(ctx, getVariantByAttributeHash, arg.ProductID, arg.AttributeHash)
-	var i ProductVariant
-	err := row.Scan(
-		&i.VariantID,
-		&i.ProductID,
-		&i.StoreID,
-		&i.AttributeHash,
-		&i.Sku,
-		&i.Price,
-		&i.StockQuantity,
-		&i.PrimaryImageUrl,
-		&i.CreatedAt,
-		&i.UpdatedAt,
-		&i.DeletedAt,
-	)
-	return i, err
-}
-
-const getVariantForCart = `-- name: GetVariantForCart :one
+const listStoreMembers = `-- name: ListStoreMembers :many
 SELECT
-  variant_id,
-  price,
-  stock_quantity
-FROM product_variant
-WHERE variant_id = $1
-  AND store_id = $2
-  AND deleted_at IS NULL
-FOR UPDATE
+  m.store_member_id,
+  m.store_owner_id,
+  o.name,
+  o.email,
+  m.role,
+  m.invited_by,
+  m.created_at
+FROM store_member m
+JOIN store_owner o ON o.store_owner_id = m.store_owner_id
+WHERE m.store_id = $1
+ORDER BY m.store_member_id
 `
 
-type GetVariantForCartParams struct {
-	VariantID int64
-	StoreID   int64
-}
-
-type GetVariantForCartRow struct {
-	VariantID     int64
-	Price         string
-	StockQuantity int32
-}
-
-func (q *Queries) GetVariantForCart(ctx context.Context, arg GetVariantForCartParams) (GetVariantForCartRow, error) {
-	row := q.db.QueryRowContext(ctx, getVariantForCart, arg.VariantID, arg.StoreID)
-	var i GetVariantForCartRow
-	err := row.Scan(&i.VariantID, &i.Price, &i.StockQuantity)
-	return i, err
+type ListStoreMembersRow struct {
+	StoreMemberID int64
+	StoreOwnerID  int64
+	Name          string
+	Email         string
+	Role          string
+	InvitedBy     sql.NullInt64
+	CreatedAt     time.Time
 }
 
-const getVariantForUpdate = `-- name: GetVariantForUpdate :one
-SELECT variant_id, product_id, store_id, attribute_hash, sku, price, stock_quantity, primary_image_url, created_at, updated_at, deleted_at
-FROM product_variant
-WHERE variant_id = $1
-  AND deleted_at IS NULL
-FOR UPDATE
-`
-
-func (q *Queries) GetVariantForUpdate(ctx context.Context, variantID int64) (ProductVariant, error) {
-	row := q.db.QueryRowContext(ctx, getVariantForUpdate, variantID)
-	var i ProductVariant
-	err := row.Scan(
-		&i.VariantID,
-		&i.ProductID,
-		&i.StoreID,
-		&i.AttributeHash,
-		&i.Sku,
-		&i.Price,
-		&i.StockQuantity,
-		&i.PrimaryImageUrl,
-		&i.CreatedAt,
-		&i.UpdatedAt,
-		&i.DeletedAt,
-	)
-	return i, err
+func (q *Queries) ListStoreMembers(ctx context.Context, storeID int64) ([]ListStoreMembersRow, error) {
+	rows, err := q.db.QueryContext(ctx, listStoreMembers, storeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListStoreMembersRow
+	for rows.Next() {
+		var i ListStoreMembersRow
+		if err := rows.Scan(
+			&i.StoreMemberID,
+			&i.StoreOwnerID,
+			&i.Name,
+			&i.Email,
+			&i.Role,
+			&i.InvitedBy,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
-const increaseVariantStock = `-- name: IncreaseVariantStock :exec
-UPDATE product_variant
-SET stock_quantity = stock_quantity + $2,
-    updated_at = NOW()
-WHERE variant_id = $1
+const listStoreOrderDiscounts = `-- name: ListStoreOrderDiscounts :many
+SELECT od.order_discount_id, od.order_id, od.promotion_id, od.code, od.description, od.type, od.amount, od.created_at
+FROM order_discount od
+JOIN customer_order o ON o.order_id = od.order_id
+WHERE o.store_id = $1
+  AND od.order_id = ANY($2::BIGINT[])
+ORDER BY od.order_discount_id
 `
 
-type IncreaseVariantStockParams struct {
-	VariantID     int64
-	StockQuantity int32
+type ListStoreOrderDiscountsParams struct {
+	StoreID  int64
+	OrderIds []int64
 }
 
-func (q *Queries) IncreaseVariantStock(ctx context.Context, arg IncreaseVariantStockParams) error {
-	_, err := q.db.ExecContext(ctx, increaseVariantStock, arg.VariantID, arg.StockQuantity)
-	return err
+func (q *Queries) ListStoreOrderDiscounts(ctx context.Context, arg ListStoreOrderDiscountsParams) ([]OrderDiscount, error) {
+	rows, err := q.db.QueryContext(ctx, listStoreOrderDiscounts, arg.StoreID, pq.Array(arg.OrderIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []OrderDiscount
+	for rows.Next() {
+		var i OrderDiscount
+		if err := rows.Scan(
+			&i.OrderDiscountID,
+			&i.OrderID,
+			&i.PromotionID,
+			&i.Code,
+			&i.Description,
+			&i.Type,
+			&i.Amount,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
-const insertVariantAttribute = `-- name: InsertVariantAttribute :exec
-INSERT INTO variant_attribute_value (
-  variant_id, attribute_id, value
-)
-VALUES ($1, $2, $3)
+const listStoreOrderItems = `-- name: ListStoreOrderItems :many
+SELECT oi.order_item_id, oi.order_id, oi.variant_id, oi.quantity, oi.unit_price, oi.subtotal
+FROM order_item oi
+JOIN customer_order o ON o.order_id = oi.order_id
+WHERE o.store_id = $1
+  AND oi.order_id = ANY($2::BIGINT[])
+ORDER BY oi.order_item_id
 `
 
-type InsertVariantAttributeParams struct {
-	VariantID   int64
-	AttributeID int64
-	Value       string
+type ListStoreOrderItemsParams struct {
+	StoreID  int64
+	OrderIds []int64
 }
 
-func (q *Queries) InsertVariantAttribute(ctx context.Context, arg InsertVariantAttributeParams) error {
-	_, err := q.db.ExecContext(ctx, insertVariantAttribute, arg.VariantID, arg.AttributeID, arg.Value)
-	return err
+func (q *Queries) ListStoreOrderItems(ctx context.Context, arg ListStoreOrderItemsParams) ([]OrderItem, error) {
+	rows, err := q.db.QueryContext(ctx, listStoreOrderItems, arg.StoreID, pq.Array(arg.OrderIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []OrderItem
+	for rows.Next() {
+		var i OrderItem
+		if err := rows.Scan(
+			&i.OrderItemID,
+			&i.OrderID,
+			&i.VariantID,
+			&i.Quantity,
+			&i.UnitPrice,
+			&i.Subtotal,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
-const insertVariantImage = `-- name: InsertVariantImage :one
-INSERT INTO product_variant_image (product_variant_id, image_url)
-VALUES ($1, $2)
-RETURNING image_id, product_variant_id, image_url, created_at
+const listStoreOrders = `-- name: ListStoreOrders :many
+SELECT order_id, store_id, customer_id, session_id, total_amount, status, created_at, updated_at, guest_email, shipping_address
+FROM customer_order
+WHERE store_id = $1
+  AND ($2::BIGINT IS NULL OR order_id < $2)
+ORDER BY order_id DESC
+LIMIT $3
 `
 
-type InsertVariantImageParams struct {
-	ProductVariantID int64
-	ImageUrl         string
+type ListStoreOrdersParams struct {
+	StoreID  int64
+	BeforeID sql.NullInt64
+	Limit    int32
 }
 
-func (q *Queries) InsertVariantImage(ctx context.Context, arg InsertVariantImageParams) (ProductVariantImage, error) {
-	row := q.db.QueryRowContext(ctx, insertVariantImage, arg.ProductVariantID, arg.ImageUrl)
-	var i ProductVariantImage
-	err := row.Scan(
-		&i.ImageID,
-		&i.ProductVariantID,
-		&i.ImageUrl,
-		&i.CreatedAt,
-	)
-	return i, err
+func (q *Queries) ListStoreOrders(ctx context.Context, arg ListStoreOrdersParams) ([]CustomerOrder, error) {
+	rows, err := q.db.QueryContext(ctx, listStoreOrders, arg.StoreID, arg.BeforeID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CustomerOrder
+	for rows.Next() {
+		var i CustomerOrder
+		if err := rows.Scan(
+			&i.OrderID,
+			&i.StoreID,
+			&i.CustomerID,
+			&i.SessionID,
+			&i.TotalAmount,
+			&i.Status,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.GuestEmail,
+			&i.ShippingAddress,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
-const isStoreOwner = `-- name: IsStoreOwner :one
-SELECT EXISTS (
-    SELECT 1
-    FROM store
-    WHERE store_id = $1
-      AND store_owner_id = $2
-)
+const listStorePromotions = `-- name: ListStorePromotions :many
+SELECT promotion_id, store_id, name, code, type, value, buy_quantity, get_quantity, min_subtotal, category_id, variant_ids, first_order_only, usage_limit, usage_limit_per_customer, starts_at, ends_at, is_active, created_at, updated_at
+FROM promotion
+WHERE store_id = $1
+ORDER BY created_at DESC
 `
 
-type IsStoreOwnerParams struct {
-	StoreID      int64
-	StoreOwnerID int64
-}
-
-func (q *Queries) IsStoreOwner(ctx context.Context, arg IsStoreOwnerParams) (bool, error) {
-	row := q.db.QueryRowContext(ctx, isStoreOwner, arg.StoreID, arg.StoreOwnerID)
-	var exists bool
-	err := row.Scan(&exists)
-	return exists, err
+func (q *Queries) ListStorePromotions(ctx context.Context, storeID int64) ([]Promotion, error) {
+	rows, err := q.db.QueryContext(ctx, listStorePromotions, storeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Promotion
+	for rows.Next() {
+		var i Promotion
+		if err := rows.Scan(
+			&i.PromotionID,
+			&i.StoreID,
+			&i.Name,
+			&i.Code,
+			&i.Type,
+			&i.Value,
+			&i.BuyQuantity,
+			&i.GetQuantity,
+			&i.MinSubtotal,
+			&i.CategoryID,
+			pq.Array(&i.VariantIds),
+			&i.FirstOrderOnly,
+			&i.UsageLimit,
+			&i.UsageLimitPerCustomer,
+			&i.StartsAt,
+			&i.EndsAt,
+			&i.IsActive,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
-const listCategoriesByStore = `-- name: ListCategoriesByStore :many
-SELECT c.category_id, c.name, pc.name as parent_name
-FROM store_category s
-JOIN category_definition c
-  ON s.category_id = c.category_id
-JOIN category_definition pc
-  ON c.parent_id = pc.category_id
-WHERE s.store_id = $1
-ORDER BY c.name
+const listUserAuthSessions = `-- name: ListUserAuthSessions :many
+SELECT session_id, user_id, user_role, store_id, ip_address, user_agent, created_at, last_used_at, revoked_at
+FROM auth_session
+WHERE user_id = $1 AND user_role = $2
+ORDER BY created_at
 `
 
-type ListCategoriesByStoreRow struct {
-	CategoryID int64
-	Name       string
-	ParentName string
+type ListUserAuthSessionsParams struct {
+	UserID   int64
+	UserRole string
 }
 
-func (q *Queries) ListCategoriesByStore(ctx context.Context, storeID int64) ([]ListCategoriesByStoreRow, error) {
-	rows, err := q.db.QueryContext(ctx, listCategoriesByStore, storeID)
+func (q *Queries) ListUserAuthSessions(ctx context.Context, arg ListUserAuthSessionsParams) ([]AuthSession, error) {
+	rows, err := q.db.QueryContext(ctx, listUserAuthSessions, arg.UserID, arg.UserRole)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []ListCategoriesByStoreRow
+	var items []AuthSession
 	for rows.Next() {
-		var i ListCategoriesByStoreRow
-		if err := rows.Scan(&i.CategoryID, &i.Name, &i.ParentName); err != nil {
+		var i AuthSession
+		if err := rows.Scan(
+			&i.SessionID,
+			&i.UserID,
+			&i.UserRole,
+			&i.StoreID,
+			&i.IpAddress,
+			&i.UserAgent,
+			&i.CreatedAt,
+			&i.LastUsedAt,
+			&i.RevokedAt,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -1481,30 +4464,46 @@ func (q *Queries) ListCategoriesByStore(ctx context.Context, storeID int64) ([]L
 	return items, nil
 }
 
-const listCategoryAttributes = `-- name: ListCategoryAttributes :many
-SELECT a.attribute_id, a.name, ca.is_required
-FROM category_attribute ca
-JOIN attribute_definition a 
-ON a.attribute_id = ca.attribute_id
-WHERE ca.category_id = $1
+const lockPromotions = `-- name: LockPromotions :many
+SELECT promotion_id, store_id, name, code, type, value, buy_quantity, get_quantity, min_subtotal, category_id, variant_ids, first_order_only, usage_limit, usage_limit_per_customer, starts_at, ends_at, is_active, created_at, updated_at
+FROM promotion
+WHERE promotion_id = ANY($1::BIGINT[])
+ORDER BY promotion_id
+FOR UPDATE
 `
 
-type ListCategoryAttributesRow struct {
-	AttributeID int64
-	Name        string
-	IsRequired  bool
-}
-
-func (q *Queries) ListCategoryAttributes(ctx context.Context, categoryID int64) ([]ListCategoryAttributesRow, error) {
-	rows, err := q.db.QueryContext(ctx, listCategoryAttributes, categoryID)
+// Locks the promotions so that their usage limits hold across concurrent
+// checkouts.
+func (q *Queries) LockPromotions(ctx context.Context, promotionIds []int64) ([]Promotion, error) {
+	rows, err := q.db.QueryContext(ctx, lockPromotions, pq.Array(promotionIds))
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []ListCategoryAttributesRow
+	var items []Promotion
 	for rows.Next() {
-		var i ListCategoryAttributesRow
-		if err := rows.Scan(&i.AttributeID, &i.Name, &i.IsRequired); err != nil {
+		var i Promotion
+		if err := rows.Scan(
+			&i.PromotionID,
+			&i.StoreID,
+			&i.Name,
+			&i.Code,
+			&i.Type,
+			&i.Value,
+			&i.BuyQuantity,
+			&i.GetQuantity,
+			&i.MinSubtotal,
+			&i.CategoryID,
+			pq.Array(&i.VariantIds),
+			&i.FirstOrderOnly,
+			&i.UsageLimit,
+			&i.UsageLimitPerCustomer,
+			&i.StartsAt,
+			&i.EndsAt,
+			&i.IsActive,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -1518,6 +4517,88 @@ func (q *Queries) ListCategoryAttributes(ctx context.Context, categoryID int64)
 	return items, nil
 }
 
+const markCartReminderRecovered = `-- name: MarkCartReminderRecovered :exec
+UPDATE cart_reminder
+SET recovered_at = COALESCE(recovered_at, NOW())
+WHERE cart_reminder_id = $1
+`
+
+func (q *Queries) MarkCartReminderRecovered(ctx context.Context, cartReminderID int64) error {
+	_, err := q.db.ExecContext(ctx, markCartReminderRecovered, cartReminderID)
+	return err
+}
+
+const markCustomerEmailVerified = `-- name: MarkCustomerEmailVerified :execrows
+UPDATE customer
+SET email_verified_at = NOW()
+WHERE customer_id = $1 AND email = $2
+`
+
+type MarkCustomerEmailVerifiedParams struct {
+	CustomerID int64
+	Email      string
+}
+
+func (q *Queries) MarkCustomerEmailVerified(ctx context.Context, arg MarkCustomerEmailVerifiedParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, markCustomerEmailVerified, arg.CustomerID, arg.Email)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const markStoreInvitationAccepted = `-- name: MarkStoreInvitationAccepted :exec
+UPDATE store_invitation
+SET accepted_at = NOW()
+WHERE store_invitation_id = $1
+`
+
+func (q *Queries) MarkStoreInvitationAccepted(ctx context.Context, storeInvitationID int64) error {
+	_, err := q.db.ExecContext(ctx, markStoreInvitationAccepted, storeInvitationID)
+	return err
+}
+
+const markStoreOwnerEmailVerified = `-- name: MarkStoreOwnerEmailVerified :execrows
+UPDATE store_owner
+SET email_verified_at = NOW()
+WHERE store_owner_id = $1 AND email = $2
+`
+
+type MarkStoreOwnerEmailVerifiedParams struct {
+	StoreOwnerID int64
+	Email        string
+}
+
+func (q *Queries) MarkStoreOwnerEmailVerified(ctx context.Context, arg MarkStoreOwnerEmailVerifiedParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, markStoreOwnerEmailVerified, arg.StoreOwnerID, arg.Email)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const markUserMFAStepUsed = `-- name: MarkUserMFAStepUsed :execrows
+UPDATE user_mfa
+SET last_used_step = $3::BIGINT
+WHERE user_id = $1
+  AND user_role = $2
+  AND (last_used_step IS NULL OR last_used_step < $3::BIGINT)
+`
+
+type MarkUserMFAStepUsedParams struct {
+	UserID   int64
+	UserRole string
+	Step     int64
+}
+
+func (q *Queries) MarkUserMFAStepUsed(ctx context.Context, arg MarkUserMFAStepUsedParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, markUserMFAStepUsed, arg.UserID, arg.UserRole, arg.Step)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const mergeCartItems = `-- name: MergeCartItems :exec
 WITH updated AS (
   UPDATE cart_item dst
@@ -1563,6 +4644,70 @@ func (q *Queries) MergeCartItems(ctx context.Context, arg MergeCartItemsParams)
 	return err
 }
 
+const moveCartToSession = `-- name: MoveCartToSession :one
+UPDATE cart
+SET session_id = $3,
+    updated_at = NOW()
+WHERE cart_id = $1 AND store_id = $2
+RETURNING cart_id, store_id, session_id, customer_id, created_at, updated_at, promotion_code
+`
+
+type MoveCartToSessionParams struct {
+	CartID    int64
+	StoreID   int64
+	SessionID uuid.UUID
+}
+
+func (q *Queries) MoveCartToSession(ctx context.Context, arg MoveCartToSessionParams) (Cart, error) {
+	row := q.db.QueryRowContext(ctx, moveCartToSession, arg.CartID, arg.StoreID, arg.SessionID)
+	var i Cart
+	err := row.Scan(
+		&i.CartID,
+		&i.StoreID,
+		&i.SessionID,
+		&i.CustomerID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.PromotionCode,
+	)
+	return i, err
+}
+
+const recordCartEvent = `-- name: RecordCartEvent :exec
+INSERT INTO cart_event (session_id, product_id, variant_id, event_type)
+VALUES ($1, $2, $3, $4)
+`
+
+type RecordCartEventParams struct {
+	SessionID uuid.UUID
+	ProductID int64
+	VariantID sql.NullInt64
+	EventType sql.NullString
+}
+
+func (q *Queries) RecordCartEvent(ctx context.Context, arg RecordCartEventParams) error {
+	_, err := q.db.ExecContext(ctx, recordCartEvent, arg.SessionID, arg.ProductID, arg.VariantID, arg.EventType)
+	return err
+}
+
+const removeStoreMember = `-- name: RemoveStoreMember :execrows
+DELETE FROM store_member
+WHERE store_member_id = $1 AND store_id = $2
+`
+
+type RemoveStoreMemberParams struct {
+	StoreMemberID int64
+	StoreID       int64
+}
+
+func (q *Queries) RemoveStoreMember(ctx context.Context, arg RemoveStoreMemberParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, removeStoreMember, arg.StoreMemberID, arg.StoreID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const resolveAttributeIDByName = `-- name: ResolveAttributeIDByName :one
 SELECT attribute_id
 FROM attribute_definition
@@ -1570,42 +4715,253 @@ WHERE name = $1
 LIMIT 1
 `
 
-func (q *Queries) ResolveAttributeIDByName(ctx context.Context, name string) (int64, error) {
-	row := q.db.QueryRowContext(ctx, resolveAttributeIDByName, name)
-	var attribute_id int64
-	err := row.Scan(&attribute_id)
-	return attribute_id, err
+func (q *Queries) ResolveAttributeIDByName(ctx context.Context, name string) (int64, error) {
+	row := q.db.QueryRowContext(ctx, resolveAttributeIDByName, name)
+	var attribute_id int64
+	err := row.Scan(&attribute_id)
+	return attribute_id, err
+}
+
+const resolveCategoryIDByName = `-- name: ResolveCategoryIDByName :one
+SELECT c.category_id
+FROM category_definition c
+JOIN store_category s
+  ON s.category_id = c.category_id
+WHERE s.store_id = $1 AND c.name = $2
+LIMIT 1
+`
+
+type ResolveCategoryIDByNameParams struct {
+	StoreID int64
+	Name    string
+}
+
+func (q *Queries) ResolveCategoryIDByName(ctx context.Context, arg ResolveCategoryIDByNameParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, resolveCategoryIDByName, arg.StoreID, arg.Name)
+	var category_id int64
+	err := row.Scan(&category_id)
+	return category_id, err
+}
+
+const revokeAuthSession = `-- name: RevokeAuthSession :execrows
+UPDATE auth_session
+SET revoked_at = NOW()
+WHERE session_id = $1
+  AND user_id = $2
+  AND user_role = $3
+  AND revoked_at IS NULL
+`
+
+type RevokeAuthSessionParams struct {
+	SessionID uuid.UUID
+	UserID    int64
+	UserRole  string
+}
+
+func (q *Queries) RevokeAuthSession(ctx context.Context, arg RevokeAuthSessionParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, revokeAuthSession, arg.SessionID, arg.UserID, arg.UserRole)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const revokeOtherUserAuthSessions = `-- name: RevokeOtherUserAuthSessions :exec
+UPDATE auth_session
+SET revoked_at = NOW()
+WHERE user_id = $1 AND user_role = $2 AND session_id <> $3 AND revoked_at IS NULL
+`
+
+type RevokeOtherUserAuthSessionsParams struct {
+	UserID    int64
+	UserRole  string
+	SessionID uuid.UUID
+}
+
+func (q *Queries) RevokeOtherUserAuthSessions(ctx context.Context, arg RevokeOtherUserAuthSessionsParams) error {
+	_, err := q.db.ExecContext(ctx, revokeOtherUserAuthSessions, arg.UserID, arg.UserRole, arg.SessionID)
+	return err
+}
+
+const revokeOtherUserRefreshTokens = `-- name: RevokeOtherUserRefreshTokens :exec
+UPDATE refresh_token
+SET revoked = TRUE
+WHERE user_id = $1 AND user_role = $2 AND family_id <> $3 AND revoked = FALSE
+`
+
+type RevokeOtherUserRefreshTokensParams struct {
+	UserID   int64
+	UserRole string
+	FamilyID uuid.UUID
+}
+
+func (q *Queries) RevokeOtherUserRefreshTokens(ctx context.Context, arg RevokeOtherUserRefreshTokensParams) error {
+	_, err := q.db.ExecContext(ctx, revokeOtherUserRefreshTokens, arg.UserID, arg.UserRole, arg.FamilyID)
+	return err
+}
+
+// #nosec G101
+const revokeRefreshToken = `-- name: RevokeRefreshToken :exec
+UPDATE refresh_token
+SET revoked = TRUE
+WHERE token_hash = $1
+`
+
+func (q *Queries) RevokeRefreshToken(ctx context.Context, tokenHash string) error {
+	_, err := q.db.ExecContext(ctx, revokeRefreshToken, tokenHash)
+	return err
+}
+
+const revokeRefreshTokenFamily = `-- name: RevokeRefreshTokenFamily :exec
+UPDATE refresh_token
+SET revoked = TRUE
+WHERE family_id = $1 AND revoked = FALSE
+`
+
+func (q *Queries) RevokeRefreshTokenFamily(ctx context.Context, familyID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, revokeRefreshTokenFamily, familyID)
+	return err
+}
+
+const revokeSessionAccessTokens = `-- name: RevokeSessionAccessTokens :exec
+INSERT INTO revoked_token (jti, expires_at)
+SELECT a.jti, a.expires_at
+FROM access_token a
+JOIN auth_session s ON s.session_id = a.session_id
+WHERE s.user_id = $1
+  AND s.user_role = $2
+  AND s.revoked_at IS NOT NULL
+  AND a.expires_at > NOW()
+ON CONFLICT (jti) DO NOTHING
+`
+
+type RevokeSessionAccessTokensParams struct {
+	UserID   int64
+	UserRole string
+}
+
+func (q *Queries) RevokeSessionAccessTokens(ctx context.Context, arg RevokeSessionAccessTokensParams) error {
+	_, err := q.db.ExecContext(ctx, revokeSessionAccessTokens, arg.UserID, arg.UserRole)
+	return err
+}
+
+const revokeStoreAPIKey = `-- name: RevokeStoreAPIKey :execrows
+UPDATE store_api_key
+SET revoked_at = NOW()
+WHERE api_key_id = $1 AND store_id = $2 AND revoked_at IS NULL
+`
+
+type RevokeStoreAPIKeyParams struct {
+	ApiKeyID int64
+	StoreID  int64
+}
+
+func (q *Queries) RevokeStoreAPIKey(ctx context.Context, arg RevokeStoreAPIKeyParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, revokeStoreAPIKey, arg.ApiKeyID, arg.StoreID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const revokeToken = `-- name: RevokeToken :execrows
+INSERT INTO revoked_token (jti, expires_at)
+VALUES ($1, $2)
+ON CONFLICT (jti) DO NOTHING
+`
+
+type RevokeTokenParams struct {
+	Jti       uuid.UUID
+	ExpiresAt time.Time
+}
+
+// Revokes a single token. Nothing is inserted if it was revoked already.
+func (q *Queries) RevokeToken(ctx context.Context, arg RevokeTokenParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, revokeToken, arg.Jti, arg.ExpiresAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const revokeUserAuthSessions = `-- name: RevokeUserAuthSessions :exec
+UPDATE auth_session
+SET revoked_at = NOW()
+WHERE user_id = $1 AND user_role = $2 AND revoked_at IS NULL
+`
+
+type RevokeUserAuthSessionsParams struct {
+	UserID   int64
+	UserRole string
+}
+
+func (q *Queries) RevokeUserAuthSessions(ctx context.Context, arg RevokeUserAuthSessionsParams) error {
+	_, err := q.db.ExecContext(ctx, revokeUserAuthSessions, arg.UserID, arg.UserRole)
+	return err
+}
+
+const revokeUserRefreshTokens = `-- name: RevokeUserRefreshTokens :exec
+UPDATE refresh_token
+SET revoked = TRUE
+WHERE user_id = $1 AND user_role = $2 AND revoked = FALSE
+`
+
+type RevokeUserRefreshTokensParams struct {
+	UserID   int64
+	UserRole string
+}
+
+func (q *Queries) RevokeUserRefreshTokens(ctx context.Context, arg RevokeUserRefreshTokensParams) error {
+	_, err := q.db.ExecContext(ctx, revokeUserRefreshTokens, arg.UserID, arg.UserRole)
+	return err
+}
+
+const setCartItemQuantity = `-- name: SetCartItemQuantity :exec
+UPDATE cart_item
+SET quantity = $2
+WHERE cart_item_id = $1
+`
+
+type SetCartItemQuantityParams struct {
+	CartItemID int64
+	Quantity   int32
 }
 
-const resolveCategoryIDByName = `-- name: ResolveCategoryIDByName :one
-SELECT c.category_id
-FROM category_definition c
-JOIN store_category s
-  ON s.category_id = c.category_id
-WHERE s.store_id = $1 AND c.name = $2
-LIMIT 1
+func (q *Queries) SetCartItemQuantity(ctx context.Context, arg SetCartItemQuantityParams) error {
+	_, err := q.db.ExecContext(ctx, setCartItemQuantity, arg.CartItemID, arg.Quantity)
+	return err
+}
+
+const setCartItemUnitPrice = `-- name: SetCartItemUnitPrice :exec
+UPDATE cart_item
+SET unit_price = $2
+WHERE cart_item_id = $1
 `
 
-type ResolveCategoryIDByNameParams struct {
-	StoreID int64
-	Name    string
+type SetCartItemUnitPriceParams struct {
+	CartItemID int64
+	UnitPrice  string
 }
 
-func (q *Queries) ResolveCategoryIDByName(ctx context.Context, arg ResolveCategoryIDByNameParams) (int64, error) {
-	row := q.db.QueryRowContext(ctx, resolveCategoryIDByName, arg.StoreID, arg.Name)
-	var category_id int64
-	err := row.Scan(&category_id)
-	return category_id, err
+func (q *Queries) SetCartItemUnitPrice(ctx context.Context, arg SetCartItemUnitPriceParams) error {
+	_, err := q.db.ExecContext(ctx, setCartItemUnitPrice, arg.CartItemID, arg.UnitPrice)
+	return err
 }
-// #nosec G101
-const revokeRefreshToken = `-- name: RevokeRefreshToken :exec
-UPDATE refresh_token
-SET revoked = TRUE
-WHERE token = $1
+
+const setCartPromotionCode = `-- name: SetCartPromotionCode :exec
+UPDATE cart
+SET promotion_code = $2,
+    updated_at = NOW()
+WHERE cart_id = $1
 `
 
-func (q *Queries) RevokeRefreshToken(ctx context.Context, token string) error {
-	_, err := q.db.ExecContext(ctx, revokeRefreshToken, token)
+type SetCartPromotionCodeParams struct {
+	CartID        int64
+	PromotionCode sql.NullString
+}
+
+func (q *Queries) SetCartPromotionCode(ctx context.Context, arg SetCartPromotionCodeParams) error {
+	_, err := q.db.ExecContext(ctx, setCartPromotionCode, arg.CartID, arg.PromotionCode)
 	return err
 }
 
@@ -1641,6 +4997,25 @@ func (q *Queries) SetPrimaryVariantImage(ctx context.Context, arg SetPrimaryVari
 	return err
 }
 
+const touchAuthSession = `-- name: TouchAuthSession :exec
+UPDATE auth_session
+SET last_used_at = NOW(),
+    ip_address = $2,
+    user_agent = $3
+WHERE session_id = $1
+`
+
+type TouchAuthSessionParams struct {
+	SessionID uuid.UUID
+	IpAddress pqtype.Inet
+	UserAgent sql.NullString
+}
+
+func (q *Queries) TouchAuthSession(ctx context.Context, arg TouchAuthSessionParams) error {
+	_, err := q.db.ExecContext(ctx, touchAuthSession, arg.SessionID, arg.IpAddress, arg.UserAgent)
+	return err
+}
+
 const touchCart = `-- name: TouchCart :exec
 UPDATE cart SET updated_at = NOW() WHERE cart_id = $1
 `
@@ -1650,6 +5025,107 @@ func (q *Queries) TouchCart(ctx context.Context, cartID int64) error {
 	return err
 }
 
+const touchStoreAPIKey = `-- name: TouchStoreAPIKey :exec
+UPDATE store_api_key
+SET last_used_at = NOW()
+WHERE api_key_id = $1
+  AND (last_used_at IS NULL OR last_used_at < NOW() - INTERVAL '1 minute')
+`
+
+func (q *Queries) TouchStoreAPIKey(ctx context.Context, apiKeyID int64) error {
+	_, err := q.db.ExecContext(ctx, touchStoreAPIKey, apiKeyID)
+	return err
+}
+
+const touchVisitorSession = `-- name: TouchVisitorSession :one
+UPDATE visitor_session
+SET last_seen_at = NOW()
+WHERE session_id = $1
+  AND store_id = $2
+  AND last_seen_at > $3
+RETURNING session_id, store_id, customer_id, ip_address, user_agent, first_seen_at, last_seen_at, is_returning
+`
+
+type TouchVisitorSessionParams struct {
+	SessionID uuid.UUID
+	StoreID   int64
+	IdleSince sql.NullTime
+}
+
+func (q *Queries) TouchVisitorSession(ctx context.Context, arg TouchVisitorSessionParams) (VisitorSession, error) {
+	row := q.db.QueryRowContext(ctx, touchVisitorSession, arg.SessionID, arg.StoreID, arg.IdleSince)
+	var i VisitorSession
+	err := row.Scan(
+		&i.SessionID,
+		&i.StoreID,
+		&i.CustomerID,
+		&i.IpAddress,
+		&i.UserAgent,
+		&i.FirstSeenAt,
+		&i.LastSeenAt,
+		&i.IsReturning,
+	)
+	return i, err
+}
+
+const updateCustomerEmail = `-- name: UpdateCustomerEmail :exec
+UPDATE customer
+SET email = $2,
+    email_verified_at = NULL
+WHERE customer_id = $1
+`
+
+type UpdateCustomerEmailParams struct {
+	CustomerID int64
+	Email      string
+}
+
+func (q *Queries) UpdateCustomerEmail(ctx context.Context, arg UpdateCustomerEmailParams) error {
+	_, err := q.db.ExecContext(ctx, updateCustomerEmail, arg.CustomerID, arg.Email)
+	return err
+}
+
+const updateCustomerPassword = `-- name: UpdateCustomerPassword :exec
+UPDATE customer
+SET password_hash = $2
+WHERE customer_id = $1
+`
+
+type UpdateCustomerPasswordParams struct {
+	CustomerID   int64
+	PasswordHash string
+}
+
+func (q *Queries) UpdateCustomerPassword(ctx context.Context, arg UpdateCustomerPasswordParams) error {
+	_, err := q.db.ExecContext(ctx, updateCustomerPassword, arg.CustomerID, arg.PasswordHash)
+	return err
+}
+
+const updateCustomerProfile = `-- name: UpdateCustomerProfile :exec
+UPDATE customer
+SET name = $2,
+    phone = $3,
+    address = $4
+WHERE customer_id = $1
+`
+
+type UpdateCustomerProfileParams struct {
+	CustomerID int64
+	Name       string
+	Phone      sql.NullString
+	Address    types.NullableAddress
+}
+
+func (q *Queries) UpdateCustomerProfile(ctx context.Context, arg UpdateCustomerProfileParams) error {
+	_, err := q.db.ExecContext(ctx, updateCustomerProfile,
+		arg.CustomerID,
+		arg.Name,
+		arg.Phone,
+		arg.Address,
+	)
+	return err
+}
+
 const updateOrderStatus = `-- name: UpdateOrderStatus :exec
 UPDATE customer_order
 SET status = $2,
@@ -1685,6 +5161,94 @@ func (q *Queries) UpdateProductStock(ctx context.Context, arg UpdateProductStock
 	return err
 }
 
+const updatePromotion = `-- name: UpdatePromotion :one
+UPDATE promotion
+SET name = $3,
+    code = $4,
+    type = $5,
+    value = $6,
+    buy_quantity = $7,
+    get_quantity = $8,
+    min_subtotal = $9,
+    category_id = $10,
+    variant_ids = $11,
+    first_order_only = $12,
+    usage_limit = $13,
+    usage_limit_per_customer = $14,
+    starts_at = $15,
+    ends_at = $16,
+    is_active = $17,
+    updated_at = NOW()
+WHERE promotion_id = $1 AND store_id = $2
+RETURNING promotion_id, store_id, name, code, type, value, buy_quantity, get_quantity, min_subtotal, category_id, variant_ids, first_order_only, usage_limit, usage_limit_per_customer, starts_at, ends_at, is_active, created_at, updated_at
+`
+
+type UpdatePromotionParams struct {
+	PromotionID           int64
+	StoreID               int64
+	Name                  string
+	Code                  sql.NullString
+	Type                  string
+	Value                 string
+	BuyQuantity           sql.NullInt32
+	GetQuantity           sql.NullInt32
+	MinSubtotal           sql.NullString
+	CategoryID            sql.NullInt64
+	VariantIds            []int64
+	FirstOrderOnly        bool
+	UsageLimit            sql.NullInt32
+	UsageLimitPerCustomer sql.NullInt32
+	StartsAt              sql.NullTime
+	EndsAt                sql.NullTime
+	IsActive              bool
+}
+
+func (q *Queries) UpdatePromotion(ctx context.Context, arg UpdatePromotionParams) (Promotion, error) {
+	row := q.db.QueryRowContext(ctx, updatePromotion, arg.PromotionID, arg.StoreID, arg.Name, arg.Code, arg.Type, arg.Value, arg.BuyQuantity, arg.GetQuantity, arg.MinSubtotal, arg.CategoryID, pq.Array(arg.VariantIds), arg.FirstOrderOnly, arg.UsageLimit, arg.UsageLimitPerCustomer, arg.StartsAt, arg.EndsAt, arg.IsActive)
+	var i Promotion
+	err := row.Scan(
+		&i.PromotionID,
+		&i.StoreID,
+		&i.Name,
+		&i.Code,
+		&i.Type,
+		&i.Value,
+		&i.BuyQuantity,
+		&i.GetQuantity,
+		&i.MinSubtotal,
+		&i.CategoryID,
+		pq.Array(&i.VariantIds),
+		&i.FirstOrderOnly,
+		&i.UsageLimit,
+		&i.UsageLimitPerCustomer,
+		&i.StartsAt,
+		&i.EndsAt,
+		&i.IsActive,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const updateStoreCartReminders = `-- name: UpdateStoreCartReminders :exec
+UPDATE store
+SET cart_reminder_delay_hours = $2,
+    cart_reminder_max = $3,
+    updated_at = NOW()
+WHERE store_id = $1
+`
+
+type UpdateStoreCartRemindersParams struct {
+	StoreID                int64
+	CartReminderDelayHours int32
+	CartReminderMax        int32
+}
+
+func (q *Queries) UpdateStoreCartReminders(ctx context.Context, arg UpdateStoreCartRemindersParams) error {
+	_, err := q.db.ExecContext(ctx, updateStoreCartReminders, arg.StoreID, arg.CartReminderDelayHours, arg.CartReminderMax)
+	return err
+}
+
 const updateStoreDownloadStatus = `-- name: UpdateStoreDownloadStatus :exec
 UPDATE store
 SET download_status = $2,
@@ -1702,12 +5266,176 @@ func (q *Queries) UpdateStoreDownloadStatus(ctx context.Context, arg UpdateStore
 	return err
 }
 
-const upsertCartItem = `-- name: UpsertCartItem :exec
+const updateStoreMemberRole = `-- name: UpdateStoreMemberRole :execrows
+UPDATE store_member
+SET role = $3
+WHERE store_member_id = $1 AND store_id = $2
+`
+
+type UpdateStoreMemberRoleParams struct {
+	StoreMemberID int64
+	StoreID       int64
+	Role          string
+}
+
+func (q *Queries) UpdateStoreMemberRole(ctx context.Context, arg UpdateStoreMemberRoleParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, updateStoreMemberRole, arg.StoreMemberID, arg.StoreID, arg.Role)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const updateStoreOwnerEmail = `-- name: UpdateStoreOwnerEmail :exec
+UPDATE store_owner
+SET email = $2,
+    email_verified_at = NULL
+WHERE store_owner_id = $1
+`
+
+type UpdateStoreOwnerEmailParams struct {
+	StoreOwnerID int64
+	Email        string
+}
+
+func (q *Queries) UpdateStoreOwnerEmail(ctx context.Context, arg UpdateStoreOwnerEmailParams) error {
+	_, err := q.db.ExecContext(ctx, updateStoreOwnerEmail, arg.StoreOwnerID, arg.Email)
+	return err
+}
+
+const updateStoreOwnerPassword = `-- name: UpdateStoreOwnerPassword :exec
+UPDATE store_owner
+SET password_hash = $2
+WHERE store_owner_id = $1
+`
+
+type UpdateStoreOwnerPasswordParams struct {
+	StoreOwnerID int64
+	PasswordHash string
+}
+
+func (q *Queries) UpdateStoreOwnerPassword(ctx context.Context, arg UpdateStoreOwnerPasswordParams) error {
+	_, err := q.db.ExecContext(ctx, updateStoreOwnerPassword, arg.StoreOwnerID, arg.PasswordHash)
+	return err
+}
+
+const updateStoreOwnerProfile = `-- name: UpdateStoreOwnerProfile :exec
+UPDATE store_owner
+SET name = $2,
+    phone = $3,
+    address = $4
+WHERE store_owner_id = $1
+`
+
+type UpdateStoreOwnerProfileParams struct {
+	StoreOwnerID int64
+	Name         string
+	Phone        sql.NullString
+	Address      types.NullableAddress
+}
+
+func (q *Queries) UpdateStoreOwnerProfile(ctx context.Context, arg UpdateStoreOwnerProfileParams) error {
+	_, err := q.db.ExecContext(ctx, updateStoreOwnerProfile,
+		arg.StoreOwnerID,
+		arg.Name,
+		arg.Phone,
+		arg.Address,
+	)
+	return err
+}
+
+const updateStorePasswordPolicy = `-- name: UpdateStorePasswordPolicy :exec
+UPDATE store
+SET check_breached_passwords = $2,
+    updated_at = NOW()
+WHERE store_id = $1
+`
+
+type UpdateStorePasswordPolicyParams struct {
+	StoreID                int64
+	CheckBreachedPasswords bool
+}
+
+func (q *Queries) UpdateStorePasswordPolicy(ctx context.Context, arg UpdateStorePasswordPolicyParams) error {
+	_, err := q.db.ExecContext(ctx, updateStorePasswordPolicy, arg.StoreID, arg.CheckBreachedPasswords)
+	return err
+}
+
+const updateStoreStockReservation = `-- name: UpdateStoreStockReservation :exec
+UPDATE store
+SET stock_reservation_minutes = $2,
+    updated_at = NOW()
+WHERE store_id = $1
+`
+
+type UpdateStoreStockReservationParams struct {
+	StoreID                 int64
+	StockReservationMinutes int32
+}
+
+func (q *Queries) UpdateStoreStockReservation(ctx context.Context, arg UpdateStoreStockReservationParams) error {
+	_, err := q.db.ExecContext(ctx, updateStoreStockReservation, arg.StoreID, arg.StockReservationMinutes)
+	return err
+}
+
+const upgradeAdminPasswordHash = `-- name: UpgradeAdminPasswordHash :exec
+UPDATE admin
+SET password_hash = $1
+WHERE admin_id = $2 AND password_hash = $3
+`
+
+type UpgradeAdminPasswordHashParams struct {
+	NewHash string
+	AdminID int64
+	OldHash string
+}
+
+func (q *Queries) UpgradeAdminPasswordHash(ctx context.Context, arg UpgradeAdminPasswordHashParams) error {
+	_, err := q.db.ExecContext(ctx, upgradeAdminPasswordHash, arg.NewHash, arg.AdminID, arg.OldHash)
+	return err
+}
+
+const upgradeCustomerPasswordHash = `-- name: UpgradeCustomerPasswordHash :exec
+UPDATE customer
+SET password_hash = $1
+WHERE customer_id = $2 AND password_hash = $3
+`
+
+type UpgradeCustomerPasswordHashParams struct {
+	NewHash    string
+	CustomerID int64
+	OldHash    string
+}
+
+func (q *Queries) UpgradeCustomerPasswordHash(ctx context.Context, arg UpgradeCustomerPasswordHashParams) error {
+	_, err := q.db.ExecContext(ctx, upgradeCustomerPasswordHash, arg.NewHash, arg.CustomerID, arg.OldHash)
+	return err
+}
+
+const upgradeStoreOwnerPasswordHash = `-- name: UpgradeStoreOwnerPasswordHash :exec
+UPDATE store_owner
+SET password_hash = $1
+WHERE store_owner_id = $2 AND password_hash = $3
+`
+
+type UpgradeStoreOwnerPasswordHashParams struct {
+	NewHash      string
+	StoreOwnerID int64
+	OldHash      string
+}
+
+func (q *Queries) UpgradeStoreOwnerPasswordHash(ctx context.Context, arg UpgradeStoreOwnerPasswordHashParams) error {
+	_, err := q.db.ExecContext(ctx, upgradeStoreOwnerPasswordHash, arg.NewHash, arg.StoreOwnerID, arg.OldHash)
+	return err
+}
+
+const upsertCartItem = `-- name: UpsertCartItem :one
 INSERT INTO cart_item (cart_id, variant_id, quantity, unit_price)
 VALUES ($1, $2, $3, $4)
 ON CONFLICT (cart_id, variant_id)
 DO UPDATE SET
   quantity = cart_item.quantity + EXCLUDED.quantity
+RETURNING cart_item_id, cart_id, variant_id, quantity, unit_price, created_at
 `
 
 type UpsertCartItemParams struct {
@@ -1717,12 +5445,109 @@ type UpsertCartItemParams struct {
 	UnitPrice string
 }
 
-func (q *Queries) UpsertCartItem(ctx context.Context, arg UpsertCartItemParams) error {
-	_, err := q.db.ExecContext(ctx, upsertCartItem,
+func (q *Queries) UpsertCartItem(ctx context.Context, arg UpsertCartItemParams) (CartItem, error) {
+	row := q.db.QueryRowContext(ctx, upsertCartItem,
 		arg.CartID,
 		arg.VariantID,
 		arg.Quantity,
 		arg.UnitPrice,
 	)
+	var i CartItem
+	err := row.Scan(
+		&i.CartItemID,
+		&i.CartID,
+		&i.VariantID,
+		&i.Quantity,
+		&i.UnitPrice,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const upsertStockReservation = `-- name: UpsertStockReservation :exec
+INSERT INTO stock_reservation (cart_item_id, variant_id, quantity, expires_at)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (cart_item_id)
+DO UPDATE SET
+  quantity = EXCLUDED.quantity
+`
+
+type UpsertStockReservationParams struct {
+	CartItemID int64
+	VariantID  int64
+	Quantity   int32
+	ExpiresAt  time.Time
+}
+
+func (q *Queries) UpsertStockReservation(ctx context.Context, arg UpsertStockReservationParams) error {
+	_, err := q.db.ExecContext(ctx, upsertStockReservation,
+		arg.CartItemID,
+		arg.VariantID,
+		arg.Quantity,
+		arg.ExpiresAt,
+	)
+	return err
+}
+
+const upsertUserMFA = `-- name: UpsertUserMFA :exec
+INSERT INTO user_mfa (user_id, user_role, totp_secret)
+VALUES ($1, $2, $3)
+ON CONFLICT (user_id, user_role)
+DO UPDATE SET
+  totp_secret    = EXCLUDED.totp_secret,
+  confirmed_at   = NULL,
+  last_used_step = NULL,
+  created_at     = NOW()
+`
+
+type UpsertUserMFAParams struct {
+	UserID     int64
+	UserRole   string
+	TotpSecret string
+}
+
+func (q *Queries) UpsertUserMFA(ctx context.Context, arg UpsertUserMFAParams) error {
+	_, err := q.db.ExecContext(ctx, upsertUserMFA, arg.UserID, arg.UserRole, arg.TotpSecret)
 	return err
 }
+
+const useMFARecoveryCode = `-- name: UseMFARecoveryCode :execrows
+UPDATE mfa_recovery_code
+SET used_at = NOW()
+WHERE user_id = $1
+  AND user_role = $2
+  AND code_hash = $3
+  AND used_at IS NULL
+`
+
+type UseMFARecoveryCodeParams struct {
+	UserID   int64
+	UserRole string
+	CodeHash string
+}
+
+func (q *Queries) UseMFARecoveryCode(ctx context.Context, arg UseMFARecoveryCodeParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, useMFARecoveryCode, arg.UserID, arg.UserRole, arg.CodeHash)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const visitorSessionExists = `-- name: VisitorSessionExists :one
+SELECT EXISTS (
+  SELECT 1 FROM visitor_session WHERE session_id = $1 AND store_id = $2
+)
+`
+
+type VisitorSessionExistsParams struct {
+	SessionID uuid.UUID
+	StoreID   int64
+}
+
+func (q *Queries) VisitorSessionExists(ctx context.Context, arg VisitorSessionExistsParams) (bool, error) {
+	row := q.db.QueryRowContext(ctx, visitorSessionExists, arg.SessionID, arg.StoreID)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
@@ -0,0 +1,84 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Message is a single outbound notification (an email in production).
+type Message struct {
+	To      string    `json:"to"`
+	Subject string    `json:"subject"`
+	Body    string    `json:"body"`
+	SentAt  time.Time `json:"sent_at"`
+}
+
+type Notifier interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// New builds the notifier selected by driver ("stdout" or "file").
+func New(driver, filePath string) (Notifier, error) {
+	switch driver {
+	case "", "stdout":
+		return NewWriterNotifier(os.Stdout), nil
+	case "file":
+		if filePath == "" {
+			return nil, fmt.Errorf("notifications file_path is required for the file driver")
+		}
+		return NewFileNotifier(filePath), nil
+	default:
+		return nil, fmt.Errorf("unknown notification driver %q", driver)
+	}
+}
+
+// WriterNotifier writes each message as a JSON line to w. It is meant for
+// local development and tests where no mail server is available.
+type WriterNotifier struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewWriterNotifier(w io.Writer) *WriterNotifier {
+	return &WriterNotifier{w: w}
+}
+
+func (n *WriterNotifier) Send(ctx context.Context, msg Message) error {
+	msg.SentAt = time.Now().UTC()
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return json.NewEncoder(n.w).Encode(msg)
+}
+
+// FileNotifier appends each message as a JSON line to a file, so tests can
+// read back the links that would have been emailed.
+type FileNotifier struct {
+	mu   sync.Mutex
+	path string
+}
+
+func NewFileNotifier(path string) *FileNotifier {
+	return &FileNotifier{path: path}
+}
+
+func (n *FileNotifier) Send(ctx context.Context, msg Message) error {
+	msg.SentAt = time.Now().UTC()
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	f, err := os.OpenFile(n.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return json.NewEncoder(f).Encode(msg)
+}
@@ -0,0 +1,164 @@
+package oidc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// minRefreshInterval limits how often an unknown kid can trigger a JWKS
+// download, so forged tokens cannot be used to hammer the provider.
+const minRefreshInterval = time.Minute
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// keyCache holds the provider's signing keys and refetches them when a token
+// names a kid it has not seen yet (the provider rotated its keys).
+type keyCache struct {
+	client *http.Client
+	uri    string
+
+	mu        sync.Mutex
+	keys      map[string]interface{}
+	fetchedAt time.Time
+}
+
+func newKeyCache(client *http.Client, uri string) *keyCache {
+	return &keyCache{client: client, uri: uri, keys: map[string]interface{}{}}
+}
+
+func (c *keyCache) get(ctx context.Context, kid, alg string) (interface{}, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key, ok := c.keys[kid]
+	if !ok && time.Since(c.fetchedAt) > minRefreshInterval {
+		if err := c.refresh(ctx); err != nil {
+			return nil, err
+		}
+		key, ok = c.keys[kid]
+	}
+	if !ok {
+		return nil, fmt.Errorf("unknown kid %q", kid)
+	}
+
+	if !keyMatchesAlg(key, alg) {
+		return nil, fmt.Errorf("key %q cannot verify %s", kid, alg)
+	}
+
+	return key, nil
+}
+
+func (c *keyCache) refresh(ctx context.Context) error {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := getJSON(ctx, c.client, c.uri, &set); err != nil {
+		return err
+	}
+
+	keys := map[string]interface{}{}
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		pub, err := k.publicKey()
+		if err != nil {
+			// skip keys we cannot use rather than failing every login
+			continue
+		}
+		keys[k.Kid] = pub
+	}
+
+	c.keys = keys
+	c.fetchedAt = time.Now()
+	return nil
+}
+
+func (k jwk) publicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		pub := &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}
+		if !pub.Curve.IsOnCurve(x, y) {
+			return nil, fmt.Errorf("invalid EC point")
+		}
+		return pub, nil
+
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid Ed25519 key size")
+		}
+		return ed25519.PublicKey(x), nil
+
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+func keyMatchesAlg(key interface{}, alg string) bool {
+	switch key.(type) {
+	case *rsa.PublicKey:
+		return alg == "RS256"
+	case *ecdsa.PublicKey:
+		return alg == "ES256"
+	case ed25519.PublicKey:
+		return alg == "EdDSA"
+	default:
+		return false
+	}
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
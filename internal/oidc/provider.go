@@ -0,0 +1,280 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Secure-Website-Builder/Backend/internal/config"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrUnknownProvider = errors.New("unknown oidc provider")
+	ErrInvalidIDToken  = errors.New("invalid id token")
+)
+
+// discovery is the subset of the provider metadata we rely on
+// (OpenID Connect Discovery 1.0, section 3).
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Claims are the ID token claims used to identify the user.
+type Claims struct {
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	Name          string `json:"name"`
+	Nonce         string `json:"nonce"`
+	jwt.RegisteredClaims
+}
+
+// Provider runs the authorization code flow with PKCE against one identity
+// provider. Metadata and signing keys are fetched lazily and cached.
+type Provider struct {
+	cfg          config.OIDCProviderConfig
+	clientSecret string
+	client       *http.Client
+
+	mu        sync.Mutex
+	meta      *discovery
+	keys      *keyCache
+	fetchedAt time.Time
+}
+
+func NewProvider(cfg config.OIDCProviderConfig, clientSecret string) *Provider {
+	return &Provider{
+		cfg:          cfg,
+		clientSecret: clientSecret,
+		client:       &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (p *Provider) Name() string {
+	return p.cfg.Name
+}
+
+// AllowSignup reports whether unknown users may create an account.
+func (p *Provider) AllowSignup() bool {
+	return p.cfg.AllowSignup
+}
+
+// AuthCodeURL returns the URL the user is sent to in order to sign in.
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, codeChallenge string) (string, error) {
+	meta, _, err := p.metadata(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	scopes := p.cfg.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{"openid", "email", "profile"}
+	}
+
+	q := url.Values{}
+	q.Set("response_type", "code")
+	q.Set("client_id", p.cfg.ClientID)
+	q.Set("redirect_uri", p.cfg.RedirectURL)
+	q.Set("scope", strings.Join(scopes, " "))
+	q.Set("state", state)
+	q.Set("nonce", nonce)
+	q.Set("code_challenge", codeChallenge)
+	q.Set("code_challenge_method", "S256")
+
+	sep := "?"
+	if strings.Contains(meta.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+
+	return meta.AuthorizationEndpoint + sep + q.Encode(), nil
+}
+
+// Exchange redeems an authorization code and returns the verified ID token
+// claims. nonce must be the value sent with the authorization request.
+func (p *Provider) Exchange(ctx context.Context, code, codeVerifier, nonce string) (*Claims, error) {
+	meta, keys, err := p.metadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.cfg.RedirectURL)
+	form.Set("code_verifier", codeVerifier)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, meta.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.clientSecret))
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("oidc %s: token endpoint returned %s", p.cfg.Name, resp.Status)
+	}
+
+	var tokens struct {
+		IDToken string `json:"id_token"`
+	}
+	if err := json.Unmarshal(body, &tokens); err != nil {
+		return nil, err
+	}
+	if tokens.IDToken == "" {
+		return nil, fmt.Errorf("oidc %s: no id_token in token response", p.cfg.Name)
+	}
+
+	return p.verifyIDToken(ctx, meta, keys, tokens.IDToken, nonce)
+}
+
+func (p *Provider) verifyIDToken(
+	ctx context.Context,
+	meta *discovery,
+	keys *keyCache,
+	raw, nonce string,
+) (*Claims, error) {
+	claims := &Claims{}
+
+	_, err := jwt.ParseWithClaims(
+		raw,
+		claims,
+		func(token *jwt.Token) (interface{}, error) {
+			kid, _ := token.Header["kid"].(string)
+			return keys.get(ctx, kid, token.Method.Alg())
+		},
+		jwt.WithValidMethods([]string{"RS256", "ES256", "EdDSA"}),
+		jwt.WithIssuer(meta.Issuer),
+		jwt.WithAudience(p.cfg.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+
+	if claims.Nonce != nonce {
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrInvalidIDToken)
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: missing sub", ErrInvalidIDToken)
+	}
+
+	return claims, nil
+}
+
+// metadata fetches the discovery document once and refreshes it hourly.
+func (p *Provider) metadata(ctx context.Context) (*discovery, *keyCache, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.meta != nil && time.Since(p.fetchedAt) < time.Hour {
+		return p.meta, p.keys, nil
+	}
+
+	wellKnown := strings.TrimSuffix(p.cfg.Issuer, "/") + "/.well-known/openid-configuration"
+
+	var meta discovery
+	if err := getJSON(ctx, p.client, wellKnown, &meta); err != nil {
+		return nil, nil, fmt.Errorf("oidc %s: discovery: %w", p.cfg.Name, err)
+	}
+
+	// The issuer in the document must be exactly the configured one,
+	// otherwise tokens from another issuer could be accepted.
+	if meta.Issuer != p.cfg.Issuer {
+		return nil, nil, fmt.Errorf("oidc %s: discovery issuer %q does not match %q", p.cfg.Name, meta.Issuer, p.cfg.Issuer)
+	}
+	if meta.AuthorizationEndpoint == "" || meta.TokenEndpoint == "" || meta.JWKSURI == "" {
+		return nil, nil, fmt.Errorf("oidc %s: incomplete discovery document", p.cfg.Name)
+	}
+
+	p.meta = &meta
+	p.fetchedAt = time.Now()
+	if p.keys == nil || p.keys.uri != meta.JWKSURI {
+		p.keys = newKeyCache(p.client, meta.JWKSURI)
+	}
+
+	return p.meta, p.keys, nil
+}
+
+func getJSON(ctx context.Context, client *http.Client, endpoint string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", endpoint, resp.Status)
+	}
+
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(out)
+}
+
+// Registry holds the configured providers by name.
+type Registry struct {
+	providers map[string]*Provider
+	stateTTL  time.Duration
+}
+
+// NewRegistry builds a provider for every configured entry. secret returns
+// the client secret of a provider.
+func NewRegistry(
+	cfg config.OIDCConfig,
+	secret func(config.OIDCProviderConfig) (string, error),
+) (*Registry, error) {
+
+	r := &Registry{
+		providers: map[string]*Provider{},
+		stateTTL:  cfg.StateTTL(),
+	}
+
+	for _, pc := range cfg.Providers {
+		s, err := secret(pc)
+		if err != nil {
+			return nil, err
+		}
+		r.providers[pc.Name] = NewProvider(pc, s)
+	}
+
+	return r, nil
+}
+
+func (r *Registry) Get(name string) (*Provider, error) {
+	p, ok := r.providers[name]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+	return p, nil
+}
+
+// StateTTL is how long a started login may take to come back.
+func (r *Registry) StateTTL() time.Duration {
+	return r.stateTTL
+}
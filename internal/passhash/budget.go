@@ -0,0 +1,42 @@
+package passhash
+
+import "sync"
+
+// memoryBudget bounds the memory of the Argon2id hashes computed at once.
+// Each hash takes the memory it needs (in KiB) and waits while the budget
+// cannot cover it; one larger than the whole budget runs alone.
+type memoryBudget struct {
+	mu    sync.Mutex
+	freed *sync.Cond
+	total uint64
+	free  uint64
+}
+
+func newMemoryBudget(kib uint64) *memoryBudget {
+	b := &memoryBudget{total: kib, free: kib}
+	b.freed = sync.NewCond(&b.mu)
+	return b
+}
+
+// acquire waits until kib fits the budget and takes it. It returns what
+// was taken, to be given back with release.
+func (b *memoryBudget) acquire(kib uint64) uint64 {
+	kib = min(kib, b.total)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for b.free < kib {
+		b.freed.Wait()
+	}
+	b.free -= kib
+	return kib
+}
+
+func (b *memoryBudget) release(kib uint64) {
+	b.mu.Lock()
+	b.free += kib
+	b.mu.Unlock()
+
+	b.freed.Broadcast()
+}
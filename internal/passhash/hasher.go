@@ -0,0 +1,218 @@
+// Package passhash hashes and verifies account passwords.
+//
+// New hashes use the algorithm and parameters from the app config. Hashes
+// in any supported format keep verifying, and Verify reports when a stored
+// hash is weaker than the current settings so it can be replaced the next
+// time the plain password is known (after a successful login).
+package passhash
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Secure-Website-Builder/Backend/internal/config"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	AlgorithmArgon2id = "argon2id"
+	AlgorithmBcrypt   = "bcrypt"
+
+	argon2SaltLength = 16
+	argon2KeyLength  = 32
+
+	// bcryptMaxBytes is where bcrypt stops reading the password; longer
+	// passwords are refused rather than silently truncated.
+	bcryptMaxBytes = 72
+
+	// MaxPasswordBytes bounds the work a single hash or login attempt can
+	// cause. It is far above any password policy.
+	MaxPasswordBytes = 1024
+)
+
+var ErrPasswordTooLong = errors.New("password too long")
+
+// argon2Params are the cost parameters encoded in an Argon2id hash.
+type argon2Params struct {
+	memory      uint32 // KiB
+	iterations  uint32
+	parallelism uint8
+}
+
+// Hasher creates hashes with the configured algorithm.
+type Hasher struct {
+	algorithm  string
+	argon2     argon2Params
+	bcryptCost int
+
+	// budget bounds the memory taken by concurrent Argon2id hashes, which
+	// every login and password change computes.
+	budget *memoryBudget
+}
+
+func New(cfg config.PasswordHashingConfig) (*Hasher, error) {
+	h := &Hasher{
+		algorithm: cfg.Algorithm,
+		argon2: argon2Params{
+			memory:      cfg.Argon2MemoryKiB,
+			iterations:  cfg.Argon2Iterations,
+			parallelism: cfg.Argon2Parallelism,
+		},
+		bcryptCost: cfg.BcryptCost,
+		budget:     newMemoryBudget(uint64(cfg.Argon2MaxMemoryMiB) * 1024),
+	}
+
+	if cfg.Argon2MaxMemoryMiB == 0 {
+		return nil, errors.New("invalid argon2id memory budget")
+	}
+
+	switch h.algorithm {
+	case AlgorithmArgon2id:
+		if h.argon2.iterations == 0 || h.argon2.parallelism == 0 ||
+			h.argon2.memory < 8*uint32(h.argon2.parallelism) {
+			return nil, errors.New("invalid argon2id parameters")
+		}
+		if uint64(h.argon2.memory) > h.budget.total {
+			return nil, errors.New("argon2id memory budget is below the memory of one hash")
+		}
+	case AlgorithmBcrypt:
+		if h.bcryptCost < bcrypt.MinCost || h.bcryptCost > bcrypt.MaxCost {
+			return nil, fmt.Errorf("invalid bcrypt cost %d", h.bcryptCost)
+		}
+	default:
+		return nil, fmt.Errorf("unknown password hashing algorithm %q", h.algorithm)
+	}
+
+	return h, nil
+}
+
+// Hash returns the encoded hash of password. Argon2id hashes use the PHC
+// string format: $argon2id$v=19$m=<KiB>,t=<iterations>,p=<lanes>$<salt>$<key>.
+func (h *Hasher) Hash(password string) (string, error) {
+	if len(password) > MaxPasswordBytes {
+		return "", ErrPasswordTooLong
+	}
+
+	if h.algorithm == AlgorithmBcrypt {
+		if len(password) > bcryptMaxBytes {
+			return "", ErrPasswordTooLong
+		}
+		hashed, err := bcrypt.GenerateFromPassword([]byte(password), h.bcryptCost)
+		return string(hashed), err
+	}
+
+	salt := make([]byte, argon2SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	p := h.argon2
+	key := h.argon2Key([]byte(password), salt, p, argon2KeyLength)
+
+	return fmt.Sprintf(
+		"$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, p.memory, p.iterations, p.parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// Verify reports whether password matches the encoded hash and, if it
+// does, whether the hash should be replaced by a fresh one from Hash.
+// Hashes that cannot be parsed, such as the placeholders of accounts
+// without a password, never match.
+func (h *Hasher) Verify(password, encoded string) (ok, needsRehash bool) {
+	if len(password) > MaxPasswordBytes {
+		return false, false
+	}
+
+	switch {
+	case strings.HasPrefix(encoded, "$argon2id$"):
+		params, salt, key, err := decodeArgon2id(encoded)
+		if err != nil {
+			return false, false
+		}
+		candidate := h.argon2Key([]byte(password), salt, params, uint32(len(key)))
+		if subtle.ConstantTimeCompare(candidate, key) != 1 {
+			return false, false
+		}
+		return true, h.argon2Weaker(params, len(salt), len(key))
+
+	case strings.HasPrefix(encoded, "$2a$"), strings.HasPrefix(encoded, "$2b$"), strings.HasPrefix(encoded, "$2y$"):
+		if bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password)) != nil {
+			return false, false
+		}
+		return true, h.bcryptWeaker(encoded)
+	}
+
+	return false, false
+}
+
+// argon2Key derives an Argon2id key within the memory budget.
+func (h *Hasher) argon2Key(password, salt []byte, p argon2Params, keyLen uint32) []byte {
+	taken := h.budget.acquire(uint64(p.memory))
+	defer h.budget.release(taken)
+
+	return argon2.IDKey(password, salt, p.iterations, p.memory, p.parallelism, keyLen)
+}
+
+// argon2Weaker reports whether a stored Argon2id hash uses less memory,
+// fewer iterations or a shorter salt or key than new hashes would. When
+// bcrypt is configured, existing Argon2id hashes are kept.
+func (h *Hasher) argon2Weaker(p argon2Params, saltLen, keyLen int) bool {
+	if h.algorithm != AlgorithmArgon2id {
+		return false
+	}
+	return p.memory < h.argon2.memory ||
+		p.iterations < h.argon2.iterations ||
+		saltLen < argon2SaltLength ||
+		keyLen < argon2KeyLength
+}
+
+// bcryptWeaker reports whether a bcrypt hash should be upgraded: always
+// when Argon2id is configured, otherwise if its cost is below the setting.
+func (h *Hasher) bcryptWeaker(encoded string) bool {
+	if h.algorithm != AlgorithmBcrypt {
+		return true
+	}
+	cost, err := bcrypt.Cost([]byte(encoded))
+	return err == nil && cost < h.bcryptCost
+}
+
+func decodeArgon2id(encoded string) (argon2Params, []byte, []byte, error) {
+	var p argon2Params
+
+	// "", "argon2id", "v=19", "m=..,t=..,p=..", salt, key
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 {
+		return p, nil, nil, errors.New("malformed argon2id hash")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return p, nil, nil, errors.New("unsupported argon2 version")
+	}
+
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.memory, &p.iterations, &p.parallelism); err != nil {
+		return p, nil, nil, errors.New("malformed argon2id parameters")
+	}
+	if p.iterations == 0 || p.parallelism == 0 {
+		return p, nil, nil, errors.New("malformed argon2id parameters")
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return p, nil, nil, err
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return p, nil, nil, errors.New("malformed argon2id key")
+	}
+
+	return p, salt, key, nil
+}
@@ -0,0 +1,77 @@
+// Package permissions defines what the staff roles of a store and store
+// API keys may do. Dashboard routes name the permission they need (see
+// middleware.RequirePermission).
+package permissions
+
+const (
+	ProductsRead     = "products:read"
+	ProductsWrite    = "products:write"
+	OrdersRead       = "orders:read"
+	StoreSettings    = "store:settings"
+	AnalyticsRead    = "analytics:read"
+	CustomerData     = "customers:data" // data export and erasure requests
+	APIKeysManage    = "api_keys:manage"
+	StaffManage      = "staff:manage"
+	PromotionsManage = "promotions:manage"
+)
+
+// Roles in a store. The owner has every permission; the others are staff
+// roles that can be given to invited members.
+const (
+	RoleOwner           = "owner"
+	RoleManager         = "manager"
+	RoleInventoryClerk  = "inventory_clerk"
+	RoleOrderFulfilment = "order_fulfilment"
+)
+
+var rolePermissions = map[string][]string{
+	RoleManager: {
+		ProductsRead,
+		ProductsWrite,
+		OrdersRead,
+		StoreSettings,
+		AnalyticsRead,
+		CustomerData,
+		PromotionsManage,
+	},
+	RoleInventoryClerk: {
+		ProductsRead,
+		ProductsWrite,
+	},
+	RoleOrderFulfilment: {
+		ProductsRead,
+		OrdersRead,
+	},
+}
+
+// apiKeyScopes are the permissions that can be granted to an API key.
+// Managing the store's staff, keys and settings stays with people.
+var apiKeyScopes = map[string]bool{
+	ProductsRead:  true,
+	ProductsWrite: true,
+	OrdersRead:    true,
+}
+
+// RoleHas reports whether role grants permission.
+func RoleHas(role, permission string) bool {
+	if role == RoleOwner {
+		return true
+	}
+	for _, p := range rolePermissions[role] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// IsStaffRole reports whether role can be given to a staff member.
+func IsStaffRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// IsAPIKeyScope reports whether permission can be granted to an API key.
+func IsAPIKeyScope(permission string) bool {
+	return apiKeyScopes[permission]
+}
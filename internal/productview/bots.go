@@ -0,0 +1,42 @@
+package productview
+
+import "strings"
+
+// botMarkers are lowercase substrings of the user agents of crawlers,
+// link previewers, monitoring and HTTP libraries.
+var botMarkers = []string{
+	"bot",
+	"crawl",
+	"spider",
+	"slurp",
+	"preview",
+	"facebookexternalhit",
+	"headless",
+	"lighthouse",
+	"pingdom",
+	"uptime",
+	"curl/",
+	"wget/",
+	"python-requests",
+	"python-urllib",
+	"go-http-client",
+	"okhttp",
+	"java/",
+	"libwww",
+	"httpclient",
+}
+
+// IsBot reports whether a user agent looks automated. Requests without a
+// user agent are treated as automated too; browsers always send one.
+func IsBot(userAgent string) bool {
+	ua := strings.ToLower(strings.TrimSpace(userAgent))
+	if ua == "" {
+		return true
+	}
+	for _, marker := range botMarkers {
+		if strings.Contains(ua, marker) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,8 @@
+package productview
+
+import "expvar"
+
+// metrics are published through expvar as "product_views", keyed by
+// "queued", "written", "failed", "dropped" (queue full), "deduplicated"
+// and "bots".
+var metrics = expvar.NewMap("product_views")
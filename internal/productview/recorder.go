@@ -0,0 +1,205 @@
+// Package productview records which products visitors look at, for the
+// store analytics.
+//
+// Recording must not slow down serving a product, so views are queued in
+// memory and written in batches by Run. A view that does not fit in the
+// queue is dropped: analytics can lose a few views under load, the
+// storefront cannot wait for them.
+package productview
+
+import (
+	"container/list"
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/Secure-Website-Builder/Backend/internal/config"
+	"github.com/Secure-Website-Builder/Backend/internal/database"
+	"github.com/Secure-Website-Builder/Backend/internal/models"
+	"github.com/google/uuid"
+)
+
+// shutdownFlushTimeout bounds writing the last batch when Run stops.
+const shutdownFlushTimeout = 10 * time.Second
+
+type view struct {
+	storeID   int64
+	productID int64
+	sessionID uuid.UUID
+	viewedAt  time.Time
+}
+
+type viewKey struct {
+	sessionID uuid.UUID
+	productID int64
+}
+
+type seenView struct {
+	key viewKey
+	at  time.Time
+}
+
+// Recorder queues product views and writes them in batches.
+type Recorder struct {
+	db    *database.DB
+	cfg   config.ProductViewConfig
+	queue chan view
+
+	// seen holds when each session last had a view of a product recorded,
+	// in order, the most recent first. It is per instance, so behind a load
+	// balancer a view can count once per instance within the window. The
+	// session comes from the client, so it holds at most DedupMaxEntries
+	// views and forgets the oldest first.
+	mu    sync.Mutex
+	seen  map[viewKey]*list.Element
+	order *list.List
+}
+
+func New(db *database.DB, cfg config.ProductViewConfig) *Recorder {
+	return &Recorder{
+		db:    db,
+		cfg:   cfg,
+		queue: make(chan view, cfg.QueueSize),
+		seen:  map[viewKey]*list.Element{},
+		order: list.New(),
+	}
+}
+
+// Record queues a view of productID by a visitor session. It never blocks.
+// Views from bots and repeated views within the dedup window are ignored.
+func (r *Recorder) Record(storeID, productID int64, sessionID uuid.UUID, userAgent string) {
+	if IsBot(userAgent) {
+		metrics.Add("bots", 1)
+		return
+	}
+
+	now := time.Now()
+	key := viewKey{sessionID: sessionID, productID: productID}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if e, ok := r.seen[key]; ok && now.Sub(e.Value.(*seenView).at) < r.cfg.DedupWindow() {
+		metrics.Add("deduplicated", 1)
+		return
+	}
+
+	// A dropped view is not remembered, so the next one can still count.
+	select {
+	case r.queue <- view{storeID: storeID, productID: productID, sessionID: sessionID, viewedAt: now}:
+		metrics.Add("queued", 1)
+		r.remember(key, now)
+	default:
+		metrics.Add("dropped", 1)
+	}
+}
+
+// remember records a view of the key at now, forgetting the oldest view
+// when there are too many. r.mu must be held.
+func (r *Recorder) remember(key viewKey, now time.Time) {
+	if e, ok := r.seen[key]; ok {
+		e.Value.(*seenView).at = now
+		r.order.MoveToFront(e)
+		return
+	}
+
+	r.seen[key] = r.order.PushFront(&seenView{key: key, at: now})
+	if r.order.Len() > r.cfg.DedupMaxEntries {
+		r.forget(r.order.Back())
+	}
+}
+
+// forget removes a remembered view. r.mu must be held.
+func (r *Recorder) forget(e *list.Element) {
+	r.order.Remove(e)
+	delete(r.seen, e.Value.(*seenView).key)
+}
+
+// Run writes queued views until ctx is done, then writes what is left.
+func (r *Recorder) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.cfg.FlushInterval())
+	defer ticker.Stop()
+
+	batch := make([]view, 0, r.cfg.BatchSize)
+
+	for {
+		select {
+		case v := <-r.queue:
+			batch = append(batch, v)
+			if len(batch) >= r.cfg.BatchSize {
+				r.flush(ctx, batch)
+				batch = batch[:0]
+			}
+
+		case <-ticker.C:
+			r.flush(ctx, batch)
+			batch = batch[:0]
+			r.prune(time.Now())
+
+		case <-ctx.Done():
+			r.drain(batch)
+			return
+		}
+	}
+}
+
+// drain writes the batch in progress and everything still queued.
+func (r *Recorder) drain(batch []view) {
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownFlushTimeout)
+	defer cancel()
+
+	for {
+		select {
+		case v := <-r.queue:
+			batch = append(batch, v)
+			if len(batch) >= r.cfg.BatchSize {
+				r.flush(ctx, batch)
+				batch = batch[:0]
+			}
+		default:
+			r.flush(ctx, batch)
+			return
+		}
+	}
+}
+
+func (r *Recorder) flush(ctx context.Context, batch []view) {
+	if len(batch) == 0 {
+		return
+	}
+
+	params := models.InsertProductViewsParams{
+		ProductIds: make([]int64, len(batch)),
+		StoreIds:   make([]int64, len(batch)),
+		SessionIds: make([]uuid.UUID, len(batch)),
+		ViewedAt:   make([]time.Time, len(batch)),
+	}
+	for i, v := range batch {
+		params.ProductIds[i] = v.productID
+		params.StoreIds[i] = v.storeID
+		params.SessionIds[i] = v.sessionID
+		params.ViewedAt[i] = v.viewedAt
+	}
+
+	if err := r.db.Queries.InsertProductViews(ctx, params); err != nil {
+		metrics.Add("failed", int64(len(batch)))
+		log.Printf("product views: failed to write %d views: %v", len(batch), err)
+		return
+	}
+	metrics.Add("written", int64(len(batch)))
+}
+
+// prune forgets views older than the dedup window. They are the last in
+// order, so only those are visited.
+func (r *Recorder) prune(now time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for e := r.order.Back(); e != nil; e = r.order.Back() {
+		if now.Sub(e.Value.(*seenView).at) < r.cfg.DedupWindow() {
+			return
+		}
+		r.forget(e)
+	}
+}
@@ -0,0 +1,152 @@
+package pwned
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+)
+
+// bloomMagic starts a filter file, followed by k (uint32) and the number of
+// bits m (uint64), both big endian, and then the m bits.
+var bloomMagic = []byte("PWNDBLM1")
+
+const bloomHeaderSize = 8 + 4 + 8
+
+// Bloom looks passwords up in a Bloom filter built from the SHA-1 dataset
+// with BuildBloom. It is much smaller than the dataset at the cost of a
+// configurable false positive rate (a strong password is occasionally
+// rejected); there are no false negatives. Bits are read from the file on
+// demand.
+type Bloom struct {
+	file *os.File
+	k    uint32
+	m    uint64
+}
+
+func OpenBloom(path string) (*Bloom, error) {
+	f, err := os.Open(path) // #nosec G304 -- path comes from the app config
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, bloomHeaderSize)
+	if _, err := io.ReadFull(f, header); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("read bloom filter header: %w", err)
+	}
+	if !bytes.Equal(header[:8], bloomMagic) {
+		f.Close()
+		return nil, errors.New("not a password bloom filter file")
+	}
+
+	b := &Bloom{
+		file: f,
+		k:    binary.BigEndian.Uint32(header[8:12]),
+		m:    binary.BigEndian.Uint64(header[12:20]),
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if b.k == 0 || b.m == 0 || info.Size() < bloomHeaderSize+int64((b.m+7)/8) {
+		f.Close()
+		return nil, errors.New("truncated password bloom filter file")
+	}
+
+	return b, nil
+}
+
+func (b *Bloom) Name() string {
+	return "bloom"
+}
+
+func (b *Bloom) IsPwned(ctx context.Context, password string) (bool, error) {
+	digest, err := hex.DecodeString(hashHex(password))
+	if err != nil {
+		return false, err
+	}
+
+	var bit [1]byte
+	for _, pos := range bloomPositions(digest, b.k, b.m) {
+		if _, err := b.file.ReadAt(bit[:], bloomHeaderSize+int64(pos/8)); err != nil {
+			return false, err
+		}
+		if bit[0]&(1<<(pos%8)) == 0 {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// bloomPositions derives k bit positions from a SHA-1 digest by double
+// hashing; the digest is already uniformly distributed.
+func bloomPositions(digest []byte, k uint32, m uint64) []uint64 {
+	h1 := binary.BigEndian.Uint64(digest[0:8])
+	h2 := binary.BigEndian.Uint64(digest[8:16]) | 1
+
+	out := make([]uint64, k)
+	for i := uint32(0); i < k; i++ {
+		out[i] = (h1 + uint64(i)*h2) % m
+	}
+	return out
+}
+
+// BloomSize returns the number of bits and hash functions for n entries at
+// false positive rate p.
+func BloomSize(n uint64, p float64) (m uint64, k uint32) {
+	mf := math.Ceil(-float64(n) * math.Log(p) / (math.Ln2 * math.Ln2))
+	m = uint64(mf)
+	k = uint32(math.Max(1, math.Round(mf/float64(n)*math.Ln2)))
+	return m, k
+}
+
+// BuildBloom writes a filter for the "HASH:COUNT" lines of r, sized for n
+// entries at false positive rate p. The filter is built in memory.
+func BuildBloom(r io.Reader, w io.Writer, n uint64, p float64) error {
+	if n == 0 || p <= 0 || p >= 1 {
+		return errors.New("invalid bloom filter parameters")
+	}
+
+	m, k := BloomSize(n, p)
+	bits := make([]byte, (m+7)/8)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		hash, _, _ := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if hash == "" {
+			continue
+		}
+		digest, err := hex.DecodeString(hash)
+		if err != nil || len(digest) != 20 {
+			return fmt.Errorf("invalid dataset line %q", scanner.Text())
+		}
+		for _, pos := range bloomPositions(digest, k, m) {
+			bits[pos/8] |= 1 << (pos % 8)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	header := make([]byte, bloomHeaderSize)
+	copy(header, bloomMagic)
+	binary.BigEndian.PutUint32(header[8:12], k)
+	binary.BigEndian.PutUint64(header[12:20], m)
+
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	_, err := w.Write(bits)
+	return err
+}
@@ -0,0 +1,94 @@
+// Package pwned checks passwords against known data breaches.
+package pwned
+
+import (
+	"context"
+	"crypto/sha1" // #nosec G505 -- SHA-1 is the format of the breach datasets, not used for security
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/Secure-Website-Builder/Backend/internal/config"
+)
+
+var (
+	ErrBreached    = errors.New("password has been found in a data breach, please choose another one")
+	ErrUnavailable = errors.New("password breach check unavailable")
+)
+
+// Checker reports whether a password appears in a breach corpus.
+type Checker interface {
+	IsPwned(ctx context.Context, password string) (bool, error)
+	// Name identifies the backend in logs and metrics.
+	Name() string
+}
+
+// Policy runs a Checker and decides what happens when the check fails.
+type Policy struct {
+	checker    Checker
+	failClosed bool
+}
+
+func NewPolicy(checker Checker, failClosed bool) *Policy {
+	return &Policy{checker: checker, failClosed: failClosed}
+}
+
+// New builds the policy selected by the configuration.
+func New(cfg config.PasswordBreachConfig) (*Policy, error) {
+	var (
+		checker Checker
+		err     error
+	)
+
+	switch cfg.Backend {
+	case "hibp":
+		checker = NewHIBP(cfg.HIBPBaseURL, cfg.Timeout(), cfg.CacheTTL(), cfg.CacheMaxEntries)
+	case "file":
+		checker, err = OpenDataset(cfg.DatasetPath)
+	case "bloom":
+		checker, err = OpenBloom(cfg.DatasetPath)
+	case "none":
+	default:
+		err = fmt.Errorf("unknown password breach backend %q", cfg.Backend)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return NewPolicy(checker, cfg.FailClosed), nil
+}
+
+// Check returns ErrBreached for a breached password. When the backend fails
+// the password is accepted, or rejected with ErrUnavailable if the policy
+// fails closed. A nil policy or checker accepts every password.
+func (p *Policy) Check(ctx context.Context, password string) error {
+	if p == nil || p.checker == nil {
+		return nil
+	}
+
+	pwned, err := p.checker.IsPwned(ctx, password)
+	if err != nil {
+		recordFailure(p.checker.Name())
+		log.Printf("password breach check (%s) failed: %v", p.checker.Name(), err)
+		if p.failClosed {
+			return ErrUnavailable
+		}
+		return nil
+	}
+
+	recordCheck(p.checker.Name(), pwned)
+	if pwned {
+		return ErrBreached
+	}
+
+	return nil
+}
+
+// hashHex returns the upper-case hex SHA-1 of a password, the form used by
+// the breach datasets.
+func hashHex(password string) string {
+	sum := sha1.Sum([]byte(password)) // #nosec G401
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
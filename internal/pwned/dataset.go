@@ -0,0 +1,121 @@
+package pwned
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// maxLineLength bounds a "HASH:COUNT" line of the dataset.
+const maxLineLength = 128
+
+// Dataset looks passwords up in a local copy of the Pwned Passwords SHA-1
+// list ordered by hash, one "HASH:COUNT" line per entry, as produced by the
+// official downloader. The file is binary searched in place, so it is never
+// loaded into memory.
+type Dataset struct {
+	file *os.File
+	size int64
+}
+
+func OpenDataset(path string) (*Dataset, error) {
+	f, err := os.Open(path) // #nosec G304 -- path comes from the app config
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return &Dataset{file: f, size: info.Size()}, nil
+}
+
+func (d *Dataset) Name() string {
+	return "file"
+}
+
+func (d *Dataset) IsPwned(ctx context.Context, password string) (bool, error) {
+	return d.contains(hashHex(password))
+}
+
+// contains binary searches for the line of target. lo is always the start
+// of a line; every line before lo sorts before target and every line
+// starting at or after hi sorts after it.
+func (d *Dataset) contains(target string) (bool, error) {
+	lo, hi := int64(0), d.size
+
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+
+		start, next, line, err := d.lineFrom(mid)
+		if err != nil {
+			return false, err
+		}
+		if line == "" || start >= hi {
+			hi = mid
+			continue
+		}
+
+		hash, _, _ := strings.Cut(line, ":")
+		hash = strings.ToUpper(hash)
+
+		switch {
+		case hash == target:
+			return true, nil
+		case hash < target:
+			lo = next
+		default:
+			hi = mid
+		}
+	}
+
+	return false, nil
+}
+
+// lineFrom returns the first line starting at or after off, its offset and
+// the offset of the line after it. An empty line is returned at the end of
+// the file.
+func (d *Dataset) lineFrom(off int64) (start, next int64, line string, err error) {
+	readAt := off
+	if off > 0 {
+		// Start one byte early: if off begins a line the byte before it is
+		// the newline that ends the previous one.
+		readAt = off - 1
+	}
+
+	buf := make([]byte, 2*maxLineLength)
+	n, err := d.file.ReadAt(buf, readAt)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return 0, 0, "", err
+	}
+	buf = buf[:n]
+
+	start = readAt
+	if off > 0 {
+		i := bytes.IndexByte(buf, '\n')
+		if i < 0 {
+			return d.size, d.size, "", nil
+		}
+		buf = buf[i+1:]
+		start = readAt + int64(i) + 1
+	}
+
+	end := bytes.IndexByte(buf, '\n')
+	next = start + int64(end) + 1
+	if end < 0 {
+		if start+int64(len(buf)) < d.size {
+			return 0, 0, "", fmt.Errorf("dataset line at offset %d is too long", start)
+		}
+		end = len(buf)
+		next = d.size
+	}
+
+	return start, next, strings.TrimRight(string(buf[:end]), "\r"), nil
+}
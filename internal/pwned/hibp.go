@@ -0,0 +1,161 @@
+package pwned
+
+import (
+	"bufio"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HIBP queries a Pwned Passwords range API. Only the first five characters
+// of the SHA-1 leave the process (k-anonymity); padded responses are
+// requested so the response size does not leak the prefix either.
+//
+// The result of each check is cached, not the whole range: a range holds
+// hundreds of suffixes, too many to keep for every prefix seen. Results
+// are keyed by an HMAC of the hash under a key made at startup, so the
+// cache does not hold unsalted password hashes.
+type HIBP struct {
+	baseURL string
+	client  *http.Client
+
+	cacheTTL   time.Duration
+	maxEntries int
+	cacheKey   []byte
+
+	mu    sync.Mutex
+	cache map[string]resultEntry
+}
+
+type resultEntry struct {
+	pwned     bool
+	fetchedAt time.Time
+}
+
+// NewHIBP creates a checker for the range API at baseURL, e.g.
+// https://api.pwnedpasswords.com. A cacheTTL or maxEntries of 0 disables
+// caching.
+func NewHIBP(baseURL string, timeout, cacheTTL time.Duration, maxEntries int) *HIBP {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		// Without a key results are not cached rather than cached in clear.
+		maxEntries = 0
+	}
+
+	return &HIBP{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		client:     &http.Client{Timeout: timeout},
+		cacheTTL:   cacheTTL,
+		maxEntries: maxEntries,
+		cacheKey:   key,
+		cache:      map[string]resultEntry{},
+	}
+}
+
+func (h *HIBP) Name() string {
+	return "hibp"
+}
+
+func (h *HIBP) IsPwned(ctx context.Context, password string) (bool, error) {
+	hash := hashHex(password)
+	prefix, suffix := hash[:5], hash[5:]
+
+	key := h.key(hash)
+	if pwned, ok := h.cached(key); ok {
+		return pwned, nil
+	}
+
+	suffixes, err := h.fetchRange(ctx, prefix)
+	if err != nil {
+		return false, err
+	}
+
+	_, pwned := suffixes[suffix]
+	h.store(key, pwned)
+	return pwned, nil
+}
+
+func (h *HIBP) key(hash string) string {
+	mac := hmac.New(sha256.New, h.cacheKey)
+	mac.Write([]byte(hash))
+	return string(mac.Sum(nil))
+}
+
+func (h *HIBP) cached(key string) (bool, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	entry, ok := h.cache[key]
+	if !ok || time.Since(entry.fetchedAt) > h.cacheTTL {
+		return false, false
+	}
+	return entry.pwned, true
+}
+
+func (h *HIBP) store(key string, pwned bool) {
+	if h.cacheTTL <= 0 || h.maxEntries <= 0 {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(h.cache) >= h.maxEntries {
+		// Drop expired entries first, then anything, to stay bounded.
+		for k, e := range h.cache {
+			if time.Since(e.fetchedAt) > h.cacheTTL {
+				delete(h.cache, k)
+			}
+		}
+		for k := range h.cache {
+			if len(h.cache) < h.maxEntries {
+				break
+			}
+			delete(h.cache, k)
+		}
+	}
+
+	h.cache[key] = resultEntry{pwned: pwned, fetchedAt: time.Now()}
+}
+
+func (h *HIBP) fetchRange(ctx context.Context, prefix string) (map[string]struct{}, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, h.baseURL+"/range/"+prefix, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "password-checker/1.0")
+	req.Header.Set("Add-Padding", "true")
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HIBP API error: %s", resp.Status)
+	}
+
+	suffixes := map[string]struct{}{}
+	scanner := bufio.NewScanner(io.LimitReader(resp.Body, 4<<20))
+	for scanner.Scan() {
+		suffix, count, ok := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		// Padding entries have a count of 0.
+		if !ok || count == "0" {
+			continue
+		}
+		suffixes[strings.ToUpper(suffix)] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return suffixes, nil
+}
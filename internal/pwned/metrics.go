@@ -0,0 +1,19 @@
+package pwned
+
+import "expvar"
+
+// metrics are published through expvar as "password_breach_checks", keyed
+// by "<backend>.checks", "<backend>.breached" and "<backend>.failures".
+var metrics = expvar.NewMap("password_breach_checks")
+
+func recordCheck(backend string, breached bool) {
+	metrics.Add(backend+".checks", 1)
+	if breached {
+		metrics.Add(backend+".breached", 1)
+	}
+}
+
+func recordFailure(backend string) {
+	metrics.Add(backend+".checks", 1)
+	metrics.Add(backend+".failures", 1)
+}
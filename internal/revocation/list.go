@@ -0,0 +1,122 @@
+package revocation
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/Secure-Website-Builder/Backend/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	// syncOverlap re-reads revocations slightly older than the newest one
+	// seen. revoked_at is the start of the revoking transaction, so a row
+	// can become visible after rows with a later revoked_at.
+	syncOverlap = time.Minute
+
+	// expiryLeeway keeps entries a little past their expiry to cover clock
+	// differences between instances and the database.
+	expiryLeeway = 5 * time.Minute
+)
+
+// List is the set of revoked access token ids (the "jti" claim). Lookups
+// are served from memory so JWTAuth can check every request; the set is
+// loaded from the revoked_token table and kept in sync with it, so
+// revocations made by other instances apply within one sync interval.
+// Entries are dropped once the token has expired anyway.
+type List struct {
+	db *database.DB
+
+	mu      sync.RWMutex
+	revoked map[uuid.UUID]time.Time
+	cursor  time.Time
+	syncMu  sync.Mutex
+}
+
+// New loads the current revocations and starts syncing them every interval.
+func New(ctx context.Context, db *database.DB, interval time.Duration) (*List, error) {
+	l := &List{
+		db:      db,
+		revoked: map[uuid.UUID]time.Time{},
+	}
+
+	if err := l.Sync(ctx); err != nil {
+		return nil, err
+	}
+
+	go l.syncLoop(interval)
+	return l, nil
+}
+
+// IsRevoked reports whether the token with the given id was revoked.
+func (l *List) IsRevoked(jti uuid.UUID) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	_, ok := l.revoked[jti]
+	return ok
+}
+
+// Sync reads revocations made since the last sync. Call it after
+// committing a revocation so it applies on this instance right away.
+func (l *List) Sync(ctx context.Context) error {
+	l.syncMu.Lock()
+	defer l.syncMu.Unlock()
+
+	since := l.cursor.Add(-syncOverlap)
+	if l.cursor.IsZero() {
+		since = time.Time{}
+	}
+
+	rows, err := l.db.Queries.ListRevokedTokensSince(ctx, since)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, row := range rows {
+		l.revoked[row.Jti] = row.ExpiresAt
+		if row.RevokedAt.After(l.cursor) {
+			l.cursor = row.RevokedAt
+		}
+	}
+
+	return nil
+}
+
+func (l *List) syncLoop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	for range ticker.C {
+		ctx := context.Background()
+
+		if err := l.Sync(ctx); err != nil {
+			log.Printf("token revocation: sync failed: %v", err)
+		}
+
+		l.prune(ctx)
+	}
+}
+
+// prune forgets expired tokens, in memory and in the database.
+func (l *List) prune(ctx context.Context) {
+	cutoff := time.Now().Add(-expiryLeeway)
+
+	l.mu.Lock()
+	for jti, expiresAt := range l.revoked {
+		if expiresAt.Before(cutoff) {
+			delete(l.revoked, jti)
+		}
+	}
+	l.mu.Unlock()
+
+	if err := l.db.Queries.DeleteExpiredRevokedTokens(ctx, cutoff); err != nil {
+		log.Printf("token revocation: failed to delete expired revocations: %v", err)
+	}
+	if err := l.db.Queries.DeleteExpiredAccessTokens(ctx, cutoff); err != nil {
+		log.Printf("token revocation: failed to delete expired access tokens: %v", err)
+	}
+}
@@ -0,0 +1,32 @@
+package admin
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/Secure-Website-Builder/Backend/internal/models"
+)
+
+func toAdminDTO(
+	adminID int64,
+	email string,
+	createdBy sql.NullInt64,
+	disabledAt sql.NullTime,
+	createdAt time.Time,
+) models.AdminDTO {
+
+	dto := models.AdminDTO{
+		AdminID:   adminID,
+		Email:     email,
+		Disabled:  disabledAt.Valid,
+		CreatedAt: createdAt,
+	}
+	if createdBy.Valid {
+		dto.CreatedBy = &createdBy.Int64
+	}
+	if disabledAt.Valid {
+		dto.DisabledAt = &disabledAt.Time
+	}
+
+	return dto
+}
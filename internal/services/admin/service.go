@@ -0,0 +1,247 @@
+package admin
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/Secure-Website-Builder/Backend/internal/database"
+	"github.com/Secure-Website-Builder/Backend/internal/errorx"
+	"github.com/Secure-Website-Builder/Backend/internal/models"
+	"github.com/Secure-Website-Builder/Backend/internal/passhash"
+	"github.com/Secure-Website-Builder/Backend/internal/pwned"
+	"github.com/Secure-Website-Builder/Backend/internal/revocation"
+	"github.com/Secure-Website-Builder/Backend/internal/utils"
+)
+
+const (
+	defaultAuditLogLimit = 50
+	maxAuditLogLimit     = 200
+)
+
+type Service struct {
+	db      *database.DB
+	breach  *pwned.Policy
+	revoked *revocation.List
+	hasher  *passhash.Hasher
+}
+
+func New(db *database.DB, breach *pwned.Policy, revoked *revocation.List, hasher *passhash.Hasher) *Service {
+	return &Service{db: db, breach: breach, revoked: revoked, hasher: hasher}
+}
+
+func (s *Service) ListAdmins(ctx context.Context) ([]models.AdminDTO, error) {
+	rows, err := s.db.Queries.ListAdmins(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]models.AdminDTO, 0, len(rows))
+	for _, row := range rows {
+		out = append(out, toAdminDTO(row.AdminID, row.Email, row.CreatedBy, row.DisabledAt, row.CreatedAt))
+	}
+
+	return out, nil
+}
+
+func (s *Service) GetAdmin(ctx context.Context, adminID int64) (*models.AdminDTO, error) {
+	row, err := s.db.Queries.GetAdminByID(ctx, adminID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errorx.ErrAdminNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	dto := toAdminDTO(row.AdminID, row.Email, row.CreatedBy, row.DisabledAt, row.CreatedAt)
+	return &dto, nil
+}
+
+// CreateAdmin adds an admin account on behalf of createdBy. The new admin
+// has to enroll MFA on first login before using any admin route.
+func (s *Service) CreateAdmin(
+	ctx context.Context,
+	createdBy int64,
+	email, password string,
+) (*models.AdminDTO, error) {
+
+	if _, err := utils.CheckPasswordPolicy(password, "admin"); err != nil {
+		return nil, fmt.Errorf("%w: %v", errorx.ErrPasswordPolicy, err)
+	}
+
+	switch err := s.breach.Check(ctx, password); {
+	case errors.Is(err, pwned.ErrBreached):
+		return nil, fmt.Errorf("%w: %v", errorx.ErrPasswordPolicy, err)
+	case errors.Is(err, pwned.ErrUnavailable):
+		return nil, errorx.ErrBreachCheckUnavailable
+	case err != nil:
+		return nil, err
+	}
+
+	hashed, err := s.hasher.Hash(password)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errorx.ErrPasswordPolicy, err)
+	}
+
+	_, err = s.db.Queries.GetAdminByEmail(ctx, email)
+	if err == nil {
+		return nil, errorx.ErrAdminEmailTaken
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	row, err := s.db.Queries.CreateAdmin(ctx, models.CreateAdminParams{
+		Email:        email,
+		PasswordHash: hashed,
+		CreatedBy:    sql.NullInt64{Int64: createdBy, Valid: true},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	dto := toAdminDTO(row.AdminID, row.Email, row.CreatedBy, row.DisabledAt, row.CreatedAt)
+	return &dto, nil
+}
+
+// DisableAdmin blocks an admin from logging in and ends all of their
+// sessions, revoking the access tokens they hold. Admins cannot disable
+// themselves so at least one always remains able to re-enable the others.
+func (s *Service) DisableAdmin(ctx context.Context, actorID, adminID int64) error {
+	if actorID == adminID {
+		return errorx.ErrCannotDisableSelf
+	}
+
+	err := s.db.RunInTx(ctx, func(q *models.Queries) error {
+		disabled, err := q.DisableAdmin(ctx, adminID)
+		if err != nil {
+			return err
+		}
+		if disabled == 0 {
+			if _, err := q.GetAdminByID(ctx, adminID); errors.Is(err, sql.ErrNoRows) {
+				return errorx.ErrAdminNotFound
+			}
+			// already disabled
+			return nil
+		}
+
+		if err := q.RevokeUserAuthSessions(ctx, models.RevokeUserAuthSessionsParams{
+			UserID:   adminID,
+			UserRole: "admin",
+		}); err != nil {
+			return err
+		}
+
+		if err := q.RevokeUserRefreshTokens(ctx, models.RevokeUserRefreshTokensParams{
+			UserID:   adminID,
+			UserRole: "admin",
+		}); err != nil {
+			return err
+		}
+
+		return q.RevokeSessionAccessTokens(ctx, models.RevokeSessionAccessTokensParams{
+			UserID:   adminID,
+			UserRole: "admin",
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := s.revoked.Sync(ctx); err != nil {
+		log.Printf("admin: failed to sync token revocations: %v", err)
+	}
+	return nil
+}
+
+func (s *Service) EnableAdmin(ctx context.Context, adminID int64) error {
+	enabled, err := s.db.Queries.EnableAdmin(ctx, adminID)
+	if err != nil {
+		return err
+	}
+	if enabled == 0 {
+		if _, err := s.db.Queries.GetAdminByID(ctx, adminID); errors.Is(err, sql.ErrNoRows) {
+			return errorx.ErrAdminNotFound
+		}
+	}
+
+	return nil
+}
+
+// AuditEntry describes one admin action.
+type AuditEntry struct {
+	AdminID    int64
+	Action     string
+	Details    map[string]string
+	StatusCode int
+	IP         string
+}
+
+// RecordAudit appends an entry to the admin audit log.
+func (s *Service) RecordAudit(ctx context.Context, entry AuditEntry) error {
+	details := entry.Details
+	if details == nil {
+		details = map[string]string{}
+	}
+
+	raw, err := json.Marshal(details)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Queries.CreateAdminAuditLog(ctx, models.CreateAdminAuditLogParams{
+		AdminID:    entry.AdminID,
+		Action:     entry.Action,
+		Details:    raw,
+		StatusCode: int32(entry.StatusCode),
+		IpAddress:  utils.ToInet(entry.IP),
+	})
+}
+
+// ListAuditLog returns audit entries newest first. adminID filters by
+// actor; beforeID pages backwards from a previous result.
+func (s *Service) ListAuditLog(
+	ctx context.Context,
+	adminID, beforeID *int64,
+	limit int32,
+) ([]models.AdminAuditLogDTO, error) {
+
+	if limit <= 0 {
+		limit = defaultAuditLogLimit
+	}
+	if limit > maxAuditLogLimit {
+		limit = maxAuditLogLimit
+	}
+
+	params := models.ListAdminAuditLogParams{Limit: limit}
+	if adminID != nil {
+		params.AdminID = sql.NullInt64{Int64: *adminID, Valid: true}
+	}
+	if beforeID != nil {
+		params.BeforeID = sql.NullInt64{Int64: *beforeID, Valid: true}
+	}
+
+	rows, err := s.db.Queries.ListAdminAuditLog(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]models.AdminAuditLogDTO, 0, len(rows))
+	for _, row := range rows {
+		dto := models.AdminAuditLogDTO{
+			AuditLogID: row.AdminAuditLogID,
+			AdminID:    row.AdminID,
+			Action:     row.Action,
+			Details:    row.Details,
+			StatusCode: row.StatusCode,
+			IPAddress:  utils.InetString(row.IpAddress),
+			CreatedAt:  row.CreatedAt,
+		}
+		out = append(out, dto)
+	}
+
+	return out, nil
+}
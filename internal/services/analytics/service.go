@@ -1,10 +1,17 @@
 package analytics
 
 import (
+	"context"
+	"time"
 
 	"github.com/Secure-Website-Builder/Backend/internal/database"
+	"github.com/Secure-Website-Builder/Backend/internal/errorx"
+	"github.com/Secure-Website-Builder/Backend/internal/models"
 )
 
+// maxRange bounds the period of a report.
+const maxRange = 366 * 24 * time.Hour
+
 type Service struct {
 	db *database.DB
 }
@@ -13,4 +20,65 @@ func New(db *database.DB) *Service {
 	return &Service{db: db}
 }
 
+// ProductAddToCartRates reports, per product viewed or added to a cart in
+// [from, to), how many sessions did each and the add-to-cart rate.
+func (s *Service) ProductAddToCartRates(
+	ctx context.Context,
+	storeID int64,
+	from, to time.Time,
+) ([]models.ProductAddToCartRateDTO, error) {
+
+	if !to.After(from) || to.Sub(from) > maxRange {
+		return nil, errorx.ErrInvalidDateRange
+	}
+
+	rows, err := s.db.Queries.GetProductAddToCartRates(ctx, models.GetProductAddToCartRatesParams{
+		StoreID: storeID,
+		From:    from,
+		To:      to,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]models.ProductAddToCartRateDTO, 0, len(rows))
+	for _, row := range rows {
+		out = append(out, models.ProductAddToCartRateDTO{
+			ProductID:            row.ProductID,
+			ProductName:          row.ProductName,
+			ViewingSessions:      row.ViewingSessions,
+			AddingSessions:       row.AddingSessions,
+			AddToCartRatePercent: row.AddToCartRatePercent,
+		})
+	}
+	return out, nil
+}
 
+// CartRecovery reports the abandoned cart reminders of a store in
+// [from, to) and the revenue of the carts they recovered.
+func (s *Service) CartRecovery(
+	ctx context.Context,
+	storeID int64,
+	from, to time.Time,
+) (*models.CartRecoveryDTO, error) {
+
+	if !to.After(from) || to.Sub(from) > maxRange {
+		return nil, errorx.ErrInvalidDateRange
+	}
+
+	row, err := s.db.Queries.GetCartRecoveryStats(ctx, models.GetCartRecoveryStatsParams{
+		StoreID: storeID,
+		From:    from,
+		To:      to,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.CartRecoveryDTO{
+		RemindersSent:    row.RemindersSent,
+		CartsRecovered:   row.CartsRecovered,
+		RecoveredOrders:  row.RecoveredOrders,
+		RecoveredRevenue: row.RecoveredRevenue,
+	}, nil
+}
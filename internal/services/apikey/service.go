@@ -0,0 +1,200 @@
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/Secure-Website-Builder/Backend/internal/database"
+	"github.com/Secure-Website-Builder/Backend/internal/errorx"
+	"github.com/Secure-Website-Builder/Backend/internal/models"
+	"github.com/Secure-Website-Builder/Backend/internal/permissions"
+	"github.com/Secure-Website-Builder/Backend/internal/utils"
+)
+
+const (
+	// keyPrefix marks our keys so they are recognizable, e.g. by secret
+	// scanners.
+	keyPrefix = "swb_"
+
+	// displayPrefixLength is how much of a key is kept in clear text to
+	// tell keys apart.
+	displayPrefixLength = len(keyPrefix) + 8
+
+	maxNameLength = 100
+)
+
+var ErrInvalidKey = errors.New("invalid api key")
+
+// Key is an authenticated API key. Its scopes are permissions (see
+// permissions.IsAPIKeyScope).
+type Key struct {
+	ID      int64
+	StoreID int64
+	Scopes  []string
+}
+
+// HasScope reports whether the key was granted scope.
+func (k *Key) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Service manages the API keys store owners create for their integrations.
+// Keys are random and long, so like refresh tokens they are stored as a
+// plain SHA-256.
+type Service struct {
+	db *database.DB
+}
+
+func New(db *database.DB) *Service {
+	return &Service{db: db}
+}
+
+// Create issues a key for storeID. The returned DTO is the only place the
+// key itself appears.
+func (s *Service) Create(
+	ctx context.Context,
+	storeID, ownerID int64,
+	name string,
+	scopes []string,
+) (*models.APIKeyDTO, error) {
+
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > maxNameLength {
+		return nil, errorx.ErrInvalidAPIKeyName
+	}
+
+	scopes, err := normalizeScopes(scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	key := keyPrefix + base64.RawURLEncoding.EncodeToString(secret)
+
+	row, err := s.db.Queries.CreateStoreAPIKey(ctx, models.CreateStoreAPIKeyParams{
+		StoreID:   storeID,
+		Name:      name,
+		KeyPrefix: key[:displayPrefixLength],
+		KeyHash:   utils.HashToken(key),
+		Scopes:    scopes,
+		CreatedBy: ownerID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	dto := toAPIKeyDTO(row)
+	dto.Key = key
+	return &dto, nil
+}
+
+func (s *Service) List(ctx context.Context, storeID int64) ([]models.APIKeyDTO, error) {
+	rows, err := s.db.Queries.ListStoreAPIKeys(ctx, storeID)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]models.APIKeyDTO, 0, len(rows))
+	for _, row := range rows {
+		out = append(out, toAPIKeyDTO(row))
+	}
+	return out, nil
+}
+
+// Revoke stops a key from working. Revoked keys stay listed.
+func (s *Service) Revoke(ctx context.Context, storeID, keyID int64) error {
+	n, err := s.db.Queries.RevokeStoreAPIKey(ctx, models.RevokeStoreAPIKeyParams{
+		ApiKeyID: keyID,
+		StoreID:  storeID,
+	})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return errorx.ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// Authenticate looks up a presented key and records that it was used.
+func (s *Service) Authenticate(ctx context.Context, key string) (*Key, error) {
+	if !strings.HasPrefix(key, keyPrefix) {
+		return nil, ErrInvalidKey
+	}
+
+	row, err := s.db.Queries.GetStoreAPIKeyByHash(ctx, utils.HashToken(key))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrInvalidKey
+	}
+	if err != nil {
+		return nil, err
+	}
+	if row.RevokedAt.Valid {
+		return nil, ErrInvalidKey
+	}
+
+	if err := s.db.Queries.TouchStoreAPIKey(ctx, row.ApiKeyID); err != nil {
+		log.Printf("api keys: failed to record use of key %d: %v", row.ApiKeyID, err)
+	}
+
+	return &Key{
+		ID:      row.ApiKeyID,
+		StoreID: row.StoreID,
+		Scopes:  row.Scopes,
+	}, nil
+}
+
+// normalizeScopes rejects unknown scopes and returns the rest sorted and
+// without duplicates.
+func normalizeScopes(scopes []string) ([]string, error) {
+	seen := map[string]bool{}
+	out := make([]string, 0, len(scopes))
+
+	for _, scope := range scopes {
+		if !permissions.IsAPIKeyScope(scope) {
+			return nil, errorx.ErrInvalidAPIKeyScope
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			out = append(out, scope)
+		}
+	}
+	if len(out) == 0 {
+		return nil, errorx.ErrInvalidAPIKeyScope
+	}
+
+	sort.Strings(out)
+	return out, nil
+}
+
+func toAPIKeyDTO(row models.StoreApiKey) models.APIKeyDTO {
+	dto := models.APIKeyDTO{
+		APIKeyID:  row.ApiKeyID,
+		Name:      row.Name,
+		KeyPrefix: row.KeyPrefix,
+		Scopes:    row.Scopes,
+		CreatedBy: row.CreatedBy,
+		CreatedAt: row.CreatedAt,
+	}
+	if row.LastUsedAt.Valid {
+		dto.LastUsedAt = &row.LastUsedAt.Time
+	}
+	if row.RevokedAt.Valid {
+		dto.RevokedAt = &row.RevokedAt.Time
+	}
+	return dto
+}
@@ -0,0 +1,51 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Secure-Website-Builder/Backend/internal/errorx"
+	"github.com/Secure-Website-Builder/Backend/internal/models"
+	"github.com/Secure-Website-Builder/Backend/internal/pwned"
+)
+
+// checkBreachedPassword rejects passwords found in known breaches. Store
+// owners and admins are always checked; customers only when their store
+// enabled check_breached_passwords.
+func (s *Service) checkBreachedPassword(
+	ctx context.Context,
+	q *models.Queries,
+	password, role string,
+	storeID *int64,
+) error {
+
+	if role == "customer" {
+		if storeID == nil {
+			return nil
+		}
+		store, err := q.GetStore(ctx, *storeID)
+		if err != nil {
+			return err
+		}
+		if !store.CheckBreachedPasswords {
+			return nil
+		}
+	}
+
+	return breachError(s.breach.Check(ctx, password))
+}
+
+// breachError maps pwned.Policy errors to API errors.
+func breachError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, pwned.ErrBreached):
+		return fmt.Errorf("%w: %v", errorx.ErrPasswordPolicy, err)
+	case errors.Is(err, pwned.ErrUnavailable):
+		return errorx.ErrBreachCheckUnavailable
+	default:
+		return err
+	}
+}
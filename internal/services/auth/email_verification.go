@@ -0,0 +1,228 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	"github.com/Secure-Website-Builder/Backend/internal/errorx"
+	"github.com/Secure-Website-Builder/Backend/internal/models"
+	"github.com/Secure-Website-Builder/Backend/internal/notify"
+	"github.com/Secure-Website-Builder/Backend/internal/utils"
+	"github.com/lib/pq"
+)
+
+// VerifyEmail consumes a verification token and marks the address it was
+// sent to as verified. A token sent by ChangeEmail first moves the account
+// to the new address. Only the latest token of an account works.
+func (s *Service) VerifyEmail(ctx context.Context, token string) error {
+	return s.db.RunInTx(ctx, func(q *models.Queries) error {
+
+		vt, err := q.GetEmailVerificationTokenForUpdate(ctx, utils.HashToken(token))
+		if errors.Is(err, sql.ErrNoRows) {
+			return errorx.ErrInvalidVerificationToken
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := applyPendingEmail(ctx, q, vt); err != nil {
+			return err
+		}
+
+		var updated int64
+		switch vt.UserRole {
+		case "store_owner":
+			updated, err = q.MarkStoreOwnerEmailVerified(ctx, models.MarkStoreOwnerEmailVerifiedParams{
+				StoreOwnerID: vt.UserID,
+				Email:        vt.Email,
+			})
+		case "customer":
+			updated, err = q.MarkCustomerEmailVerified(ctx, models.MarkCustomerEmailVerifiedParams{
+				CustomerID: vt.UserID,
+				Email:      vt.Email,
+			})
+		}
+		if err != nil {
+			return err
+		}
+		if updated == 0 {
+			return errorx.ErrInvalidVerificationToken
+		}
+
+		// Orders placed as a guest with this address now belong to the
+		// customer. This waits for verification so that registering with
+		// someone else's address does not reveal their orders.
+		if vt.UserRole == "customer" {
+			if _, err := q.LinkGuestOrdersToCustomer(ctx, vt.UserID); err != nil {
+				return err
+			}
+		}
+
+		return q.InvalidateUserEmailVerificationTokens(ctx, models.InvalidateUserEmailVerificationTokensParams{
+			UserID:   vt.UserID,
+			UserRole: vt.UserRole,
+		})
+	})
+}
+
+// applyPendingEmail moves the account to the address of a token sent by
+// ChangeEmail. Tokens for the current address leave it unchanged. The
+// address may have been taken since the change was requested.
+func applyPendingEmail(ctx context.Context, q *models.Queries, vt models.EmailVerificationToken) error {
+	var err error
+	switch vt.UserRole {
+	case "store_owner":
+		var owner models.GetStoreOwnerByIDRow
+		owner, err = q.GetStoreOwnerByID(ctx, vt.UserID)
+		if err != nil || owner.Email == vt.Email {
+			return err
+		}
+		err = q.UpdateStoreOwnerEmail(ctx, models.UpdateStoreOwnerEmailParams{
+			StoreOwnerID: vt.UserID,
+			Email:        vt.Email,
+		})
+
+	case "customer":
+		var customer models.GetCustomerByIDRow
+		customer, err = q.GetCustomerByID(ctx, vt.UserID)
+		if err != nil || customer.Email == vt.Email {
+			return err
+		}
+		err = q.UpdateCustomerEmail(ctx, models.UpdateCustomerEmailParams{
+			CustomerID: vt.UserID,
+			Email:      vt.Email,
+		})
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return errorx.ErrEmailTaken
+	}
+	return err
+}
+
+// ResendEmailVerification sends a fresh verification link, invalidating
+// any earlier one.
+func (s *Service) ResendEmailVerification(
+	ctx context.Context,
+	userID int64,
+	role string,
+) error {
+
+	email, storeID, verified, err := s.emailStatus(ctx, userID, role)
+	if err != nil {
+		return err
+	}
+	if verified {
+		return errorx.ErrEmailAlreadyVerified
+	}
+
+	return s.sendEmailVerification(ctx, userID, role, storeID, email)
+}
+
+// IsEmailVerified reports whether the account's current email address has
+// been verified.
+func (s *Service) IsEmailVerified(ctx context.Context, userID int64, role string) (bool, error) {
+	_, _, verified, err := s.emailStatus(ctx, userID, role)
+	return verified, err
+}
+
+func (s *Service) emailStatus(
+	ctx context.Context,
+	userID int64,
+	role string,
+) (email string, storeID *int64, verified bool, err error) {
+
+	switch role {
+	case "store_owner":
+		owner, err := s.db.Queries.GetStoreOwnerByID(ctx, userID)
+		if err != nil {
+			return "", nil, false, err
+		}
+		return owner.Email, nil, owner.EmailVerifiedAt.Valid, nil
+
+	case "customer":
+		customer, err := s.db.Queries.GetCustomerByID(ctx, userID)
+		if err != nil {
+			return "", nil, false, err
+		}
+		return customer.Email, &customer.StoreID, customer.EmailVerifiedAt.Valid, nil
+
+	default:
+		return "", nil, false, errors.New("invalid role")
+	}
+}
+
+// sendEmailVerification stores a new verification token for email and
+// mails the link to it.
+func (s *Service) sendEmailVerification(
+	ctx context.Context,
+	userID int64,
+	role string,
+	storeID *int64,
+	email string,
+) error {
+
+	token, err := utils.GenerateRefreshToken()
+	if err != nil {
+		return err
+	}
+
+	nullableStoreID := sql.NullInt64{}
+	if storeID != nil {
+		nullableStoreID = sql.NullInt64{Int64: *storeID, Valid: true}
+	}
+
+	err = s.db.RunInTx(ctx, func(q *models.Queries) error {
+		if err := q.InvalidateUserEmailVerificationTokens(ctx, models.InvalidateUserEmailVerificationTokensParams{
+			UserID:   userID,
+			UserRole: role,
+		}); err != nil {
+			return err
+		}
+
+		return q.CreateEmailVerificationToken(ctx, models.CreateEmailVerificationTokenParams{
+			TokenHash: utils.HashToken(token),
+			UserID:    userID,
+			UserRole:  role,
+			StoreID:   nullableStoreID,
+			Email:     email,
+			ExpiresAt: time.Now().Add(s.cfg.EmailVerification.TTL()),
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	link := s.cfg.EmailVerification.URL + "?token=" + url.QueryEscape(token)
+
+	return s.notifier.Send(ctx, notify.Message{
+		To:      email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf(
+			"Confirm this email address by opening the link below. It expires in %d minutes.\n\n%s\n\nIf you did not create an account, you can ignore this email.",
+			s.cfg.EmailVerification.TTLMinutes,
+			link,
+		),
+	})
+}
+
+// sendWelcomeVerification is used right after registration, where a failed
+// delivery must not fail the registration itself: the user can ask for a
+// new link.
+func (s *Service) sendWelcomeVerification(
+	ctx context.Context,
+	userID int64,
+	role string,
+	storeID *int64,
+	email string,
+) {
+	if err := s.sendEmailVerification(ctx, userID, role, storeID, email); err != nil {
+		log.Printf("email verification: failed to send to %s account %d: %v", role, userID, err)
+	}
+}
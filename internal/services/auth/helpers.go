@@ -3,11 +3,189 @@ package auth
 import (
 	"context"
 	"database/sql"
+	"time"
 
 	"github.com/Secure-Website-Builder/Backend/internal/models"
+	"github.com/Secure-Website-Builder/Backend/internal/utils"
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 )
 
+const (
+	accessTokenTTL          = 24 * time.Hour
+	refreshedAccessTokenTTL = 15 * time.Minute
+	refreshTokenTTL         = 7 * 24 * time.Hour
+)
+
+// issueTokens opens a new auth session for the given identity and creates an
+// access token and a persisted refresh token for it. The session ID is also
+// the family of the refresh token.
+// mfaVerified is carried over to refreshed access tokens.
+func (s *Service) issueTokens(
+	ctx context.Context,
+	userID int64,
+	role string,
+	storeID *int64,
+	mfaVerified bool,
+	client ClientInfo,
+) (accessToken, refreshToken string, err error) {
+
+	nullableStoreID := sql.NullInt64{
+		Valid: false,
+	}
+	if storeID != nil {
+		nullableStoreID = sql.NullInt64{
+			Int64: *storeID,
+			Valid: true,
+		}
+	}
+
+	sessionID := uuid.New()
+	tokenID := uuid.New()
+
+	err = s.db.RunInTx(ctx, func(q *models.Queries) error {
+		if err := q.CreateAuthSession(ctx, models.CreateAuthSessionParams{
+			SessionID: sessionID,
+			UserID:    userID,
+			UserRole:  role,
+			StoreID:   nullableStoreID,
+			IpAddress: client.inet(),
+			UserAgent: client.userAgent(),
+		}); err != nil {
+			return err
+		}
+
+		if err := q.CreateAccessToken(ctx, models.CreateAccessTokenParams{
+			Jti:       tokenID,
+			SessionID: sessionID,
+			ExpiresAt: time.Now().Add(accessTokenTTL),
+		}); err != nil {
+			return err
+		}
+
+		var err error
+		refreshToken, err = createRefreshToken(ctx, q, models.RefreshToken{
+			FamilyID:    sessionID,
+			UserID:      userID,
+			UserRole:    role,
+			StoreID:     nullableStoreID,
+			MfaVerified: mfaVerified,
+		})
+		return err
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	accessToken, err = utils.GenerateJWT(
+		userID,
+		role,
+		storeID,
+		sessionID.String(),
+		tokenID.String(),
+		mfaVerified,
+		s.keys,
+		accessTokenTTL,
+	)
+	if err != nil {
+		return "", "", err
+	}
+
+	return accessToken, refreshToken, nil
+}
+
+// completeLogin finishes a login whose first factor (password or OIDC) was
+// verified: it either issues an MFA challenge or opens a session.
+func (s *Service) completeLogin(
+	ctx context.Context,
+	userID int64,
+	role string,
+	storeID *int64,
+	client ClientInfo,
+) (*AuthResult, error) {
+
+	enrolled, err := s.hasConfirmedMFA(ctx, userID, role)
+	if err != nil {
+		return nil, err
+	}
+
+	if enrolled {
+		mfaToken, err := utils.GenerateMFAPendingJWT(
+			userID,
+			role,
+			storeID,
+			uuid.NewString(),
+			s.keys,
+			s.cfg.MFAPendingTokenTTL(),
+		)
+		if err != nil {
+			return nil, err
+		}
+		return &AuthResult{MFAToken: mfaToken}, nil
+	}
+
+	accessToken, refreshToken, err := s.issueTokens(ctx, userID, role, storeID, false, client)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AuthResult{
+		AccessToken:           accessToken,
+		RefreshToken:          refreshToken,
+		MFAEnrollmentRequired: utils.MFARequired(role),
+	}, nil
+}
+
+// createRefreshToken generates a new refresh token in the family and with the
+// identity of parent, and stores only its hash.
+func createRefreshToken(
+	ctx context.Context,
+	q *models.Queries,
+	parent models.RefreshToken,
+) (string, error) {
+
+	token, err := utils.GenerateRefreshToken()
+	if err != nil {
+		return "", err
+	}
+
+	err = q.CreateRefreshToken(ctx, models.CreateRefreshTokenParams{
+		TokenHash:   utils.HashToken(token),
+		FamilyID:    parent.FamilyID,
+		UserID:      parent.UserID,
+		UserRole:    parent.UserRole,
+		StoreID:     parent.StoreID,
+		ExpiresAt:   time.Now().Add(refreshTokenTTL),
+		MfaVerified: parent.MfaVerified,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// identityFromClaims extracts the subject of a token issued by utils.GenerateJWT
+// or utils.GenerateMFAPendingJWT.
+func identityFromClaims(claims jwt.MapClaims) (userID int64, role string, storeID *int64, ok bool) {
+	rawUserID, ok := claims["user_id"].(float64)
+	if !ok {
+		return 0, "", nil, false
+	}
+
+	role, ok = claims["role"].(string)
+	if !ok {
+		return 0, "", nil, false
+	}
+
+	if rawStoreID, exists := claims["store_id"].(float64); exists {
+		id := int64(rawStoreID)
+		storeID = &id
+	}
+
+	return int64(rawUserID), role, storeID, true
+}
+
 func (s *Service) mergeCustomerCartOnLogin(
 	ctx context.Context,
 	storeID int64,
@@ -22,6 +200,15 @@ func (s *Service) mergeCustomerCartOnLogin(
 			customerCart *models.Cart
 		)
 
+		// The visitor session now belongs to the customer
+		if err := q.LinkVisitorSessionToCustomer(ctx, models.LinkVisitorSessionToCustomerParams{
+			CustomerID: sql.NullInt64{Int64: customerID, Valid: true},
+			SessionID:  sessionID,
+			StoreID:    storeID,
+		}); err != nil {
+			return err
+		}
+
 		// Get guest/session cart
 		cartBySession, err := q.GetCartBySessionForUpdate(ctx, models.GetCartBySessionForUpdateParams{
 			StoreID:   storeID,
@@ -0,0 +1,109 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/Secure-Website-Builder/Backend/internal/errorx"
+	"github.com/Secure-Website-Builder/Backend/internal/models"
+)
+
+var errInvalidCredentials = errors.New("invalid credentials")
+
+// loginKey identifies an account for the failed-login counters. It is built
+// from the submitted identity rather than a user ID so attempts against
+// unknown emails are throttled exactly like attempts against real ones.
+func loginKey(role string, storeID *int64, email string) string {
+	store := "-"
+	if storeID != nil {
+		store = strconv.FormatInt(*storeID, 10)
+	}
+	return "login:" + role + ":" + store + ":" + strings.ToLower(strings.TrimSpace(email))
+}
+
+// mfaKey identifies an account for the failed second-factor counters.
+func mfaKey(role string, userID int64) string {
+	return "mfa:" + role + ":" + strconv.FormatInt(userID, 10)
+}
+
+// checkLockout refuses an attempt while key is delayed or locked out, and
+// otherwise counts it as failed until it succeeds and the key is reset.
+func (s *Service) checkLockout(key string) error {
+	retryAfter, locked := s.lockout.Attempt(key)
+	if retryAfter == 0 {
+		return nil
+	}
+
+	err := errorx.ErrLoginThrottled
+	if locked {
+		err = errorx.ErrAccountLocked
+	}
+
+	return &errorx.RetryAfterError{Err: err, RetryAfter: retryAfter}
+}
+
+// UnlockAccount clears the failed-attempt counters of an account so its
+// owner can log in again before the lockout expires.
+func (s *Service) UnlockAccount(
+	ctx context.Context,
+	email, role string,
+	storeID *int64,
+) error {
+
+	if role != "customer" {
+		storeID = nil
+	}
+	s.lockout.Reset(loginKey(role, storeID, email))
+
+	var (
+		userID int64
+		err    error
+	)
+
+	switch role {
+	case "store_owner":
+		var owner models.GetStoreOwnerByEmailRow
+		owner, err = s.db.Queries.GetStoreOwnerByEmail(ctx, email)
+		userID = owner.StoreOwnerID
+	case "admin":
+		var admin models.GetAdminByEmailRow
+		admin, err = s.db.Queries.GetAdminByEmail(ctx, email)
+		userID = admin.AdminID
+	default:
+		return nil
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	s.lockout.Reset(mfaKey(role, userID))
+
+	return nil
+}
+
+// unlockUser clears the counters of an account identified by ID, used once
+// the user has proven control of the account by other means.
+func (s *Service) unlockUser(ctx context.Context, userID int64, role string) error {
+	switch role {
+	case "store_owner":
+		owner, err := s.db.Queries.GetStoreOwnerByID(ctx, userID)
+		if err != nil {
+			return err
+		}
+		s.lockout.Reset(loginKey(role, nil, owner.Email))
+	case "customer":
+		customer, err := s.db.Queries.GetCustomerByID(ctx, userID)
+		if err != nil {
+			return err
+		}
+		s.lockout.Reset(loginKey(role, &customer.StoreID, customer.Email))
+	}
+
+	s.lockout.Reset(mfaKey(role, userID))
+	return nil
+}
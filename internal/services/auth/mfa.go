@@ -0,0 +1,339 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Secure-Website-Builder/Backend/internal/errorx"
+	"github.com/Secure-Website-Builder/Backend/internal/models"
+	"github.com/Secure-Website-Builder/Backend/internal/utils"
+	"github.com/google/uuid"
+)
+
+const recoveryCodeCount = 10
+
+// EnrollMFA generates a new TOTP secret and a fresh set of recovery codes.
+//
+// The factor stays inactive until ConfirmMFA succeeds, so calling EnrollMFA
+// again before confirming simply replaces the pending secret. An already
+// confirmed factor must be disabled first.
+func (s *Service) EnrollMFA(
+	ctx context.Context,
+	userID int64,
+	role string,
+) (*models.MFAEnrollmentDTO, error) {
+
+	if !utils.MFARequired(role) {
+		return nil, errorx.ErrMFANotSupported
+	}
+
+	existing, err := s.db.Queries.GetUserMFA(ctx, models.GetUserMFAParams{
+		UserID:   userID,
+		UserRole: role,
+	})
+	if err == nil && existing.ConfirmedAt.Valid {
+		return nil, errorx.ErrMFAAlreadyEnabled
+	}
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	accountName, err := s.mfaAccountName(ctx, userID, role)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	codes, err := utils.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.db.RunInTx(ctx, func(q *models.Queries) error {
+		if err := q.UpsertUserMFA(ctx, models.UpsertUserMFAParams{
+			UserID:     userID,
+			UserRole:   role,
+			TotpSecret: secret,
+		}); err != nil {
+			return err
+		}
+
+		if err := q.DeleteMFARecoveryCodes(ctx, models.DeleteMFARecoveryCodesParams{
+			UserID:   userID,
+			UserRole: role,
+		}); err != nil {
+			return err
+		}
+
+		for _, code := range codes {
+			if err := q.CreateMFARecoveryCode(ctx, models.CreateMFARecoveryCodeParams{
+				UserID:   userID,
+				UserRole: role,
+				CodeHash: utils.HashToken(code),
+			}); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.MFAEnrollmentDTO{
+		Secret:        secret,
+		OTPAuthURI:    utils.TOTPURI(s.cfg.MFAIssuer, accountName, secret),
+		RecoveryCodes: codes,
+	}, nil
+}
+
+// ConfirmMFA activates a pending factor once the user proves they can
+// generate valid codes with it.
+func (s *Service) ConfirmMFA(
+	ctx context.Context,
+	userID int64,
+	role string,
+	code string,
+) error {
+
+	m, err := s.db.Queries.GetUserMFA(ctx, models.GetUserMFAParams{
+		UserID:   userID,
+		UserRole: role,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return errorx.ErrMFANotEnrolled
+	}
+	if err != nil {
+		return err
+	}
+
+	if m.ConfirmedAt.Valid {
+		return errorx.ErrMFAAlreadyEnabled
+	}
+
+	if err := s.consumeTOTP(ctx, m, code); err != nil {
+		return err
+	}
+
+	return s.db.Queries.ConfirmUserMFA(ctx, models.ConfirmUserMFAParams{
+		UserID:   userID,
+		UserRole: role,
+	})
+}
+
+// VerifyMFA completes a login started with a password: it exchanges the
+// mfa_pending token and a TOTP (or recovery) code for an access/refresh pair.
+func (s *Service) VerifyMFA(
+	ctx context.Context,
+	mfaToken string,
+	code string,
+	client ClientInfo,
+) (*AuthResult, error) {
+
+	_, claims, err := utils.ParseJWT(mfaToken, s.keys)
+	if err != nil || claims["typ"] != utils.TokenTypeMFAPending {
+		return nil, errorx.ErrInvalidMFAToken
+	}
+
+	userID, role, storeID, ok := identityFromClaims(claims)
+	if !ok {
+		return nil, errorx.ErrInvalidMFAToken
+	}
+
+	rawJTI, _ := claims["jti"].(string)
+	jti, err := uuid.Parse(rawJTI)
+	if err != nil {
+		return nil, errorx.ErrInvalidMFAToken
+	}
+	exp, err := claims.GetExpirationTime()
+	if err != nil || exp == nil {
+		return nil, errorx.ErrInvalidMFAToken
+	}
+
+	if err := s.verifySecondFactor(ctx, userID, role, code); err != nil {
+		return nil, err
+	}
+
+	// The token is spent once exchanged, so a copy of it cannot log in
+	// again with another code.
+	revoked, err := s.db.Queries.RevokeToken(ctx, models.RevokeTokenParams{
+		Jti:       jti,
+		ExpiresAt: exp.Time,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if revoked == 0 {
+		return nil, errorx.ErrInvalidMFAToken
+	}
+
+	accessToken, refreshToken, err := s.issueTokens(ctx, userID, role, storeID, true, client)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AuthResult{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+	}, nil
+}
+
+// DisableMFA removes the factor and its recovery codes. A valid TOTP or
+// recovery code is required so a stolen access token alone cannot strip MFA.
+// Re-enrolling afterwards is how a lost or replaced device is reset.
+func (s *Service) DisableMFA(
+	ctx context.Context,
+	userID int64,
+	role string,
+	code string,
+) error {
+
+	if err := s.verifySecondFactor(ctx, userID, role, code); err != nil {
+		return err
+	}
+
+	return s.db.RunInTx(ctx, func(q *models.Queries) error {
+		if err := q.DeleteMFARecoveryCodes(ctx, models.DeleteMFARecoveryCodesParams{
+			UserID:   userID,
+			UserRole: role,
+		}); err != nil {
+			return err
+		}
+
+		return q.DeleteUserMFA(ctx, models.DeleteUserMFAParams{
+			UserID:   userID,
+			UserRole: role,
+		})
+	})
+}
+
+func (s *Service) hasConfirmedMFA(ctx context.Context, userID int64, role string) (bool, error) {
+	if !utils.MFARequired(role) {
+		return false, nil
+	}
+
+	m, err := s.db.Queries.GetUserMFA(ctx, models.GetUserMFAParams{
+		UserID:   userID,
+		UserRole: role,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return m.ConfirmedAt.Valid, nil
+}
+
+// verifySecondFactor accepts either a current TOTP code or an unused
+// recovery code for a confirmed factor. Wrong codes count towards the
+// account's lockout just like wrong passwords.
+func (s *Service) verifySecondFactor(
+	ctx context.Context,
+	userID int64,
+	role string,
+	code string,
+) error {
+
+	key := mfaKey(role, userID)
+	if err := s.checkLockout(key); err != nil {
+		return err
+	}
+
+	err := s.checkSecondFactor(ctx, userID, role, code)
+	if err == nil {
+		s.lockout.Reset(key)
+	}
+
+	return err
+}
+
+func (s *Service) checkSecondFactor(
+	ctx context.Context,
+	userID int64,
+	role string,
+	code string,
+) error {
+
+	m, err := s.db.Queries.GetUserMFA(ctx, models.GetUserMFAParams{
+		UserID:   userID,
+		UserRole: role,
+	})
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && !m.ConfirmedAt.Valid) {
+		return errorx.ErrMFANotEnrolled
+	}
+	if err != nil {
+		return err
+	}
+
+	code = strings.ToLower(strings.TrimSpace(code))
+
+	if !strings.Contains(code, "-") {
+		return s.consumeTOTP(ctx, m, code)
+	}
+
+	used, err := s.db.Queries.UseMFARecoveryCode(ctx, models.UseMFARecoveryCodeParams{
+		UserID:   userID,
+		UserRole: role,
+		CodeHash: utils.HashToken(code),
+	})
+	if err != nil {
+		return err
+	}
+	if used == 0 {
+		return errorx.ErrInvalidMFACode
+	}
+
+	return nil
+}
+
+// consumeTOTP validates code and records its time step so the same code
+// cannot be replayed within its validity window.
+func (s *Service) consumeTOTP(ctx context.Context, m models.UserMfa, code string) error {
+	step, ok := utils.ValidateTOTP(m.TotpSecret, strings.TrimSpace(code), time.Now())
+	if !ok {
+		return errorx.ErrInvalidMFACode
+	}
+
+	updated, err := s.db.Queries.MarkUserMFAStepUsed(ctx, models.MarkUserMFAStepUsedParams{
+		UserID:   m.UserID,
+		UserRole: m.UserRole,
+		Step:     step,
+	})
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return errorx.ErrInvalidMFACode
+	}
+
+	return nil
+}
+
+func (s *Service) mfaAccountName(ctx context.Context, userID int64, role string) (string, error) {
+	switch role {
+	case "store_owner":
+		owner, err := s.db.Queries.GetStoreOwnerByID(ctx, userID)
+		if err != nil {
+			return "", err
+		}
+		return owner.Email, nil
+	case "admin":
+		admin, err := s.db.Queries.GetAdminByID(ctx, userID)
+		if err != nil {
+			return "", err
+		}
+		return admin.Email, nil
+	default:
+		return "", errorx.ErrMFANotSupported
+	}
+}
@@ -0,0 +1,281 @@
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/Secure-Website-Builder/Backend/internal/errorx"
+	"github.com/Secure-Website-Builder/Backend/internal/models"
+	"github.com/Secure-Website-Builder/Backend/internal/oidc"
+	"github.com/Secure-Website-Builder/Backend/internal/utils"
+)
+
+// oidcOnlyPasswordHash is stored for store owners created through OIDC. It
+// is not a valid password hash, so password login fails until the owner sets
+// a password through the reset flow.
+const oidcOnlyPasswordHash = "!oidc"
+
+// StartOIDCLogin begins an authorization code flow with PKCE and returns the
+// URL of the provider's login page. When linkOwnerID is set the flow links
+// the identity the user signs in with to that store owner and has to be
+// finished with CompleteOIDCLink.
+//
+// It also returns the browser binding of the flow. It is kept in a cookie of
+// the browser that started the login and required by CompleteOIDCLogin, so
+// that a callback started by someone else is refused.
+func (s *Service) StartOIDCLogin(
+	ctx context.Context,
+	providerName string,
+	linkOwnerID *int64,
+) (authURL, binding string, err error) {
+
+	provider, err := s.oidc.Get(providerName)
+	if err != nil {
+		return "", "", errorx.ErrUnknownOIDCProvider
+	}
+
+	state, err := oidc.RandomString(32)
+	if err != nil {
+		return "", "", err
+	}
+	nonce, err := oidc.RandomString(32)
+	if err != nil {
+		return "", "", err
+	}
+	verifier, err := oidc.RandomString(32)
+	if err != nil {
+		return "", "", err
+	}
+
+	authURL, err = provider.AuthCodeURL(ctx, state, nonce, oidc.S256Challenge(verifier))
+	if err != nil {
+		return "", "", err
+	}
+
+	if err := s.db.Queries.DeleteExpiredOIDCLoginStates(ctx); err != nil {
+		log.Printf("oidc: delete expired login states: %v", err)
+	}
+
+	link := sql.NullInt64{}
+	if linkOwnerID != nil {
+		link = sql.NullInt64{Int64: *linkOwnerID, Valid: true}
+	}
+
+	err = s.db.Queries.CreateOIDCLoginState(ctx, models.CreateOIDCLoginStateParams{
+		StateHash:        utils.HashToken(state),
+		Provider:         provider.Name(),
+		CodeVerifier:     verifier,
+		Nonce:            nonce,
+		LinkStoreOwnerID: link,
+		ExpiresAt:        time.Now().Add(s.oidc.StateTTL()),
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	return authURL, oidcBinding(state), nil
+}
+
+// oidcBinding ties the state of a flow to the browser that started it.
+func oidcBinding(state string) string {
+	return utils.HashToken(state)
+}
+
+// CompleteOIDCLogin handles the redirect back from the provider after a
+// login started without a link request.
+//
+// The external identity is resolved to a store owner as follows:
+//   - an identity linked before logs in its owner;
+//   - otherwise, if signup is allowed and the provider verified an email no
+//     account uses yet, a store owner is created with that email.
+//
+// An existing account is never taken over by email alone: its owner has to
+// log in and link the provider first. MFA still applies after OIDC.
+//
+// binding is the one StartOIDCLogin returned to the browser. Without it a
+// victim could be made to finish a login the attacker started, and end up
+// signed in to the attacker's account.
+func (s *Service) CompleteOIDCLogin(
+	ctx context.Context,
+	providerName, code, state, binding string,
+	client ClientInfo,
+) (*AuthResult, error) {
+
+	if subtle.ConstantTimeCompare([]byte(oidcBinding(state)), []byte(binding)) != 1 {
+		return nil, errorx.ErrInvalidOIDCState
+	}
+
+	provider, pending, claims, err := s.exchangeOIDCCode(ctx, providerName, code, state)
+	if err != nil {
+		return nil, err
+	}
+	if pending.LinkStoreOwnerID.Valid {
+		return nil, errorx.ErrInvalidOIDCState
+	}
+
+	var ownerID int64
+
+	err = s.db.RunInTx(ctx, func(q *models.Queries) error {
+		identity, err := q.GetStoreOwnerIdentity(ctx, models.GetStoreOwnerIdentityParams{
+			Provider: provider.Name(),
+			Subject:  claims.Subject,
+		})
+		if err == nil {
+			ownerID = identity.StoreOwnerID
+			return nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		ownerID, err = s.signupOIDCOwner(ctx, q, provider, claims)
+		if err != nil {
+			return err
+		}
+		return linkIdentity(ctx, q, ownerID, provider.Name(), claims)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.completeLogin(ctx, ownerID, "store_owner", nil, client)
+}
+
+// CompleteOIDCLink handles the redirect back from the provider after a link
+// request. It has to be called by the owner who started the link so that a
+// victim cannot be made to attach their identity to someone else's account.
+func (s *Service) CompleteOIDCLink(
+	ctx context.Context,
+	providerName, code, state string,
+	ownerID int64,
+) error {
+
+	provider, pending, claims, err := s.exchangeOIDCCode(ctx, providerName, code, state)
+	if err != nil {
+		return err
+	}
+	if !pending.LinkStoreOwnerID.Valid || pending.LinkStoreOwnerID.Int64 != ownerID {
+		return errorx.ErrInvalidOIDCState
+	}
+
+	return s.db.RunInTx(ctx, func(q *models.Queries) error {
+		identity, err := q.GetStoreOwnerIdentity(ctx, models.GetStoreOwnerIdentityParams{
+			Provider: provider.Name(),
+			Subject:  claims.Subject,
+		})
+		if err == nil {
+			if identity.StoreOwnerID != ownerID {
+				return errorx.ErrOIDCIdentityLinked
+			}
+			return nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		return linkIdentity(ctx, q, ownerID, provider.Name(), claims)
+	})
+}
+
+// exchangeOIDCCode consumes the state of a started flow, which can be used
+// once, and redeems the code with the stored PKCE verifier. The ID token is
+// checked against the stored nonce.
+func (s *Service) exchangeOIDCCode(
+	ctx context.Context,
+	providerName, code, state string,
+) (*oidc.Provider, models.OidcLoginState, *oidc.Claims, error) {
+
+	provider, err := s.oidc.Get(providerName)
+	if err != nil {
+		return nil, models.OidcLoginState{}, nil, errorx.ErrUnknownOIDCProvider
+	}
+
+	pending, err := s.db.Queries.ConsumeOIDCLoginState(ctx, models.ConsumeOIDCLoginStateParams{
+		StateHash: utils.HashToken(state),
+		Provider:  provider.Name(),
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, pending, nil, errorx.ErrInvalidOIDCState
+	}
+	if err != nil {
+		return nil, pending, nil, err
+	}
+
+	claims, err := provider.Exchange(ctx, code, pending.CodeVerifier, pending.Nonce)
+	if err != nil {
+		log.Printf("oidc %s: %v", provider.Name(), err)
+		return nil, pending, nil, errorx.ErrOIDCLoginFailed
+	}
+
+	return provider, pending, claims, nil
+}
+
+// signupOIDCOwner creates a store owner for an identity seen for the first
+// time.
+func (s *Service) signupOIDCOwner(
+	ctx context.Context,
+	q *models.Queries,
+	provider *oidc.Provider,
+	claims *oidc.Claims,
+) (int64, error) {
+
+	email := strings.TrimSpace(claims.Email)
+	if email == "" || !claims.EmailVerified {
+		return 0, fmt.Errorf("%w: provider did not return a verified email", errorx.ErrOIDCLoginFailed)
+	}
+
+	_, err := q.GetStoreOwnerByEmail(ctx, email)
+	if err == nil {
+		return 0, errorx.ErrOIDCAccountExists
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return 0, err
+	}
+
+	if !provider.AllowSignup() {
+		return 0, fmt.Errorf("%w: signup is disabled for %s", errorx.ErrOIDCLoginFailed, provider.Name())
+	}
+
+	name := strings.TrimSpace(claims.Name)
+	if name == "" {
+		name = email
+	}
+
+	owner, err := q.CreateOIDCStoreOwner(ctx, models.CreateOIDCStoreOwnerParams{
+		Name:         name,
+		Email:        email,
+		PasswordHash: oidcOnlyPasswordHash,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return owner.StoreOwnerID, nil
+}
+
+func linkIdentity(
+	ctx context.Context,
+	q *models.Queries,
+	ownerID int64,
+	provider string,
+	claims *oidc.Claims,
+) error {
+
+	email := sql.NullString{}
+	if claims.Email != "" {
+		email = sql.NullString{String: claims.Email, Valid: true}
+	}
+
+	return q.CreateStoreOwnerIdentity(ctx, models.CreateStoreOwnerIdentityParams{
+		StoreOwnerID: ownerID,
+		Provider:     provider,
+		Subject:      claims.Subject,
+		Email:        email,
+	})
+}
@@ -0,0 +1,234 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	"github.com/Secure-Website-Builder/Backend/internal/errorx"
+	"github.com/Secure-Website-Builder/Backend/internal/models"
+	"github.com/Secure-Website-Builder/Backend/internal/notify"
+	"github.com/Secure-Website-Builder/Backend/internal/utils"
+)
+
+// passwordResetSendTimeout bounds creating and sending a reset link, which
+// outlives the request.
+const passwordResetSendTimeout = 30 * time.Second
+
+// RequestPasswordReset emails a single-use reset link to the account.
+//
+// It returns nil when no account matches so the endpoint cannot be used to
+// discover which emails are registered. Earlier outstanding tokens for the
+// account are invalidated so only the latest link works.
+func (s *Service) RequestPasswordReset(
+	ctx context.Context,
+	email, role string,
+	storeID *int64,
+) error {
+
+	var (
+		userID int64
+		// The link goes to the address on the account, not to the one typed
+		to string
+	)
+
+	switch role {
+	case "store_owner":
+		user, err := s.db.Queries.GetStoreOwnerByEmail(ctx, email)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		userID = user.StoreOwnerID
+		to = user.Email
+		storeID = nil
+
+	case "customer":
+		if storeID == nil {
+			return errorx.ErrInvalidStoreID
+		}
+		user, err := s.db.Queries.GetCustomerByEmail(ctx, models.GetCustomerByEmailParams{
+			Email:   email,
+			StoreID: *storeID,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		userID = user.CustomerID
+		to = user.Email
+
+	default:
+		return errors.New("invalid role")
+	}
+
+	// The link is created and sent in the background, so that a known
+	// account answers as fast as an unknown one.
+	go s.sendPasswordReset(context.WithoutCancel(ctx), to, role, userID, storeID)
+
+	return nil
+}
+
+// sendPasswordReset invalidates the earlier reset tokens of the account,
+// creates a new one and emails the link. It runs after the request was
+// answered, so failures are logged.
+func (s *Service) sendPasswordReset(
+	ctx context.Context,
+	email, role string,
+	userID int64,
+	storeID *int64,
+) {
+
+	ctx, cancel := context.WithTimeout(ctx, passwordResetSendTimeout)
+	defer cancel()
+
+	token, err := utils.GenerateRefreshToken()
+	if err != nil {
+		log.Printf("password reset: failed to generate token for %s account %d: %v", role, userID, err)
+		return
+	}
+
+	nullableStoreID := sql.NullInt64{}
+	if storeID != nil {
+		nullableStoreID = sql.NullInt64{Int64: *storeID, Valid: true}
+	}
+
+	err = s.db.RunInTx(ctx, func(q *models.Queries) error {
+		if err := q.InvalidateUserPasswordResetTokens(ctx, models.InvalidateUserPasswordResetTokensParams{
+			UserID:   userID,
+			UserRole: role,
+		}); err != nil {
+			return err
+		}
+
+		return q.CreatePasswordResetToken(ctx, models.CreatePasswordResetTokenParams{
+			TokenHash: utils.HashToken(token),
+			UserID:    userID,
+			UserRole:  role,
+			StoreID:   nullableStoreID,
+			ExpiresAt: time.Now().Add(s.cfg.PasswordResetTTL()),
+		})
+	})
+	if err != nil {
+		log.Printf("password reset: failed to create token for %s account %d: %v", role, userID, err)
+		return
+	}
+
+	link := s.cfg.PasswordResetURL + "?token=" + url.QueryEscape(token)
+
+	err = s.notifier.Send(ctx, notify.Message{
+		To:      email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf(
+			"Use the link below to choose a new password. It expires in %d minutes and can only be used once.\n\n%s\n\nIf you did not request this, you can ignore this email.",
+			s.cfg.PasswordResetTTLMinutes,
+			link,
+		),
+	})
+	if err != nil {
+		log.Printf("password reset: failed to notify %s account %d: %v", role, userID, err)
+	}
+}
+
+// ResetPassword consumes a reset token and sets a new password.
+//
+// On success every session of the account is revoked so any device that
+// logged in with the old password has to log in again, and the account is
+// unlocked.
+func (s *Service) ResetPassword(
+	ctx context.Context,
+	token, newPassword string,
+) error {
+
+	var (
+		userID int64
+		role   string
+	)
+
+	tokenHash := utils.HashToken(token)
+
+	// The password is checked and hashed before the token is locked: the
+	// breach check calls an external service and hashing is slow, and
+	// neither should hold the row or a connection in a transaction.
+	pending, err := s.db.Queries.GetPasswordResetToken(ctx, tokenHash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return errorx.ErrInvalidResetToken
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := utils.CheckPasswordPolicy(newPassword, pending.UserRole); err != nil {
+		return fmt.Errorf("%w: %v", errorx.ErrPasswordPolicy, err)
+	}
+
+	var storeID *int64
+	if pending.StoreID.Valid {
+		storeID = &pending.StoreID.Int64
+	}
+	if err := s.checkBreachedPassword(ctx, s.db.Queries, newPassword, pending.UserRole, storeID); err != nil {
+		return err
+	}
+
+	hashed, err := s.hasher.Hash(newPassword)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errorx.ErrPasswordPolicy, err)
+	}
+
+	err = s.db.RunInTx(ctx, func(q *models.Queries) error {
+
+		// Locked again, since the token may have been used meanwhile
+		rt, err := q.GetPasswordResetTokenForUpdate(ctx, tokenHash)
+		if errors.Is(err, sql.ErrNoRows) {
+			return errorx.ErrInvalidResetToken
+		}
+		if err != nil {
+			return err
+		}
+
+		switch rt.UserRole {
+		case "store_owner":
+			err = q.UpdateStoreOwnerPassword(ctx, models.UpdateStoreOwnerPasswordParams{
+				StoreOwnerID: rt.UserID,
+				PasswordHash: hashed,
+			})
+		case "customer":
+			err = q.UpdateCustomerPassword(ctx, models.UpdateCustomerPasswordParams{
+				CustomerID:   rt.UserID,
+				PasswordHash: hashed,
+			})
+		default:
+			err = errorx.ErrInvalidResetToken
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := q.InvalidateUserPasswordResetTokens(ctx, models.InvalidateUserPasswordResetTokensParams{
+			UserID:   rt.UserID,
+			UserRole: rt.UserRole,
+		}); err != nil {
+			return err
+		}
+
+		userID, role = rt.UserID, rt.UserRole
+
+		return revokeUserSessions(ctx, q, rt.UserID, rt.UserRole)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.syncRevocations(ctx)
+
+	// Proving control of the mailbox also lifts any login lockout.
+	return s.unlockUser(ctx, userID, role)
+}
@@ -0,0 +1,333 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/Secure-Website-Builder/Backend/internal/errorx"
+	"github.com/Secure-Website-Builder/Backend/internal/models"
+	"github.com/Secure-Website-Builder/Backend/internal/notify"
+	"github.com/Secure-Website-Builder/Backend/internal/types"
+	"github.com/Secure-Website-Builder/Backend/internal/utils"
+	"github.com/google/uuid"
+)
+
+const (
+	maxNameLength  = 255
+	maxPhoneLength = 50
+)
+
+// ProfileUpdate holds the profile fields to change; nil fields are left as
+// they are. An empty Phone removes the phone number.
+type ProfileUpdate struct {
+	Name    *string
+	Phone   *string
+	Address *types.Address
+}
+
+func (s *Service) GetProfile(
+	ctx context.Context,
+	userID int64,
+	role string,
+) (*models.ProfileDTO, error) {
+
+	switch role {
+	case "store_owner":
+		owner, err := s.db.Queries.GetStoreOwnerProfile(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		return &models.ProfileDTO{
+			UserID:        owner.StoreOwnerID,
+			Role:          role,
+			Name:          owner.Name,
+			Email:         owner.Email,
+			EmailVerified: owner.EmailVerifiedAt.Valid,
+			Phone:         utils.NullStringToPtr(owner.Phone),
+			Address:       owner.Address.Addr,
+			CreatedAt:     owner.CreatedAt,
+		}, nil
+
+	case "customer":
+		customer, err := s.db.Queries.GetCustomerProfile(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		return &models.ProfileDTO{
+			UserID:        customer.CustomerID,
+			Role:          role,
+			StoreID:       &customer.StoreID,
+			Name:          customer.Name,
+			Email:         customer.Email,
+			EmailVerified: customer.EmailVerifiedAt.Valid,
+			Phone:         utils.NullStringToPtr(customer.Phone),
+			Address:       customer.Address.Addr,
+			CreatedAt:     customer.CreatedAt,
+		}, nil
+
+	default:
+		return nil, errorx.ErrProfileNotSupported
+	}
+}
+
+// UpdateProfile changes the name, phone and address of the account. Email
+// and password have their own flows.
+func (s *Service) UpdateProfile(
+	ctx context.Context,
+	userID int64,
+	role string,
+	update ProfileUpdate,
+) (*models.ProfileDTO, error) {
+
+	profile, err := s.GetProfile(ctx, userID, role)
+	if err != nil {
+		return nil, err
+	}
+
+	if update.Name != nil {
+		name := strings.TrimSpace(*update.Name)
+		if name == "" || len(name) > maxNameLength {
+			return nil, fmt.Errorf("%w: name must be between 1 and %d characters", errorx.ErrInvalidProfile, maxNameLength)
+		}
+		profile.Name = name
+	}
+
+	if update.Phone != nil {
+		phone := strings.TrimSpace(*update.Phone)
+		if len(phone) > maxPhoneLength {
+			return nil, fmt.Errorf("%w: phone must be at most %d characters", errorx.ErrInvalidProfile, maxPhoneLength)
+		}
+		profile.Phone = &phone
+		if phone == "" {
+			profile.Phone = nil
+		}
+	}
+
+	if update.Address != nil {
+		if err := update.Address.Validate(); err != nil {
+			return nil, fmt.Errorf("%w: %v", errorx.ErrInvalidProfile, err)
+		}
+		profile.Address = update.Address
+	}
+
+	phone := sql.NullString{}
+	if profile.Phone != nil {
+		phone = sql.NullString{String: *profile.Phone, Valid: true}
+	}
+	address := types.NullableAddress{Addr: profile.Address, Valid: profile.Address != nil}
+
+	switch role {
+	case "store_owner":
+		err = s.db.Queries.UpdateStoreOwnerProfile(ctx, models.UpdateStoreOwnerProfileParams{
+			StoreOwnerID: userID,
+			Name:         profile.Name,
+			Phone:        phone,
+			Address:      address,
+		})
+	case "customer":
+		err = s.db.Queries.UpdateCustomerProfile(ctx, models.UpdateCustomerProfileParams{
+			CustomerID: userID,
+			Name:       profile.Name,
+			Phone:      phone,
+			Address:    address,
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return profile, nil
+}
+
+// ChangeEmail requests moving the account to a new address after
+// confirming the current password. The account keeps its current address
+// until the verification link sent to the new one is used (see
+// VerifyEmail); the current address is told about the request.
+func (s *Service) ChangeEmail(
+	ctx context.Context,
+	userID int64,
+	role string,
+	currentPassword, newEmail string,
+) error {
+
+	oldEmail, storeID, err := s.verifyCurrentPassword(ctx, userID, role, currentPassword)
+	if err != nil {
+		return err
+	}
+
+	newEmail = strings.TrimSpace(newEmail)
+	if strings.EqualFold(newEmail, oldEmail) {
+		return nil
+	}
+
+	switch role {
+	case "store_owner":
+		_, err = s.db.Queries.GetStoreOwnerByEmail(ctx, newEmail)
+	case "customer":
+		_, err = s.db.Queries.GetCustomerByEmail(ctx, models.GetCustomerByEmailParams{
+			Email:   newEmail,
+			StoreID: *storeID,
+		})
+	}
+	if err == nil {
+		return errorx.ErrEmailTaken
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	// The new address is kept with its verification token until then,
+	// which also ends any earlier link.
+	if err := s.sendEmailVerification(ctx, userID, role, storeID, newEmail); err != nil {
+		return err
+	}
+
+	err = s.notifier.Send(ctx, notify.Message{
+		To:      oldEmail,
+		Subject: "Your email address is being changed",
+		Body: fmt.Sprintf(
+			"A change of the email address of your account to %s was requested. It takes effect once the new address is verified.\n\nIf you did not make this request, reset your password and contact support.",
+			newEmail,
+		),
+	})
+	if err != nil {
+		log.Printf("email change: failed to notify %s account %d: %v", role, userID, err)
+	}
+
+	return nil
+}
+
+// ChangePassword sets a new password after confirming the current one and
+// ends every other session of the account. The caller's session is kept.
+func (s *Service) ChangePassword(
+	ctx context.Context,
+	userID int64,
+	role string,
+	sessionID uuid.UUID,
+	currentPassword, newPassword string,
+) error {
+
+	_, storeID, err := s.verifyCurrentPassword(ctx, userID, role, currentPassword)
+	if err != nil {
+		return err
+	}
+
+	if _, err := utils.CheckPasswordPolicy(newPassword, role); err != nil {
+		return fmt.Errorf("%w: %v", errorx.ErrPasswordPolicy, err)
+	}
+	if err := s.checkBreachedPassword(ctx, s.db.Queries, newPassword, role, storeID); err != nil {
+		return err
+	}
+
+	hashed, err := s.hasher.Hash(newPassword)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errorx.ErrPasswordPolicy, err)
+	}
+
+	err = s.db.RunInTx(ctx, func(q *models.Queries) error {
+		var err error
+		switch role {
+		case "store_owner":
+			err = q.UpdateStoreOwnerPassword(ctx, models.UpdateStoreOwnerPasswordParams{
+				StoreOwnerID: userID,
+				PasswordHash: hashed,
+			})
+		case "customer":
+			err = q.UpdateCustomerPassword(ctx, models.UpdateCustomerPasswordParams{
+				CustomerID:   userID,
+				PasswordHash: hashed,
+			})
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := q.InvalidateUserPasswordResetTokens(ctx, models.InvalidateUserPasswordResetTokensParams{
+			UserID:   userID,
+			UserRole: role,
+		}); err != nil {
+			return err
+		}
+
+		if err := q.RevokeOtherUserAuthSessions(ctx, models.RevokeOtherUserAuthSessionsParams{
+			UserID:    userID,
+			UserRole:  role,
+			SessionID: sessionID,
+		}); err != nil {
+			return err
+		}
+
+		if err := q.RevokeOtherUserRefreshTokens(ctx, models.RevokeOtherUserRefreshTokensParams{
+			UserID:   userID,
+			UserRole: role,
+			FamilyID: sessionID,
+		}); err != nil {
+			return err
+		}
+
+		return q.RevokeSessionAccessTokens(ctx, models.RevokeSessionAccessTokensParams{
+			UserID:   userID,
+			UserRole: role,
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	s.syncRevocations(ctx)
+	return nil
+}
+
+// ConfirmPassword re-authenticates the user before an action that cannot be
+// undone, such as erasing the account.
+func (s *Service) ConfirmPassword(ctx context.Context, userID int64, role, password string) error {
+	_, _, err := s.verifyCurrentPassword(ctx, userID, role, password)
+	return err
+}
+
+// verifyCurrentPassword re-authenticates the user before a sensitive
+// change. Failures count towards the login lockout of the account, so a
+// stolen access token cannot be used to guess the password.
+func (s *Service) verifyCurrentPassword(
+	ctx context.Context,
+	userID int64,
+	role, password string,
+) (email string, storeID *int64, err error) {
+
+	var hashed string
+
+	switch role {
+	case "store_owner":
+		owner, err := s.db.Queries.GetStoreOwnerByID(ctx, userID)
+		if err != nil {
+			return "", nil, err
+		}
+		email, hashed = owner.Email, owner.PasswordHash
+
+	case "customer":
+		customer, err := s.db.Queries.GetCustomerByID(ctx, userID)
+		if err != nil {
+			return "", nil, err
+		}
+		email, hashed, storeID = customer.Email, customer.PasswordHash, &customer.StoreID
+
+	default:
+		return "", nil, errorx.ErrProfileNotSupported
+	}
+
+	key := loginKey(role, storeID, email)
+	if err := s.checkLockout(key); err != nil {
+		return "", nil, err
+	}
+
+	if ok, _ := s.hasher.Verify(password, hashed); !ok {
+		return "", nil, errorx.ErrInvalidCurrentPassword
+	}
+	s.lockout.Reset(key)
+
+	return email, storeID, nil
+}
@@ -4,27 +4,75 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"log"
 	"time"
 
+	"github.com/Secure-Website-Builder/Backend/internal/config"
 	"github.com/Secure-Website-Builder/Backend/internal/database"
+	"github.com/Secure-Website-Builder/Backend/internal/errorx"
+	"github.com/Secure-Website-Builder/Backend/internal/jwtkeys"
+	"github.com/Secure-Website-Builder/Backend/internal/limiter"
 	"github.com/Secure-Website-Builder/Backend/internal/models"
+	"github.com/Secure-Website-Builder/Backend/internal/notify"
+	"github.com/Secure-Website-Builder/Backend/internal/oidc"
+	"github.com/Secure-Website-Builder/Backend/internal/passhash"
+	"github.com/Secure-Website-Builder/Backend/internal/pwned"
+	"github.com/Secure-Website-Builder/Backend/internal/revocation"
 	"github.com/Secure-Website-Builder/Backend/internal/types"
 	"github.com/Secure-Website-Builder/Backend/internal/utils"
 	"github.com/google/uuid"
 )
 
 type Service struct {
-	db   *database.DB
-	jwtSecret string
+	db       *database.DB
+	keys     *jwtkeys.KeySet
+	cfg      config.AuthConfig
+	notifier notify.Notifier
+	lockout  *limiter.Lockout
+	oidc     *oidc.Registry
+	breach   *pwned.Policy
+	revoked  *revocation.List
+	hasher   *passhash.Hasher
 }
 
-func New(db *database.DB, jwtSecret string) *Service {
+func New(
+	db *database.DB,
+	keys *jwtkeys.KeySet,
+	cfg config.AuthConfig,
+	notifier notify.Notifier,
+	lockout *limiter.Lockout,
+	oidcProviders *oidc.Registry,
+	breach *pwned.Policy,
+	revoked *revocation.List,
+	hasher *passhash.Hasher,
+) *Service {
 	return &Service{
 		db:       db,
-		jwtSecret: jwtSecret,
+		keys:     keys,
+		cfg:      cfg,
+		notifier: notifier,
+		lockout:  lockout,
+		oidc:     oidcProviders,
+		breach:   breach,
+		revoked:  revoked,
+		hasher:   hasher,
 	}
 }
 
+// AuthResult is returned by the registration and login flows.
+//
+// When MFAToken is set the password step succeeded but the account has a
+// second factor enrolled: no access/refresh pair is issued until the token
+// is exchanged together with a TOTP code via VerifyMFA.
+// MFAEnrollmentRequired signals that the role requires MFA but the account
+// has not enrolled yet, so routes guarded by RequireMFA will be refused.
+type AuthResult struct {
+	AccessToken           string
+	RefreshToken          string
+	MFAToken              string
+	MFAEnrollmentRequired bool
+}
+
 /* ================= REGISTER ================= */
 
 func (s *Service) Register(
@@ -33,21 +81,28 @@ func (s *Service) Register(
 	storeID *int64,
 	phone *string,
 	address *types.Address,
-) (accessToken, refreshToken string, err error) {
+	client ClientInfo,
+) (*AuthResult, error) {
 
-	mfaRequired, err := utils.CheckPasswordPolicy(password, role) 
+	mfaRequired, err := utils.CheckPasswordPolicy(password, role)
 	if err != nil {
-		return "", "", err
+		return nil, err
 	}
-	_ = mfaRequired //TODO: implement MFA logic
 
-	hashed, err := utils.HashPassword(password)
+	if err := s.checkBreachedPassword(ctx, s.db.Queries, password, role, storeID); err != nil {
+		return nil, err
+	}
+
+	hashed, err := s.hasher.Hash(password)
 	if err != nil {
-		return "", "", err
+		return nil, err
 	}
 
 	addr := types.NullableAddress{Valid: false}
 	if address != nil {
+		if err := address.Validate(); err != nil {
+			return nil, err
+		}
 		addr = types.NullableAddress{
 			Addr:  address,
 			Valid: true,
@@ -75,13 +130,13 @@ func (s *Service) Register(
 			Address:      addr,
 		})
 		if err != nil {
-			return "", "", err
+			return nil, err
 		}
 		userID = user.StoreOwnerID
 
 	case "customer":
 		if storeID == nil {
-			return "", "", errors.New("store_id is required")
+			return nil, errors.New("store_id is required")
 		}
 
 		user, err := s.db.Queries.CreateCustomer(ctx, models.CreateCustomerParams{
@@ -93,51 +148,26 @@ func (s *Service) Register(
 			Address:      addr,
 		})
 		if err != nil {
-			return "", "", err
+			return nil, err
 		}
 		userID = user.CustomerID
 
 	default:
-		return "", "", errors.New("invalid role")
+		return nil, errors.New("invalid role")
 	}
 
-	accessToken, err = utils.GenerateJWT(
-		userID,
-		role,
-		storeID,
-		s.jwtSecret,
-		24*time.Hour,
-	)
-	if err != nil {
-		return "", "", err
-	}
-	refreshToken, err = utils.GenerateRefreshToken()
-	if err != nil {
-		return "", "", err
-	}
-
-	nullableStoreID := sql.NullInt64{
-		Valid: false,
-	}
-	if storeID != nil {
-		nullableStoreID = sql.NullInt64{
-			Int64: *storeID,
-			Valid: true,
-		}
-	}
+	s.sendWelcomeVerification(ctx, userID, role, storeID, email)
 
-	err = s.db.Queries.CreateRefreshToken(ctx, models.CreateRefreshTokenParams{
-		Token:     refreshToken,
-		UserID:    userID,
-		UserRole:  role,
-		StoreID:   nullableStoreID,
-		ExpiresAt: time.Now().Add(7 * 24 * time.Hour),
-	})
+	accessToken, refreshToken, err := s.issueTokens(ctx, userID, role, storeID, false, client)
 	if err != nil {
-		return "", "", err
+		return nil, err
 	}
 
-	return accessToken, refreshToken, nil
+	return &AuthResult{
+		AccessToken:           accessToken,
+		RefreshToken:          refreshToken,
+		MFAEnrollmentRequired: mfaRequired,
+	}, nil
 }
 
 /* ================= LOGIN ================= */
@@ -147,7 +177,19 @@ func (s *Service) Login(
 	email, password, role string,
 	storeID *int64,
 	sessionID *uuid.UUID,
-) (accessToken, refreshToken string, err error) {
+	client ClientInfo,
+) (result *AuthResult, err error) {
+
+	// Only customers belong to a store; any other store_id would give the
+	// account a fresh failure counter.
+	if role != "customer" {
+		storeID = nil
+	}
+
+	key := loginKey(role, storeID, email)
+	if err := s.checkLockout(key); err != nil {
+		return nil, err
+	}
 
 	var (
 		userID int64
@@ -159,14 +201,14 @@ func (s *Service) Login(
 	case "store_owner":
 		user, err := s.db.Queries.GetStoreOwnerByEmail(ctx, email)
 		if err != nil {
-			return "", "", errors.New("invalid credentials")
+			return nil, errInvalidCredentials
 		}
 		userID = user.StoreOwnerID
 		hashed = user.PasswordHash
 
 	case "customer":
 		if storeID == nil {
-			return "", "", errors.New("store_id is required")
+			return nil, errors.New("store_id is required")
 		}
 
 		user, err := s.db.Queries.GetCustomerByEmail(ctx, models.GetCustomerByEmailParams{
@@ -174,7 +216,7 @@ func (s *Service) Login(
 			StoreID: *storeID,
 		})
 		if err != nil {
-			return "", "", errors.New("invalid credentials")
+			return nil, errInvalidCredentials
 		}
 		userID = user.CustomerID
 		hashed = user.PasswordHash
@@ -191,108 +233,177 @@ func (s *Service) Login(
 			}
 		}()
 	default:
-		return "", "", errors.New("invalid role")
+		return nil, errors.New("invalid role")
 	}
 
-	if !utils.CheckPasswordHash(password, hashed) {
-		return "", "", errors.New("invalid credentials")
+	ok, needsRehash := s.hasher.Verify(password, hashed)
+	if !ok {
+		return nil, errInvalidCredentials
 	}
+	s.lockout.Reset(key)
 
-	accessToken, err = utils.GenerateJWT(
-		userID,
-		role,
-		storeID,
-		s.jwtSecret,
-		24*time.Hour,
-	)
+	if needsRehash {
+		s.upgradePasswordHash(ctx, userID, role, password, hashed)
+	}
 
-	if err != nil {
-		return "", "", err
+	return s.completeLogin(ctx, userID, role, storeID, client)
+}
+
+// AdminLogin authenticates an admin. Admins always need a second factor:
+// once enrolled an MFA challenge is returned, before that the issued tokens
+// only allow enrolling (see RequireMFA).
+func (s *Service) AdminLogin(
+	ctx context.Context,
+	email, password string,
+	client ClientInfo,
+) (*AuthResult, error) {
+
+	key := loginKey("admin", nil, email)
+	if err := s.checkLockout(key); err != nil {
+		return nil, err
 	}
-	refreshToken, err = utils.GenerateRefreshToken()
+
+	admin, err := s.db.Queries.GetAdminByEmail(ctx, email)
 	if err != nil {
-		return "", "", err
+		return nil, errInvalidCredentials
 	}
 
-	nullableStoreID := sql.NullInt64{
-		Valid: false,
+	ok, needsRehash := s.hasher.Verify(password, admin.PasswordHash)
+	if !ok {
+		return nil, errInvalidCredentials
 	}
-	if storeID != nil {
-		nullableStoreID = sql.NullInt64{
-			Int64: *storeID,
-			Valid: true,
-		}
+
+	// Disabled admins get the same answer as a wrong password.
+	if admin.DisabledAt.Valid {
+		return nil, errInvalidCredentials
 	}
+	s.lockout.Reset(key)
 
-	err = s.db.Queries.CreateRefreshToken(ctx, models.CreateRefreshTokenParams{
-		Token:    refreshToken,
-		UserID:    userID,
-		UserRole:  role,
-		StoreID:   nullableStoreID,
-		ExpiresAt: time.Now().Add(7 * 24 * time.Hour),
-	})
-	if err != nil {
-		return "", "", err
+	if needsRehash {
+		s.upgradePasswordHash(ctx, admin.AdminID, "admin", password, admin.PasswordHash)
 	}
 
-	return accessToken, refreshToken, nil
+	return s.completeLogin(ctx, admin.AdminID, "admin", nil, client)
 }
 
-func (s *Service) AdminLogin(
-	ctx context.Context,
-	email, password string,
-) (string, error) {
-
-	admin, err := s.db.Queries.GetAdminByEmail(ctx, email)
+// upgradePasswordHash replaces a hash made with weaker settings than the
+// current ones, using the password that was just verified. Failing to do
+// so is logged and retried on the next login; it never fails the login.
+func (s *Service) upgradePasswordHash(ctx context.Context, userID int64, role, password, oldHash string) {
+	newHash, err := s.hasher.Hash(password)
 	if err != nil {
-		return "", errors.New("invalid credentials")
+		log.Printf("auth: failed to rehash password of %s %d: %v", role, userID, err)
+		return
 	}
 
-	if !utils.CheckPasswordHash(password, admin.PasswordHash) {
-		return "", errors.New("invalid credentials")
+	switch role {
+	case "store_owner":
+		err = s.db.Queries.UpgradeStoreOwnerPasswordHash(ctx, models.UpgradeStoreOwnerPasswordHashParams{
+			NewHash:      newHash,
+			StoreOwnerID: userID,
+			OldHash:      oldHash,
+		})
+	case "customer":
+		err = s.db.Queries.UpgradeCustomerPasswordHash(ctx, models.UpgradeCustomerPasswordHashParams{
+			NewHash:    newHash,
+			CustomerID: userID,
+			OldHash:    oldHash,
+		})
+	case "admin":
+		err = s.db.Queries.UpgradeAdminPasswordHash(ctx, models.UpgradeAdminPasswordHashParams{
+			NewHash: newHash,
+			AdminID: userID,
+			OldHash: oldHash,
+		})
+	}
+	if err != nil {
+		log.Printf("auth: failed to store rehashed password of %s %d: %v", role, userID, err)
 	}
-
-	return utils.GenerateJWT(
-		admin.AdminID,
-		"admin",
-		nil, // storeID is ALWAYS nil for admin
-		s.jwtSecret,
-		24*time.Hour,
-	)
 }
 
+// Refresh rotates a refresh token and issues a new access token.
+//
+// Presenting a token that was already rotated or revoked is treated as
+// theft: one of the two parties holding it is an attacker, so the whole
+// family is revoked and both have to log in again. Tokens of a session the
+// user revoked are simply rejected.
 func (s *Service) Refresh(
 	ctx context.Context,
 	refreshToken string,
+	client ClientInfo,
 ) (string, string, error) {
 
-	rt, err := s.db.Queries.GetRefreshToken(ctx, refreshToken)
-	if err != nil {
-		return "", "", errors.New("invalid refresh token")
-	}
+	var (
+		rt      models.RefreshToken
+		newRT   string
+		reused  bool
+		tokenID = uuid.New()
+	)
 
-	if rt.ExpiresAt.Before(time.Now()) {
-		return "", "", errors.New("refresh token expired")
-	}
+	err := s.db.RunInTx(ctx, func(q *models.Queries) error {
+		var err error
 
-	// rotate refresh token
-	if err := s.db.Queries.RevokeRefreshToken(ctx, refreshToken); err != nil {
-		return "", "", err
-	}
+		rt, err = q.GetRefreshTokenForUpdate(ctx, utils.HashToken(refreshToken))
+		if errors.Is(err, sql.ErrNoRows) {
+			return errorx.ErrInvalidRefreshToken
+		}
+		if err != nil {
+			return err
+		}
+
+		session, err := q.GetAuthSession(ctx, rt.FamilyID)
+		if err != nil {
+			return err
+		}
+		if session.RevokedAt.Valid {
+			return errorx.ErrSessionRevoked
+		}
 
-	newRT, err := utils.GenerateRefreshToken()
+		// Returning nil commits the family revocation.
+		if rt.Revoked.Bool {
+			reused = true
+			return revokeSession(ctx, q, session)
+		}
+
+		if rt.ExpiresAt.Before(time.Now()) {
+			return errorx.ErrRefreshTokenExpired
+		}
+
+		// rotate refresh token
+		if err := q.RevokeRefreshToken(ctx, rt.TokenHash); err != nil {
+			return err
+		}
+
+		if err := q.TouchAuthSession(ctx, models.TouchAuthSessionParams{
+			SessionID: session.SessionID,
+			IpAddress: client.inet(),
+			UserAgent: client.userAgent(),
+		}); err != nil {
+			return err
+		}
+
+		newRT, err = createRefreshToken(ctx, q, rt)
+		if err != nil {
+			return err
+		}
+
+		return q.CreateAccessToken(ctx, models.CreateAccessTokenParams{
+			Jti:       tokenID,
+			SessionID: session.SessionID,
+			ExpiresAt: time.Now().Add(refreshedAccessTokenTTL),
+		})
+	})
 	if err != nil {
 		return "", "", err
 	}
 
-	if err := s.db.Queries.CreateRefreshToken(ctx, models.CreateRefreshTokenParams{
-		Token:     newRT,
-		UserID:    rt.UserID,
-		UserRole:  rt.UserRole,
-		StoreID:   rt.StoreID,
-		ExpiresAt: time.Now().Add(7 * 24 * time.Hour),
-	}); err != nil {
-		return "", "", err
+	if reused {
+		s.syncRevocations(ctx)
+		log.Printf(
+			"auth: refresh token reuse detected for %s %d, revoked session %s",
+			rt.UserRole, rt.UserID, rt.FamilyID,
+		)
+		return "", "", errorx.ErrRefreshTokenReused
 	}
 
 	var storeID *int64
@@ -305,8 +416,11 @@ func (s *Service) Refresh(
 		rt.UserID,
 		rt.UserRole,
 		storeID,
-		s.jwtSecret,
-		15*time.Minute,
+		rt.FamilyID.String(),
+		tokenID.String(),
+		rt.MfaVerified,
+		s.keys,
+		refreshedAccessTokenTTL,
 	)
 	if err != nil {
 		return "", "", err
@@ -315,6 +429,8 @@ func (s *Service) Refresh(
 	return accessToken, newRT, nil
 }
 
+// Logout revokes the session the refresh token belongs to, together with
+// the access tokens issued for it.
 func (s *Service) Logout(
 	ctx context.Context,
 	refreshToken string,
@@ -324,5 +440,26 @@ func (s *Service) Logout(
 		return nil
 	}
 
-	return s.db.Queries.RevokeRefreshToken(ctx, refreshToken)
+	err := s.db.RunInTx(ctx, func(q *models.Queries) error {
+		rt, err := q.GetRefreshTokenForUpdate(ctx, utils.HashToken(refreshToken))
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		session, err := q.GetAuthSession(ctx, rt.FamilyID)
+		if err != nil {
+			return err
+		}
+
+		return revokeSession(ctx, q, session)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.syncRevocations(ctx)
+	return nil
 }
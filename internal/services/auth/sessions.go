@@ -0,0 +1,189 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+
+	"github.com/Secure-Website-Builder/Backend/internal/errorx"
+	"github.com/Secure-Website-Builder/Backend/internal/models"
+	"github.com/Secure-Website-Builder/Backend/internal/utils"
+	"github.com/google/uuid"
+	"github.com/sqlc-dev/pqtype"
+)
+
+// ClientInfo describes the device a session is used from. It is recorded
+// when the session is opened and updated on every refresh.
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+}
+
+func (c ClientInfo) inet() pqtype.Inet {
+	return utils.ToInet(c.IP)
+}
+
+func (c ClientInfo) userAgent() sql.NullString {
+	if c.UserAgent == "" {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: c.UserAgent, Valid: true}
+}
+
+// ListSessions returns the user's sessions that can still be refreshed, most
+// recently used first. currentSessionID marks the session of the caller.
+func (s *Service) ListSessions(
+	ctx context.Context,
+	userID int64,
+	role string,
+	currentSessionID uuid.UUID,
+) ([]models.SessionDTO, error) {
+
+	sessions, err := s.db.Queries.ListActiveUserSessions(ctx, models.ListActiveUserSessionsParams{
+		UserID:   userID,
+		UserRole: role,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]models.SessionDTO, 0, len(sessions))
+	for _, session := range sessions {
+		dto := models.SessionDTO{
+			SessionID:  session.SessionID,
+			CreatedAt:  session.CreatedAt,
+			LastUsedAt: session.LastUsedAt,
+			IPAddress:  utils.InetString(session.IpAddress),
+			Current:    session.SessionID == currentSessionID,
+		}
+		if session.UserAgent.Valid {
+			dto.UserAgent = &session.UserAgent.String
+		}
+		out = append(out, dto)
+	}
+
+	return out, nil
+}
+
+// RevokeSession ends one of the user's sessions. Access tokens already issued
+// for it are rejected by JWTAuth from then on.
+func (s *Service) RevokeSession(
+	ctx context.Context,
+	userID int64,
+	role string,
+	sessionID uuid.UUID,
+) error {
+
+	err := s.db.RunInTx(ctx, func(q *models.Queries) error {
+		revoked, err := q.RevokeAuthSession(ctx, models.RevokeAuthSessionParams{
+			SessionID: sessionID,
+			UserID:    userID,
+			UserRole:  role,
+		})
+		if err != nil {
+			return err
+		}
+		if revoked == 0 {
+			return errorx.ErrSessionNotFound
+		}
+
+		if err := q.RevokeRefreshTokenFamily(ctx, sessionID); err != nil {
+			return err
+		}
+
+		return q.RevokeSessionAccessTokens(ctx, models.RevokeSessionAccessTokensParams{
+			UserID:   userID,
+			UserRole: role,
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	s.syncRevocations(ctx)
+	return nil
+}
+
+// RevokeAllSessions ends every session of the user, including the caller's.
+func (s *Service) RevokeAllSessions(
+	ctx context.Context,
+	userID int64,
+	role string,
+) error {
+
+	err := s.db.RunInTx(ctx, func(q *models.Queries) error {
+		return revokeUserSessions(ctx, q, userID, role)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.syncRevocations(ctx)
+	return nil
+}
+
+// IsSessionActive reports whether access tokens of the session may still be
+// used. JWTAuth only needs it for access tokens without a "jti" claim,
+// which were issued before token revocation existed; newer tokens are
+// checked against the revocation list.
+func (s *Service) IsSessionActive(ctx context.Context, sessionID uuid.UUID) (bool, error) {
+	session, err := s.db.Queries.GetAuthSession(ctx, sessionID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return !session.RevokedAt.Valid, nil
+}
+
+func revokeSession(ctx context.Context, q *models.Queries, session models.AuthSession) error {
+	if _, err := q.RevokeAuthSession(ctx, models.RevokeAuthSessionParams{
+		SessionID: session.SessionID,
+		UserID:    session.UserID,
+		UserRole:  session.UserRole,
+	}); err != nil {
+		return err
+	}
+
+	if err := q.RevokeRefreshTokenFamily(ctx, session.SessionID); err != nil {
+		return err
+	}
+
+	return q.RevokeSessionAccessTokens(ctx, models.RevokeSessionAccessTokensParams{
+		UserID:   session.UserID,
+		UserRole: session.UserRole,
+	})
+}
+
+func revokeUserSessions(ctx context.Context, q *models.Queries, userID int64, role string) error {
+	if err := q.RevokeUserAuthSessions(ctx, models.RevokeUserAuthSessionsParams{
+		UserID:   userID,
+		UserRole: role,
+	}); err != nil {
+		return err
+	}
+
+	if err := q.RevokeUserRefreshTokens(ctx, models.RevokeUserRefreshTokensParams{
+		UserID:   userID,
+		UserRole: role,
+	}); err != nil {
+		return err
+	}
+
+	return q.RevokeSessionAccessTokens(ctx, models.RevokeSessionAccessTokensParams{
+		UserID:   userID,
+		UserRole: role,
+	})
+}
+
+// syncRevocations applies access token revocations committed by this
+// request on this instance right away instead of at the next periodic
+// sync. A failure only delays them.
+func (s *Service) syncRevocations(ctx context.Context) {
+	if err := s.revoked.Sync(ctx); err != nil {
+		log.Printf("auth: failed to sync token revocations: %v", err)
+	}
+}
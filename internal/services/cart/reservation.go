@@ -0,0 +1,100 @@
+package cart
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Secure-Website-Builder/Backend/internal/errorx"
+	"github.com/Secure-Website-Builder/Backend/internal/models"
+	"github.com/google/uuid"
+)
+
+// availableStock is the stock of a variant that cartID can still take:
+// the stock on hand less what other carts hold reserved. The variant must
+// be locked, see GetVariantForCart.
+func availableStock(
+	ctx context.Context,
+	q *models.Queries,
+	variant models.GetVariantForCartRow,
+	cartID int64,
+) (int32, error) {
+
+	reserved, err := q.GetReservedStock(ctx, models.GetReservedStockParams{
+		VariantID: variant.VariantID,
+		CartID:    cartID,
+	})
+	if err != nil {
+		return 0, err
+	}
+	return variant.StockQuantity - reserved, nil
+}
+
+// reserveItem holds the quantity of a cart item, up to the per-item cap,
+// for the reservation time of the store. The time counts from when the
+// item was added, so adding more or changing the quantity does not extend
+// it. Stores without a reservation time do not reserve stock.
+func (s *Service) reserveItem(ctx context.Context, q *models.Queries, storeID int64, item models.CartItem) error {
+	store, err := q.GetStore(ctx, storeID)
+	if err != nil {
+		return err
+	}
+	if store.StockReservationMinutes <= 0 {
+		return nil
+	}
+
+	expiresAt := item.CreatedAt.Add(time.Duration(store.StockReservationMinutes) * time.Minute)
+	if !expiresAt.After(time.Now()) {
+		return nil
+	}
+
+	return q.UpsertStockReservation(ctx, models.UpsertStockReservationParams{
+		CartItemID: item.CartItemID,
+		VariantID:  item.VariantID,
+		Quantity:   min(item.Quantity, s.reservations.MaxQuantityPerItem),
+		ExpiresAt:  expiresAt,
+	})
+}
+
+// allowChange rate limits the changes a visitor session makes to its
+// cart, so a script cannot churn items to keep stock reserved.
+func (s *Service) allowChange(sessionID uuid.UUID) error {
+	if !s.changes.GetBucket("cart:" + sessionID.String()).Allow() {
+		return errorx.ErrTooManyCartChanges
+	}
+	return nil
+}
+
+// Run deletes expired stock reservations every sweep interval until ctx is
+// done. Several instances may run against the same database.
+func (s *Service) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.reservations.SweepInterval())
+	defer ticker.Stop()
+
+	for {
+		s.SweepReservations(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// SweepReservations deletes expired stock reservations in batches until
+// none are left.
+func (s *Service) SweepReservations(ctx context.Context) {
+	batch := int32(s.reservations.SweepBatchSize)
+
+	for ctx.Err() == nil {
+		deleted, err := s.db.Queries.DeleteExpiredStockReservations(ctx, batch)
+		if err != nil {
+			log.Printf("stock reservations: failed to delete expired reservations: %v", err)
+			return
+		}
+		if deleted < int64(batch) {
+			return
+		}
+	}
+}
@@ -3,49 +3,286 @@ package cart
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"net/mail"
+	"strings"
+	"time"
 
+	"github.com/Secure-Website-Builder/Backend/internal/config"
 	"github.com/Secure-Website-Builder/Backend/internal/database"
 	"github.com/Secure-Website-Builder/Backend/internal/errorx"
+	"github.com/Secure-Website-Builder/Backend/internal/limiter"
 	"github.com/Secure-Website-Builder/Backend/internal/models"
+	"github.com/Secure-Website-Builder/Backend/internal/services/promotion"
+	"github.com/Secure-Website-Builder/Backend/internal/types"
 	"github.com/Secure-Website-Builder/Backend/internal/utils"
 	"github.com/google/uuid"
 )
 
+// maxGuestEmailLength matches customer_order.guest_email.
+const maxGuestEmailLength = 255
+
+// Cart event types, see cart_event.
+const (
+	cartEventAdd    = "add"
+	cartEventRemove = "remove"
+)
+
+// Kinds of models.CartChangeDTO.
+const (
+	cartChangePriceChanged    = "price_changed"
+	cartChangeQuantityReduced = "quantity_reduced"
+	cartChangeItemRemoved     = "item_removed"
+)
+
 type Service struct {
-	db *database.DB
+	db           *database.DB
+	sessions     config.VisitorSessionConfig
+	reservations config.StockReservationConfig
+	changes      *limiter.Manager
+}
+
+// New returns the cart service. changes rate limits adding to and
+// changing the cart, per visitor session.
+func New(
+	db *database.DB,
+	sessions config.VisitorSessionConfig,
+	reservations config.StockReservationConfig,
+	changes *limiter.Manager,
+) *Service {
+	return &Service{db: db, sessions: sessions, reservations: reservations, changes: changes}
+}
+
+// CheckoutInput is what the shopper provides at checkout. Guests must give
+// an email address and a shipping address; customers default to the
+// address of their account.
+type CheckoutInput struct {
+	PaymentMethod   string
+	Email           string
+	ShippingAddress *types.Address
+
+	// Total is the total after discounts the shopper accepted. The order
+	// is only placed for that total.
+	Total string
 }
 
-func New(db *database.DB) *Service {
-	return &Service{db: db}
+// touchSession returns the visitor session if it has not expired and
+// extends it. customerID is the logged-in customer, or nil for guests. A
+// session a customer logged in with stays theirs and is refused without
+// their token.
+func (s *Service) touchSession(
+	ctx context.Context,
+	q *models.Queries,
+	storeID int64,
+	sessionID uuid.UUID,
+	customerID *int64,
+) (models.VisitorSession, error) {
+
+	session, err := q.TouchVisitorSession(ctx, models.TouchVisitorSessionParams{
+		SessionID: sessionID,
+		StoreID:   storeID,
+		IdleSince: sql.NullTime{Time: time.Now().Add(-s.sessions.IdleTimeout()), Valid: true},
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return session, errorx.ErrInvalidSession
+	}
+	if err != nil {
+		return session, err
+	}
+
+	if session.CustomerID.Valid && (customerID == nil || *customerID != session.CustomerID.Int64) {
+		return session, errorx.ErrInvalidSession
+	}
+	return session, nil
 }
 
+// GetCart returns the cart of the session. A cart that no longer matches
+// the catalog is repriced first; others are read without locks.
 func (s *Service) GetCart(
 	ctx context.Context,
 	storeID int64,
 	sessionID uuid.UUID,
+	customerID *int64,
 ) (*models.CartDTO, error) {
 
-	cartRow, err := s.db.Queries.GetCartBySession(ctx, models.GetCartBySessionParams{
+	if _, err := s.touchSession(ctx, s.db.Queries, storeID, sessionID, customerID); err != nil {
+		return nil, err
+	}
+
+	cart, err := s.db.Queries.GetCartBySession(ctx, models.GetCartBySessionParams{
+		SessionID: sessionID,
+		StoreID:   storeID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return loadCart(ctx, s.db.Queries, storeID, sessionID, customerID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	stale, err := s.db.Queries.CartNeedsRepricing(ctx, cart.CartID)
+	if err != nil {
+		return nil, err
+	}
+	if !stale {
+		return loadCart(ctx, s.db.Queries, storeID, sessionID, customerID)
+	}
+
+	return s.repriceAndLoadCart(ctx, storeID, sessionID, customerID)
+}
+
+// repriceAndLoadCart locks the cart, reprices it and returns it with what
+// changed.
+func (s *Service) repriceAndLoadCart(
+	ctx context.Context,
+	storeID int64,
+	sessionID uuid.UUID,
+	customerID *int64,
+) (*models.CartDTO, error) {
+
+	var cartDTO *models.CartDTO
+	err := s.db.RunInTx(ctx, func(qtx *models.Queries) error {
+		cart, err := s.lockCart(ctx, qtx, storeID, sessionID, customerID)
+		if errors.Is(err, errorx.ErrCartNotFound) {
+			cartDTO, err = loadCart(ctx, qtx, storeID, sessionID, customerID)
+			return err
+		}
+		if err != nil {
+			return err
+		}
+
+		changes, err := repriceCart(ctx, qtx, sessionID, cart.CartID)
+		if err != nil {
+			return err
+		}
+
+		cartDTO, err = loadCart(ctx, qtx, storeID, sessionID, customerID)
+		if err != nil {
+			return err
+		}
+		cartDTO.Changes = changes
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return cartDTO, nil
+}
+
+// repriceCart brings the items of a cart in line with their variants.
+// Items of deleted or sold out variants are removed, quantities above the
+// stock are reduced and unit prices are updated to the current price. It
+// returns what it changed, for the shopper to be told.
+func repriceCart(
+	ctx context.Context,
+	q *models.Queries,
+	sessionID uuid.UUID,
+	cartID int64,
+) ([]models.CartChangeDTO, error) {
+
+	items, err := q.GetCartItemsForUpdate(ctx, cartID)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []models.CartChangeDTO
+	for _, item := range items {
+		change := models.CartChangeDTO{
+			CartItemID: item.CartItemID,
+			VariantID:  item.VariantID,
+			ProductID:  item.ProductID,
+			Product:    item.ProductName,
+		}
+
+		if item.Discontinued || item.AvailableStock <= 0 {
+			if _, err := q.DeleteCartItem(ctx, models.DeleteCartItemParams{
+				CartItemID: item.CartItemID,
+				CartID:     cartID,
+			}); err != nil {
+				return nil, err
+			}
+			if err := recordCartEvent(ctx, q, sessionID, item.ProductID, item.VariantID, cartEventRemove); err != nil {
+				return nil, err
+			}
+
+			change.Type = cartChangeItemRemoved
+			change.OldQuantity = item.CartQuantity
+			changes = append(changes, change)
+			continue
+		}
+
+		if item.AvailableStock < item.CartQuantity {
+			if err := q.SetCartItemQuantity(ctx, models.SetCartItemQuantityParams{
+				CartItemID: item.CartItemID,
+				Quantity:   item.AvailableStock,
+			}); err != nil {
+				return nil, err
+			}
+			if err := recordCartEvent(ctx, q, sessionID, item.ProductID, item.VariantID, cartEventRemove); err != nil {
+				return nil, err
+			}
+
+			reduced := change
+			reduced.Type = cartChangeQuantityReduced
+			reduced.OldQuantity = item.CartQuantity
+			reduced.NewQuantity = item.AvailableStock
+			changes = append(changes, reduced)
+		}
+
+		if item.UnitPrice != item.CurrentPrice {
+			if err := q.SetCartItemUnitPrice(ctx, models.SetCartItemUnitPriceParams{
+				CartItemID: item.CartItemID,
+				UnitPrice:  item.CurrentPrice,
+			}); err != nil {
+				return nil, err
+			}
+
+			repriced := change
+			repriced.Type = cartChangePriceChanged
+			repriced.OldPrice = item.UnitPrice
+			repriced.NewPrice = item.CurrentPrice
+			changes = append(changes, repriced)
+		}
+	}
+
+	return changes, nil
+}
+
+// loadCart returns the cart of the session with its promotions applied, or
+// an empty one if it has none.
+func loadCart(
+	ctx context.Context,
+	q *models.Queries,
+	storeID int64,
+	sessionID uuid.UUID,
+	customerID *int64,
+) (*models.CartDTO, error) {
+
+	cartRow, err := q.GetCartBySession(ctx, models.GetCartBySessionParams{
 		SessionID: sessionID,
 		StoreID:   storeID,
 	})
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return &models.CartDTO{
-				StoreID: storeID,
-				Items:   []models.CartItemDTO{},
-				Total:   "0",
+				StoreID:       storeID,
+				Items:         []models.CartItemDTO{},
+				Subtotal:      "0",
+				Discounts:     []models.DiscountDTO{},
+				DiscountTotal: "0",
+				Total:         "0",
 			}, nil
 		}
 		return nil, err
 	}
 
-	itemsRaw, err := s.db.Queries.GetCartItems(ctx, cartRow.CartID)
+	itemsRaw, err := q.GetCartItems(ctx, cartRow.CartID)
 	if err != nil {
 		return nil, err
 	}
 
 	items := make([]models.CartItemDTO, 0, len(itemsRaw))
+	lines := make([]promotion.Line, 0, len(itemsRaw))
 	for _, it := range itemsRaw {
 		items = append(items, models.CartItemDTO{
 			CartItemID: it.CartItemID,
@@ -58,32 +295,145 @@ func (s *Service) GetCart(
 			Quantity:   it.Quantity,
 			Subtotal:   it.Subtotal,
 		})
+		lines = append(lines, promotion.Line{
+			VariantID:  it.VariantID,
+			CategoryID: it.CategoryID,
+			UnitPrice:  it.UnitPrice,
+			Quantity:   it.Quantity,
+		})
 	}
 
-	total, err := s.db.Queries.GetCartTotal(ctx, cartRow.CartID)
+	subtotal, err := q.GetCartTotal(ctx, cartRow.CartID)
+	if err != nil {
+		return nil, err
+	}
+
+	total, discounts, err := applyPromotions(ctx, q, storeID, customerID, cartRow.PromotionCode, lines, subtotal)
 	if err != nil {
 		return nil, err
 	}
 
 	return &models.CartDTO{
-		CartID:    cartRow.CartID,
-		StoreID:   cartRow.StoreID,
-		Items:     items,
-		Total:     total,
-		UpdatedAt: cartRow.UpdatedAt,
+		CartID:        cartRow.CartID,
+		StoreID:       cartRow.StoreID,
+		Items:         items,
+		Subtotal:      subtotal,
+		Discounts:     discounts.DTOs(),
+		DiscountTotal: promotion.FormatCents(discounts.Total),
+		Total:         total,
+		PromotionCode: utils.NullStringToPtr(cartRow.PromotionCode),
+		FreeShipping:  discounts.FreeShipping,
+		UpdatedAt:     cartRow.UpdatedAt,
 	}, nil
 }
 
+// applyPromotions works out the discounts of a cart and its total after
+// them.
+func applyPromotions(
+	ctx context.Context,
+	q *models.Queries,
+	storeID int64,
+	customerID *int64,
+	code sql.NullString,
+	lines []promotion.Line,
+	subtotal string,
+) (string, *promotion.Result, error) {
+
+	discounts, err := promotion.Evaluate(ctx, q, storeID, customerID, code, lines)
+	if err != nil {
+		return "", nil, err
+	}
+
+	sub, err := promotion.ParseCents(subtotal)
+	if err != nil {
+		return "", nil, err
+	}
+	return promotion.FormatCents(sub - discounts.Total), discounts, nil
+}
+
+// ApplyPromotionCode sets the promotion code of the cart, replacing the
+// one entered before. The code must be active and usable by the shopper;
+// whether the cart meets its conditions shows in the discounts.
+func (s *Service) ApplyPromotionCode(
+	ctx context.Context,
+	storeID int64,
+	sessionID uuid.UUID,
+	customerID *int64,
+	code string,
+) (*models.CartDTO, error) {
+
+	var cartDTO *models.CartDTO
+	err := s.db.RunInTx(ctx, func(qtx *models.Queries) error {
+		cart, err := s.lockCart(ctx, qtx, storeID, sessionID, customerID)
+		if err != nil {
+			return err
+		}
+
+		p, err := promotion.CheckCode(ctx, qtx, storeID, customerID, code)
+		if err != nil {
+			return err
+		}
+
+		if err := qtx.SetCartPromotionCode(ctx, models.SetCartPromotionCodeParams{
+			CartID:        cart.CartID,
+			PromotionCode: p.Code,
+		}); err != nil {
+			return err
+		}
+
+		cartDTO, err = loadCart(ctx, qtx, storeID, sessionID, customerID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return cartDTO, nil
+}
+
+// RemovePromotionCode removes the promotion code from the cart. Automatic
+// promotions still apply.
+func (s *Service) RemovePromotionCode(
+	ctx context.Context,
+	storeID int64,
+	sessionID uuid.UUID,
+	customerID *int64,
+) (*models.CartDTO, error) {
+
+	var cartDTO *models.CartDTO
+	err := s.db.RunInTx(ctx, func(qtx *models.Queries) error {
+		cart, err := s.lockCart(ctx, qtx, storeID, sessionID, customerID)
+		if errors.Is(err, errorx.ErrCartNotFound) {
+			cartDTO, err = loadCart(ctx, qtx, storeID, sessionID, customerID)
+			return err
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := qtx.SetCartPromotionCode(ctx, models.SetCartPromotionCodeParams{
+			CartID: cart.CartID,
+		}); err != nil {
+			return err
+		}
+
+		cartDTO, err = loadCart(ctx, qtx, storeID, sessionID, customerID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return cartDTO, nil
+}
 
 func (s *Service) AddItem(
 	ctx context.Context,
 	storeID int64,
 	sessionID uuid.UUID,
+	customerID *int64,
 	variantID int64,
 	qty int32,
 ) (err error) {
 
-
 	return s.db.RunInTx(ctx, func(qtx *models.Queries) error {
 
 		if qty <= 0 {
@@ -91,13 +441,12 @@ func (s *Service) AddItem(
 		}
 
 		// Validate session
-		session, err := qtx.GetSession(ctx, models.GetSessionParams{
-			SessionID: sessionID,
-			StoreID:   storeID,
-		})
-		
-		if err != nil || !session.CustomerID.Valid{
-			return errorx.ErrInvalidSession
+		session, err := s.touchSession(ctx, qtx, storeID, sessionID, customerID)
+		if err != nil {
+			return err
+		}
+		if err := s.allowChange(sessionID); err != nil {
+			return err
 		}
 
 		// Lock or create cart
@@ -128,17 +477,30 @@ func (s *Service) AddItem(
 			return errorx.ErrInvalidVariant
 		}
 
-		if variant.StockQuantity < qty {
-			return errorx.ErrInsufficientStock
+		available, err := availableStock(ctx, qtx, variant, cart.CartID)
+		if err != nil {
+			return err
 		}
 
-		// Upsert item
-		if err = qtx.UpsertCartItem(ctx, models.UpsertCartItemParams{
+		// Upsert item; the stock must cover what is already in the cart too
+		item, err := qtx.UpsertCartItem(ctx, models.UpsertCartItemParams{
 			CartID:    cart.CartID,
 			VariantID: variant.VariantID,
 			Quantity:  qty,
 			UnitPrice: variant.Price,
-		}); err != nil {
+		})
+		if err != nil {
+			return err
+		}
+		if item.Quantity > available {
+			return errorx.ErrInsufficientStock
+		}
+
+		if err = s.reserveItem(ctx, qtx, storeID, item); err != nil {
+			return err
+		}
+
+		if err = recordCartEvent(ctx, qtx, sessionID, variant.ProductID, variant.VariantID, cartEventAdd); err != nil {
 			return err
 		}
 
@@ -146,24 +508,241 @@ func (s *Service) AddItem(
 		if err = qtx.TouchCart(ctx, cart.CartID); err != nil {
 			return err
 		}
-	return nil
+		return nil
 	})
 }
 
-func (s *Service) Checkout(
+// UpdateItemQuantity sets the quantity of a cart item. Raising it is
+// recorded as an add, lowering it as a remove.
+func (s *Service) UpdateItemQuantity(
 	ctx context.Context,
 	storeID int64,
 	sessionID uuid.UUID,
-	paymentMethod string,
-) error {
+	customerID *int64,
+	cartItemID int64,
+	qty int32,
+) (*models.CartDTO, error) {
 
-	return s.db.RunInTx(ctx, func(qtx *models.Queries) error {
+	if qty <= 0 {
+		return nil, errorx.ErrInvalidQuantity
+	}
 
-		// Validate session
-		session, err := qtx.GetSession(ctx, models.GetSessionParams{
-			SessionID: sessionID,
+	var cartDTO *models.CartDTO
+	err := s.db.RunInTx(ctx, func(qtx *models.Queries) error {
+		cart, err := s.lockCart(ctx, qtx, storeID, sessionID, customerID)
+		if err != nil {
+			return err
+		}
+		if err := s.allowChange(sessionID); err != nil {
+			return err
+		}
+
+		item, err := qtx.GetCartItemForUpdate(ctx, models.GetCartItemForUpdateParams{
+			CartItemID: cartItemID,
+			CartID:     cart.CartID,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			return errorx.ErrCartItemNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		variant, err := qtx.GetVariantForCart(ctx, models.GetVariantForCartParams{
+			VariantID: item.VariantID,
 			StoreID:   storeID,
 		})
+		if err != nil {
+			return errorx.ErrInvalidVariant
+		}
+
+		available, err := availableStock(ctx, qtx, variant, cart.CartID)
+		if err != nil {
+			return err
+		}
+		if available < qty {
+			return errorx.ErrInsufficientStock
+		}
+
+		if qty != item.Quantity {
+			if err := qtx.SetCartItemQuantity(ctx, models.SetCartItemQuantityParams{
+				CartItemID: item.CartItemID,
+				Quantity:   qty,
+			}); err != nil {
+				return err
+			}
+
+			eventType := cartEventAdd
+			if qty < item.Quantity {
+				eventType = cartEventRemove
+			}
+			if err := recordCartEvent(ctx, qtx, sessionID, variant.ProductID, variant.VariantID, eventType); err != nil {
+				return err
+			}
+		}
+
+		item.Quantity = qty
+		if err := s.reserveItem(ctx, qtx, storeID, item); err != nil {
+			return err
+		}
+
+		if err := qtx.TouchCart(ctx, cart.CartID); err != nil {
+			return err
+		}
+
+		cartDTO, err = loadCart(ctx, qtx, storeID, sessionID, customerID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return cartDTO, nil
+}
+
+// RemoveItem deletes an item from the cart.
+func (s *Service) RemoveItem(
+	ctx context.Context,
+	storeID int64,
+	sessionID uuid.UUID,
+	customerID *int64,
+	cartItemID int64,
+) (*models.CartDTO, error) {
+
+	var cartDTO *models.CartDTO
+	err := s.db.RunInTx(ctx, func(qtx *models.Queries) error {
+		cart, err := s.lockCart(ctx, qtx, storeID, sessionID, customerID)
+		if err != nil {
+			return err
+		}
+
+		removed, err := qtx.DeleteCartItem(ctx, models.DeleteCartItemParams{
+			CartItemID: cartItemID,
+			CartID:     cart.CartID,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			return errorx.ErrCartItemNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := recordCartEvent(ctx, qtx, sessionID, removed.ProductID, removed.VariantID, cartEventRemove); err != nil {
+			return err
+		}
+
+		if err := qtx.TouchCart(ctx, cart.CartID); err != nil {
+			return err
+		}
+
+		cartDTO, err = loadCart(ctx, qtx, storeID, sessionID, customerID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return cartDTO, nil
+}
+
+// ClearCart removes every item from the cart. Clearing a session without
+// a cart is not an error.
+func (s *Service) ClearCart(
+	ctx context.Context,
+	storeID int64,
+	sessionID uuid.UUID,
+	customerID *int64,
+) (*models.CartDTO, error) {
+
+	var cartDTO *models.CartDTO
+	err := s.db.RunInTx(ctx, func(qtx *models.Queries) error {
+		cart, err := s.lockCart(ctx, qtx, storeID, sessionID, customerID)
+		if errors.Is(err, errorx.ErrCartNotFound) {
+			cartDTO, err = loadCart(ctx, qtx, storeID, sessionID, customerID)
+			return err
+		}
+		if err != nil {
+			return err
+		}
+
+		removed, err := qtx.DeleteCartItems(ctx, cart.CartID)
+		if err != nil {
+			return err
+		}
+		for _, r := range removed {
+			if err := recordCartEvent(ctx, qtx, sessionID, r.ProductID, r.VariantID, cartEventRemove); err != nil {
+				return err
+			}
+		}
+
+		if err := qtx.TouchCart(ctx, cart.CartID); err != nil {
+			return err
+		}
+
+		cartDTO, err = loadCart(ctx, qtx, storeID, sessionID, customerID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return cartDTO, nil
+}
+
+// lockCart validates the session and locks its cart.
+func (s *Service) lockCart(
+	ctx context.Context,
+	q *models.Queries,
+	storeID int64,
+	sessionID uuid.UUID,
+	customerID *int64,
+) (models.Cart, error) {
+
+	if _, err := s.touchSession(ctx, q, storeID, sessionID, customerID); err != nil {
+		return models.Cart{}, err
+	}
+
+	cart, err := q.GetCartForSession(ctx, models.GetCartForSessionParams{
+		StoreID:   storeID,
+		SessionID: sessionID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return cart, errorx.ErrCartNotFound
+	}
+	return cart, err
+}
+
+// Checkout places an order for the cart at the current prices, less the
+// discounts of its promotions. If the cart no longer matches the catalog,
+// it is repriced instead and returned with ErrCartChanged, so the shopper
+// can review it before paying. Promotions that stopped applying since the
+// cart was shown fail the same way.
+func (s *Service) Checkout(
+	ctx context.Context,
+	storeID int64,
+	sessionID uuid.UUID,
+	customerID *int64,
+	input CheckoutInput,
+) (*models.CartDTO, error) {
+
+	accepted, err := promotion.ParseCents(input.Total)
+	if err != nil {
+		return nil, errorx.ErrInvalidRequestBody
+	}
+
+	cartDTO, err := s.GetCart(ctx, storeID, sessionID, customerID)
+	if err != nil {
+		return nil, err
+	}
+	if len(cartDTO.Changes) > 0 || !sameTotal(cartDTO.Total, accepted) {
+		return cartDTO, errorx.ErrCartChanged
+	}
+
+	err = s.db.RunInTx(ctx, func(qtx *models.Queries) error {
+
+		// Validate session
+		if _, err := s.touchSession(ctx, qtx, storeID, sessionID, customerID); err != nil {
+			return err
+		}
+
+		buyer, err := s.checkoutBuyer(ctx, qtx, storeID, customerID, input)
 		if err != nil {
 			return err
 		}
@@ -189,30 +768,82 @@ func (s *Service) Checkout(
 			return errorx.ErrCartEmpty
 		}
 
-		// Validate stock
+		// Validate stock, and that the catalog did not change since the
+		// cart was repriced
 		for _, item := range items {
+			if item.Discontinued || item.UnitPrice != item.CurrentPrice {
+				return errorx.ErrCartChanged
+			}
 			if item.AvailableStock < item.CartQuantity {
 				return errorx.ErrOutOfStock
 			}
 		}
 
-		// Calculate total IN SQL
-		total, err := qtx.GetCartTotal(ctx, cart.CartID)
+		// Calculate subtotal IN SQL, then apply the promotions
+		subtotal, err := qtx.GetCartTotal(ctx, cart.CartID)
 		if err != nil {
 			return err
 		}
 
+		lines := make([]promotion.Line, 0, len(items))
+		for _, item := range items {
+			lines = append(lines, promotion.Line{
+				VariantID:  item.VariantID,
+				CategoryID: item.CategoryID,
+				UnitPrice:  item.UnitPrice,
+				Quantity:   item.CartQuantity,
+			})
+		}
+
+		total, discounts, err := applyPromotions(ctx, qtx, storeID, customerID, cart.PromotionCode, lines, subtotal)
+		if err != nil {
+			return err
+		}
+		if !sameTotal(total, accepted) {
+			return errorx.ErrCartChanged
+		}
+
+		// Lock the promotions with usage limits until the order is placed
+		if err := promotion.Claim(ctx, qtx, storeID, customerID, discounts); err != nil {
+			return err
+		}
+
 		// Create order with status 'pending'
 		order, err := qtx.CreateOrder(ctx, models.CreateOrderParams{
-			StoreID:    storeID,
-			CustomerID: session.CustomerID,
-			SessionID:  sessionID,
-			TotalAmount: total,
+			StoreID:         storeID,
+			CustomerID:      buyer.CustomerID,
+			SessionID:       sessionID,
+			TotalAmount:     total,
+			GuestEmail:      buyer.GuestEmail,
+			ShippingAddress: buyer.ShippingAddress,
 		})
 		if err != nil {
 			return err
 		}
 
+		// Credit the order to a reminder that restored the cart, if any
+		if err := qtx.AttributeOrderToCartReminder(ctx, models.AttributeOrderToCartReminderParams{
+			CartID:  sql.NullInt64{Int64: cart.CartID, Valid: true},
+			OrderID: sql.NullInt64{Int64: order.OrderID, Valid: true},
+		}); err != nil {
+			return err
+		}
+
+		// Record the discounts; the code and name are copied so the order
+		// reads the same once the promotion changes
+		for _, d := range discounts.Discounts {
+			if err := qtx.CreateOrderDiscount(ctx, models.CreateOrderDiscountParams{
+				OrderID:     order.OrderID,
+				PromotionID: sql.NullInt64{Int64: d.Promotion.PromotionID, Valid: true},
+				Code:        d.Promotion.Code,
+				Description: d.Promotion.Name,
+				Type:        d.Promotion.Type,
+				Amount:      promotion.FormatCents(d.Amount),
+			}); err != nil {
+				return err
+			}
+		}
+
 		// Create order items
 		for _, item := range items {
 
@@ -231,7 +862,7 @@ func (s *Service) Checkout(
 		// TODO: Integrate with real payment gateway
 		if err := qtx.CreatePayment(ctx, models.CreatePaymentParams{
 			OrderID: order.OrderID,
-			Method:  paymentMethod,
+			Method:  input.PaymentMethod,
 			Amount:  total,
 			Status:  "completed",
 			TransactionRef: sql.NullString{
@@ -245,7 +876,7 @@ func (s *Service) Checkout(
 		// Deduct stock
 		for _, item := range items {
 			if err := qtx.DecreaseVariantStock(ctx, models.DecreaseVariantStockParams{
-				VariantID:  item.VariantID,
+				VariantID:    item.VariantID,
 				CartQuantity: item.CartQuantity,
 			}); err != nil {
 				return err
@@ -260,11 +891,111 @@ func (s *Service) Checkout(
 			return err
 		}
 
-		// Clear cart
+		// Clear cart and the code used for the order
 		if err := qtx.ClearCartItems(ctx, cart.CartID); err != nil {
 			return err
 		}
+		if err := qtx.SetCartPromotionCode(ctx, models.SetCartPromotionCodeParams{
+			CartID: cart.CartID,
+		}); err != nil {
+			return err
+		}
 
 		return nil
 	})
+	if errors.Is(err, errorx.ErrCartChanged) {
+		// Return the cart as it is now, for the shopper to review
+		if cartDTO, cartErr := s.GetCart(ctx, storeID, sessionID, customerID); cartErr == nil {
+			return cartDTO, err
+		}
+	}
+	return nil, err
+}
+
+// sameTotal reports whether the total of a cart, as returned by postgres,
+// is the one the shopper accepted, in cents.
+func sameTotal(total string, accepted int64) bool {
+	c, err := promotion.ParseCents(total)
+	return err == nil && c == accepted
+}
+
+// orderBuyer is who an order is placed for.
+type orderBuyer struct {
+	CustomerID      sql.NullInt64
+	GuestEmail      sql.NullString
+	ShippingAddress types.NullableAddress
+}
+
+// checkoutBuyer validates the checkout details. Orders of customers are
+// linked to their account; guest orders keep the email address so they
+// can be linked once an account with that address is verified.
+//
+// A guest cannot use the address of an unverified account: signing in to
+// it would not be allowed to check out either.
+func (s *Service) checkoutBuyer(
+	ctx context.Context,
+	q *models.Queries,
+	storeID int64,
+	customerID *int64,
+	input CheckoutInput,
+) (orderBuyer, error) {
+
+	address := input.ShippingAddress
+
+	if customerID == nil {
+		email, err := mail.ParseAddress(strings.TrimSpace(input.Email))
+		if err != nil || email.Name != "" || len(email.Address) > maxGuestEmailLength {
+			return orderBuyer{}, errorx.ErrGuestEmailRequired
+		}
+		if address == nil || address.Validate() != nil {
+			return orderBuyer{}, errorx.ErrInvalidShippingAddress
+		}
+		unverified, err := q.CustomerEmailUnverified(ctx, models.CustomerEmailUnverifiedParams{
+			StoreID: storeID,
+			Email:   email.Address,
+		})
+		if err != nil {
+			return orderBuyer{}, err
+		}
+		if unverified {
+			return orderBuyer{}, errorx.ErrEmailNotVerified
+		}
+		return orderBuyer{
+			GuestEmail:      sql.NullString{String: email.Address, Valid: true},
+			ShippingAddress: types.NullableAddress{Addr: address, Valid: true},
+		}, nil
+	}
+
+	if address == nil {
+		customer, err := q.GetCustomerProfile(ctx, *customerID)
+		if err != nil {
+			return orderBuyer{}, err
+		}
+		address = customer.Address.Addr
+	}
+	if address == nil || address.Validate() != nil {
+		return orderBuyer{}, errorx.ErrInvalidShippingAddress
+	}
+
+	return orderBuyer{
+		CustomerID:      sql.NullInt64{Int64: *customerID, Valid: true},
+		ShippingAddress: types.NullableAddress{Addr: address, Valid: true},
+	}, nil
+}
+
+// recordCartEvent logs a cart change for the funnel analytics. It runs in
+// the transaction of the change, so events match what the cart holds.
+func recordCartEvent(
+	ctx context.Context,
+	q *models.Queries,
+	sessionID uuid.UUID,
+	productID, variantID int64,
+	eventType string,
+) error {
+	return q.RecordCartEvent(ctx, models.RecordCartEventParams{
+		SessionID: sessionID,
+		ProductID: productID,
+		VariantID: sql.NullInt64{Int64: variantID, Valid: true},
+		EventType: sql.NullString{String: eventType, Valid: true},
+	})
 }
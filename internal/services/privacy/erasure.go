@@ -0,0 +1,119 @@
+package privacy
+
+import (
+	"context"
+	"database/sql"
+	"log"
+
+	"github.com/Secure-Website-Builder/Backend/internal/models"
+)
+
+// erase anonymizes the customer. Orders, order items, payments and
+// shipments stay in place for accounting; they only point at the
+// anonymized customer afterwards. Everything else that identifies the
+// customer or their behaviour is removed:
+//
+//   - name, email, phone, address and password of the customer
+//   - email and shipping address on their orders
+//   - IP address and user agent of their visitor sessions
+//   - product views and cart events of those sessions
+//   - their carts
+//   - login sessions, refresh tokens and pending email tokens
+//   - export archives of earlier requests
+//
+// It returns the email address the customer had, for the confirmation.
+func (s *Service) erase(ctx context.Context, req models.DataRequest) (string, error) {
+	customer, err := s.db.Queries.GetCustomerByID(ctx, req.CustomerID)
+	if err != nil {
+		return "", err
+	}
+	if customer.ErasedAt.Valid {
+		return "", nil
+	}
+
+	exports, err := s.db.Queries.ListCustomerDataRequests(ctx, req.CustomerID)
+	if err != nil {
+		return "", err
+	}
+
+	customerID := req.CustomerID
+
+	err = s.db.RunInTx(ctx, func(q *models.Queries) error {
+		if err := q.EraseCustomer(ctx, customerID); err != nil {
+			return err
+		}
+		if err := q.AnonymizeCustomerOrders(ctx, sql.NullInt64{Int64: customerID, Valid: true}); err != nil {
+			return err
+		}
+		if err := q.AnonymizeCustomerVisitorSessions(ctx, customerID); err != nil {
+			return err
+		}
+		if err := q.DeleteCustomerProductViews(ctx, customerID); err != nil {
+			return err
+		}
+		if err := q.DeleteCustomerCartEvents(ctx, customerID); err != nil {
+			return err
+		}
+		if err := q.DeleteCustomerCarts(ctx, customerID); err != nil {
+			return err
+		}
+
+		// Revoke the access tokens of the sessions before the sessions and
+		// their token records are deleted. Refresh tokens reference their
+		// auth session, so they are deleted first.
+		if err := q.RevokeUserAuthSessions(ctx, models.RevokeUserAuthSessionsParams{
+			UserID:   customerID,
+			UserRole: "customer",
+		}); err != nil {
+			return err
+		}
+		if err := q.RevokeSessionAccessTokens(ctx, models.RevokeSessionAccessTokensParams{
+			UserID:   customerID,
+			UserRole: "customer",
+		}); err != nil {
+			return err
+		}
+		if err := q.DeleteUserRefreshTokens(ctx, models.DeleteUserRefreshTokensParams{
+			UserID:   customerID,
+			UserRole: "customer",
+		}); err != nil {
+			return err
+		}
+		if err := q.DeleteUserAuthSessions(ctx, models.DeleteUserAuthSessionsParams{
+			UserID:   customerID,
+			UserRole: "customer",
+		}); err != nil {
+			return err
+		}
+		if err := q.DeleteUserPasswordResetTokens(ctx, models.DeleteUserPasswordResetTokensParams{
+			UserID:   customerID,
+			UserRole: "customer",
+		}); err != nil {
+			return err
+		}
+		if err := q.DeleteUserEmailVerificationTokens(ctx, models.DeleteUserEmailVerificationTokensParams{
+			UserID:   customerID,
+			UserRole: "customer",
+		}); err != nil {
+			return err
+		}
+
+		return q.ClearCustomerDataRequestArchives(ctx, customerID)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	// The archives are no longer referenced; a failed delete leaves an
+	// orphaned object behind but must not undo the erasure.
+	for _, e := range exports {
+		if !e.ArchiveKey.Valid {
+			continue
+		}
+		if err := s.storage.Delete(ctx, e.ArchiveKey.String); err != nil {
+			log.Printf("data erasure: failed to delete archive of request %d: %v", e.DataRequestID, err)
+		}
+	}
+
+	return customer.Email, nil
+}
@@ -0,0 +1,335 @@
+package privacy
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Secure-Website-Builder/Backend/internal/models"
+	"github.com/Secure-Website-Builder/Backend/internal/types"
+	"github.com/Secure-Website-Builder/Backend/internal/utils"
+	"github.com/google/uuid"
+)
+
+// The archive layout is part of what we hand to customers, so it uses its
+// own types instead of the database models.
+
+type exportArchive struct {
+	GeneratedAt     time.Time               `json:"generated_at"`
+	Customer        exportCustomer          `json:"customer"`
+	VisitorSessions []exportVisitorSession  `json:"visitor_sessions"`
+	LoginSessions   []exportLoginSession    `json:"login_sessions"`
+	Cart            []exportCartItem        `json:"cart"`
+	Orders          []exportOrder           `json:"orders"`
+	ProductViews    []exportProductView     `json:"product_views"`
+	CartEvents      []exportCartEvent       `json:"cart_events"`
+	DataRequests    []models.DataRequestDTO `json:"data_requests"`
+}
+
+type exportCustomer struct {
+	CustomerID      int64          `json:"customer_id"`
+	StoreID         int64          `json:"store_id"`
+	Name            string         `json:"name"`
+	Email           string         `json:"email"`
+	EmailVerifiedAt *time.Time     `json:"email_verified_at,omitempty"`
+	Phone           *string        `json:"phone,omitempty"`
+	Address         *types.Address `json:"address,omitempty"`
+	CreatedAt       time.Time      `json:"created_at"`
+}
+
+type exportVisitorSession struct {
+	SessionID   uuid.UUID  `json:"session_id"`
+	IPAddress   *string    `json:"ip_address,omitempty"`
+	UserAgent   *string    `json:"user_agent,omitempty"`
+	FirstSeenAt *time.Time `json:"first_seen_at,omitempty"`
+	LastSeenAt  *time.Time `json:"last_seen_at,omitempty"`
+}
+
+// exportLoginSession is an auth session; the refresh tokens issued for it
+// are secrets and are not exported.
+type exportLoginSession struct {
+	SessionID  uuid.UUID  `json:"session_id"`
+	IPAddress  *string    `json:"ip_address,omitempty"`
+	UserAgent  *string    `json:"user_agent,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt time.Time  `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+type exportCartItem struct {
+	VariantID int64     `json:"variant_id"`
+	Quantity  int32     `json:"quantity"`
+	UnitPrice string    `json:"unit_price"`
+	AddedAt   time.Time `json:"added_at"`
+}
+
+type exportOrder struct {
+	OrderID         int64             `json:"order_id"`
+	Email           *string           `json:"email,omitempty"`
+	ShippingAddress *types.Address    `json:"shipping_address,omitempty"`
+	TotalAmount     string            `json:"total_amount"`
+	Status          *string           `json:"status,omitempty"`
+	CreatedAt       time.Time         `json:"created_at"`
+	Items           []exportOrderItem `json:"items"`
+	Payments        []exportPayment   `json:"payments"`
+	Shipments       []exportShipment  `json:"shipments"`
+}
+
+type exportOrderItem struct {
+	VariantID int64  `json:"variant_id"`
+	Quantity  int32  `json:"quantity"`
+	UnitPrice string `json:"unit_price"`
+	Subtotal  string `json:"subtotal"`
+}
+
+type exportPayment struct {
+	PaymentID      int64     `json:"payment_id"`
+	Method         string    `json:"method"`
+	Amount         string    `json:"amount"`
+	Status         string    `json:"status"`
+	TransactionRef *string   `json:"transaction_ref,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+type exportShipment struct {
+	ShipmentID     int64      `json:"shipment_id"`
+	Carrier        *string    `json:"carrier,omitempty"`
+	TrackingNumber *string    `json:"tracking_number,omitempty"`
+	Status         *string    `json:"status,omitempty"`
+	ShippedAt      *time.Time `json:"shipped_at,omitempty"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+}
+
+type exportProductView struct {
+	ProductID int64      `json:"product_id"`
+	SessionID uuid.UUID  `json:"session_id"`
+	ViewedAt  *time.Time `json:"viewed_at,omitempty"`
+}
+
+type exportCartEvent struct {
+	ProductID int64     `json:"product_id"`
+	VariantID *int64    `json:"variant_id,omitempty"`
+	Event     *string   `json:"event,omitempty"`
+	SessionID uuid.UUID `json:"session_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// export collects the customer's data, uploads it as one JSON document and
+// returns its storage key. The key carries a random part so it cannot be
+// guessed from the request id.
+func (s *Service) export(ctx context.Context, req models.DataRequest) (string, error) {
+	archive, err := s.buildArchive(ctx, req.CustomerID)
+	if err != nil {
+		return "", err
+	}
+
+	body, err := json.MarshalIndent(archive, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	suffix := make([]byte, 16)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", err
+	}
+	key := fmt.Sprintf("stores/%d/data-exports/%d-%s.json", req.StoreID, req.DataRequestID, hex.EncodeToString(suffix))
+
+	if _, err := s.storage.Upload(ctx, key, bytes.NewReader(body), int64(len(body)), "application/json"); err != nil {
+		return "", err
+	}
+
+	return key, nil
+}
+
+func (s *Service) buildArchive(ctx context.Context, customerID int64) (*exportArchive, error) {
+	q := s.db.Queries
+
+	profile, err := q.GetCustomerProfile(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	archive := &exportArchive{
+		GeneratedAt: time.Now().UTC(),
+		Customer: exportCustomer{
+			CustomerID:      profile.CustomerID,
+			StoreID:         profile.StoreID,
+			Name:            profile.Name,
+			Email:           profile.Email,
+			EmailVerifiedAt: nullTimePtr(profile.EmailVerifiedAt),
+			Phone:           utils.NullStringToPtr(profile.Phone),
+			Address:         profile.Address.Addr,
+			CreatedAt:       profile.CreatedAt,
+		},
+		VisitorSessions: []exportVisitorSession{},
+		LoginSessions:   []exportLoginSession{},
+		Cart:            []exportCartItem{},
+		Orders:          []exportOrder{},
+		ProductViews:    []exportProductView{},
+		CartEvents:      []exportCartEvent{},
+		DataRequests:    []models.DataRequestDTO{},
+	}
+
+	visits, err := q.ListCustomerVisitorSessions(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range visits {
+		archive.VisitorSessions = append(archive.VisitorSessions, exportVisitorSession{
+			SessionID:   v.SessionID,
+			IPAddress:   utils.InetString(v.IpAddress),
+			UserAgent:   utils.NullStringToPtr(v.UserAgent),
+			FirstSeenAt: nullTimePtr(v.FirstSeenAt),
+			LastSeenAt:  nullTimePtr(v.LastSeenAt),
+		})
+	}
+
+	logins, err := q.ListUserAuthSessions(ctx, models.ListUserAuthSessionsParams{
+		UserID:   customerID,
+		UserRole: "customer",
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range logins {
+		archive.LoginSessions = append(archive.LoginSessions, exportLoginSession{
+			SessionID:  l.SessionID,
+			IPAddress:  utils.InetString(l.IpAddress),
+			UserAgent:  utils.NullStringToPtr(l.UserAgent),
+			CreatedAt:  l.CreatedAt,
+			LastUsedAt: l.LastUsedAt,
+			RevokedAt:  nullTimePtr(l.RevokedAt),
+		})
+	}
+
+	cartItems, err := q.ListCustomerCartItems(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range cartItems {
+		archive.Cart = append(archive.Cart, exportCartItem{
+			VariantID: item.VariantID,
+			Quantity:  item.Quantity,
+			UnitPrice: item.UnitPrice,
+			AddedAt:   item.CreatedAt,
+		})
+	}
+
+	if archive.Orders, err = s.exportOrders(ctx, customerID); err != nil {
+		return nil, err
+	}
+
+	views, err := q.ListCustomerProductViews(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range views {
+		archive.ProductViews = append(archive.ProductViews, exportProductView{
+			ProductID: v.ProductID,
+			SessionID: v.SessionID,
+			ViewedAt:  nullTimePtr(v.ViewedAt),
+		})
+	}
+
+	events, err := q.ListCustomerCartEvents(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range events {
+		event := exportCartEvent{
+			ProductID: e.ProductID,
+			Event:     utils.NullStringToPtr(e.EventType),
+			SessionID: e.SessionID,
+			CreatedAt: e.CreatedAt,
+		}
+		if e.VariantID.Valid {
+			event.VariantID = &e.VariantID.Int64
+		}
+		archive.CartEvents = append(archive.CartEvents, event)
+	}
+
+	if archive.DataRequests, err = s.ListCustomerRequests(ctx, customerID); err != nil {
+		return nil, err
+	}
+
+	return archive, nil
+}
+
+// exportOrders nests the items, payments and shipments under their order.
+func (s *Service) exportOrders(ctx context.Context, customerID int64) ([]exportOrder, error) {
+	q := s.db.Queries
+
+	orders, err := q.ListCustomerOrders(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]exportOrder, 0, len(orders))
+	index := make(map[int64]int, len(orders))
+	for _, o := range orders {
+		index[o.OrderID] = len(out)
+		out = append(out, exportOrder{
+			OrderID:         o.OrderID,
+			Email:           utils.NullStringToPtr(o.GuestEmail),
+			ShippingAddress: o.ShippingAddress.Addr,
+			TotalAmount:     o.TotalAmount,
+			Status:          utils.NullStringToPtr(o.Status),
+			CreatedAt:       o.CreatedAt,
+			Items:           []exportOrderItem{},
+			Payments:        []exportPayment{},
+			Shipments:       []exportShipment{},
+		})
+	}
+
+	items, err := q.ListCustomerOrderItems(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		o := &out[index[item.OrderID]]
+		o.Items = append(o.Items, exportOrderItem{
+			VariantID: item.VariantID,
+			Quantity:  item.Quantity,
+			UnitPrice: item.UnitPrice,
+			Subtotal:  item.Subtotal,
+		})
+	}
+
+	payments, err := q.ListCustomerPayments(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range payments {
+		o := &out[index[p.OrderID]]
+		o.Payments = append(o.Payments, exportPayment{
+			PaymentID:      p.PaymentID,
+			Method:         p.Method,
+			Amount:         p.Amount,
+			Status:         p.Status,
+			TransactionRef: utils.NullStringToPtr(p.TransactionRef),
+			CreatedAt:      p.CreatedAt,
+		})
+	}
+
+	shipments, err := q.ListCustomerShipments(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+	for _, sh := range shipments {
+		o := &out[index[sh.OrderID]]
+		o.Shipments = append(o.Shipments, exportShipment{
+			ShipmentID:     sh.ShipmentID,
+			Carrier:        utils.NullStringToPtr(sh.Carrier),
+			TrackingNumber: utils.NullStringToPtr(sh.TrackingNumber),
+			Status:         utils.NullStringToPtr(sh.Status),
+			ShippedAt:      nullTimePtr(sh.ShippedAt),
+			DeliveredAt:    nullTimePtr(sh.DeliveredAt),
+		})
+	}
+
+	return out, nil
+}
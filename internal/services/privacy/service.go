@@ -0,0 +1,263 @@
+package privacy
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Secure-Website-Builder/Backend/internal/config"
+	"github.com/Secure-Website-Builder/Backend/internal/database"
+	"github.com/Secure-Website-Builder/Backend/internal/errorx"
+	"github.com/Secure-Website-Builder/Backend/internal/models"
+	"github.com/Secure-Website-Builder/Backend/internal/notify"
+	"github.com/Secure-Website-Builder/Backend/internal/storage"
+	"github.com/Secure-Website-Builder/Backend/internal/utils"
+	"github.com/lib/pq"
+)
+
+const (
+	RequestExport  = "export"
+	RequestErasure = "erasure"
+
+	RequestedByCustomer   = "customer"
+	RequestedByStoreOwner = "store_owner"
+
+	defaultRequestListLimit = 50
+	maxRequestListLimit     = 200
+)
+
+// Service handles data subject requests of customers: an export of all
+// personal data tied to the customer, and an erasure that anonymizes the
+// customer while keeping orders and payments for accounting. Requests are
+// queued and carried out by Run.
+type Service struct {
+	db       *database.DB
+	storage  storage.ObjectStorage
+	notifier notify.Notifier
+	cfg      config.PrivacyConfig
+}
+
+func New(
+	db *database.DB,
+	storage storage.ObjectStorage,
+	notifier notify.Notifier,
+	cfg config.PrivacyConfig,
+) *Service {
+	return &Service{
+		db:       db,
+		storage:  storage,
+		notifier: notifier,
+		cfg:      cfg,
+	}
+}
+
+// CreateRequest queues an export or erasure of a customer of storeID on
+// behalf of the store owner.
+func (s *Service) CreateRequest(
+	ctx context.Context,
+	storeID, customerID int64,
+	requestType string,
+) (*models.DataRequestDTO, error) {
+
+	customer, err := s.db.Queries.GetCustomerByID(ctx, customerID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && customer.StoreID != storeID) {
+		return nil, errorx.ErrCustomerNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return s.createRequest(ctx, customer, requestType, RequestedByStoreOwner)
+}
+
+// CreateOwnRequest queues an export or erasure requested by the customer.
+func (s *Service) CreateOwnRequest(
+	ctx context.Context,
+	customerID int64,
+	requestType string,
+) (*models.DataRequestDTO, error) {
+
+	customer, err := s.db.Queries.GetCustomerByID(ctx, customerID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errorx.ErrCustomerNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return s.createRequest(ctx, customer, requestType, RequestedByCustomer)
+}
+
+// createRequest queues the request. Only one request of each type can be
+// open per customer.
+func (s *Service) createRequest(
+	ctx context.Context,
+	customer models.GetCustomerByIDRow,
+	requestType, requestedBy string,
+) (*models.DataRequestDTO, error) {
+
+	if requestType != RequestExport && requestType != RequestErasure {
+		return nil, errorx.ErrInvalidDataRequestType
+	}
+	if customer.ErasedAt.Valid {
+		return nil, errorx.ErrCustomerErased
+	}
+
+	req, err := s.db.Queries.CreateDataRequest(ctx, models.CreateDataRequestParams{
+		StoreID:     customer.StoreID,
+		CustomerID:  customer.CustomerID,
+		RequestType: requestType,
+		RequestedBy: requestedBy,
+	})
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return nil, errorx.ErrDataRequestPending
+		}
+		return nil, err
+	}
+
+	dto := toDataRequestDTO(req)
+	return &dto, nil
+}
+
+func (s *Service) ListCustomerRequests(ctx context.Context, customerID int64) ([]models.DataRequestDTO, error) {
+	rows, err := s.db.Queries.ListCustomerDataRequests(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]models.DataRequestDTO, 0, len(rows))
+	for _, row := range rows {
+		out = append(out, toDataRequestDTO(row))
+	}
+	return out, nil
+}
+
+func (s *Service) ListStoreRequests(
+	ctx context.Context,
+	storeID int64,
+	beforeID *int64,
+	limit int32,
+) ([]models.DataRequestDTO, error) {
+
+	if limit <= 0 {
+		limit = defaultRequestListLimit
+	}
+	if limit > maxRequestListLimit {
+		limit = maxRequestListLimit
+	}
+
+	params := models.ListStoreDataRequestsParams{StoreID: storeID, Limit: limit}
+	if beforeID != nil {
+		params.BeforeID = sql.NullInt64{Int64: *beforeID, Valid: true}
+	}
+
+	rows, err := s.db.Queries.ListStoreDataRequests(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]models.DataRequestDTO, 0, len(rows))
+	for _, row := range rows {
+		out = append(out, toDataRequestDTO(row))
+	}
+	return out, nil
+}
+
+// GetStoreRequest returns a request of the store.
+func (s *Service) GetStoreRequest(ctx context.Context, storeID, requestID int64) (*models.DataRequestDTO, error) {
+	req, err := s.getRequest(ctx, requestID, func(r models.DataRequest) bool {
+		return r.StoreID == storeID
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	dto := toDataRequestDTO(req)
+	return &dto, nil
+}
+
+// OpenCustomerArchive streams the finished export of one of the customer's
+// own requests.
+func (s *Service) OpenCustomerArchive(ctx context.Context, customerID, requestID int64) (io.ReadCloser, string, error) {
+	req, err := s.getRequest(ctx, requestID, func(r models.DataRequest) bool {
+		return r.CustomerID == customerID
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return s.openArchive(ctx, req)
+}
+
+// OpenStoreArchive streams the finished export of a request of the store,
+// so the store owner can hand it to the customer.
+func (s *Service) OpenStoreArchive(ctx context.Context, storeID, requestID int64) (io.ReadCloser, string, error) {
+	req, err := s.getRequest(ctx, requestID, func(r models.DataRequest) bool {
+		return r.StoreID == storeID
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return s.openArchive(ctx, req)
+}
+
+// getRequest loads a request and hides it unless visible reports that the
+// caller may see it.
+func (s *Service) getRequest(
+	ctx context.Context,
+	requestID int64,
+	visible func(models.DataRequest) bool,
+) (models.DataRequest, error) {
+
+	req, err := s.db.Queries.GetDataRequest(ctx, requestID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && !visible(req)) {
+		return models.DataRequest{}, errorx.ErrDataRequestNotFound
+	}
+	return req, err
+}
+
+func (s *Service) openArchive(ctx context.Context, req models.DataRequest) (io.ReadCloser, string, error) {
+	if req.RequestType != RequestExport || req.Status != "completed" || !req.ArchiveKey.Valid {
+		return nil, "", errorx.ErrExportNotReady
+	}
+
+	r, err := s.storage.Open(ctx, req.ArchiveKey.String)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return r, fmt.Sprintf("customer-%d-export-%d.json", req.CustomerID, req.DataRequestID), nil
+}
+
+func toDataRequestDTO(r models.DataRequest) models.DataRequestDTO {
+	dto := models.DataRequestDTO{
+		DataRequestID:     r.DataRequestID,
+		StoreID:           r.StoreID,
+		CustomerID:        r.CustomerID,
+		Type:              r.RequestType,
+		Status:            r.Status,
+		RequestedBy:       r.RequestedBy,
+		Error:             utils.NullStringToPtr(r.Error),
+		Attempts:          r.Attempts,
+		DownloadAvailable: r.Status == "completed" && r.ArchiveKey.Valid,
+		CreatedAt:         r.CreatedAt,
+	}
+	if r.StartedAt.Valid {
+		dto.StartedAt = &r.StartedAt.Time
+	}
+	if r.CompletedAt.Valid {
+		dto.CompletedAt = &r.CompletedAt.Time
+	}
+	return dto
+}
+
+func nullTimePtr(nt sql.NullTime) *time.Time {
+	if !nt.Valid {
+		return nil
+	}
+	return &nt.Time
+}
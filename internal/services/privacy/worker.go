@@ -0,0 +1,133 @@
+package privacy
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Secure-Website-Builder/Backend/internal/models"
+	"github.com/Secure-Website-Builder/Backend/internal/notify"
+)
+
+// Run processes queued requests every worker interval until ctx is done.
+// Several instances may run against the same database; each request is
+// claimed by one of them.
+func (s *Service) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.WorkerInterval())
+	defer ticker.Stop()
+
+	for {
+		s.ProcessPending(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProcessPending works through the queue until it is empty.
+func (s *Service) ProcessPending(ctx context.Context) {
+	for ctx.Err() == nil {
+		req, err := s.db.Queries.ClaimDataRequest(ctx, time.Now().Add(-s.cfg.StaleAfter()))
+		if errors.Is(err, sql.ErrNoRows) {
+			return
+		}
+		if err != nil {
+			log.Printf("data requests: failed to claim request: %v", err)
+			return
+		}
+
+		s.process(ctx, req)
+	}
+}
+
+func (s *Service) process(ctx context.Context, req models.DataRequest) {
+	maxAttempts := int32(s.cfg.MaxAttempts)
+
+	// A request claimed again after its worker died may already be out of
+	// attempts.
+	if req.Attempts > maxAttempts {
+		s.fail(ctx, req, maxAttempts, errors.New("abandoned too many times"))
+		return
+	}
+
+	var (
+		archiveKey sql.NullString
+		email      string
+		err        error
+	)
+
+	switch req.RequestType {
+	case RequestExport:
+		archiveKey.String, err = s.export(ctx, req)
+		archiveKey.Valid = err == nil
+	case RequestErasure:
+		email, err = s.erase(ctx, req)
+	default:
+		err = fmt.Errorf("unknown request type %q", req.RequestType)
+	}
+	if err != nil {
+		s.fail(ctx, req, maxAttempts, err)
+		return
+	}
+
+	if err := s.db.Queries.CompleteDataRequest(ctx, models.CompleteDataRequestParams{
+		DataRequestID: req.DataRequestID,
+		ArchiveKey:    archiveKey,
+	}); err != nil {
+		log.Printf("data requests: failed to complete request %d: %v", req.DataRequestID, err)
+		return
+	}
+
+	s.notifyCompleted(ctx, req, email)
+}
+
+// fail logs the cause and puts the request back in the queue, or marks it
+// failed once it is out of attempts. The stored error is deliberately
+// generic since it is shown to the customer.
+func (s *Service) fail(ctx context.Context, req models.DataRequest, maxAttempts int32, cause error) {
+	log.Printf("data requests: %s request %d failed (attempt %d): %v",
+		req.RequestType, req.DataRequestID, req.Attempts, cause)
+
+	if err := s.db.Queries.FailDataRequest(ctx, models.FailDataRequestParams{
+		MaxAttempts:   maxAttempts,
+		Error:         fmt.Sprintf("%s could not be completed", req.RequestType),
+		DataRequestID: req.DataRequestID,
+	}); err != nil {
+		log.Printf("data requests: failed to record failure of request %d: %v", req.DataRequestID, err)
+	}
+}
+
+// notifyCompleted tells the customer the request is done. For an erasure
+// the address is the one the customer had before it was anonymized.
+func (s *Service) notifyCompleted(ctx context.Context, req models.DataRequest, erasedEmail string) {
+	msg := notify.Message{}
+
+	switch req.RequestType {
+	case RequestExport:
+		customer, err := s.db.Queries.GetCustomerByID(ctx, req.CustomerID)
+		if err != nil || customer.ErasedAt.Valid {
+			return
+		}
+		msg.To = customer.Email
+		msg.Subject = "Your data export is ready"
+		msg.Body = "The export of your personal data is ready. Log in to your account to download it."
+
+	case RequestErasure:
+		if erasedEmail == "" {
+			return
+		}
+		msg.To = erasedEmail
+		msg.Subject = "Your personal data was erased"
+		msg.Body = "Your account and the personal data tied to it were erased. Records of your orders and payments are kept as required for accounting, without your contact details."
+	}
+
+	if err := s.notifier.Send(ctx, msg); err != nil {
+		log.Printf("data requests: failed to notify customer of request %d: %v", req.DataRequestID, err)
+	}
+}
@@ -0,0 +1,420 @@
+package promotion
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Secure-Website-Builder/Backend/internal/errorx"
+	"github.com/Secure-Website-Builder/Backend/internal/models"
+)
+
+// Types of promotion, see promotion.type.
+const (
+	TypePercentage   = "percentage"
+	TypeFixedAmount  = "fixed_amount"
+	TypeFreeShipping = "free_shipping"
+	TypeBuyXGetY     = "buy_x_get_y"
+)
+
+// Line is a cart item as the promotions see it.
+type Line struct {
+	VariantID  int64
+	CategoryID int64
+	UnitPrice  string
+	Quantity   int32
+}
+
+// Discount is a promotion applied to a cart. Amount is in cents; free
+// shipping has none.
+type Discount struct {
+	Promotion models.Promotion
+	Amount    int64
+}
+
+// Result is what the promotions take off a cart. Amounts are in cents.
+type Result struct {
+	Discounts    []Discount
+	Total        int64
+	FreeShipping bool
+}
+
+// Evaluate applies the promotions of the store to a cart: the automatic
+// ones, plus the one of code when the shopper entered one. They stack, in
+// the order they were created, up to the subtotal of the cart.
+func Evaluate(
+	ctx context.Context,
+	q *models.Queries,
+	storeID int64,
+	customerID *int64,
+	code sql.NullString,
+	lines []Line,
+) (*Result, error) {
+
+	promotions, err := q.ListActivePromotions(ctx, models.ListActivePromotionsParams{
+		StoreID: storeID,
+		Code:    code,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{}
+	if len(promotions) == 0 || len(lines) == 0 {
+		return result, nil
+	}
+
+	promotions, err = eligible(ctx, q, storeID, customerID, promotions)
+	if err != nil {
+		return nil, err
+	}
+
+	subtotal, err := linesSubtotal(lines)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, p := range promotions {
+		amount, ok, err := discount(p, lines)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+
+		if amount > subtotal-result.Total {
+			amount = subtotal - result.Total
+		}
+		if p.Type == TypeFreeShipping {
+			result.FreeShipping = true
+		} else if amount <= 0 {
+			continue
+		}
+
+		result.Discounts = append(result.Discounts, Discount{Promotion: p, Amount: amount})
+		result.Total += amount
+	}
+
+	return result, nil
+}
+
+// Claim locks the promotions of r that have usage limits, until the
+// transaction ends, and checks that the shopper may still use them, so
+// that the limits hold across concurrent checkouts. It fails with
+// errorx.ErrCartChanged if one was used up meanwhile.
+func Claim(
+	ctx context.Context,
+	q *models.Queries,
+	storeID int64,
+	customerID *int64,
+	r *Result,
+) error {
+
+	var limited []int64
+	for _, d := range r.Discounts {
+		if d.Promotion.UsageLimit.Valid || d.Promotion.UsageLimitPerCustomer.Valid {
+			limited = append(limited, d.Promotion.PromotionID)
+		}
+	}
+	if len(limited) == 0 {
+		return nil
+	}
+
+	promotions, err := q.LockPromotions(ctx, limited)
+	if err != nil {
+		return err
+	}
+
+	ok, err := eligible(ctx, q, storeID, customerID, promotions)
+	if err != nil {
+		return err
+	}
+	if len(ok) != len(limited) {
+		return errorx.ErrCartChanged
+	}
+	return nil
+}
+
+// CheckCode returns the promotion of a code the shopper entered, if it
+// is active and they may still use it. Whether the cart meets its other
+// conditions is left to Evaluate, as the cart may still change.
+func CheckCode(
+	ctx context.Context,
+	q *models.Queries,
+	storeID int64,
+	customerID *int64,
+	code string,
+) (models.Promotion, error) {
+
+	p, err := q.GetActivePromotionByCode(ctx, models.GetActivePromotionByCodeParams{
+		StoreID: storeID,
+		Code:    strings.TrimSpace(code),
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return p, errorx.ErrInvalidPromotionCode
+	}
+	if err != nil {
+		return p, err
+	}
+
+	ok, err := eligible(ctx, q, storeID, customerID, []models.Promotion{p})
+	if err != nil {
+		return p, err
+	}
+	if len(ok) == 0 {
+		return p, errorx.ErrInvalidPromotionCode
+	}
+	return p, nil
+}
+
+// eligible filters out the promotions the shopper may not use: those used
+// up, and first order or per-customer promotions for returning customers
+// and guests, whose orders cannot be told apart.
+func eligible(
+	ctx context.Context,
+	q *models.Queries,
+	storeID int64,
+	customerID *int64,
+	promotions []models.Promotion,
+) ([]models.Promotion, error) {
+
+	customer := sql.NullInt64{}
+	if customerID != nil {
+		customer = sql.NullInt64{Int64: *customerID, Valid: true}
+	}
+
+	var limited []int64
+	firstOrder := false
+	for _, p := range promotions {
+		if p.UsageLimit.Valid || p.UsageLimitPerCustomer.Valid {
+			limited = append(limited, p.PromotionID)
+		}
+		firstOrder = firstOrder || p.FirstOrderOnly
+	}
+
+	usage := map[int64]models.GetPromotionUsageRow{}
+	if len(limited) > 0 {
+		rows, err := q.GetPromotionUsage(ctx, models.GetPromotionUsageParams{
+			CustomerID:   customer,
+			PromotionIds: limited,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			usage[row.PromotionID] = row
+		}
+	}
+
+	hasOrders := false
+	if firstOrder && customer.Valid {
+		var err error
+		hasOrders, err = q.CustomerHasOrders(ctx, models.CustomerHasOrdersParams{
+			StoreID:    storeID,
+			CustomerID: customer,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	out := make([]models.Promotion, 0, len(promotions))
+	for _, p := range promotions {
+		if (p.FirstOrderOnly || p.UsageLimitPerCustomer.Valid) && !customer.Valid {
+			continue
+		}
+		if p.FirstOrderOnly && hasOrders {
+			continue
+		}
+		used := usage[p.PromotionID]
+		if p.UsageLimit.Valid && used.TimesUsed >= p.UsageLimit.Int32 {
+			continue
+		}
+		if p.UsageLimitPerCustomer.Valid && used.CustomerTimesUsed >= p.UsageLimitPerCustomer.Int32 {
+			continue
+		}
+		out = append(out, p)
+	}
+	return out, nil
+}
+
+// discount returns what p takes off lines, and whether the lines meet
+// its conditions at all.
+func discount(p models.Promotion, lines []Line) (int64, bool, error) {
+	qualifying := make([]Line, 0, len(lines))
+	for _, l := range lines {
+		if applies(p, l) {
+			qualifying = append(qualifying, l)
+		}
+	}
+	if len(qualifying) == 0 {
+		return 0, false, nil
+	}
+
+	subtotal, err := linesSubtotal(qualifying)
+	if err != nil {
+		return 0, false, err
+	}
+
+	if p.MinSubtotal.Valid {
+		minimum, err := ParseCents(p.MinSubtotal.String)
+		if err != nil {
+			return 0, false, err
+		}
+		if subtotal < minimum {
+			return 0, false, nil
+		}
+	}
+
+	value, err := ParseCents(p.Value)
+	if err != nil {
+		return 0, false, err
+	}
+
+	switch p.Type {
+	case TypePercentage:
+		// value is in hundredths of a percent here, round half up
+		return (subtotal*value + 5000) / 10000, true, nil
+
+	case TypeFixedAmount:
+		if value > subtotal {
+			value = subtotal
+		}
+		return value, true, nil
+
+	case TypeFreeShipping:
+		return 0, true, nil
+
+	case TypeBuyXGetY:
+		return buyXGetY(p, qualifying)
+	}
+
+	return 0, false, fmt.Errorf("unknown promotion type %q", p.Type)
+}
+
+// buyXGetY gives get_quantity of every buy_quantity + get_quantity units
+// of the qualifying lines free, the cheapest units first.
+func buyXGetY(p models.Promotion, lines []Line) (int64, bool, error) {
+	buy, get := p.BuyQuantity.Int32, p.GetQuantity.Int32
+	if buy <= 0 || get <= 0 {
+		return 0, false, nil
+	}
+
+	type unit struct {
+		price    int64
+		quantity int64
+	}
+	units := make([]unit, 0, len(lines))
+	var count int64
+	for _, l := range lines {
+		price, err := ParseCents(l.UnitPrice)
+		if err != nil {
+			return 0, false, err
+		}
+		units = append(units, unit{price: price, quantity: int64(l.Quantity)})
+		count += int64(l.Quantity)
+	}
+
+	free := count / int64(buy+get) * int64(get)
+	if free == 0 {
+		return 0, false, nil
+	}
+
+	sort.Slice(units, func(i, j int) bool { return units[i].price < units[j].price })
+
+	var amount int64
+	for _, u := range units {
+		n := min(u.quantity, free)
+		amount += n * u.price
+		free -= n
+		if free == 0 {
+			break
+		}
+	}
+	return amount, true, nil
+}
+
+// applies reports whether a line counts toward p: it must be in the
+// category and among the variants of p, when these are set.
+func applies(p models.Promotion, l Line) bool {
+	if p.CategoryID.Valid && p.CategoryID.Int64 != l.CategoryID {
+		return false
+	}
+	if len(p.VariantIds) == 0 {
+		return true
+	}
+	for _, id := range p.VariantIds {
+		if id == l.VariantID {
+			return true
+		}
+	}
+	return false
+}
+
+func linesSubtotal(lines []Line) (int64, error) {
+	var total int64
+	for _, l := range lines {
+		price, err := ParseCents(l.UnitPrice)
+		if err != nil {
+			return 0, err
+		}
+		total += price * int64(l.Quantity)
+	}
+	return total, nil
+}
+
+// ParseCents parses a DECIMAL(10,2) amount as returned by postgres.
+func ParseCents(s string) (int64, error) {
+	whole, frac, _ := strings.Cut(strings.TrimSpace(s), ".")
+	if len(frac) > 2 {
+		return 0, fmt.Errorf("amount %q has more than 2 decimals", s)
+	}
+	frac += strings.Repeat("0", 2-len(frac))
+
+	w, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+	f, err := strconv.ParseInt(frac, 10, 64)
+	if err != nil || f < 0 {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+	if strings.HasPrefix(whole, "-") {
+		return w*100 - f, nil
+	}
+	return w*100 + f, nil
+}
+
+// FormatCents formats cents as an amount with 2 decimals.
+func FormatCents(c int64) string {
+	sign := ""
+	if c < 0 {
+		sign, c = "-", -c
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, c/100, c%100)
+}
+
+// DTOs returns the discounts of r as shown on a cart.
+func (r *Result) DTOs() []models.DiscountDTO {
+	out := make([]models.DiscountDTO, 0, len(r.Discounts))
+	for _, d := range r.Discounts {
+		promotionID := d.Promotion.PromotionID
+		dto := models.DiscountDTO{
+			PromotionID: &promotionID,
+			Description: d.Promotion.Name,
+			Type:        d.Promotion.Type,
+			Amount:      FormatCents(d.Amount),
+		}
+		if d.Promotion.Code.Valid {
+			code := d.Promotion.Code.String
+			dto.Code = &code
+		}
+		out = append(out, dto)
+	}
+	return out
+}
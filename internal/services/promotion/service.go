@@ -0,0 +1,353 @@
+// Package promotion manages the promotions of a store and works out what
+// they take off a cart.
+package promotion
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Secure-Website-Builder/Backend/internal/database"
+	"github.com/Secure-Website-Builder/Backend/internal/errorx"
+	"github.com/Secure-Website-Builder/Backend/internal/models"
+	"github.com/lib/pq"
+)
+
+const (
+	maxNameLength = 255
+
+	// maxAmount is the largest DECIMAL(10,2) amount, in cents.
+	maxAmount = 99999999_99
+)
+
+var codePattern = regexp.MustCompile(`^[A-Za-z0-9_-]{3,50}$`)
+
+// Input is a promotion as created or updated by the store. Amounts are in
+// the store's currency; Value is a percentage for percentage promotions.
+type Input struct {
+	Name                  string
+	Code                  *string
+	Type                  string
+	Value                 float64
+	BuyQuantity           *int32
+	GetQuantity           *int32
+	MinSubtotal           *float64
+	CategoryID            *int64
+	VariantIDs            []int64
+	FirstOrderOnly        bool
+	UsageLimit            *int32
+	UsageLimitPerCustomer *int32
+	StartsAt              *time.Time
+	EndsAt                *time.Time
+	IsActive              *bool
+}
+
+type Service struct {
+	db *database.DB
+}
+
+func New(db *database.DB) *Service {
+	return &Service{db: db}
+}
+
+func (s *Service) Create(ctx context.Context, storeID int64, in Input) (*models.PromotionDTO, error) {
+	params, err := promotionParams(in)
+	if err != nil {
+		return nil, err
+	}
+
+	row, err := s.db.Queries.CreatePromotion(ctx, models.CreatePromotionParams{
+		StoreID:               storeID,
+		Name:                  params.Name,
+		Code:                  params.Code,
+		Type:                  params.Type,
+		Value:                 params.Value,
+		BuyQuantity:           params.BuyQuantity,
+		GetQuantity:           params.GetQuantity,
+		MinSubtotal:           params.MinSubtotal,
+		CategoryID:            params.CategoryID,
+		VariantIds:            params.VariantIds,
+		FirstOrderOnly:        params.FirstOrderOnly,
+		UsageLimit:            params.UsageLimit,
+		UsageLimitPerCustomer: params.UsageLimitPerCustomer,
+		StartsAt:              params.StartsAt,
+		EndsAt:                params.EndsAt,
+		IsActive:              params.IsActive,
+	})
+	if err != nil {
+		return nil, promotionError(err)
+	}
+
+	dto := toPromotionDTO(row, 0)
+	return &dto, nil
+}
+
+// List returns the promotions of the store, the newest first, with how
+// often each was used.
+func (s *Service) List(ctx context.Context, storeID int64) ([]models.PromotionDTO, error) {
+	rows, err := s.db.Queries.ListStorePromotions(ctx, storeID)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int64, 0, len(rows))
+	for _, row := range rows {
+		ids = append(ids, row.PromotionID)
+	}
+
+	used := map[int64]int32{}
+	if len(ids) > 0 {
+		usage, err := s.db.Queries.GetPromotionUsage(ctx, models.GetPromotionUsageParams{
+			PromotionIds: ids,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, u := range usage {
+			used[u.PromotionID] = u.TimesUsed
+		}
+	}
+
+	out := make([]models.PromotionDTO, 0, len(rows))
+	for _, row := range rows {
+		out = append(out, toPromotionDTO(row, used[row.PromotionID]))
+	}
+	return out, nil
+}
+
+// Update replaces a promotion. Orders placed with it keep the discount
+// they got.
+func (s *Service) Update(
+	ctx context.Context,
+	storeID, promotionID int64,
+	in Input,
+) (*models.PromotionDTO, error) {
+
+	params, err := promotionParams(in)
+	if err != nil {
+		return nil, err
+	}
+
+	row, err := s.db.Queries.UpdatePromotion(ctx, models.UpdatePromotionParams{
+		PromotionID:           promotionID,
+		StoreID:               storeID,
+		Name:                  params.Name,
+		Code:                  params.Code,
+		Type:                  params.Type,
+		Value:                 params.Value,
+		BuyQuantity:           params.BuyQuantity,
+		GetQuantity:           params.GetQuantity,
+		MinSubtotal:           params.MinSubtotal,
+		CategoryID:            params.CategoryID,
+		VariantIds:            params.VariantIds,
+		FirstOrderOnly:        params.FirstOrderOnly,
+		UsageLimit:            params.UsageLimit,
+		UsageLimitPerCustomer: params.UsageLimitPerCustomer,
+		StartsAt:              params.StartsAt,
+		EndsAt:                params.EndsAt,
+		IsActive:              params.IsActive,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errorx.ErrPromotionNotFound
+	}
+	if err != nil {
+		return nil, promotionError(err)
+	}
+
+	usage, err := s.db.Queries.GetPromotionUsage(ctx, models.GetPromotionUsageParams{
+		PromotionIds: []int64{row.PromotionID},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var used int32
+	if len(usage) > 0 {
+		used = usage[0].TimesUsed
+	}
+	dto := toPromotionDTO(row, used)
+	return &dto, nil
+}
+
+// Delete removes a promotion. Orders placed with it keep their discount
+// lines.
+func (s *Service) Delete(ctx context.Context, storeID, promotionID int64) error {
+	n, err := s.db.Queries.DeletePromotion(ctx, models.DeletePromotionParams{
+		PromotionID: promotionID,
+		StoreID:     storeID,
+	})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return errorx.ErrPromotionNotFound
+	}
+	return nil
+}
+
+// promotionParams validates in and converts it to the columns of a
+// promotion. The store id is left to the caller.
+func promotionParams(in Input) (models.CreatePromotionParams, error) {
+	invalid := func(msg string) (models.CreatePromotionParams, error) {
+		return models.CreatePromotionParams{}, fmt.Errorf("%w: %s", errorx.ErrInvalidPromotion, msg)
+	}
+
+	params := models.CreatePromotionParams{
+		Name:           strings.TrimSpace(in.Name),
+		Type:           in.Type,
+		Value:          "0",
+		FirstOrderOnly: in.FirstOrderOnly,
+		IsActive:       in.IsActive == nil || *in.IsActive,
+	}
+	if params.Name == "" || len(params.Name) > maxNameLength {
+		return invalid(fmt.Sprintf("name must be between 1 and %d characters", maxNameLength))
+	}
+
+	if in.Code != nil {
+		code := strings.TrimSpace(*in.Code)
+		if !codePattern.MatchString(code) {
+			return invalid("code must be 3 to 50 letters, digits, - or _")
+		}
+		params.Code = sql.NullString{String: code, Valid: true}
+	}
+
+	value, ok := cents(in.Value)
+	switch in.Type {
+	case TypePercentage:
+		if !ok || value <= 0 || value > 100_00 {
+			return invalid("value of a percentage promotion must be more than 0 and at most 100")
+		}
+		params.Value = FormatCents(value)
+
+	case TypeFixedAmount:
+		if !ok || value <= 0 {
+			return invalid("value of a fixed_amount promotion must be more than 0")
+		}
+		params.Value = FormatCents(value)
+
+	case TypeFreeShipping:
+
+	case TypeBuyXGetY:
+		if in.BuyQuantity == nil || *in.BuyQuantity < 1 || in.GetQuantity == nil || *in.GetQuantity < 1 {
+			return invalid("buy_quantity and get_quantity of a buy_x_get_y promotion must be at least 1")
+		}
+		params.BuyQuantity = sql.NullInt32{Int32: *in.BuyQuantity, Valid: true}
+		params.GetQuantity = sql.NullInt32{Int32: *in.GetQuantity, Valid: true}
+
+	default:
+		return invalid("type must be percentage, fixed_amount, free_shipping or buy_x_get_y")
+	}
+
+	if in.MinSubtotal != nil {
+		minSubtotal, ok := cents(*in.MinSubtotal)
+		if !ok || minSubtotal <= 0 {
+			return invalid("min_subtotal must be more than 0")
+		}
+		params.MinSubtotal = sql.NullString{String: FormatCents(minSubtotal), Valid: true}
+	}
+
+	if in.CategoryID != nil {
+		params.CategoryID = sql.NullInt64{Int64: *in.CategoryID, Valid: true}
+	}
+	if len(in.VariantIDs) > 0 {
+		params.VariantIds = in.VariantIDs
+	}
+
+	if in.UsageLimit != nil {
+		if *in.UsageLimit < 1 {
+			return invalid("usage_limit must be at least 1")
+		}
+		params.UsageLimit = sql.NullInt32{Int32: *in.UsageLimit, Valid: true}
+	}
+	if in.UsageLimitPerCustomer != nil {
+		if *in.UsageLimitPerCustomer < 1 {
+			return invalid("usage_limit_per_customer must be at least 1")
+		}
+		params.UsageLimitPerCustomer = sql.NullInt32{Int32: *in.UsageLimitPerCustomer, Valid: true}
+	}
+
+	if in.StartsAt != nil {
+		params.StartsAt = sql.NullTime{Time: *in.StartsAt, Valid: true}
+	}
+	if in.EndsAt != nil {
+		if in.StartsAt != nil && !in.EndsAt.After(*in.StartsAt) {
+			return invalid("ends_at must be after starts_at")
+		}
+		params.EndsAt = sql.NullTime{Time: *in.EndsAt, Valid: true}
+	}
+
+	return params, nil
+}
+
+// cents converts an amount given by the store to cents. It fails for
+// amounts with more than 2 decimals or that do not fit DECIMAL(10,2).
+func cents(amount float64) (int64, bool) {
+	c := math.Round(amount * 100)
+	if math.Abs(amount*100-c) > 1e-6 || c < 0 || c > maxAmount {
+		return 0, false
+	}
+	return int64(c), true
+}
+
+// promotionError maps the constraint violations of a promotion to errors
+// the store can act on.
+func promotionError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code {
+		case "23505":
+			return errorx.ErrPromotionCodeTaken
+		case "23503":
+			return fmt.Errorf("%w: category_id does not exist", errorx.ErrInvalidPromotion)
+		}
+	}
+	return err
+}
+
+func toPromotionDTO(row models.Promotion, timesUsed int32) models.PromotionDTO {
+	dto := models.PromotionDTO{
+		PromotionID:    row.PromotionID,
+		Name:           row.Name,
+		Type:           row.Type,
+		Value:          row.Value,
+		VariantIDs:     row.VariantIds,
+		FirstOrderOnly: row.FirstOrderOnly,
+		TimesUsed:      timesUsed,
+		IsActive:       row.IsActive,
+		CreatedAt:      row.CreatedAt,
+		UpdatedAt:      row.UpdatedAt,
+	}
+	if row.Code.Valid {
+		dto.Code = &row.Code.String
+	}
+	if row.BuyQuantity.Valid {
+		dto.BuyQuantity = &row.BuyQuantity.Int32
+	}
+	if row.GetQuantity.Valid {
+		dto.GetQuantity = &row.GetQuantity.Int32
+	}
+	if row.MinSubtotal.Valid {
+		dto.MinSubtotal = &row.MinSubtotal.String
+	}
+	if row.CategoryID.Valid {
+		dto.CategoryID = &row.CategoryID.Int64
+	}
+	if row.UsageLimit.Valid {
+		dto.UsageLimit = &row.UsageLimit.Int32
+	}
+	if row.UsageLimitPerCustomer.Valid {
+		dto.UsageLimitPerCustomer = &row.UsageLimitPerCustomer.Int32
+	}
+	if row.StartsAt.Valid {
+		dto.StartsAt = &row.StartsAt.Time
+	}
+	if row.EndsAt.Valid {
+		dto.EndsAt = &row.EndsAt.Time
+	}
+	return dto
+}
@@ -0,0 +1,118 @@
+// Package recovery reminds customers of the carts they left, with a link
+// that restores the cart. Stores choose how many reminders are sent and
+// how long after the cart was last changed.
+package recovery
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	"github.com/Secure-Website-Builder/Backend/internal/config"
+	"github.com/Secure-Website-Builder/Backend/internal/database"
+	"github.com/Secure-Website-Builder/Backend/internal/models"
+	"github.com/Secure-Website-Builder/Backend/internal/notify"
+	"github.com/Secure-Website-Builder/Backend/internal/utils"
+)
+
+type Service struct {
+	db       *database.DB
+	notifier notify.Notifier
+	cfg      config.CartRecoveryConfig
+}
+
+func New(db *database.DB, notifier notify.Notifier, cfg config.CartRecoveryConfig) *Service {
+	return &Service{db: db, notifier: notifier, cfg: cfg}
+}
+
+// Run sends the reminders that are due every worker interval until ctx is
+// done. Several instances may run against the same database; each
+// reminder is sent by one of them.
+func (s *Service) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.WorkerInterval())
+	defer ticker.Stop()
+
+	for {
+		s.SendDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// SendDue sends up to a batch of reminders that are due. Only customers
+// with a verified email address are reminded; guests have not given one
+// before checkout.
+func (s *Service) SendDue(ctx context.Context) {
+	carts, err := s.db.Queries.ListCartsDueForReminder(ctx, int32(s.cfg.BatchSize))
+	if err != nil {
+		log.Printf("cart recovery: failed to list abandoned carts: %v", err)
+		return
+	}
+
+	for _, cart := range carts {
+		if ctx.Err() != nil {
+			return
+		}
+		s.remind(ctx, cart)
+	}
+}
+
+func (s *Service) remind(ctx context.Context, cart models.ListCartsDueForReminderRow) {
+	token, err := utils.GenerateRefreshToken()
+	if err != nil {
+		log.Printf("cart recovery: failed to generate token: %v", err)
+		return
+	}
+
+	reminder, err := s.db.Queries.CreateCartReminder(ctx, models.CreateCartReminderParams{
+		CartID:         sql.NullInt64{Int64: cart.CartID, Valid: true},
+		StoreID:        cart.StoreID,
+		CartActivityAt: cart.CartActivityAt,
+		ReminderNumber: cart.RemindersSent + 1,
+		TokenHash:      utils.HashToken(token),
+		ExpiresAt:      time.Now().Add(s.cfg.LinkTTL()),
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		// Another instance sent this reminder.
+		return
+	}
+	if err != nil {
+		log.Printf("cart recovery: failed to record reminder for cart %d: %v", cart.CartID, err)
+		return
+	}
+
+	// Only the newest link of a cart works.
+	if err := s.db.Queries.ExpireCartReminders(ctx, models.ExpireCartRemindersParams{
+		CartID:         reminder.CartID,
+		CartReminderID: reminder.CartReminderID,
+	}); err != nil {
+		log.Printf("cart recovery: failed to expire older reminders of cart %d: %v", cart.CartID, err)
+		return
+	}
+
+	link := fmt.Sprintf("%s?store_id=%d&token=%s", s.cfg.RecoveryURL, cart.StoreID, url.QueryEscape(token))
+
+	// The reminder counts as sent either way, so a failed delivery is not
+	// retried before the next one is due.
+	if err := s.notifier.Send(ctx, notify.Message{
+		To:      cart.Email,
+		Subject: fmt.Sprintf("You left items in your cart at %s", cart.StoreName),
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nYou left items in your cart at %s. Open the link below to pick up where you left off. It expires in %d hours.\n\n%s",
+			cart.CustomerName,
+			cart.StoreName,
+			s.cfg.LinkTTLHours,
+			link,
+		),
+	}); err != nil {
+		log.Printf("cart recovery: failed to send reminder %d: %v", reminder.CartReminderID, err)
+	}
+}
@@ -0,0 +1,305 @@
+package staff
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Secure-Website-Builder/Backend/internal/config"
+	"github.com/Secure-Website-Builder/Backend/internal/database"
+	"github.com/Secure-Website-Builder/Backend/internal/errorx"
+	"github.com/Secure-Website-Builder/Backend/internal/models"
+	"github.com/Secure-Website-Builder/Backend/internal/notify"
+	"github.com/Secure-Website-Builder/Backend/internal/permissions"
+	"github.com/Secure-Website-Builder/Backend/internal/utils"
+)
+
+// Service manages who can work in a store's dashboard. Besides the owner,
+// a store can have staff: store owner accounts invited by email and given
+// a role (see permissions).
+type Service struct {
+	db       *database.DB
+	notifier notify.Notifier
+	cfg      config.StaffConfig
+}
+
+func New(db *database.DB, notifier notify.Notifier, cfg config.StaffConfig) *Service {
+	return &Service{db: db, notifier: notifier, cfg: cfg}
+}
+
+// Role returns the role of a store owner account in storeID, and false if
+// the account has no access to the store.
+func (s *Service) Role(ctx context.Context, storeID, userID int64) (string, bool, error) {
+	role, err := s.db.Queries.GetStoreRole(ctx, models.GetStoreRoleParams{
+		StoreID:      storeID,
+		StoreOwnerID: userID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return role, true, nil
+}
+
+// Invite mails an invitation to join storeID with role to email. Inviting
+// an address again replaces its pending invitation.
+func (s *Service) Invite(
+	ctx context.Context,
+	storeID, inviterID int64,
+	email, role string,
+) (*models.StoreInvitationDTO, error) {
+
+	email = strings.TrimSpace(email)
+	if email == "" {
+		return nil, errorx.ErrInvalidRequestBody
+	}
+	if !permissions.IsStaffRole(role) {
+		return nil, errorx.ErrInvalidStaffRole
+	}
+
+	store, err := s.db.Queries.GetStore(ctx, storeID)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := utils.GenerateRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	var invitation models.StoreInvitation
+	err = s.db.RunInTx(ctx, func(q *models.Queries) error {
+		var err error
+
+		if err := q.DeletePendingStoreInvitations(ctx, models.DeletePendingStoreInvitationsParams{
+			StoreID: storeID,
+			Email:   email,
+		}); err != nil {
+			return err
+		}
+
+		invitation, err = q.CreateStoreInvitation(ctx, models.CreateStoreInvitationParams{
+			StoreID:   storeID,
+			Email:     email,
+			Role:      role,
+			TokenHash: utils.HashToken(token),
+			InvitedBy: inviterID,
+			ExpiresAt: time.Now().Add(s.cfg.InvitationTTL()),
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	link := s.cfg.InvitationURL + "?token=" + url.QueryEscape(token)
+
+	// The invitation can be sent again, so a failed delivery is not an error.
+	if err := s.notifier.Send(ctx, notify.Message{
+		To:      email,
+		Subject: fmt.Sprintf("You were invited to join %s", store.Name),
+		Body: fmt.Sprintf(
+			"You were invited to work on the store %s as %s. Log in to your business account with this email address, or create one, and open the link below. It expires in %d hours.\n\n%s\n\nIf you did not expect this invitation, you can ignore this email.",
+			store.Name,
+			strings.ReplaceAll(role, "_", " "),
+			s.cfg.InvitationTTLHours,
+			link,
+		),
+	}); err != nil {
+		log.Printf("staff: failed to send invitation %d: %v", invitation.StoreInvitationID, err)
+	}
+
+	dto := toInvitationDTO(invitation)
+	return &dto, nil
+}
+
+func (s *Service) ListInvitations(ctx context.Context, storeID int64) ([]models.StoreInvitationDTO, error) {
+	rows, err := s.db.Queries.ListStoreInvitations(ctx, storeID)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]models.StoreInvitationDTO, 0, len(rows))
+	for _, row := range rows {
+		out = append(out, toInvitationDTO(row))
+	}
+	return out, nil
+}
+
+// RevokeInvitation deletes a pending invitation so its link stops working.
+func (s *Service) RevokeInvitation(ctx context.Context, storeID, invitationID int64) error {
+	n, err := s.db.Queries.DeleteStoreInvitation(ctx, models.DeleteStoreInvitationParams{
+		StoreInvitationID: invitationID,
+		StoreID:           storeID,
+	})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return errorx.ErrInvitationNotFound
+	}
+	return nil
+}
+
+// AcceptInvitation adds the logged-in store owner account to the staff of
+// the inviting store. The token alone is not enough: the account must use
+// the address the invitation was sent to.
+func (s *Service) AcceptInvitation(
+	ctx context.Context,
+	userID int64,
+	token string,
+) (*models.StoreMemberDTO, error) {
+
+	account, err := s.db.Queries.GetStoreOwnerByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var member models.StoreMember
+	err = s.db.RunInTx(ctx, func(q *models.Queries) error {
+		invitation, err := q.GetStoreInvitationForUpdate(ctx, utils.HashToken(token))
+		if errors.Is(err, sql.ErrNoRows) {
+			return errorx.ErrInvalidInvitation
+		}
+		if err != nil {
+			return err
+		}
+
+		if !strings.EqualFold(invitation.Email, account.Email) {
+			return errorx.ErrInvitationEmailMismatch
+		}
+
+		store, err := q.GetStore(ctx, invitation.StoreID)
+		if err != nil {
+			return err
+		}
+		if store.StoreOwnerID == userID {
+			return errorx.ErrAlreadyStoreOwner
+		}
+
+		member, err = q.AddStoreMember(ctx, models.AddStoreMemberParams{
+			StoreID:      invitation.StoreID,
+			StoreOwnerID: userID,
+			Role:         invitation.Role,
+			InvitedBy:    sql.NullInt64{Int64: invitation.InvitedBy, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+
+		return q.MarkStoreInvitationAccepted(ctx, invitation.StoreInvitationID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.StoreMemberDTO{
+		MemberID:  member.StoreMemberID,
+		UserID:    userID,
+		Name:      account.Name,
+		Email:     account.Email,
+		Role:      member.Role,
+		InvitedBy: nullInt64Ptr(member.InvitedBy),
+		CreatedAt: member.CreatedAt,
+	}, nil
+}
+
+// ListMembers returns the owner followed by the staff of storeID.
+func (s *Service) ListMembers(ctx context.Context, storeID int64) ([]models.StoreMemberDTO, error) {
+	store, err := s.db.Queries.GetStore(ctx, storeID)
+	if err != nil {
+		return nil, err
+	}
+
+	owner, err := s.db.Queries.GetStoreOwnerByID(ctx, store.StoreOwnerID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.Queries.ListStoreMembers(ctx, storeID)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]models.StoreMemberDTO, 0, len(rows)+1)
+	out = append(out, models.StoreMemberDTO{
+		UserID:    owner.StoreOwnerID,
+		Name:      owner.Name,
+		Email:     owner.Email,
+		Role:      permissions.RoleOwner,
+		CreatedAt: store.CreatedAt,
+	})
+	for _, row := range rows {
+		out = append(out, models.StoreMemberDTO{
+			MemberID:  row.StoreMemberID,
+			UserID:    row.StoreOwnerID,
+			Name:      row.Name,
+			Email:     row.Email,
+			Role:      row.Role,
+			InvitedBy: nullInt64Ptr(row.InvitedBy),
+			CreatedAt: row.CreatedAt,
+		})
+	}
+	return out, nil
+}
+
+// UpdateMemberRole changes the role of a staff member. It applies to the
+// member's next request.
+func (s *Service) UpdateMemberRole(ctx context.Context, storeID, memberID int64, role string) error {
+	if !permissions.IsStaffRole(role) {
+		return errorx.ErrInvalidStaffRole
+	}
+
+	n, err := s.db.Queries.UpdateStoreMemberRole(ctx, models.UpdateStoreMemberRoleParams{
+		StoreMemberID: memberID,
+		StoreID:       storeID,
+		Role:          role,
+	})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return errorx.ErrStaffMemberNotFound
+	}
+	return nil
+}
+
+// RemoveMember takes away a staff member's access to the store.
+func (s *Service) RemoveMember(ctx context.Context, storeID, memberID int64) error {
+	n, err := s.db.Queries.RemoveStoreMember(ctx, models.RemoveStoreMemberParams{
+		StoreMemberID: memberID,
+		StoreID:       storeID,
+	})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return errorx.ErrStaffMemberNotFound
+	}
+	return nil
+}
+
+func toInvitationDTO(row models.StoreInvitation) models.StoreInvitationDTO {
+	return models.StoreInvitationDTO{
+		InvitationID: row.StoreInvitationID,
+		Email:        row.Email,
+		Role:         row.Role,
+		InvitedBy:    row.InvitedBy,
+		ExpiresAt:    row.ExpiresAt,
+		CreatedAt:    row.CreatedAt,
+	}
+}
+
+func nullInt64Ptr(v sql.NullInt64) *int64 {
+	if !v.Valid {
+		return nil
+	}
+	return &v.Int64
+}
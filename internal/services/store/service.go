@@ -8,11 +8,26 @@ import (
 	"errors"
 
 	"github.com/Secure-Website-Builder/Backend/internal/database"
+	"github.com/Secure-Website-Builder/Backend/internal/errorx"
 	"github.com/Secure-Website-Builder/Backend/internal/models"
 	"github.com/Secure-Website-Builder/Backend/internal/storage"
 	"github.com/Secure-Website-Builder/Backend/internal/utils"
 )
 
+const (
+	defaultOrderListLimit = 50
+	maxOrderListLimit     = 200
+
+	// maxReservationMinutes keeps a shopper from holding stock for more
+	// than a day.
+	maxReservationMinutes = 24 * 60
+
+	// Bounds of the abandoned cart reminder settings, so a store cannot
+	// flood its customers.
+	maxReminderDelayHours = 30 * 24
+	maxCartReminders      = 5
+)
+
 type Service struct {
 	db   *database.DB
 	site *storage.MinIOStorage
@@ -25,15 +40,6 @@ func New(db *database.DB, site *storage.MinIOStorage) *Service {
 	}
 }
 
-// IsOwner checks if user is owner of the store
-func (s *Service) IsOwner(ctx context.Context, userID, storeID int64) (bool, error) {
-	return s.db.Queries.IsStoreOwner(ctx, models.IsStoreOwnerParams{
-		StoreOwnerID: userID,
-		StoreID:      storeID,
-	})
-}
-
-
 // CreateStore creates a store for a given owner or retries store initialization
 // if a previous attempt failed.
 //
@@ -158,3 +164,194 @@ func (s *Service) GetStore(
 		UpdatedAt:    store.UpdatedAt,
 	}, nil
 }
+
+func (s *Service) GetPasswordPolicy(
+	ctx context.Context,
+	storeID int64,
+) (*models.StorePasswordPolicyDTO, error) {
+
+	store, err := s.db.Queries.GetStore(ctx, storeID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.StorePasswordPolicyDTO{
+		CheckBreachedPasswords: store.CheckBreachedPasswords,
+	}, nil
+}
+
+// UpdatePasswordPolicy sets whether customer passwords of the store are
+// checked against known breaches on registration and reset.
+func (s *Service) UpdatePasswordPolicy(
+	ctx context.Context,
+	storeID int64,
+	policy models.StorePasswordPolicyDTO,
+) error {
+
+	return s.db.Queries.UpdateStorePasswordPolicy(ctx, models.UpdateStorePasswordPolicyParams{
+		StoreID:                storeID,
+		CheckBreachedPasswords: policy.CheckBreachedPasswords,
+	})
+}
+
+func (s *Service) GetStockReservation(
+	ctx context.Context,
+	storeID int64,
+) (*models.StoreStockReservationDTO, error) {
+
+	store, err := s.db.Queries.GetStore(ctx, storeID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.StoreStockReservationDTO{
+		ReservationMinutes: store.StockReservationMinutes,
+	}, nil
+}
+
+// UpdateStockReservation sets how long adding to a cart holds stock.
+// Reservations already made keep their expiry.
+func (s *Service) UpdateStockReservation(
+	ctx context.Context,
+	storeID int64,
+	setting models.StoreStockReservationDTO,
+) error {
+
+	if setting.ReservationMinutes < 0 || setting.ReservationMinutes > maxReservationMinutes {
+		return errorx.ErrInvalidReservationTime
+	}
+
+	return s.db.Queries.UpdateStoreStockReservation(ctx, models.UpdateStoreStockReservationParams{
+		StoreID:                 storeID,
+		StockReservationMinutes: setting.ReservationMinutes,
+	})
+}
+
+func (s *Service) GetCartReminders(
+	ctx context.Context,
+	storeID int64,
+) (*models.StoreCartRemindersDTO, error) {
+
+	store, err := s.db.Queries.GetStore(ctx, storeID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.StoreCartRemindersDTO{
+		DelayHours:   store.CartReminderDelayHours,
+		MaxReminders: store.CartReminderMax,
+	}, nil
+}
+
+// UpdateCartReminders sets when customers of the store are reminded of
+// abandoned carts.
+func (s *Service) UpdateCartReminders(
+	ctx context.Context,
+	storeID int64,
+	setting models.StoreCartRemindersDTO,
+) error {
+
+	if setting.DelayHours < 1 || setting.DelayHours > maxReminderDelayHours ||
+		setting.MaxReminders < 0 || setting.MaxReminders > maxCartReminders {
+		return errorx.ErrInvalidReminderSettings
+	}
+
+	return s.db.Queries.UpdateStoreCartReminders(ctx, models.UpdateStoreCartRemindersParams{
+		StoreID:                storeID,
+		CartReminderDelayHours: setting.DelayHours,
+		CartReminderMax:        setting.MaxReminders,
+	})
+}
+
+// ListOrders returns the store's orders with their items, newest first.
+// Pass the id of the last order of a page as beforeID to get the next one.
+func (s *Service) ListOrders(
+	ctx context.Context,
+	storeID int64,
+	beforeID *int64,
+	limit int32,
+) ([]models.StoreOrderDTO, error) {
+
+	if limit <= 0 {
+		limit = defaultOrderListLimit
+	}
+	if limit > maxOrderListLimit {
+		limit = maxOrderListLimit
+	}
+
+	params := models.ListStoreOrdersParams{StoreID: storeID, Limit: limit}
+	if beforeID != nil {
+		params.BeforeID = sql.NullInt64{Int64: *beforeID, Valid: true}
+	}
+
+	orders, err := s.db.Queries.ListStoreOrders(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]models.StoreOrderDTO, 0, len(orders))
+	index := make(map[int64]int, len(orders))
+	orderIDs := make([]int64, 0, len(orders))
+	for _, o := range orders {
+		dto := models.StoreOrderDTO{
+			OrderID:         o.OrderID,
+			TotalAmount:     o.TotalAmount,
+			GuestEmail:      utils.NullStringToPtr(o.GuestEmail),
+			ShippingAddress: o.ShippingAddress.Addr,
+			Status:          utils.NullStringToPtr(o.Status),
+			CreatedAt:       o.CreatedAt,
+			Items:           []models.StoreOrderItemDTO{},
+			Discounts:       []models.DiscountDTO{},
+		}
+		if o.CustomerID.Valid {
+			dto.CustomerID = &o.CustomerID.Int64
+		}
+
+		index[o.OrderID] = len(out)
+		orderIDs = append(orderIDs, o.OrderID)
+		out = append(out, dto)
+	}
+	if len(out) == 0 {
+		return out, nil
+	}
+
+	items, err := s.db.Queries.ListStoreOrderItems(ctx, models.ListStoreOrderItemsParams{
+		StoreID:  storeID,
+		OrderIds: orderIDs,
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		o := &out[index[item.OrderID]]
+		o.Items = append(o.Items, models.StoreOrderItemDTO{
+			VariantID: item.VariantID,
+			Quantity:  item.Quantity,
+			UnitPrice: item.UnitPrice,
+			Subtotal:  item.Subtotal,
+		})
+	}
+
+	discounts, err := s.db.Queries.ListStoreOrderDiscounts(ctx, models.ListStoreOrderDiscountsParams{
+		StoreID:  storeID,
+		OrderIds: orderIDs,
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range discounts {
+		o := &out[index[d.OrderID]]
+		dto := models.DiscountDTO{
+			Code:        utils.NullStringToPtr(d.Code),
+			Description: d.Description,
+			Type:        d.Type,
+			Amount:      d.Amount,
+		}
+		if d.PromotionID.Valid {
+			dto.PromotionID = &d.PromotionID.Int64
+		}
+		o.Discounts = append(o.Discounts, dto)
+	}
+
+	return out, nil
+}
@@ -0,0 +1,177 @@
+package visitor
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Secure-Website-Builder/Backend/internal/config"
+	"github.com/Secure-Website-Builder/Backend/internal/database"
+	"github.com/Secure-Website-Builder/Backend/internal/errorx"
+	"github.com/Secure-Website-Builder/Backend/internal/models"
+	"github.com/Secure-Website-Builder/Backend/internal/utils"
+	"github.com/google/uuid"
+)
+
+// maxUserAgentLength bounds what a client can make us store per session.
+const maxUserAgentLength = 512
+
+// Service issues the visitor sessions a storefront needs before it can
+// use the cart. Sessions are anonymous until a customer logs in with one.
+type Service struct {
+	db  *database.DB
+	cfg config.VisitorSessionConfig
+}
+
+func New(db *database.DB, cfg config.VisitorSessionConfig) *Service {
+	return &Service{db: db, cfg: cfg}
+}
+
+// Start resumes the session the client presented if it belongs to storeID
+// and has not expired, and creates a new one otherwise. A client whose
+// previous session expired is marked as returning. The bool result reports
+// whether a session was created.
+func (s *Service) Start(
+	ctx context.Context,
+	storeID int64,
+	presented *uuid.UUID,
+	ip, userAgent string,
+) (*models.VisitorSessionDTO, bool, error) {
+
+	if _, err := s.db.Queries.GetStore(ctx, storeID); err != nil {
+		return nil, false, err
+	}
+
+	returning := false
+	if presented != nil {
+		session, err := s.db.Queries.TouchVisitorSession(ctx, models.TouchVisitorSessionParams{
+			SessionID: *presented,
+			StoreID:   storeID,
+			IdleSince: s.idleSince(),
+		})
+		if err == nil {
+			return s.toDTO(session), false, nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, false, err
+		}
+
+		returning, err = s.db.Queries.VisitorSessionExists(ctx, models.VisitorSessionExistsParams{
+			SessionID: *presented,
+			StoreID:   storeID,
+		})
+		if err != nil {
+			return nil, false, err
+		}
+	}
+
+	session, err := createSession(ctx, s.db.Queries, storeID, ip, userAgent, returning)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return s.toDTO(session), true, nil
+}
+
+// RecoverCart redeems the link of an abandoned cart reminder: the cart is
+// moved to a new session, which is returned. The cart of a customer stays
+// theirs, so its session can only be used once they log in.
+func (s *Service) RecoverCart(
+	ctx context.Context,
+	storeID int64,
+	token, ip, userAgent string,
+) (*models.VisitorSessionDTO, error) {
+
+	var session models.VisitorSession
+	err := s.db.RunInTx(ctx, func(q *models.Queries) error {
+		reminder, err := q.GetCartReminderByTokenForUpdate(ctx, utils.HashToken(token))
+		if errors.Is(err, sql.ErrNoRows) {
+			return errorx.ErrInvalidRecoveryLink
+		}
+		if err != nil {
+			return err
+		}
+		if reminder.StoreID != storeID || !reminder.CartID.Valid {
+			return errorx.ErrInvalidRecoveryLink
+		}
+
+		session, err = createSession(ctx, q, storeID, ip, userAgent, true)
+		if err != nil {
+			return err
+		}
+
+		cart, err := q.MoveCartToSession(ctx, models.MoveCartToSessionParams{
+			CartID:    reminder.CartID.Int64,
+			StoreID:   storeID,
+			SessionID: session.SessionID,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			return errorx.ErrInvalidRecoveryLink
+		}
+		if err != nil {
+			return err
+		}
+
+		if cart.CustomerID.Valid {
+			if err := q.LinkVisitorSessionToCustomer(ctx, models.LinkVisitorSessionToCustomerParams{
+				CustomerID: cart.CustomerID,
+				SessionID:  session.SessionID,
+				StoreID:    storeID,
+			}); err != nil {
+				return err
+			}
+		}
+
+		// A link restores the cart once, and the older links of the cart
+		// stop working with it
+		if err := q.ExpireCartReminders(ctx, models.ExpireCartRemindersParams{
+			CartID:         reminder.CartID,
+			CartReminderID: reminder.CartReminderID,
+		}); err != nil {
+			return err
+		}
+		return q.MarkCartReminderRecovered(ctx, reminder.CartReminderID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.toDTO(session), nil
+}
+
+func createSession(
+	ctx context.Context,
+	q *models.Queries,
+	storeID int64,
+	ip, userAgent string,
+	returning bool,
+) (models.VisitorSession, error) {
+
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = strings.ToValidUTF8(userAgent[:maxUserAgentLength], "")
+	}
+
+	return q.CreateVisitorSession(ctx, models.CreateVisitorSessionParams{
+		StoreID:     storeID,
+		IpAddress:   utils.ToInet(ip),
+		UserAgent:   sql.NullString{String: userAgent, Valid: userAgent != ""},
+		IsReturning: sql.NullBool{Bool: returning, Valid: true},
+	})
+}
+
+func (s *Service) idleSince() sql.NullTime {
+	return sql.NullTime{Time: time.Now().Add(-s.cfg.IdleTimeout()), Valid: true}
+}
+
+func (s *Service) toDTO(row models.VisitorSession) *models.VisitorSessionDTO {
+	return &models.VisitorSessionDTO{
+		SessionID:   row.SessionID,
+		StoreID:     row.StoreID,
+		IsReturning: row.IsReturning.Bool,
+		FirstSeenAt: row.FirstSeenAt.Time,
+		LastSeenAt:  row.LastSeenAt.Time,
+		ExpiresAt:   row.LastSeenAt.Time.Add(s.cfg.IdleTimeout()),
+	}
+}
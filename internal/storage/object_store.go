@@ -11,6 +11,7 @@ import (
 
 type ObjectStorage interface {
 	Upload(ctx context.Context, key string, r io.Reader, size int64, contentType string) (publicURL string, err error)
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
 	Delete(ctx context.Context, key string) error
 }
 
@@ -62,6 +63,24 @@ func (m *MinIOStorage) Upload(ctx context.Context, key string, r io.Reader, size
 	return fmt.Sprintf("%s/%s", m.baseURL, key), nil
 }
 
+// Open streams an object from the bucket, for objects that must not be
+// served through their public URL.
+func (m *MinIOStorage) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	obj, err := m.client.GetObject(ctx, m.bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	// GetObject is lazy; Stat surfaces a missing object here rather than on
+	// the first Read.
+	if _, err := obj.Stat(); err != nil {
+		obj.Close()
+		return nil, err
+	}
+
+	return obj, nil
+}
+
 func (m *MinIOStorage) Delete(ctx context.Context, key string) error {
 	return m.client.RemoveObject(ctx, m.bucket, key, minio.RemoveObjectOptions{})
 }
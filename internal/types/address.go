@@ -4,8 +4,12 @@ import (
 	"database/sql/driver"
 	"encoding/json"
 	"errors"
+	"strings"
 )
 
+// maxAddressFieldLength bounds every address field.
+const maxAddressFieldLength = 255
+
 // Address represents the expected JSON structure.
 type Address struct {
 	Street     string `json:"street"`
@@ -15,6 +19,33 @@ type Address struct {
 	Country    string `json:"country"`
 }
 
+// Validate trims the fields and checks that street, city and country are
+// present.
+func (a *Address) Validate() error {
+	a.Street = strings.TrimSpace(a.Street)
+	a.City = strings.TrimSpace(a.City)
+	a.State = strings.TrimSpace(a.State)
+	a.PostalCode = strings.TrimSpace(a.PostalCode)
+	a.Country = strings.TrimSpace(a.Country)
+
+	switch {
+	case a.Street == "":
+		return errors.New("address street is required")
+	case a.City == "":
+		return errors.New("address city is required")
+	case a.Country == "":
+		return errors.New("address country is required")
+	}
+
+	for _, field := range []string{a.Street, a.City, a.State, a.PostalCode, a.Country} {
+		if len(field) > maxAddressFieldLength {
+			return errors.New("address fields must be at most 255 characters")
+		}
+	}
+
+	return nil
+}
+
 // NullableAddress safely handles NULL JSONB values.
 type NullableAddress struct {
 	Addr  *Address
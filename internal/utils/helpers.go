@@ -2,53 +2,85 @@ package utils
 
 import (
 	"crypto/rand"
-	"crypto/sha1"
 	"crypto/sha256"
 	"database/sql"
 	"encoding/base64"
 	"encoding/hex"
 	"errors"
 	"fmt"
-	"io"
-	"net/http"
 	"regexp"
 	"sort"
 	"strings"
 	"time"
 
+	"github.com/Secure-Website-Builder/Backend/internal/jwtkeys"
 	"github.com/Secure-Website-Builder/Backend/internal/models"
 	"github.com/golang-jwt/jwt/v5"
-	"golang.org/x/crypto/bcrypt"
 )
 
-// Password Hashing
+// JWT generation
+
+// Token types carried in the "typ" claim. Only access tokens are accepted by
+// JWTAuth; mfa_pending tokens can only be exchanged at the MFA verify step.
+const (
+	TokenTypeAccess     = "access"
+	TokenTypeMFAPending = "mfa_pending"
+)
+
+// GenerateJWT issues an access token. sessionID is the auth session the token
+// belongs to (the "sid" claim) and tokenID identifies the token itself (the
+// "jti" claim) so it can be revoked before it expires.
+func GenerateJWT(
+	userID int64,
+	role string,
+	storeID *int64,
+	sessionID string,
+	tokenID string,
+	mfaVerified bool,
+	keys *jwtkeys.KeySet,
+	duration time.Duration,
+) (string, error) {
+
+	claims := jwt.MapClaims{
+		"user_id": userID,
+		"role":    role,
+		"typ":     TokenTypeAccess,
+		"mfa":     mfaVerified,
+		"exp":     time.Now().Add(duration).Unix(),
+	}
 
-func HashPassword(password string) (string, error) {
-	if len([]byte(password)) > 72 {
-		return "", errors.New("password too long")
+	if storeID != nil {
+		claims["store_id"] = *storeID
 	}
 
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), 12)
-	return string(bytes), err
-}
+	if sessionID != "" {
+		claims["sid"] = sessionID
+	}
 
-func CheckPasswordHash(password, hash string) bool {
-	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
-}
+	if tokenID != "" {
+		claims["jti"] = tokenID
+	}
 
-// JWT generation
+	return keys.Sign(claims)
+}
 
-func GenerateJWT(
+// GenerateMFAPendingJWT issues the short-lived token returned after a correct
+// password when a second factor is still required. tokenID (the "jti" claim)
+// lets it be exchanged only once.
+func GenerateMFAPendingJWT(
 	userID int64,
 	role string,
 	storeID *int64,
-	secret string,
+	tokenID string,
+	keys *jwtkeys.KeySet,
 	duration time.Duration,
 ) (string, error) {
 
 	claims := jwt.MapClaims{
 		"user_id": userID,
 		"role":    role,
+		"typ":     TokenTypeMFAPending,
+		"jti":     tokenID,
 		"exp":     time.Now().Add(duration).Unix(),
 	}
 
@@ -56,19 +88,13 @@ func GenerateJWT(
 		claims["store_id"] = *storeID
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(secret))
+	return keys.Sign(claims)
 }
 
 // JWT parsing
-func ParseJWT(tokenStr, secret string) (*jwt.Token, jwt.MapClaims, error) {
+func ParseJWT(tokenStr string, keys *jwtkeys.KeySet) (*jwt.Token, jwt.MapClaims, error) {
 	claims := jwt.MapClaims{}
-	token, err := jwt.ParseWithClaims(tokenStr, claims, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(secret), nil
-	})
+	token, err := keys.Parse(tokenStr, claims)
 
 	if err != nil || !token.Valid {
 		return nil, nil, errors.New("invalid token")
@@ -94,14 +120,20 @@ func CheckPasswordPolicy(password string, role string) (bool, error) {
 	case "customer":
 		return false, validateCustomerPassword(password)
 
-	case "store_owner":
-		return true, validateBusinessOwnerPassword(password)
+	case "store_owner", "admin":
+		return MFARequired(role), validateBusinessOwnerPassword(password)
 
 	default:
 		return false, errors.New("unknown user role")
 	}
 }
 
+// MFARequired reports whether accounts of the given role must complete a
+// second factor before using privileged routes.
+func MFARequired(role string) bool {
+	return role == "store_owner" || role == "admin"
+}
+
 // Customer rules
 func validateCustomerPassword(password string) error {
 	if len(password) < 8 {
@@ -122,14 +154,6 @@ func validateBusinessOwnerPassword(password string) error {
 		return errors.New("password must include letters, numbers, and symbols")
 	}
 
-	// HIBP check 
-	pwned, err := IsPasswordPwned(password)
-	if err != nil {
-		// TODO: log the error and skip HIBP check
-	} else if pwned {
-		return errors.New("password has been found in a data breach, please choose another one")
-	}
-
 	return nil
 }
 
@@ -146,59 +170,6 @@ func hasSymbol(s string) bool {
 	return regexp.MustCompile(`[^A-Za-z0-9]`).MatchString(s)
 }
 
-// HIBP Check 
-
-func IsPasswordPwned(password string) (bool, error) {
-	hash := sha1.Sum([]byte(password))
-	hashHex := strings.ToUpper(hex.EncodeToString(hash[:]))
-
-	prefix := hashHex[:5]
-	suffix := hashHex[5:]
-
-	client := &http.Client{
-		Timeout: 5 * time.Second,
-	}
-
-	req, err := http.NewRequest(
-		http.MethodGet,
-		"https://api.pwnedpasswords.com/range/"+prefix,
-		nil,
-	)
-	if err != nil {
-		return false, err
-	}
-
-	req.Header.Set("User-Agent", "password-checker/1.0")
-
-	resp, err := client.Do(req)
-	if err != nil {
-		return false, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return false, fmt.Errorf("HIBP API error: %s", resp.Status)
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return false, err
-	}
-
-	lines := strings.Split(string(body), "\n")
-	for _, line := range lines {
-		parts := strings.Split(line, ":")
-		if len(parts) < 2 {
-			continue
-		}
-		if parts[0] == suffix {
-			return true, nil
-		}
-	}
-
-	return false, nil
-}
-
 func HashAttributes(attrs []models.VariantAttributeInput) string {
 	sort.Slice(attrs, func(i, j int) bool {
 		return attrs[i].AttributeID < attrs[j].AttributeID
@@ -0,0 +1,36 @@
+package utils
+
+import (
+	"net"
+
+	"github.com/sqlc-dev/pqtype"
+)
+
+// ToInet converts a textual IP address to a host INET value. Unparsable
+// input yields a NULL.
+func ToInet(ip string) pqtype.Inet {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return pqtype.Inet{}
+	}
+
+	bits := 128
+	if v4 := parsed.To4(); v4 != nil {
+		parsed = v4
+		bits = 32
+	}
+
+	return pqtype.Inet{
+		IPNet: net.IPNet{IP: parsed, Mask: net.CIDRMask(bits, bits)},
+		Valid: true,
+	}
+}
+
+// InetString renders a host INET value, or nil for NULL.
+func InetString(ip pqtype.Inet) *string {
+	if !ip.Valid {
+		return nil
+	}
+	s := ip.IPNet.IP.String()
+	return &s
+}